@@ -0,0 +1,198 @@
+// Package apitoken erzeugt und prüft die Tokens, mit denen Apps und Skripte
+// die API ohne Cookies nutzen: kurzlebige JWT-Access-Tokens (HS256),
+// Refresh-Tokens und persönliche Zugriffstokens. Von Refresh-Tokens und
+// persönlichen Tokens wird nur der Hash gespeichert.
+package apitoken
+
+import (
+	stdcrypto "crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"trainora/crypto"
+	"trainora/jwt"
+)
+
+// Berechtigungen (Scopes) eines Tokens
+const (
+	ScopeReadPlan      = "read:plan"
+	ScopeWriteFeedback = "write:feedback"
+	ScopeReadProfile   = "read:profile"
+)
+
+// Scopes sind alle bekannten Berechtigungen in fester Reihenfolge
+var Scopes = []string{ScopeReadPlan, ScopeWriteFeedback, ScopeReadProfile}
+
+const (
+	// AccessTTL ist die Laufzeit eines Access-Tokens
+	AccessTTL = 15 * time.Minute
+	// RefreshTTL ist die Laufzeit eines Refresh-Tokens; jede Nutzung
+	// tauscht ihn gegen einen neuen aus
+	RefreshTTL = 30 * 24 * time.Hour
+
+	issuer = "trainora"
+	// Präfixe machen Tokens in Logs und Secret-Scannern erkennbar
+	personalPrefix = "trn_pat_"
+	refreshPrefix  = "trn_rt_"
+)
+
+var (
+	ErrInvalid = errors.New("Token ungültig")
+	ErrExpired = errors.New("Token abgelaufen")
+)
+
+// key signiert die Access-Tokens; bis Init aufgerufen wird, sind alle
+// Access-Tokens ungültig
+var key []byte
+
+// Init leitet den Signaturschlüssel aus dem hex-kodierten SECRET_KEY ab
+func Init(hexKey string) error {
+	k, err := crypto.DeriveKey(hexKey, "trainora-api-tokens")
+	if err != nil {
+		return err
+	}
+	key = k
+	return nil
+}
+
+// Claims sind die Angaben eines Access-Tokens
+type Claims struct {
+	Issuer   string `json:"iss"`
+	Subject  string `json:"sub"` // user_id
+	IssuedAt int64  `json:"iat"`
+	Expiry   int64  `json:"exp"`
+	Scope    string `json:"scope"` // durch Leerzeichen getrennt
+}
+
+// UserID liefert die user_id aus dem Subject
+func (c *Claims) UserID() int64 {
+	id, _ := strconv.ParseInt(c.Subject, 10, 64)
+	return id
+}
+
+// Scopes liefert die Berechtigungen des Tokens
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// IssueAccess signiert ein Access-Token für den Benutzer
+func IssueAccess(userID int64, scopes []string, now time.Time) (string, error) {
+	if key == nil {
+		return "", errors.New("apitoken: Init wurde nicht aufgerufen")
+	}
+	return jwt.SignHS256(Claims{
+		Issuer:   issuer,
+		Subject:  strconv.FormatInt(userID, 10),
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(AccessTTL).Unix(),
+		Scope:    strings.Join(scopes, " "),
+	}, key)
+}
+
+// ParseAccess prüft Signatur, Aussteller und Ablauf eines Access-Tokens
+func ParseAccess(token string, now time.Time) (*Claims, error) {
+	payload, err := jwt.Verify(token, []string{"HS256"}, func(jwt.Header) (stdcrypto.PublicKey, error) {
+		if key == nil {
+			return nil, ErrInvalid
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalid
+	}
+	if claims.Issuer != issuer || claims.UserID() <= 0 {
+		return nil, ErrInvalid
+	}
+	if jwt.CheckTime(claims.Expiry, 0, now, 0) != nil {
+		return nil, ErrExpired
+	}
+	return &claims, nil
+}
+
+// NewPersonal erzeugt ein persönliches Zugriffstoken und seinen Hash
+func NewPersonal() (token, hash string, err error) {
+	return newOpaque(personalPrefix)
+}
+
+// NewRefresh erzeugt ein Refresh-Token und seinen Hash
+func NewRefresh() (token, hash string, err error) {
+	return newOpaque(refreshPrefix)
+}
+
+// IsPersonal meldet, ob token ein persönliches Zugriffstoken ist
+func IsPersonal(token string) bool {
+	return strings.HasPrefix(token, personalPrefix)
+}
+
+func newOpaque(prefix string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), nil
+}
+
+// Hash liefert den gespeicherten Hash eines Refresh- oder persönlichen Tokens
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseScopes prüft die angefragten Berechtigungen und bringt sie in die
+// feste Reihenfolge. Eine leere Anfrage ergibt keine Berechtigungen.
+func ParseScopes(requested []string) ([]string, error) {
+	want := map[string]bool{}
+	for _, s := range requested {
+		if !Known(s) {
+			return nil, fmt.Errorf("unbekannter Scope %q", s)
+		}
+		want[s] = true
+	}
+	var scopes []string
+	for _, s := range Scopes {
+		if want[s] {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, nil
+}
+
+// Known meldet, ob scope eine bekannte Berechtigung ist
+func Known(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers meldet, ob granted alle Berechtigungen aus required enthält
+func Covers(granted []string, required ...string) bool {
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
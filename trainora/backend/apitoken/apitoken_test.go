@@ -0,0 +1,104 @@
+package apitoken
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"trainora/jwt"
+)
+
+func initKey(t *testing.T) {
+	t.Helper()
+	if err := Init(strings.Repeat("cd", 32)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseAccess(t *testing.T) {
+	initKey(t)
+	now := time.Now()
+	token, err := IssueAccess(42, []string{ScopeReadPlan, ScopeReadProfile}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseAccess(token, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID() != 42 || !Covers(claims.Scopes(), ScopeReadPlan, ScopeReadProfile) || Covers(claims.Scopes(), ScopeWriteFeedback) {
+		t.Errorf("Claims = %+v", claims)
+	}
+
+	if _, err := ParseAccess(token, now.Add(AccessTTL)); !errors.Is(err, ErrExpired) {
+		t.Errorf("nach AccessTTL: %v, want ErrExpired", err)
+	}
+}
+
+func TestParseAccessRejects(t *testing.T) {
+	initKey(t)
+	now := time.Now()
+	valid := Claims{Issuer: issuer, Subject: "42", IssuedAt: now.Unix(), Expiry: now.Add(AccessTTL).Unix()}
+
+	sign := func(c Claims, k []byte) string {
+		t.Helper()
+		token, err := jwt.SignHS256(c, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	otherIssuer, noSubject := valid, valid
+	otherIssuer.Issuer = "fremd"
+	noSubject.Subject = ""
+
+	tests := map[string]string{
+		"anderer Schlüssel":  sign(valid, []byte("anderer")),
+		"anderer Aussteller": sign(otherIssuer, key),
+		"ohne Benutzer":      sign(noSubject, key),
+		"persönlicher Token": personalPrefix + "abc",
+		"kein JWT":           "kaputt",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseAccess(token, now); !errors.Is(err, ErrInvalid) {
+				t.Errorf("ParseAccess = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestOpaqueTokens(t *testing.T) {
+	personal, hash, err := NewPersonal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPersonal(personal) || Hash(personal) != hash {
+		t.Errorf("NewPersonal = %q, %q", personal, hash)
+	}
+	refresh, _, err := NewRefresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsPersonal(refresh) || refresh == personal {
+		t.Errorf("NewRefresh = %q", refresh)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{ScopeReadProfile, ScopeReadPlan, ScopeReadProfile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(scopes, " ") != ScopeReadPlan+" "+ScopeReadProfile {
+		t.Errorf("ParseScopes = %v", scopes)
+	}
+	if _, err := ParseScopes([]string{"admin"}); err == nil {
+		t.Error("unbekannter Scope akzeptiert")
+	}
+	if !Covers(scopes) || Covers(nil, ScopeReadPlan) {
+		t.Error("Covers falsch")
+	}
+}
@@ -0,0 +1,243 @@
+// Package config liest die Laufzeitkonfiguration aus den Umgebungsvariablen
+// (bzw. der .env-Datei). Fehlende Werte erhalten sinnvolle Standardwerte.
+package config
+
+import (
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config ist die gesamte Konfiguration des Backends
+type Config struct {
+	// ProxyHeader enthält die Client-IP, wenn das Backend hinter einem Proxy
+	// läuft. Leer = IP der Verbindung. Der Proxy muss den Header selbst setzen
+	// und nicht nur ergänzen (z. B. X-Real-IP), sonst bestimmt der Client den
+	// ersten Eintrag.
+	ProxyHeader string
+	// TrustedProxies sind die IPs bzw. CIDR-Bereiche der Proxys, deren
+	// ProxyHeader ausgewertet wird. Bei allen anderen Verbindungen gilt deren
+	// eigene IP, damit Clients die IP für Login-Limits und Audit-Log nicht
+	// fälschen können.
+	TrustedProxies []string
+
+	// BaseURL ist die öffentliche Adresse des Frontends, Grundlage für Links in Mails
+	BaseURL string
+
+	// CORSOrigins sind die Origins, die mit Cookies auf die API zugreifen
+	// dürfen. Leer = nur gleiche Origin (Frontend über den Proxy).
+	CORSOrigins []string
+
+	// AccountDeletionGrace ist die Karenzzeit, in der ein gelöschtes Konto
+	// wiederhergestellt werden kann, bevor es endgültig entfernt wird
+	AccountDeletionGrace time.Duration
+
+	Session  Session
+	Login    Login
+	Mail     Mail
+	Password Password
+	OIDC     OIDC
+}
+
+// Session konfiguriert Session-Speicher und Cookies
+type Session struct {
+	// Storage ist "db" (Standard, Datenbank aus DB_DRIVER), "redis" oder
+	// "memory" (geht bei jedem Neustart verloren)
+	Storage    string
+	RedisURL   string
+	Expiration time.Duration
+
+	CookieName     string
+	CookieSecure   bool   // bei HTTPS auf true setzen
+	CookieSameSite string // Lax, Strict oder None
+}
+
+// Login begrenzt Fehlversuche beim Login
+type Login struct {
+	// Window ist das Sliding Window, in dem Fehlversuche gezählt werden
+	Window time.Duration
+	// MaxPerIP Fehlversuche je IP im Fenster, danach 429
+	MaxPerIP int
+	// MaxPerAccount Fehlversuche je Konto im Fenster, danach wird das Konto gesperrt
+	MaxPerAccount int
+	// LockoutBase ist die erste Sperrdauer; jeder weitere Fehlversuch verdoppelt
+	// sie bis höchstens LockoutMax
+	LockoutBase time.Duration
+	LockoutMax  time.Duration
+}
+
+// Password legt die Anforderungen an neue Passwörter fest
+type Password struct {
+	MinLength int
+	// MinScore ist die geforderte Stärke von 0 (trivial) bis 4 (sehr stark)
+	MinScore int
+}
+
+// OIDC konfiguriert den Login über einen OpenID-Connect-Provider. Ohne
+// Issuer ist der Login über OIDC abgeschaltet.
+type OIDC struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL ist die Callback-Adresse, wie sie beim Provider hinterlegt ist
+	RedirectURL string
+	Scopes      []string
+	// Name wird im Frontend auf dem Login-Button angezeigt
+	Name string
+
+	// DevIdP startet einen lokalen Test-Provider unter /api/dev-idp. Nur für
+	// die Entwicklung: Jeder kann sich dort als beliebiger Benutzer ausgeben.
+	DevIdP bool
+}
+
+// Enabled meldet, ob ein Provider konfiguriert ist
+func (o OIDC) Enabled() bool {
+	return o.Issuer != ""
+}
+
+// Mail konfiguriert den Versand von E-Mails
+type Mail struct {
+	// Driver ist "log" (Standard, Mails landen in Dir und im Log) oder "smtp"
+	Driver string
+	Dir    string
+	From   string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+}
+
+// Load liest die Konfiguration aus der Umgebung
+func Load() Config {
+	baseURL := strings.TrimSuffix(str("APP_BASE_URL", "http://localhost:5173"), "/")
+
+	// Der Test-Provider läuft im Backend selbst und ist vorkonfiguriert
+	oidc := OIDC{
+		Issuer:       os.Getenv("OIDC_ISSUER"),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  str("OIDC_REDIRECT_URL", baseURL+"/api/oidc/callback"),
+		Scopes:       strings.Fields(str("OIDC_SCOPES", "openid email profile")),
+		Name:         str("OIDC_NAME", "Single Sign-On"),
+		DevIdP:       boolean("OIDC_DEV_IDP", false),
+	}
+	if oidc.DevIdP && oidc.Issuer == "" {
+		oidc.Issuer = "http://localhost:3000/api/dev-idp"
+		oidc.ClientID = "trainora-dev"
+		oidc.ClientSecret = "trainora-dev-secret"
+		oidc.Name = "Test-Login"
+	}
+
+	// Mit Credentials verbietet der Browser "*", jede Origin muss einzeln stehen
+	var corsOrigins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOW_ORIGINS"), ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			log.Fatalf("❌ CORS_ALLOW_ORIGINS darf kein * enthalten, Origins einzeln angeben")
+		}
+		if origin != "" {
+			corsOrigins = append(corsOrigins, origin)
+		}
+	}
+
+	// Ohne vertrauenswürdige Proxys könnte jeder Client den Header setzen
+	proxyHeader := os.Getenv("PROXY_HEADER")
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			log.Fatalf("❌ TRUSTED_PROXIES enthält keine gültige IP bzw. CIDR: %q", proxy)
+		}
+		trustedProxies = append(trustedProxies, proxy)
+	}
+	if proxyHeader != "" && len(trustedProxies) == 0 {
+		log.Fatalf("❌ PROXY_HEADER braucht TRUSTED_PROXIES mit den IPs des Proxys")
+	}
+
+	return Config{
+		ProxyHeader:          proxyHeader,
+		TrustedProxies:       trustedProxies,
+		BaseURL:              baseURL,
+		CORSOrigins:          corsOrigins,
+		AccountDeletionGrace: duration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+		Session: Session{
+			Storage:        str("SESSION_STORAGE", "db"),
+			RedisURL:       str("REDIS_URL", "redis://localhost:6379/0"),
+			Expiration:     duration("SESSION_EXPIRATION", 24*time.Hour),
+			CookieName:     str("SESSION_COOKIE_NAME", "session_id"),
+			CookieSecure:   boolean("COOKIE_SECURE", false),
+			CookieSameSite: str("COOKIE_SAMESITE", "Lax"),
+		},
+		Login: Login{
+			Window:        duration("LOGIN_WINDOW", 15*time.Minute),
+			MaxPerIP:      integer("LOGIN_MAX_PER_IP", 20),
+			MaxPerAccount: integer("LOGIN_MAX_PER_ACCOUNT", 5),
+			LockoutBase:   duration("LOGIN_LOCKOUT_BASE", time.Minute),
+			LockoutMax:    duration("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+		},
+		Mail: Mail{
+			Driver:       str("MAIL_DRIVER", "log"),
+			Dir:          str("MAIL_DIR", "tmp/mail"),
+			From:         str("MAIL_FROM", "Trainora <no-reply@trainora.local>"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     integer("SMTP_PORT", 587),
+			SMTPUser:     os.Getenv("SMTP_USER"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		},
+		Password: Password{
+			MinLength: integer("PASSWORD_MIN_LENGTH", 10),
+			MinScore:  integer("PASSWORD_MIN_SCORE", 3),
+		},
+		OIDC: oidc,
+	}
+}
+
+func str(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func integer(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("❌ %s muss eine Zahl sein, ist %q", key, v)
+	}
+	return n
+}
+
+func boolean(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("❌ %s muss true oder false sein, ist %q", key, v)
+	}
+	return b
+}
+
+func duration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("❌ %s ist keine gültige Dauer (z. B. 24h): %q", key, v)
+	}
+	return d
+}
@@ -0,0 +1,133 @@
+// Package consent enthält die versionierten Einwilligungstexte. Jede
+// inhaltliche Änderung eines Textes bekommt eine neue Datei
+// documents/<art>.v<version>.md; Einwilligungen in ältere Versionen gelten
+// dann nicht mehr und müssen neu eingeholt werden.
+package consent
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// Arten von Einwilligungen
+const (
+	// Privacy ist die Datenschutzerklärung, Pflicht bei der Registrierung
+	Privacy = "privacy"
+	// HealthData erlaubt die Verarbeitung der Gesundheitsdaten aus dem Setup
+	// durch das LLM, Pflicht für Setup und Plan-Generierung
+	HealthData = "health_data"
+)
+
+// Schritte, für die eine Einwilligung Pflicht ist
+const (
+	StepRegister = "register"
+	StepSetup    = "setup"
+)
+
+// requiredFor ordnet jeder Art den Schritt zu, ab dem sie Pflicht ist
+var requiredFor = map[string]string{
+	Privacy:    StepRegister,
+	HealthData: StepSetup,
+}
+
+// Kinds sind alle Arten in fester Reihenfolge
+var Kinds = []string{Privacy, HealthData}
+
+// Document ist eine Version eines Einwilligungstextes
+type Document struct {
+	Kind        string `json:"kind"`
+	Version     int    `json:"version"`
+	Title       string `json:"title"`
+	RequiredFor string `json:"required_for"`
+	Text        string `json:"text"`
+}
+
+//go:embed documents/*.md
+var files embed.FS
+
+// documents enthält alle Versionen je Art, aufsteigend sortiert
+var documents = mustLoad()
+
+func mustLoad() map[string][]Document {
+	docs := map[string][]Document{}
+	paths, err := fs.Glob(files, "documents/*.md")
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range paths {
+		var kind string
+		var version int
+		name := strings.TrimPrefix(path, "documents/")
+		if _, err := fmt.Sscanf(strings.Replace(name, ".v", " ", 1), "%s %d.md", &kind, &version); err != nil {
+			panic("consent: ungültiger Dateiname " + name)
+		}
+		if _, ok := requiredFor[kind]; !ok {
+			panic("consent: unbekannte Art " + kind)
+		}
+		data, err := files.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		text := string(data)
+		title, _, _ := strings.Cut(text, "\n")
+		docs[kind] = append(docs[kind], Document{
+			Kind:        kind,
+			Version:     version,
+			Title:       strings.TrimSpace(strings.TrimPrefix(title, "#")),
+			RequiredFor: requiredFor[kind],
+			Text:        text,
+		})
+	}
+	for _, kind := range Kinds {
+		if len(docs[kind]) == 0 {
+			panic("consent: kein Text für " + kind)
+		}
+		sort.Slice(docs[kind], func(i, j int) bool { return docs[kind][i].Version < docs[kind][j].Version })
+	}
+	return docs
+}
+
+// Current liefert die aktuelle Version jeder Art
+func Current() []Document {
+	list := make([]Document, 0, len(Kinds))
+	for _, kind := range Kinds {
+		versions := documents[kind]
+		list = append(list, versions[len(versions)-1])
+	}
+	return list
+}
+
+// CurrentVersion liefert die aktuelle Version einer Art, 0 bei unbekannter Art
+func CurrentVersion(kind string) int {
+	versions := documents[kind]
+	if len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1].Version
+}
+
+// Find liefert eine bestimmte Version eines Textes
+func Find(kind string, version int) (Document, bool) {
+	for _, d := range documents[kind] {
+		if d.Version == version {
+			return d, true
+		}
+	}
+	return Document{}, false
+}
+
+// Required liefert die Arten, die bis einschließlich step vorliegen müssen.
+// Für das Setup gehört auch die Datenschutzerklärung dazu, da Konten über
+// Single Sign-On ohne Registrierungsformular entstehen.
+func Required(step string) []string {
+	var kinds []string
+	for _, kind := range Kinds {
+		if requiredFor[kind] == StepRegister || requiredFor[kind] == step {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,141 @@
+// Package crypto kapselt die Verschlüsselung einzelner Datenbankfelder.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// versionPrefix markiert Chiffrate, deren user_id und Spaltenname als GCM
+// Associated Data gebunden sind. Ältere Werte ohne Präfix wurden ohne
+// Associated Data verschlüsselt.
+const versionPrefix = "v2:"
+
+var (
+	ErrInvalidKey = errors.New("SECRET_KEY muss ein 64-stelliger Hex-Schlüssel sein")
+	ErrUnbound    = errors.New("Chiffrat ohne Spaltenbindung")
+	ErrShort      = errors.New("Ciphertext zu kurz")
+)
+
+// FieldCipher verschlüsselt Felder mit AES-256-GCM. Eine Instanz wird beim
+// Start einmal erzeugt und ist für parallele Nutzung sicher.
+type FieldCipher struct {
+	aead cipher.AEAD
+}
+
+// NewFieldCipher erzeugt einen FieldCipher aus einem hex-kodierten
+// 32-Byte-Schlüssel und schlägt bei ungültigem Schlüssel sofort fehl.
+func NewFieldCipher(hexKey string) (*FieldCipher, error) {
+	if len(hexKey) != 64 {
+		return nil, ErrInvalidKey
+	}
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FieldCipher{aead: aead}, nil
+}
+
+// ColumnAD baut die Associated Data für eine Spalte der users-Tabelle. Ein
+// Chiffrat lässt sich dadurch nicht in eine andere Spalte oder zu einem
+// anderen Benutzer kopieren, ohne dass die Entschlüsselung fehlschlägt.
+func ColumnAD(userID int64, column string) []byte {
+	return []byte("users." + column + ":" + strconv.FormatInt(userID, 10))
+}
+
+// IsLegacy meldet, ob ein Chiffrat noch ohne Associated Data gespeichert ist
+func IsLegacy(enc string) bool {
+	return !strings.HasPrefix(enc, versionPrefix)
+}
+
+// Encrypt verschlüsselt plain und bindet ad an das Chiffrat
+func (f *FieldCipher) Encrypt(plain string, ad []byte) (string, error) {
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	ciphertext := f.aead.Seal(nonce, nonce, []byte(plain), ad)
+	return versionPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt entschlüsselt einen gebundenen Wert. Vertauschte oder kopierte
+// Chiffrate sowie Altwerte ohne Associated Data werden abgelehnt.
+func (f *FieldCipher) Decrypt(enc string, ad []byte) (string, error) {
+	if IsLegacy(enc) {
+		return "", ErrUnbound
+	}
+	return f.open(strings.TrimPrefix(enc, versionPrefix), ad)
+}
+
+// DecryptLegacy entschlüsselt Altwerte ohne Associated Data. Nur für die
+// Migration auf gebundene Chiffrate gedacht.
+func (f *FieldCipher) DecryptLegacy(enc string) (string, error) {
+	return f.open(enc, nil)
+}
+
+func (f *FieldCipher) open(enc string, ad []byte) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := f.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return "", ErrShort
+	}
+	nonce, ct := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	plain, err := f.aead.Open(nil, nonce, ct, ad)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// Binding verknüpft einen FieldCipher mit Benutzer und Spalte eines Feldes
+type Binding struct {
+	cipher *FieldCipher
+	UserID int64
+	Column string
+}
+
+// Bind liefert die Bindung für die Spalte column des Benutzers userID
+func (f *FieldCipher) Bind(userID int64, column string) Binding {
+	return Binding{cipher: f, UserID: userID, Column: column}
+}
+
+// String erzeugt einen zu schreibenden verschlüsselten Text
+func (b Binding) String(v string) EncryptedString {
+	return EncryptedString{Binding: b, String: v, Valid: true}
+}
+
+// Int erzeugt eine zu schreibende verschlüsselte Zahl
+func (b Binding) Int(v int) EncryptedInt {
+	return EncryptedInt{Binding: b, Int: v, Valid: true}
+}
+
+func (b Binding) encrypt(plain string) (string, error) {
+	if b.cipher == nil {
+		return "", errors.New("Feld ohne FieldCipher")
+	}
+	return b.cipher.Encrypt(plain, ColumnAD(b.UserID, b.Column))
+}
+
+func (b Binding) decrypt(enc string) (string, error) {
+	if b.cipher == nil {
+		return "", errors.New("Feld ohne FieldCipher")
+	}
+	return b.cipher.Decrypt(enc, ColumnAD(b.UserID, b.Column))
+}
@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func newTestCipher(t *testing.T) *FieldCipher {
+	t.Helper()
+	f, err := NewFieldCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// legacy verschlüsselt wie vor der Spaltenbindung ohne Präfix und Associated Data
+func legacy(t *testing.T, f *FieldCipher, plain string) string {
+	t.Helper()
+	nonce := make([]byte, f.aead.NonceSize())
+	return base64.StdEncoding.EncodeToString(f.aead.Seal(nonce, nonce, []byte(plain), nil))
+}
+
+func TestNewFieldCipher(t *testing.T) {
+	for _, key := range []string{"", "abcd", strings.Repeat("zz", 32), strings.Repeat("ab", 31)} {
+		if _, err := NewFieldCipher(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("NewFieldCipher(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	f := newTestCipher(t)
+	ad := ColumnAD(7, "goal_encrypted")
+
+	enc, err := f.Encrypt("Marathon", ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsLegacy(enc) {
+		t.Fatalf("Chiffrat ohne Präfix: %q", enc)
+	}
+	plain, err := f.Decrypt(enc, ad)
+	if err != nil || plain != "Marathon" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	again, _ := f.Encrypt("Marathon", ad)
+	if again == enc {
+		t.Error("gleicher Klartext ergibt gleiches Chiffrat, Nonce wird nicht erneuert")
+	}
+}
+
+func TestDecryptRejects(t *testing.T) {
+	f := newTestCipher(t)
+	enc, err := f.Encrypt("Marathon", ColumnAD(7, "goal_encrypted"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(enc, versionPrefix))
+	raw[len(raw)-1] ^= 1
+	tampered := versionPrefix + base64.StdEncoding.EncodeToString(raw)
+
+	other, _ := NewFieldCipher(strings.Repeat("ab", 32))
+
+	tests := []struct {
+		name   string
+		cipher *FieldCipher
+		enc    string
+		ad     []byte
+		want   error // nil: beliebiger Fehler
+	}{
+		{"anderer Benutzer", f, enc, ColumnAD(8, "goal_encrypted"), nil},
+		{"andere Spalte", f, enc, ColumnAD(7, "allergies_encrypted"), nil},
+		{"ohne Associated Data", f, enc, nil, nil},
+		{"verändertes Chiffrat", f, tampered, ColumnAD(7, "goal_encrypted"), nil},
+		{"falscher Schlüssel", other, enc, ColumnAD(7, "goal_encrypted"), nil},
+		{"zu kurz", f, versionPrefix + base64.StdEncoding.EncodeToString([]byte("kurz")), ColumnAD(7, "goal_encrypted"), ErrShort},
+		{"Altwert", f, legacy(t, f, "Marathon"), ColumnAD(7, "goal_encrypted"), ErrUnbound},
+		{"Altwert ohne Associated Data", f, legacy(t, f, "Marathon"), nil, ErrUnbound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := tt.cipher.Decrypt(tt.enc, tt.ad)
+			if err == nil {
+				t.Fatalf("Decrypt = %q, want Fehler", plain)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	f := newTestCipher(t)
+
+	plain, err := f.DecryptLegacy(legacy(t, f, "Marathon"))
+	if err != nil || plain != "Marathon" {
+		t.Fatalf("DecryptLegacy = %q, %v", plain, err)
+	}
+
+	// Gebundene Werte lassen sich ohne Associated Data nicht lesen
+	enc, _ := f.Encrypt("Marathon", ColumnAD(7, "goal_encrypted"))
+	if _, err := f.DecryptLegacy(strings.TrimPrefix(enc, versionPrefix)); err == nil {
+		t.Error("gebundener Wert als Altwert gelesen")
+	}
+}
+
+func TestColumnAD(t *testing.T) {
+	if got := string(ColumnAD(42, "goal_encrypted")); got != "users.goal_encrypted:42" {
+		t.Errorf("ColumnAD = %q", got)
+	}
+}
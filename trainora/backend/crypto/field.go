@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+)
+
+// EncryptedString ist ein verschlüsselt gespeicherter Text. Vor dem Scan muss
+// die Bindung gesetzt sein, z. B.:
+//
+//	goal := crypto.EncryptedString{Binding: cipher.Bind(userID, "goal_encrypted")}
+//	err := db.QueryRow("SELECT goal_encrypted FROM users WHERE id = ?", userID).Scan(&goal)
+type EncryptedString struct {
+	Binding
+	String string
+	Valid  bool // false bei NULL
+}
+
+// Scan implementiert sql.Scanner und entschlüsselt den Spaltenwert
+func (s *EncryptedString) Scan(src interface{}) error {
+	enc, ok, err := rawString(src)
+	if err != nil || !ok {
+		s.String, s.Valid = "", false
+		return err
+	}
+	plain, err := s.decrypt(enc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.Column, err)
+	}
+	s.String, s.Valid = plain, true
+	return nil
+}
+
+// Value implementiert driver.Valuer und verschlüsselt den Text
+func (s EncryptedString) Value() (driver.Value, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	return s.encrypt(s.String)
+}
+
+// EncryptedInt ist eine verschlüsselt gespeicherte Ganzzahl
+type EncryptedInt struct {
+	Binding
+	Int   int
+	Valid bool // false bei NULL
+}
+
+// Scan implementiert sql.Scanner und entschlüsselt den Spaltenwert
+func (i *EncryptedInt) Scan(src interface{}) error {
+	enc, ok, err := rawString(src)
+	if err != nil || !ok {
+		i.Int, i.Valid = 0, false
+		return err
+	}
+	plain, err := i.decrypt(enc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Column, err)
+	}
+	n, err := strconv.Atoi(plain)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i.Column, err)
+	}
+	i.Int, i.Valid = n, true
+	return nil
+}
+
+// Value implementiert driver.Valuer und verschlüsselt die Zahl
+func (i EncryptedInt) Value() (driver.Value, error) {
+	if !i.Valid {
+		return nil, nil
+	}
+	return i.encrypt(strconv.Itoa(i.Int))
+}
+
+func rawString(src interface{}) (string, bool, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", false, nil
+	case []byte:
+		return string(v), len(v) > 0, nil
+	case string:
+		return v, v != "", nil
+	default:
+		return "", false, fmt.Errorf("unerwarteter Typ %T für verschlüsseltes Feld", src)
+	}
+}
@@ -0,0 +1,132 @@
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedStringRoundTrip(t *testing.T) {
+	b := newTestCipher(t).Bind(7, "goal_encrypted")
+
+	v, err := b.String("Marathon").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, ok := v.(string)
+	if !ok || IsLegacy(enc) {
+		t.Fatalf("Value = %#v", v)
+	}
+
+	// Treiber liefern Text je nach Spaltentyp als string oder []byte
+	for _, src := range []interface{}{enc, []byte(enc)} {
+		s := EncryptedString{Binding: b}
+		if err := s.Scan(src); err != nil {
+			t.Fatalf("Scan(%T) = %v", src, err)
+		}
+		if !s.Valid || s.String != "Marathon" {
+			t.Errorf("Scan(%T) = %+v", src, s)
+		}
+	}
+}
+
+func TestEncryptedIntRoundTrip(t *testing.T) {
+	b := newTestCipher(t).Bind(7, "height_cm_encrypted")
+
+	v, err := b.Int(183).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := EncryptedInt{Binding: b}
+	if err := i.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if !i.Valid || i.Int != 183 {
+		t.Errorf("Scan = %+v", i)
+	}
+
+	// Entschlüsselter Text, der keine Zahl ist
+	enc, _ := b.String("groß").Value()
+	if err := i.Scan(enc); err == nil {
+		t.Error("Scan von Text ohne Zahl erfolgreich")
+	}
+}
+
+func TestEncryptedNull(t *testing.T) {
+	b := newTestCipher(t).Bind(7, "goal_encrypted")
+
+	for _, src := range []interface{}{nil, "", []byte{}} {
+		s := EncryptedString{Binding: b, String: "alt", Valid: true}
+		if err := s.Scan(src); err != nil || s.Valid || s.String != "" {
+			t.Errorf("EncryptedString.Scan(%#v) = %+v, %v", src, s, err)
+		}
+		i := EncryptedInt{Binding: b, Int: 1, Valid: true}
+		if err := i.Scan(src); err != nil || i.Valid || i.Int != 0 {
+			t.Errorf("EncryptedInt.Scan(%#v) = %+v, %v", src, i, err)
+		}
+	}
+
+	if v, err := (EncryptedString{Binding: b}).Value(); v != nil || err != nil {
+		t.Errorf("EncryptedString.Value ungültig = %#v, %v", v, err)
+	}
+	if v, err := (EncryptedInt{Binding: b}).Value(); v != nil || err != nil {
+		t.Errorf("EncryptedInt.Value ungültig = %#v, %v", v, err)
+	}
+
+	// Ein gesetzter leerer Text ist kein NULL und wird verschlüsselt
+	v, err := b.String("").Value()
+	if err != nil || v == nil {
+		t.Fatalf("Value leerer Text = %#v, %v", v, err)
+	}
+	s := EncryptedString{Binding: b}
+	if err := s.Scan(v); err != nil || !s.Valid || s.String != "" {
+		t.Errorf("Scan leerer Text = %+v, %v", s, err)
+	}
+}
+
+func TestEncryptedWrongKey(t *testing.T) {
+	b := newTestCipher(t).Bind(7, "goal_encrypted")
+	other, err := NewFieldCipher(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, _ := b.String("Marathon").Value()
+	s := EncryptedString{Binding: other.Bind(7, "goal_encrypted")}
+	if err := s.Scan(enc); err == nil || s.Valid {
+		t.Errorf("Scan mit falschem Schlüssel = %+v, %v", s, err)
+	}
+	if err := s.Scan(enc); err != nil && !strings.HasPrefix(err.Error(), "goal_encrypted: ") {
+		t.Errorf("Fehler ohne Spaltenname: %v", err)
+	}
+
+	n, _ := b.Int(183).Value()
+	i := EncryptedInt{Binding: other.Bind(7, "goal_encrypted")}
+	if err := i.Scan(n); err == nil || i.Valid {
+		t.Errorf("Scan mit falschem Schlüssel = %+v, %v", i, err)
+	}
+}
+
+func TestEncryptedUnbound(t *testing.T) {
+	f := newTestCipher(t)
+
+	s := EncryptedString{Binding: f.Bind(7, "goal_encrypted")}
+	if err := s.Scan(legacy(t, f, "Marathon")); !errors.Is(err, ErrUnbound) {
+		t.Errorf("Scan Altwert = %v, want ErrUnbound", err)
+	}
+
+	// Ohne FieldCipher schlagen Lesen und Schreiben fehl statt Klartext zu liefern
+	if _, err := (EncryptedString{String: "Marathon", Valid: true}).Value(); err == nil {
+		t.Error("Value ohne FieldCipher erfolgreich")
+	}
+	if err := (&EncryptedString{}).Scan("v2:abc"); err == nil {
+		t.Error("Scan ohne FieldCipher erfolgreich")
+	}
+}
+
+func TestEncryptedUnexpectedType(t *testing.T) {
+	s := EncryptedString{Binding: newTestCipher(t).Bind(7, "goal_encrypted")}
+	if err := s.Scan(int64(1)); err == nil {
+		t.Error("Scan von int64 erfolgreich")
+	}
+}
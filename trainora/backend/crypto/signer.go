@@ -0,0 +1,74 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// ErrBadSignature wird bei manipulierten oder ungültigen Tokens zurückgegeben
+var ErrBadSignature = errors.New("ungültige Signatur")
+
+// Signer signiert Nutzdaten mit HMAC-SHA256, z. B. für Links in E-Mails.
+// Der Schlüssel wird aus SECRET_KEY abgeleitet, damit er sich vom
+// Verschlüsselungsschlüssel der Felder unterscheidet.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner leitet den Signaturschlüssel aus dem hex-kodierten SECRET_KEY ab
+func NewSigner(hexKey string) (*Signer, error) {
+	key, err := DeriveKey(hexKey, "trainora-token-signing")
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{key: key}, nil
+}
+
+// DeriveKey leitet aus dem hex-kodierten SECRET_KEY einen eigenen Schlüssel
+// je Verwendungszweck ab
+func DeriveKey(hexKey, purpose string) ([]byte, error) {
+	if len(hexKey) != 64 {
+		return nil, ErrInvalidKey
+	}
+	master, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil), nil
+}
+
+func (s *Signer) mac(data []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Sign liefert ein URL-taugliches Token der Form nutzdaten.signatur
+func (s *Signer) Sign(data []byte) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(data) + "." + enc.EncodeToString(s.mac(data))
+}
+
+// Verify prüft die Signatur und liefert die Nutzdaten
+func (s *Signer) Verify(token string) ([]byte, error) {
+	enc := base64.RawURLEncoding
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrBadSignature
+	}
+	data, err := enc.DecodeString(payload)
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+	got, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(data)) {
+		return nil, ErrBadSignature
+	}
+	return data, nil
+}
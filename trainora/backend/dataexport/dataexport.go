@@ -0,0 +1,449 @@
+// Package dataexport erstellt den Datenexport eines Benutzers (Auskunft und
+// Datenübertragbarkeit nach Art. 15 und 20 DSGVO). Das Ergebnis ist ein
+// ZIP-Archiv, das jeden Datenbestand als JSON und als CSV enthält.
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"trainora/store"
+)
+
+const readme = `Trainora – Export deiner Daten
+================================
+
+Erstellt am: %s
+
+Jeder Datenbestand liegt als JSON (vollständig) und als CSV (für
+Tabellenkalkulationen) bei:
+
+  profile        Konto und Angaben aus dem Setup, entschlüsselt
+  measurements   Körpermaße aus dem Setup
+  plans          alle Einträge deiner Wochenpläne
+  workouts       Übungen, Sätze und Intervalle der Trainings in deinen Plänen
+  feedback       dein Feedback zu Einträgen
+  comments       Kommentare deiner Coaches
+  tasks          alle für dich erzeugten Aufgaben
+  recipes        deine Rezepte
+  exercises      deine eigenen Übungen
+  sessions       deine protokollierten Trainings
+  sets           die Sätze deiner protokollierten Trainings
+  records        deine persönlichen Rekorde mit allen Verbesserungen
+  consents       deine erteilten und widerrufenen Einwilligungen
+
+Zeitangaben sind in UTC (RFC 3339).
+`
+
+type profile struct {
+	UserID          int64    `json:"user_id"`
+	Username        string   `json:"username"`
+	Email           string   `json:"email"`
+	Roles           []string `json:"roles"`
+	CreatedAt       string   `json:"created_at"`
+	EmailVerifiedAt string   `json:"email_verified_at"`
+	SetupCompleted  bool     `json:"setup_completed"`
+	Birthday        string   `json:"birthday"`
+	ActivityLevel   string   `json:"activity_level"`
+	Goal            string   `json:"goal"`
+	Allergies       string   `json:"allergies"`
+}
+
+type measurement struct {
+	Source   string `json:"source"`
+	HeightCM int    `json:"height_cm"`
+	WeightKG int    `json:"weight_kg"`
+}
+
+type planEntry struct {
+	ScheduleID     int64  `json:"schedule_id"`
+	WeekStartDate  string `json:"week_start_date"`
+	Weekday        int    `json:"weekday"`
+	DayPeriod      string `json:"day_period"`
+	TaskID         int64  `json:"task_id"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	Duration       int    `json:"duration"`
+	FeedbackOption string `json:"feedback_option"`
+	Feedback       string `json:"feedback"`
+}
+
+type workoutBlock struct {
+	ScheduleID   int64   `json:"schedule_id"`
+	Position     int     `json:"position"`
+	Type         string  `json:"type"`
+	ExerciseID   int64   `json:"exercise_id"`
+	ExerciseName string  `json:"exercise_name"`
+	Sets         int     `json:"sets"`
+	Reps         int     `json:"reps"`
+	WeightKG     float64 `json:"weight_kg"`
+	Rounds       int     `json:"rounds"`
+	WorkSeconds  int     `json:"work_seconds"`
+	RestSeconds  int     `json:"rest_seconds"`
+	Notes        string  `json:"notes"`
+}
+
+type feedback struct {
+	ScheduleID     int64  `json:"schedule_id"`
+	WeekStartDate  string `json:"week_start_date"`
+	Title          string `json:"title"`
+	FeedbackOption string `json:"feedback_option"`
+	Feedback       string `json:"feedback"`
+}
+
+type comment struct {
+	ID         int64  `json:"id"`
+	ScheduleID int64  `json:"schedule_id"`
+	Author     string `json:"author"`
+	Body       string `json:"body"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type task struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Duration    int    `json:"duration"`
+}
+
+type customExercise struct {
+	ID                int64    `json:"id"`
+	Name              string   `json:"name"`
+	MuscleGroups      []string `json:"muscle_groups"`
+	Equipment         []string `json:"equipment"`
+	Difficulty        string   `json:"difficulty"`
+	Instructions      string   `json:"instructions"`
+	Contraindications []string `json:"contraindications"`
+	CreatedAt         string   `json:"created_at"`
+}
+
+type session struct {
+	ID         int64  `json:"id"`
+	ScheduleID int64  `json:"schedule_id"`
+	Title      string `json:"title"`
+	Notes      string `json:"notes"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+}
+
+type sessionSet struct {
+	SessionID    int64   `json:"session_id"`
+	Position     int     `json:"position"`
+	ExerciseID   int64   `json:"exercise_id"`
+	ExerciseName string  `json:"exercise_name"`
+	Reps         int     `json:"reps"`
+	WeightKG     float64 `json:"weight_kg"`
+	RPE          float64 `json:"rpe"`
+	Notes        string  `json:"notes"`
+}
+
+type personalRecord struct {
+	ExerciseID   int64   `json:"exercise_id"`
+	ExerciseName string  `json:"exercise_name"`
+	Type         string  `json:"type"`
+	Value        float64 `json:"value"`
+	SessionID    int64   `json:"session_id"`
+	AchievedAt   string  `json:"achieved_at"`
+}
+
+type consentEntry struct {
+	Kind        string `json:"kind"`
+	Version     int    `json:"version"`
+	IP          string `json:"ip"`
+	GivenAt     string `json:"given_at"`
+	WithdrawnAt string `json:"withdrawn_at"`
+}
+
+type recipe struct {
+	ID           int64    `json:"id"`
+	Title        string   `json:"title"`
+	Ingredients  []string `json:"ingredients"`
+	Instructions string   `json:"instructions"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// Build erstellt das ZIP-Archiv mit allen Daten des Benutzers
+func Build(ctx context.Context, st *store.Store, userID int64, now time.Time) ([]byte, error) {
+	user, err := st.Users.ByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := st.Users.Profile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := st.Roles.ByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := st.Plans.History(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := st.Comments.History(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := st.Tasks.ListByCreator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := st.Recipes.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := st.Exercises.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := st.Workouts.History(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := st.Workouts.RecordHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	consents, err := st.Consents.History(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := archive{zip: zip.NewWriter(&buf), now: now}
+	w.file("README.txt", []byte(strings.Replace(readme, "%s", formatTime(now), 1)))
+
+	prof := profile{
+		UserID:          user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Roles:           append([]string{store.RoleUser}, roles...),
+		CreatedAt:       formatTime(user.CreatedAt),
+		EmailVerifiedAt: formatTime(user.EmailVerifiedAt),
+		SetupCompleted:  user.SetupCompleted,
+		Birthday:        p.Birthday,
+		ActivityLevel:   p.ActivityLevel,
+		Goal:            p.Goal,
+		Allergies:       p.Allergies,
+	}
+	w.dataset("profile", prof,
+		[]string{"user_id", "username", "email", "roles", "created_at", "email_verified_at", "setup_completed",
+			"birthday", "activity_level", "goal", "allergies"},
+		[][]string{{itoa(prof.UserID), prof.Username, prof.Email, strings.Join(prof.Roles, " "), prof.CreatedAt,
+			prof.EmailVerifiedAt, strconv.FormatBool(prof.SetupCompleted), prof.Birthday, prof.ActivityLevel,
+			prof.Goal, prof.Allergies}})
+
+	// Körpermaße gibt es bisher nur als Stand aus dem Setup
+	var measurements []measurement
+	if user.SetupCompleted {
+		measurements = append(measurements, measurement{Source: "setup", HeightCM: p.HeightCM, WeightKG: p.WeightKG})
+	}
+	rows := [][]string{}
+	for _, m := range measurements {
+		rows = append(rows, []string{m.Source, strconv.Itoa(m.HeightCM), strconv.Itoa(m.WeightKG)})
+	}
+	w.dataset("measurements", nonNil(measurements), []string{"source", "height_cm", "weight_kg"}, rows)
+
+	entries := make([]planEntry, 0, len(history))
+	fb := []feedback{}
+	rows = [][]string{}
+	fbRows := [][]string{}
+	for _, h := range history {
+		e := planEntry{
+			ScheduleID:     h.ScheduleID,
+			WeekStartDate:  h.WeekStartDate,
+			Weekday:        h.Weekday,
+			DayPeriod:      h.DayPeriod,
+			TaskID:         h.Task.ID,
+			Title:          h.Task.Title,
+			Description:    h.Task.Description,
+			Duration:       h.Task.Duration,
+			FeedbackOption: h.FeedbackOption,
+			Feedback:       h.Feedback,
+		}
+		entries = append(entries, e)
+		rows = append(rows, []string{itoa(e.ScheduleID), e.WeekStartDate, strconv.Itoa(e.Weekday), e.DayPeriod,
+			itoa(e.TaskID), e.Title, e.Description, strconv.Itoa(e.Duration), e.FeedbackOption, e.Feedback})
+
+		if h.Feedback != "" || (h.FeedbackOption != "" && h.FeedbackOption != "none") {
+			fb = append(fb, feedback{h.ScheduleID, h.WeekStartDate, h.Task.Title, h.FeedbackOption, h.Feedback})
+			fbRows = append(fbRows, []string{itoa(h.ScheduleID), h.WeekStartDate, h.Task.Title, h.FeedbackOption, h.Feedback})
+		}
+	}
+	w.dataset("plans", entries,
+		[]string{"schedule_id", "week_start_date", "weekday", "day_period", "task_id", "title", "description",
+			"duration", "feedback_option", "feedback"}, rows)
+	blocks := []workoutBlock{}
+	rows = [][]string{}
+	for _, h := range history {
+		for i, b := range h.Task.Workout {
+			wb := workoutBlock{h.ScheduleID, i + 1, b.Kind, b.ExerciseID, b.ExerciseName, b.Sets, b.Reps, b.WeightKG,
+				b.Rounds, b.WorkSeconds, b.RestSeconds, b.Notes}
+			blocks = append(blocks, wb)
+			rows = append(rows, []string{itoa(wb.ScheduleID), strconv.Itoa(wb.Position), wb.Type, itoa(wb.ExerciseID),
+				wb.ExerciseName, strconv.Itoa(wb.Sets), strconv.Itoa(wb.Reps), formatFloat(wb.WeightKG),
+				strconv.Itoa(wb.Rounds), strconv.Itoa(wb.WorkSeconds), strconv.Itoa(wb.RestSeconds), wb.Notes})
+		}
+	}
+	w.dataset("workouts", blocks, []string{"schedule_id", "position", "type", "exercise_id", "exercise_name", "sets",
+		"reps", "weight_kg", "rounds", "work_seconds", "rest_seconds", "notes"}, rows)
+
+	w.dataset("feedback", fb, []string{"schedule_id", "week_start_date", "title", "feedback_option", "feedback"}, fbRows)
+
+	cms := make([]comment, 0, len(comments))
+	rows = [][]string{}
+	for _, cm := range comments {
+		c := comment{cm.ID, cm.ScheduleID, cm.AuthorName, cm.Body, formatTime(cm.CreatedAt)}
+		cms = append(cms, c)
+		rows = append(rows, []string{itoa(c.ID), itoa(c.ScheduleID), c.Author, c.Body, c.CreatedAt})
+	}
+	w.dataset("comments", cms, []string{"id", "schedule_id", "author", "body", "created_at"}, rows)
+
+	ts := make([]task, 0, len(tasks))
+	rows = [][]string{}
+	for _, t := range tasks {
+		ts = append(ts, task{t.ID, t.Title, t.Description, t.Duration})
+		rows = append(rows, []string{itoa(t.ID), t.Title, t.Description, strconv.Itoa(t.Duration)})
+	}
+	w.dataset("tasks", ts, []string{"id", "title", "description", "duration"}, rows)
+
+	rs := make([]recipe, 0, len(recipes))
+	rows = [][]string{}
+	for _, r := range recipes {
+		ingredients := r.Ingredients
+		if ingredients == nil {
+			ingredients = []string{}
+		}
+		rs = append(rs, recipe{r.ID, r.Title, ingredients, r.Instructions, formatTime(r.CreatedAt)})
+		rows = append(rows, []string{itoa(r.ID), r.Title, strings.Join(ingredients, "; "), r.Instructions, formatTime(r.CreatedAt)})
+	}
+	w.dataset("recipes", rs, []string{"id", "title", "ingredients", "instructions", "created_at"}, rows)
+
+	es := make([]customExercise, 0, len(exercises))
+	rows = [][]string{}
+	for _, e := range exercises {
+		ce := customExercise{e.ID, e.Name, nonNil(e.MuscleGroups), nonNil(e.Equipment), e.Difficulty,
+			e.Instructions, nonNil(e.Contraindications), formatTime(e.CreatedAt)}
+		es = append(es, ce)
+		rows = append(rows, []string{itoa(ce.ID), ce.Name, strings.Join(ce.MuscleGroups, "; "), strings.Join(ce.Equipment, "; "),
+			ce.Difficulty, ce.Instructions, strings.Join(ce.Contraindications, "; "), ce.CreatedAt})
+	}
+	w.dataset("exercises", es, []string{"id", "name", "muscle_groups", "equipment", "difficulty", "instructions",
+		"contraindications", "created_at"}, rows)
+
+	ss := make([]session, 0, len(sessions))
+	sets := []sessionSet{}
+	rows = [][]string{}
+	var setRows [][]string
+	for _, ws := range sessions {
+		e := session{ws.ID, ws.ScheduleID, ws.Title, ws.Notes, formatTime(ws.StartedAt), formatTime(ws.FinishedAt)}
+		ss = append(ss, e)
+		rows = append(rows, []string{itoa(e.ID), itoa(e.ScheduleID), e.Title, e.Notes, e.StartedAt, e.FinishedAt})
+		for i, set := range ws.Sets {
+			ps := sessionSet{ws.ID, i + 1, set.ExerciseID, set.ExerciseName, set.Reps, set.WeightKG, set.RPE, set.Notes}
+			sets = append(sets, ps)
+			setRows = append(setRows, []string{itoa(ps.SessionID), strconv.Itoa(ps.Position), itoa(ps.ExerciseID),
+				ps.ExerciseName, strconv.Itoa(ps.Reps), formatFloat(ps.WeightKG), formatFloat(ps.RPE), ps.Notes})
+		}
+	}
+	w.dataset("sessions", ss, []string{"id", "schedule_id", "title", "notes", "started_at", "finished_at"}, rows)
+	w.dataset("sets", sets, []string{"session_id", "position", "exercise_id", "exercise_name", "reps", "weight_kg",
+		"rpe", "notes"}, setRows)
+
+	prs := make([]personalRecord, 0, len(records))
+	rows = [][]string{}
+	for _, r := range records {
+		pr := personalRecord{r.ExerciseID, r.ExerciseName, r.Kind, r.Value, r.SessionID, formatTime(r.AchievedAt)}
+		prs = append(prs, pr)
+		rows = append(rows, []string{itoa(pr.ExerciseID), pr.ExerciseName, pr.Type, formatFloat(pr.Value),
+			itoa(pr.SessionID), pr.AchievedAt})
+	}
+	w.dataset("records", prs, []string{"exercise_id", "exercise_name", "type", "value", "session_id", "achieved_at"}, rows)
+
+	cs := make([]consentEntry, 0, len(consents))
+	rows = [][]string{}
+	for _, cn := range consents {
+		e := consentEntry{cn.Kind, cn.Version, cn.IP, formatTime(cn.GivenAt), formatTime(cn.WithdrawnAt)}
+		cs = append(cs, e)
+		rows = append(rows, []string{e.Kind, strconv.Itoa(e.Version), e.IP, e.GivenAt, e.WithdrawnAt})
+	}
+	w.dataset("consents", cs, []string{"kind", "version", "ip", "given_at", "withdrawn_at"}, rows)
+
+	if err := w.close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// archive schreibt Dateien ins ZIP und merkt sich den ersten Fehler
+type archive struct {
+	zip *zip.Writer
+	now time.Time
+	err error
+}
+
+func (a *archive) file(name string, data []byte) {
+	if a.err != nil {
+		return
+	}
+	f, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.now})
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	a.err = err
+}
+
+// dataset legt name.json und name.csv an
+func (a *archive) dataset(name string, v interface{}, header []string, rows [][]string) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		a.err = err
+		return
+	}
+	a.file(name+".json", data)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(header)
+	_ = w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		a.err = err
+		return
+	}
+	a.file(name+".csv", buf.Bytes())
+}
+
+func (a *archive) close() error {
+	if a.err != nil {
+		return a.err
+	}
+	return a.zip.Close()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
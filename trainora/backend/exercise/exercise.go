@@ -0,0 +1,173 @@
+// Package exercise enthält den Übungskatalog. Der Datensatz catalog.json
+// ist eingebettet und wird beim Start per Sync in die Datenbank übernommen;
+// eine Übung wird über ihren Slug wiedererkannt, Änderungen am Datensatz
+// landen so beim nächsten Start in der Datenbank.
+package exercise
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"trainora/store"
+)
+
+// Option ist ein Wert eines Filters mit seiner Bezeichnung
+type Option struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// MuscleGroups sind die bekannten Muskelgruppen
+var MuscleGroups = []Option{
+	{"chest", "Brust"},
+	{"back", "Rücken"},
+	{"shoulders", "Schultern"},
+	{"biceps", "Bizeps"},
+	{"triceps", "Trizeps"},
+	{"core", "Rumpf"},
+	{"glutes", "Gesäß"},
+	{"quadriceps", "Oberschenkel vorne"},
+	{"hamstrings", "Oberschenkel hinten"},
+	{"calves", "Waden"},
+	{"cardio", "Ausdauer"},
+}
+
+// Equipment sind die bekannten Geräte. Eine Übung ohne Geräte hat eine
+// leere Liste.
+var Equipment = []Option{
+	{"dumbbell", "Kurzhantel"},
+	{"barbell", "Langhantel"},
+	{"kettlebell", "Kettlebell"},
+	{"resistance_band", "Widerstandsband"},
+	{"pull_up_bar", "Klimmzugstange"},
+	{"bench", "Bank"},
+	{"mat", "Matte"},
+	{"jump_rope", "Springseil"},
+}
+
+// Schwierigkeitsgrade
+const (
+	Beginner     = "beginner"
+	Intermediate = "intermediate"
+	Advanced     = "advanced"
+)
+
+// Difficulties sind die Schwierigkeitsgrade in aufsteigender Reihenfolge
+var Difficulties = []Option{
+	{Beginner, "Einsteiger"},
+	{Intermediate, "Fortgeschritten"},
+	{Advanced, "Profi"},
+}
+
+// Grenzen für eigene Übungen
+const (
+	maxNameLength         = 255
+	maxInstructionsLength = 5000
+	maxContraindications  = 20
+	maxListEntryLength    = 200
+)
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+type catalogEntry struct {
+	Slug              string   `json:"slug"`
+	Name              string   `json:"name"`
+	MuscleGroups      []string `json:"muscle_groups"`
+	Equipment         []string `json:"equipment"`
+	Difficulty        string   `json:"difficulty"`
+	Instructions      string   `json:"instructions"`
+	Contraindications []string `json:"contraindications"`
+}
+
+// catalog enthält die Übungen des Datensatzes
+var catalog = mustLoad()
+
+func mustLoad() []store.Exercise {
+	var entries []catalogEntry
+	if err := json.Unmarshal(catalogJSON, &entries); err != nil {
+		panic("exercise: ungültiger Katalog: " + err.Error())
+	}
+	list := make([]store.Exercise, 0, len(entries))
+	slugs := map[string]bool{}
+	for _, c := range entries {
+		e := store.Exercise{
+			Slug:              c.Slug,
+			Name:              c.Name,
+			MuscleGroups:      c.MuscleGroups,
+			Equipment:         c.Equipment,
+			Difficulty:        c.Difficulty,
+			Instructions:      c.Instructions,
+			Contraindications: c.Contraindications,
+		}
+		if c.Slug == "" || slugs[c.Slug] {
+			panic("exercise: fehlender oder doppelter Slug " + c.Slug)
+		}
+		if err := Validate(&e); err != nil {
+			panic(fmt.Sprintf("exercise: %s: %v", c.Slug, err))
+		}
+		slugs[c.Slug] = true
+		list = append(list, e)
+	}
+	return list
+}
+
+// Sync übernimmt den Datensatz in die Datenbank
+func Sync(ctx context.Context, st *store.Store) error {
+	return st.Exercises.SyncCatalog(ctx, catalog)
+}
+
+// Validate prüft eine Übung gegen die bekannten Werte und bereinigt die
+// Textfelder
+func Validate(e *store.Exercise) error {
+	e.Name = strings.TrimSpace(e.Name)
+	e.Instructions = strings.TrimSpace(e.Instructions)
+	if e.Name == "" || utf8.RuneCountInString(e.Name) > maxNameLength {
+		return fmt.Errorf("Der Name muss zwischen 1 und %d Zeichen lang sein", maxNameLength)
+	}
+	if utf8.RuneCountInString(e.Instructions) > maxInstructionsLength {
+		return fmt.Errorf("Die Anleitung darf höchstens %d Zeichen lang sein", maxInstructionsLength)
+	}
+	if len(e.MuscleGroups) == 0 {
+		return errors.New("Mindestens eine Muskelgruppe ist nötig")
+	}
+	for _, m := range e.MuscleGroups {
+		if !Known(MuscleGroups, m) {
+			return errors.New("Unbekannte Muskelgruppe: " + m)
+		}
+	}
+	for _, eq := range e.Equipment {
+		if !Known(Equipment, eq) {
+			return errors.New("Unbekanntes Gerät: " + eq)
+		}
+	}
+	if !Known(Difficulties, e.Difficulty) {
+		return errors.New("Unbekannter Schwierigkeitsgrad: " + e.Difficulty)
+	}
+	if len(e.Contraindications) > maxContraindications {
+		return fmt.Errorf("Höchstens %d Kontraindikationen erlaubt", maxContraindications)
+	}
+	cleaned := e.Contraindications[:0]
+	for _, c := range e.Contraindications {
+		c = strings.TrimSpace(c)
+		if utf8.RuneCountInString(c) > maxListEntryLength {
+			return fmt.Errorf("Eine Kontraindikation darf höchstens %d Zeichen lang sein", maxListEntryLength)
+		}
+		if c != "" {
+			cleaned = append(cleaned, c)
+		}
+	}
+	e.Contraindications = cleaned
+	return nil
+}
+
+// Known meldet, ob value einer der Werte in options ist
+func Known(options []Option, value string) bool {
+	return slices.ContainsFunc(options, func(o Option) bool { return o.Value == value })
+}
@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK ist ein öffentlicher Schlüssel im JSON-Web-Key-Format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS ist ein Schlüsselsatz, wie ihn ein Provider unter jwks_uri veröffentlicht
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey wandelt den JWK in einen Go-Schlüssel um
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := enc.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := enc.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 {
+			return nil, errors.New("jwk: ungültiger RSA-Exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("jwk: Kurve %q nicht unterstützt", k.Crv)
+		}
+		x, err := enc.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := enc.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("jwk: Punkt liegt nicht auf der Kurve")
+		}
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("jwk: Schlüsseltyp %q nicht unterstützt", k.Kty)
+	}
+}
+
+// RSAJWK beschreibt einen öffentlichen RSA-Schlüssel als JWK
+func RSAJWK(pub *rsa.PublicKey, kid string) JWK {
+	return JWK{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   enc.EncodeToString(pub.N.Bytes()),
+		E:   enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
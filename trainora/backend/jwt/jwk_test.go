@@ -0,0 +1,77 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+)
+
+func TestRSAJWKRoundTrip(t *testing.T) {
+	key, _ := testRSAKeys(t)
+	jwk := RSAJWK(&key.PublicKey, "k1")
+	if jwk.Kty != "RSA" || jwk.Kid != "k1" || jwk.Alg != "RS256" {
+		t.Errorf("RSAJWK = %+v", jwk)
+	}
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(pub) {
+		t.Error("Schlüssel nach Umwandlung verschieden")
+	}
+
+	token, _ := SignRS256(testClaims{Subject: "42"}, key, "k1")
+	if _, err := Verify(token, []string{"RS256"}, staticKey(pub)); err != nil {
+		t.Errorf("Verify mit JWK-Schlüssel = %v", err)
+	}
+}
+
+func TestECJWK(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk := JWK{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   enc.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   enc.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(pub) {
+		t.Error("Schlüssel nach Umwandlung verschieden")
+	}
+	if _, err := Verify(signES256(t, key), []string{"ES256"}, staticKey(pub)); err != nil {
+		t.Errorf("Verify mit JWK-Schlüssel = %v", err)
+	}
+}
+
+func TestJWKRejects(t *testing.T) {
+	key, _ := testRSAKeys(t)
+	rsaJWK := RSAJWK(&key.PublicKey, "")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	x := enc.EncodeToString(ecKey.X.Bytes())
+
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{"unbekannter Typ", JWK{Kty: "oct"}},
+		{"RSA-Exponent 1", JWK{Kty: "RSA", N: rsaJWK.N, E: enc.EncodeToString([]byte{1})}},
+		{"RSA-Exponent zu groß", JWK{Kty: "RSA", N: rsaJWK.N, E: enc.EncodeToString([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0})}},
+		{"RSA kein Base64", JWK{Kty: "RSA", N: "!!!", E: rsaJWK.E}},
+		{"andere Kurve", JWK{Kty: "EC", Crv: "P-384", X: x, Y: x}},
+		{"Punkt nicht auf der Kurve", JWK{Kty: "EC", Crv: "P-256", X: x, Y: x}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if pub, err := tt.jwk.PublicKey(); err == nil {
+				t.Errorf("PublicKey = %v, want Fehler", pub)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package jwt signiert und prüft JSON Web Tokens (kompakte JWS) mit den
+// Verfahren, die Trainora braucht: RS256 und ES256 für ID-Tokens von
+// OpenID-Connect-Providern, HS256 für die eigenen API-Tokens. Die Prüfung
+// der Claims (Aussteller, Zielgruppe, ...) ist Sache des Aufrufers; für exp
+// und nbf gibt es CheckTime.
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed    = errors.New("jwt: ungültiges Format")
+	ErrAlgorithm    = errors.New("jwt: Algorithmus nicht erlaubt")
+	ErrBadSignature = errors.New("jwt: Signatur ungültig")
+	ErrExpired      = errors.New("jwt: Token abgelaufen")
+	ErrNotYetValid  = errors.New("jwt: Token noch nicht gültig")
+)
+
+// Header ist der JOSE-Header eines Tokens
+type Header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// KeyFunc liefert den Schlüssel zum Header eines Tokens: *rsa.PublicKey für
+// RS256, *ecdsa.PublicKey für ES256, []byte für HS256
+type KeyFunc func(h Header) (crypto.PublicKey, error)
+
+var enc = base64.RawURLEncoding
+
+// Verify prüft die Signatur und liefert den Payload. Nur die Algorithmen in
+// allowed werden akzeptiert, damit ein Angreifer nicht auf "none" oder ein
+// schwächeres Verfahren ausweichen kann.
+func Verify(token string, allowed []string, key KeyFunc) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var h Header
+	if err := json.Unmarshal(rawHeader, &h); err != nil {
+		return nil, ErrMalformed
+	}
+	if !contains(allowed, h.Alg) {
+		return nil, ErrAlgorithm
+	}
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	pub, err := key(h)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	switch h.Alg {
+	case "HS256":
+		k, ok := pub.([]byte)
+		if !ok || len(k) == 0 || !hmac.Equal(sig, hs256(k, parts[0]+"."+parts[1])) {
+			return nil, ErrBadSignature
+		}
+	case "RS256":
+		k, ok := pub.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return nil, ErrBadSignature
+		}
+	case "ES256":
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return nil, ErrBadSignature
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return nil, ErrBadSignature
+		}
+	default:
+		return nil, ErrAlgorithm
+	}
+	return payload, nil
+}
+
+// CheckTime prüft die Claims exp (Pflicht) und nbf (0 = nicht gesetzt) in
+// Unix-Sekunden. leeway gleicht Uhrabweichungen zum Aussteller aus.
+func CheckTime(exp, nbf int64, now time.Time, leeway time.Duration) error {
+	if !now.Before(time.Unix(exp, 0).Add(leeway)) {
+		return ErrExpired
+	}
+	if nbf != 0 && now.Add(leeway).Before(time.Unix(nbf, 0)) {
+		return ErrNotYetValid
+	}
+	return nil
+}
+
+// SignRS256 signiert die Claims mit einem RSA-Schlüssel
+func SignRS256(claims interface{}, key *rsa.PrivateKey, kid string) (string, error) {
+	signingInput, err := encodeParts(Header{Alg: "RS256", Kid: kid, Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+// SignHS256 signiert die Claims mit einem symmetrischen Schlüssel
+func SignHS256(claims interface{}, key []byte) (string, error) {
+	signingInput, err := encodeParts(Header{Alg: "HS256", Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + enc.EncodeToString(hs256(key, signingInput)), nil
+}
+
+func hs256(key []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+func encodeParts(h Header, claims interface{}) (string, error) {
+	rawHeader, err := json.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return enc.EncodeToString(rawHeader) + "." + enc.EncodeToString(payload), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testClaims struct {
+	Subject string `json:"sub"`
+}
+
+var (
+	rsaOnce sync.Once
+	rsaKeys [2]*rsa.PrivateKey
+)
+
+// testRSAKeys erzeugt zwei RSA-Schlüssel einmal für alle Tests
+func testRSAKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	t.Helper()
+	rsaOnce.Do(func() {
+		for i := range rsaKeys {
+			k, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			rsaKeys[i] = k
+		}
+	})
+	return rsaKeys[0], rsaKeys[1]
+}
+
+func staticKey(k crypto.PublicKey) KeyFunc {
+	return func(Header) (crypto.PublicKey, error) { return k, nil }
+}
+
+// forge baut ein Token mit beliebigem Header und Signatur
+func forge(t *testing.T, h Header, sig []byte) string {
+	t.Helper()
+	signingInput, err := encodeParts(h, testClaims{Subject: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig)
+}
+
+// signES256 signiert wie ein Provider mit ES256 (r und s je 32 Byte)
+func signES256(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	signingInput, err := encodeParts(Header{Alg: "ES256", Typ: "JWT"}, testClaims{Subject: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + enc.EncodeToString(sig)
+}
+
+func TestVerifyRoundTrip(t *testing.T) {
+	rsaKey, _ := testRSAKeys(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := SignRS256(testClaims{Subject: "42"}, rsaKey, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs, err := SignHS256(testClaims{Subject: "42"}, []byte("geheim"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		alg   string
+		key   crypto.PublicKey
+	}{
+		{"RS256", rs, "RS256", &rsaKey.PublicKey},
+		{"HS256", hs, "HS256", []byte("geheim")},
+		{"ES256", signES256(t, ecKey), "ES256", &ecKey.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kid string
+			payload, err := Verify(tt.token, []string{tt.alg}, func(h Header) (crypto.PublicKey, error) {
+				kid = h.Kid
+				return tt.key, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(payload) != `{"sub":"42"}` {
+				t.Errorf("payload = %s", payload)
+			}
+			if tt.alg == "RS256" && kid != "k1" {
+				t.Errorf("kid = %q", kid)
+			}
+		})
+	}
+}
+
+func TestVerifyAlgorithm(t *testing.T) {
+	rsaKey, _ := testRSAKeys(t)
+	rs, err := SignRS256(testClaims{Subject: "42"}, rsaKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Klassischer Angriff: HS256 mit dem öffentlichen RSA-Schlüssel als Secret
+	confused, err := SignHS256(testClaims{Subject: "42"}, rsaKey.PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	none := forge(t, Header{Alg: "none"}, nil)
+
+	tests := []struct {
+		name    string
+		token   string
+		allowed []string
+		key     crypto.PublicKey
+		want    error
+	}{
+		{"none", none, []string{"RS256"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"none erlaubt", none, []string{"none"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"NONE", forge(t, Header{Alg: "NONE"}, nil), []string{"RS256"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"leerer alg", forge(t, Header{}, nil), []string{"RS256"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"HS256 statt RS256", confused, []string{"RS256"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"HS256 mit RSA-Schlüssel", confused, []string{"RS256", "HS256"}, &rsaKey.PublicKey, ErrBadSignature},
+		{"RS256 mit HMAC-Schlüssel", rs, []string{"RS256", "HS256"}, rsaKey.PublicKey.N.Bytes(), ErrBadSignature},
+		{"RS256 nicht erlaubt", rs, []string{"ES256"}, &rsaKey.PublicKey, ErrAlgorithm},
+		{"ES256 mit RSA-Schlüssel", forge(t, Header{Alg: "ES256"}, make([]byte, 64)), []string{"ES256"}, &rsaKey.PublicKey, ErrBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Verify(tt.token, tt.allowed, staticKey(tt.key)); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWrongKey(t *testing.T) {
+	rsaKey, otherRSA := testRSAKeys(t)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherEC, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	rs, _ := SignRS256(testClaims{Subject: "42"}, rsaKey, "")
+	hs, _ := SignHS256(testClaims{Subject: "42"}, []byte("geheim"))
+
+	tests := []struct {
+		name  string
+		token string
+		alg   string
+		key   crypto.PublicKey
+	}{
+		{"RS256", rs, "RS256", &otherRSA.PublicKey},
+		{"HS256", hs, "HS256", []byte("anderes")},
+		{"HS256 leerer Schlüssel", hs, "HS256", []byte{}},
+		{"ES256", signES256(t, ecKey), "ES256", &otherEC.PublicKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Verify(tt.token, []string{tt.alg}, staticKey(tt.key)); !errors.Is(err, ErrBadSignature) {
+				t.Errorf("Verify = %v, want ErrBadSignature", err)
+			}
+		})
+	}
+
+	// Fehler der KeyFunc (z. B. unbekannte kid) werden durchgereicht
+	errUnknown := errors.New("kid unbekannt")
+	_, err := Verify(rs, []string{"RS256"}, func(Header) (crypto.PublicKey, error) { return nil, errUnknown })
+	if !errors.Is(err, errUnknown) {
+		t.Errorf("Verify = %v, want %v", err, errUnknown)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	hs, err := SignHS256(testClaims{Subject: "42"}, []byte("geheim"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hs, ".")
+	other, _ := encodeParts(Header{Alg: "HS256", Typ: "JWT"}, testClaims{Subject: "1"})
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"anderer Payload", other + "." + parts[2], ErrBadSignature},
+		{"ohne Signatur", parts[0] + "." + parts[1] + ".", ErrBadSignature},
+		{"zwei Teile", parts[0] + "." + parts[1], ErrMalformed},
+		{"kein Base64", parts[0] + ".!!!." + parts[2], ErrMalformed},
+		{"Header kein JSON", enc.EncodeToString([]byte("kaputt")) + "." + parts[1] + "." + parts[2], ErrMalformed},
+		{"leer", "", ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Verify(tt.token, []string{"HS256"}, staticKey([]byte("geheim"))); !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTime(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	unix := now.Unix()
+
+	tests := []struct {
+		name     string
+		exp, nbf int64
+		leeway   time.Duration
+		want     error
+	}{
+		{"gültig", unix + 60, 0, 0, nil},
+		{"exp erreicht", unix, 0, 0, ErrExpired},
+		{"abgelaufen", unix - 1, 0, 0, ErrExpired},
+		{"exp fehlt", 0, 0, time.Minute, ErrExpired},
+		{"abgelaufen innerhalb leeway", unix - 30, 0, time.Minute, nil},
+		{"abgelaufen außerhalb leeway", unix - 61, 0, time.Minute, ErrExpired},
+		{"nbf erreicht", unix + 60, unix, 0, nil},
+		{"nbf in der Zukunft", unix + 60, unix + 1, 0, ErrNotYetValid},
+		{"nbf innerhalb leeway", unix + 600, unix + 30, time.Minute, nil},
+		{"nbf außerhalb leeway", unix + 600, unix + 61, time.Minute, ErrNotYetValid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckTime(tt.exp, tt.nbf, now, tt.leeway); !errors.Is(err, tt.want) {
+				t.Errorf("CheckTime = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
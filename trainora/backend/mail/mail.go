@@ -0,0 +1,44 @@
+// Package mail verschickt E-Mails. Im Betrieb über SMTP, in der Entwicklung
+// werden Mails nur als Datei abgelegt und geloggt, damit alles offline
+// funktioniert.
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"trainora/config"
+)
+
+// Message ist eine einfache Text-Mail
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer verschickt Nachrichten
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New erzeugt den Mailer aus der Konfiguration (MAIL_DRIVER: log oder smtp)
+func New(cfg config.Mail) (Mailer, error) {
+	switch cfg.Driver {
+	case "log":
+		return &FileMailer{Dir: cfg.Dir, From: cfg.From}, nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST fehlt für MAIL_DRIVER=smtp")
+		}
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unbekannter MAIL_DRIVER %q (erlaubt: log, smtp)", cfg.Driver)
+	}
+}
@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPMailer verschickt Mails über einen SMTP-Server. STARTTLS wird
+// verwendet, sobald der Server es anbietet.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	// Der Umschlag braucht die reine Adresse ohne Anzeigenamen
+	sender, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(addr, auth, sender.Address, []string{msg.To}, buildMessage(m.From, msg))
+}
+
+// buildMessage baut eine RFC-5322-Nachricht mit UTF-8-Text
+func buildMessage(from string, msg Message) []byte {
+	var b bytes.Buffer
+	header := func(k, v string) {
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return b.Bytes()
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"log"
 	"os"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/joho/godotenv"
 
+	"trainora/apitoken"
+	"trainora/config"
+	"trainora/crypto"
+	"trainora/exercise"
+	"trainora/mail"
+	"trainora/oidc"
+	"trainora/oidc/devidp"
+	"trainora/password"
 	"trainora/routes"
+	"trainora/session"
+	"trainora/store/sqlstore"
 )
 
 func ensureEnvFile() error {
@@ -56,34 +70,130 @@ func main() {
 	}
 	_ = godotenv.Load()
 
-	app := fiber.New()
-	app.Use(cors.New())
+	// Unterbefehl: trainora migrate [up|down [n]|status]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	// Unterbefehl: trainora role <benutzer> [grant|revoke <rolle>]
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		runRole(os.Args[2:])
+		return
+	}
 
-	api := app.Group("/api")
+	// Feldverschlüsselung einmalig aufbauen, ungültiger Schlüssel bricht den Start ab
+	fieldCipher, err := crypto.NewFieldCipher(os.Getenv("SECRET_KEY"))
+	if err != nil {
+		panic("Ungültiger SECRET_KEY: " + err.Error())
+	}
+
+	// DB öffnen (DB_DRIVER: mysql oder sqlite) und Schema migrieren
+	driver := sqlstore.Driver()
+	db := sqlstore.Open(driver)
+	defer db.Close()
+	if err := sqlstore.Migrate(db, driver, fieldCipher); err != nil {
+		log.Fatalf("❌ Migration fehlgeschlagen: %v", err)
+	}
 
-	// DB öffnen
-	routes.InitDB()
-    defer routes.Db.Close()
+	st := sqlstore.New(db, fieldCipher)
+
+	// Übungskatalog aus dem eingebetteten Datensatz übernehmen
+	if err := exercise.Sync(context.Background(), st); err != nil {
+		log.Fatalf("❌ Übungskatalog konnte nicht geladen werden: %v", err)
+	}
+
+	// Sessions persistent speichern (SESSION_STORAGE: db, redis oder memory)
+	cfg := config.Load()
+	if err := session.Init(cfg.Session, db); err != nil {
+		log.Fatalf("❌ Session-Speicher konnte nicht erstellt werden: %v", err)
+	}
+
+	// Mails mit signierten Einmal-Links (MAIL_DRIVER: log oder smtp)
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("❌ Mailer konnte nicht erstellt werden: %v", err)
+	}
+	signer, err := crypto.NewSigner(os.Getenv("SECRET_KEY"))
+	if err != nil {
+		panic("Ungültiger SECRET_KEY: " + err.Error())
+	}
+	// Schlüssel für die JWT-Access-Tokens der API
+	if err := apitoken.Init(os.Getenv("SECRET_KEY")); err != nil {
+		panic("Ungültiger SECRET_KEY: " + err.Error())
+	}
+	accountMails := routes.NewAccountMailer(st, mailer, signer, cfg.BaseURL)
+	passwordPolicy := password.NewPolicy(cfg.Password)
+
+	// Login über OpenID Connect, nur wenn ein Provider konfiguriert ist
+	var oidcClient *oidc.Client
+	if cfg.OIDC.Enabled() {
+		oidcClient = oidc.New(cfg.OIDC)
+	}
+
+	// Den ProxyHeader nur von TRUSTED_PROXIES übernehmen und nur gültige IPs
+	// daraus lesen; ohne Proxy gilt immer die IP der Verbindung
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+	})
+	// Panics in Handlern (z. B. routes.Current ohne AuthMiddleware) als 500 beantworten
+	app.Use(recover.New())
+
+	// Fremde Origins nur aus CORS_ALLOW_ORIGINS, dann auch mit Cookies
+	if len(cfg.CORSOrigins) > 0 {
+		app.Use(cors.New(cors.Config{
+			AllowOrigins:     strings.Join(cfg.CORSOrigins, ","),
+			AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Csrf-Token",
+			AllowCredentials: true,
+		}))
+	}
+
+	api := app.Group("/api")
+	api.Use(routes.CSRFMiddleware(cfg.Session))
 
 	// Starte Ollama Healthcheck
 	routes.StartOllamaModelChecker()
 
+	// Abgelaufene Tokens, alte Protokolle und gelöschte Konten regelmäßig entfernen
+	routes.StartCleanupJobs(st, cfg.AccountDeletionGrace)
+
 	// API-Routen registrieren
 	routes.RegisterHealthRoutes(api)
-	routes.RegisterUserRoutes(api)
-	routes.RegisterAuthRoutes(api)
-	routes.RegisterSetupRoutes(api)
-	routes.RegisterOllamaRoutes(api, routes.Db)
-	routes.RegisterGetRoutes(api, routes.Db)
-	routes.RegisterDeleteAccountRoute(api)
+	routes.RegisterCSRFRoutes(api)
+	routes.RegisterUserRoutes(api, st, accountMails, passwordPolicy)
+	routes.RegisterVerifyEmailRoutes(api, st, accountMails)
+	routes.RegisterPasswordRoutes(api, st, accountMails, passwordPolicy, cfg)
+	routes.RegisterAuthRoutes(api, st, cfg)
+	routes.RegisterTokenRoutes(api, st, cfg)
+	routes.RegisterDeviceRoutes(api, st)
+	routes.RegisterTwoFactorRoutes(api, st, cfg)
+	routes.RegisterOIDCRoutes(api, st, oidcClient, cfg.BaseURL)
+	if cfg.OIDC.DevIdP {
+		idp, err := devidp.New(cfg.OIDC)
+		if err != nil {
+			log.Fatalf("❌ Test-Provider konnte nicht erstellt werden: %v", err)
+		}
+		idp.Mount(api.Group("/dev-idp"))
+		log.Println("⚠️ OIDC-Test-Provider aktiv unter /api/dev-idp – nicht in Produktion verwenden")
+	}
+	routes.RegisterSetupRoutes(api, st)
+	routes.RegisterOllamaRoutes(api, st)
+	routes.RegisterGetRoutes(api, st)
+	routes.RegisterFeedbackRoutes(api, st)
+	routes.RegisterExerciseRoutes(api, st)
+	routes.RegisterWorkoutSessionRoutes(api, st)
+	routes.RegisterCoachRoutes(api, st)
+	routes.RegisterDeleteAccountRoute(api, st, accountMails, cfg.AccountDeletionGrace)
+	routes.RegisterExportRoutes(api, st, accountMails)
+	routes.RegisterActivityRoutes(api, st)
+	routes.RegisterConsentRoutes(api, st)
+	routes.RegisterAdminRoutes(api, st)
 	routes.RegisterPingRoute(api)
 
-	// Hilfsrouten
-	api.Get("/check-email", routes.CheckEmail)
-	api.Get("/check-username", routes.CheckUsername)
-
 	// Geschützte Routen (nur eingeloggte Benutzer)
-	private := app.Group("/api/private", routes.AuthMiddleware)
+	private := app.Group("/api/private", routes.AuthMiddleware(st))
 	private.Get("/me", routes.MeHandler)
 	private.Get("/recipes", routes.MeHandler)
 
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"trainora/crypto"
+	"trainora/store/sqlstore"
+)
+
+// runMigrate führt den Unterbefehl "migrate" aus:
+//
+//	trainora migrate          alle ausstehenden Migrationen anwenden
+//	trainora migrate up       wie oben
+//	trainora migrate down [n] die letzten n Migrationen zurücknehmen (Standard 1)
+//	trainora migrate status   Zustand aller Migrationen anzeigen
+func runMigrate(args []string) {
+	// Datenmigrationen verschlüsseln Spalten neu und brauchen den Schlüssel
+	fieldCipher, err := crypto.NewFieldCipher(os.Getenv("SECRET_KEY"))
+	if err != nil {
+		log.Fatalf("❌ Ungültiger SECRET_KEY: %v", err)
+	}
+	driver := sqlstore.Driver()
+	db := sqlstore.Open(driver)
+	defer db.Close()
+
+	migrator, err := sqlstore.NewMigrator(db, driver, fieldCipher)
+	if err != nil {
+		log.Fatalf("❌ Migrationen konnten nicht geladen werden: %v", err)
+	}
+
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		n, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ %d Migration(en) angewendet", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("❌ Ungültige Anzahl: %s", args[1])
+			}
+		}
+		n, err := migrator.Down(steps)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ %d Migration(en) zurückgenommen", n)
+	case "status":
+		list, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		for _, s := range list {
+			state := "ausstehend"
+			if s.Applied {
+				state = "angewendet"
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unbekannter Befehl %q. Erlaubt: up, down [n], status\n", cmd)
+		os.Exit(2)
+	}
+}
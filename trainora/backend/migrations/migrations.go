@@ -0,0 +1,308 @@
+// Package migrations verwaltet das versionierte Datenbankschema. Die
+// SQL-Dateien liegen eingebettet im Binary, angewendete Versionen werden in
+// der Tabelle schema_migrations festgehalten. Jede Version existiert für
+// MySQL (mysql/) und SQLite (sqlite/).
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// Dialects sind die unterstützten Datenbanken, gleichzeitig die Verzeichnisnamen
+var Dialects = []string{"mysql", "sqlite"}
+
+// Migration ist eine Schemaversion mit Up- und Down-Skript.
+// Dateinamen folgen dem Muster <version>_<name>.up.sql / .down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Func ist eine Datenmigration in Go für Änderungen, die sich nicht in SQL
+// ausdrücken lassen, z. B. das Neuverschlüsseln von Spalten
+type Func func(db *sql.DB) error
+
+// Status beschreibt, ob eine Migration bereits angewendet wurde
+type Status struct {
+	Migration
+	Applied bool
+}
+
+// Load liest die eingebetteten Migrationen eines Dialekts sortiert nach
+// Version. Weichen die Versionen der Dialekte voneinander ab, schlägt Load fehl.
+func Load(dialect string) ([]Migration, error) {
+	list, err := loadDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range Dialects {
+		if other == dialect {
+			continue
+		}
+		otherList, err := loadDialect(other)
+		if err != nil {
+			return nil, err
+		}
+		if err := sameVersions(list, otherList); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", dialect, other, err)
+		}
+	}
+	return list, nil
+}
+
+func sameVersions(a, b []Migration) error {
+	if len(a) != len(b) {
+		return fmt.Errorf("unterschiedliche Anzahl Migrationen (%d, %d)", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].Version != b[i].Version || a[i].Name != b[i].Name {
+			return fmt.Errorf("Migration %04d_%s fehlt im anderen Dialekt", a[i].Version, a[i].Name)
+		}
+	}
+	return nil
+}
+
+func loadDialect(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("ungültiger Migrationsname %q", name)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("ungültige Version in %q: %w", name, err)
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dialect, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("Version %d doppelt vergeben (%s, %s)", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("Migration %d ohne Up-Skript", m.Version)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Migrator wendet Migrationen auf eine Datenbank an
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+	funcs      map[int]Func
+}
+
+// New lädt die eingebetteten Migrationen des Dialekts und legt
+// schema_migrations an
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	list, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: list, funcs: map[int]Func{}}, nil
+}
+
+// Register hinterlegt eine Datenmigration für version. Sie läuft nach dem
+// Up-Skript der Version; erst wenn sie erfolgreich war, gilt die Version als
+// angewendet und läuft nie wieder. Versionen, deren Up-Skript keine
+// Anweisung enthält, brauchen eine Datenmigration.
+func (m *Migrator) Register(version int, fn Func) {
+	m.funcs[version] = fn
+}
+
+func (m *Migrator) applied() (map[int]bool, error) {
+	rows, err := m.db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+// Status liefert alle bekannten Migrationen mit ihrem Zustand
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		list[i] = Status{Migration: mig, Applied: applied[mig.Version]}
+	}
+	return list, nil
+}
+
+// Up wendet alle ausstehenden Migrationen an und gibt ihre Anzahl zurück
+func (m *Migrator) Up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mig := range m.migrations {
+		if applied[mig.Version] {
+			continue
+		}
+		fn := m.funcs[mig.Version]
+		if fn == nil && len(splitStatements(mig.Up)) == 0 {
+			return count, fmt.Errorf("Migration %04d_%s: keine Datenmigration registriert", mig.Version, mig.Name)
+		}
+		if err := m.exec(mig.Up); err != nil {
+			return count, fmt.Errorf("Migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		if fn != nil {
+			if err := fn(m.db); err != nil {
+				return count, fmt.Errorf("Migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+		}
+		if _, err := m.db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", mig.Version, mig.Name); err != nil {
+			return count, err
+		}
+		log.Printf("📦 Migration %04d_%s angewendet", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down nimmt die letzten n angewendeten Migrationen zurück
+func (m *Migrator) Down(n int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < n; i-- {
+		mig := m.migrations[i]
+		if !applied[mig.Version] {
+			continue
+		}
+		if mig.Down == "" {
+			return count, fmt.Errorf("Migration %04d_%s hat kein Down-Skript", mig.Version, mig.Name)
+		}
+		if err := m.exec(mig.Down); err != nil {
+			return count, fmt.Errorf("Migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		if _, err := m.db.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version); err != nil {
+			return count, err
+		}
+		log.Printf("↩️ Migration %04d_%s zurückgenommen", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// exec führt ein Skript aus. Der MySQL-Treiber erlaubt ohne multiStatements
+// nur eine Anweisung pro Exec, daher wird dort Anweisung für Anweisung
+// ausgeführt. SQLite verarbeitet das Skript am Stück, was Trigger mit
+// BEGIN ... END erlaubt.
+func (m *Migrator) exec(script string) error {
+	if len(splitStatements(script)) == 0 {
+		return nil
+	}
+	if m.dialect == "sqlite" {
+		_, err := m.db.Exec(script)
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := m.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements entfernt Zeilenkommentare und trennt an Semikolons am
+// Zeilenende
+func splitStatements(script string) []string {
+	var stmts []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			stmts = append(stmts, stmt)
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
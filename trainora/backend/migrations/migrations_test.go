@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// openSQLite öffnet eine leere In-Memory-Datenbank wie sqlstore
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)&_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// dataMigrations sind die Versionen, deren Up-Skript keine Anweisung enthält
+func dataMigrations(t *testing.T, list []Migration) []int {
+	t.Helper()
+	var versions []int
+	for _, m := range list {
+		if len(splitStatements(m.Up)) == 0 {
+			versions = append(versions, m.Version)
+		}
+	}
+	return versions
+}
+
+// tables liefert die Tabellen der Datenbank ohne schema_migrations
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestLoad(t *testing.T) {
+	for _, dialect := range Dialects {
+		list, err := Load(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		for i, m := range list {
+			if m.Version != i+1 {
+				t.Errorf("%s: Version %d an Stelle %d, Versionen müssen lückenlos sein", dialect, m.Version, i+1)
+			}
+			if m.Down == "" {
+				t.Errorf("%s: Migration %04d_%s ohne Down-Skript", dialect, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func TestSQLiteUpDown(t *testing.T) {
+	db := openSQLite(t)
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran := map[int]int{}
+	for _, v := range dataMigrations(t, m.migrations) {
+		m.Register(v, func(*sql.DB) error {
+			ran[v]++
+			return nil
+		})
+	}
+
+	n, err := m.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(m.migrations) {
+		t.Errorf("Up = %d, want %d", n, len(m.migrations))
+	}
+	schema := tables(t, db)
+	for _, want := range []string{"users", "tasks", "coach_clients", "remember_tokens"} {
+		if !contains(schema, want) {
+			t.Errorf("Tabelle %s fehlt nach Up: %v", want, schema)
+		}
+	}
+
+	// Ein zweites Up ändert nichts, auch Datenmigrationen laufen nicht erneut
+	if n, err := m.Up(); err != nil || n != 0 {
+		t.Errorf("zweites Up = %d, %v", n, err)
+	}
+	for v, count := range ran {
+		if count != 1 {
+			t.Errorf("Datenmigration %d lief %d-mal", v, count)
+		}
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if !s.Applied {
+			t.Errorf("Migration %04d_%s nicht angewendet", s.Version, s.Name)
+		}
+	}
+
+	// Alle Down-Skripte laufen und hinterlassen eine leere Datenbank, auf der
+	// Up wieder dasselbe Schema aufbaut
+	if n, err := m.Down(len(m.migrations)); err != nil || n != len(m.migrations) {
+		t.Fatalf("Down = %d, %v", n, err)
+	}
+	if left := tables(t, db); len(left) != 0 {
+		t.Errorf("Tabellen nach Down: %v", left)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if again := tables(t, db); !reflect.DeepEqual(again, schema) {
+		t.Errorf("Schema nach erneutem Up = %v, want %v", again, schema)
+	}
+}
+
+func TestUpNeedsDataMigration(t *testing.T) {
+	db := openSQLite(t)
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := dataMigrations(t, m.migrations)
+	if len(versions) == 0 {
+		t.Skip("keine Datenmigrationen")
+	}
+	first := versions[0]
+
+	// Ohne registrierte Funktion bricht Up vor der Version ab
+	n, err := m.Up()
+	if err == nil || !strings.Contains(err.Error(), "keine Datenmigration registriert") {
+		t.Fatalf("Up = %v", err)
+	}
+	if n != first-1 {
+		t.Errorf("Up = %d, want %d", n, first-1)
+	}
+
+	// Eine fehlgeschlagene Datenmigration wird nicht als angewendet vermerkt
+	errFailed := errors.New("fehlgeschlagen")
+	m.Register(first, func(*sql.DB) error { return errFailed })
+	if _, err := m.Up(); !errors.Is(err, errFailed) {
+		t.Fatalf("Up = %v, want %v", err, errFailed)
+	}
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status[first-1].Applied {
+		t.Errorf("Migration %d trotz Fehler angewendet", first)
+	}
+
+	for _, v := range versions {
+		m.Register(v, func(*sql.DB) error { return nil })
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Kommentar
+CREATE TABLE a (
+    id INT -- Spalte
+);
+
+INSERT INTO a VALUES (1);
+UPDATE a SET id = 2`
+	want := []string{"CREATE TABLE a (\n    id INT \n)", "INSERT INTO a VALUES (1)", "UPDATE a SET id = 2"}
+	if got := splitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements = %q, want %q", got, want)
+	}
+	if got := splitStatements("-- nur Kommentare\n\n-- ...\n"); len(got) != 0 {
+		t.Errorf("splitStatements = %q, want leer", got)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
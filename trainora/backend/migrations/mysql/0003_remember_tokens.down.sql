@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN remember_token VARCHAR(64) DEFAULT NULL;
+DROP TABLE IF EXISTS remember_tokens;
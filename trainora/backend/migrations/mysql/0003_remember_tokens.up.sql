@@ -0,0 +1,16 @@
+-- Remember-Me-Tokens pro Gerät. Der Cookie enthält selector:validator, in der
+-- Datenbank liegt nur der SHA-256-Hash des Validators.
+CREATE TABLE IF NOT EXISTS remember_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    selector CHAR(24) NOT NULL UNIQUE,
+    validator_hash CHAR(64) NOT NULL,
+    device VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Klartext-Tokens sind nicht mehr gültig
+ALTER TABLE users DROP COLUMN remember_token;
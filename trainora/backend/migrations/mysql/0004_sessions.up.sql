@@ -0,0 +1,9 @@
+-- Persistente Sessions. data enthält die von Fiber serialisierten
+-- Session-Werte, expires_at ist ein Unix-Zeitstempel (0 = kein Ablauf).
+CREATE TABLE IF NOT EXISTS sessions (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    data BLOB NOT NULL,
+    expires_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
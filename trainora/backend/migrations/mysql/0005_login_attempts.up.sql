@@ -0,0 +1,18 @@
+-- Fehlversuche beim Login. Aufeinanderfolgende Fehlversuche führen zu einer
+-- exponentiell wachsenden Sperre bis locked_until.
+ALTER TABLE users ADD COLUMN failed_login_count INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP NULL DEFAULT NULL;
+
+-- Protokoll aller Login-Versuche, Grundlage der Sliding Windows pro IP und Konto
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT DEFAULT NULL,
+    login VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    attempted_at TIMESTAMP NOT NULL,
+    INDEX idx_login_attempts_ip (ip, attempted_at),
+    INDEX idx_login_attempts_user (user_id, attempted_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Zeitpunkt der letzten Passwortänderung; ältere Sessions werden damit ungültig
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP NULL DEFAULT NULL;
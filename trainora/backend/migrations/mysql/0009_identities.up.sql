@@ -0,0 +1,13 @@
+-- Verknüpfte Identitäten externer OpenID-Connect-Provider. issuer und
+-- subject zusammen identifizieren einen Benutzer beim Provider eindeutig.
+CREATE TABLE IF NOT EXISTS identities (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    issuer VARCHAR(255) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP NULL DEFAULT NULL,
+    UNIQUE KEY uniq_identities_subject (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,28 @@
+-- Persönliche Zugriffstokens für Skripte und fremde Clients. Gespeichert
+-- wird nur der SHA-256-Hash, scopes ist eine durch Leerzeichen getrennte Liste.
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NULL DEFAULT NULL,
+    expires_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Refresh-Tokens der API. Jeder Token gilt einmal, family verbindet alle
+-- Nachfolger eines Logins für den Widerruf bei Wiederverwendung.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family CHAR(32) NOT NULL,
+    scopes VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_refresh_tokens_family (family),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
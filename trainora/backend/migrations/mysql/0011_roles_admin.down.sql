@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS generation_jobs;
+ALTER TABLE users DROP COLUMN disabled_at;
+DROP TABLE IF EXISTS user_roles;
@@ -0,0 +1,25 @@
+-- Zusätzlich vergebene Rollen (coach, admin); jeder Benutzer ist "user"
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INT NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Von einem Admin deaktivierte Konten können sich nicht mehr anmelden
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP NULL DEFAULT NULL;
+
+-- Protokoll der Wochenplan-Generierung, damit Fehlschläge sichtbar werden
+CREATE TABLE IF NOT EXISTS generation_jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    week_start_date DATE NOT NULL,
+    triggered_by VARCHAR(20) NOT NULL,
+    status ENUM('running', 'succeeded', 'failed') NOT NULL DEFAULT 'running',
+    error TEXT DEFAULT NULL,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_generation_jobs_status (status, started_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS schedule_comments;
+DROP TABLE IF EXISTS plan_approvals;
+DROP TABLE IF EXISTS coach_clients;
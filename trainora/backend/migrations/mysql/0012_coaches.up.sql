@@ -0,0 +1,38 @@
+-- Beziehung zwischen Coach und Klient; aktiv erst nach Annahme der Einladung
+CREATE TABLE IF NOT EXISTS coach_clients (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    coach_id INT NOT NULL,
+    client_id INT NOT NULL,
+    status ENUM('pending', 'active') NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP NULL DEFAULT NULL,
+    UNIQUE KEY uq_coach_clients (coach_id, client_id),
+    INDEX idx_coach_clients_client (client_id),
+    FOREIGN KEY (coach_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Wochen, die ein Coach vor der Anzeige freigeben muss. Ohne Eintrag ist
+-- die Woche sichtbar, approved_at = NULL hält sie zurück.
+CREATE TABLE IF NOT EXISTS plan_approvals (
+    user_id INT NOT NULL,
+    week_start_date DATE NOT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    approved_by INT NULL DEFAULT NULL,
+    approved_at TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (user_id, week_start_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Kommentare zu einzelnen Einträgen im Wochenplan
+CREATE TABLE IF NOT EXISTS schedule_comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    schedule_id INT NOT NULL,
+    author_id INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_schedule_comments_schedule (schedule_id),
+    FOREIGN KEY (schedule_id) REFERENCES task_schedule(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+-- Datenexporte der Benutzer. Das ZIP-Archiv ist an user_id gebunden
+-- verschlüsselt und wird nach dem Download oder spätestens nach 24 Stunden
+-- gelöscht.
+CREATE TABLE IF NOT EXISTS data_exports (
+    user_id INT PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    archive_encrypted LONGTEXT DEFAULT NULL,
+    error TEXT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+-- Audit-Log sicherheits- und datenschutzrelevanter Ereignisse. Einträge
+-- werden nur angehängt und nie geändert; sie verschwinden erst mit dem Konto.
+-- actor_id ist der Handelnde (z. B. ein Admin), NULL bei System-Ereignissen.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    actor_id INT DEFAULT NULL,
+    event_type VARCHAR(40) NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    metadata TEXT DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_audit_events_user (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
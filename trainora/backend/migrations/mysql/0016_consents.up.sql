@@ -0,0 +1,14 @@
+-- Einwilligungen der Benutzer in die versionierten Texte aus dem Paket
+-- consent. Jede Einwilligung ist eine eigene Zeile; ein Widerruf setzt
+-- withdrawn_at, damit der Verlauf nachweisbar bleibt.
+CREATE TABLE IF NOT EXISTS user_consents (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind VARCHAR(40) NOT NULL,
+    version INT NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    given_at TIMESTAMP NOT NULL,
+    withdrawn_at TIMESTAMP NULL DEFAULT NULL,
+    INDEX idx_user_consents_user (user_id, kind),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
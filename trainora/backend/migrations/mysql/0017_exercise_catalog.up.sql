@@ -0,0 +1,14 @@
+-- Übungskatalog: Katalog-Übungen haben user_id NULL und einen Slug aus dem
+-- eingebetteten Datensatz, eigene Übungen gehören einem Benutzer.
+-- Listen (Muskelgruppen, Geräte, Kontraindikationen) sind JSON-Arrays.
+ALTER TABLE exercises ADD COLUMN slug VARCHAR(100) NULL DEFAULT NULL;
+ALTER TABLE exercises ADD COLUMN muscle_groups TEXT;
+ALTER TABLE exercises ADD COLUMN equipment TEXT;
+ALTER TABLE exercises ADD COLUMN difficulty VARCHAR(20) NOT NULL DEFAULT 'beginner';
+ALTER TABLE exercises ADD COLUMN instructions TEXT;
+ALTER TABLE exercises ADD COLUMN contraindications TEXT;
+CREATE UNIQUE INDEX idx_exercises_slug ON exercises (slug);
+
+-- Bisherige Beschreibungen werden zur Anleitung
+UPDATE exercises SET instructions = description;
+ALTER TABLE exercises DROP COLUMN description;
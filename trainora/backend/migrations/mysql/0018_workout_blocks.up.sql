@@ -0,0 +1,21 @@
+-- Strukturierte Trainings: eine Aufgabe besteht aus geordneten Blöcken.
+-- kind "sets" sind Sätze mit Wiederholungen und Gewicht, "interval" Runden aus
+-- Belastung und Pause. exercise_name bleibt erhalten, wenn die Übung gelöscht wird.
+CREATE TABLE IF NOT EXISTS workout_blocks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    task_id INT NOT NULL,
+    position INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    exercise_id INT DEFAULT NULL,
+    exercise_name VARCHAR(255) NOT NULL,
+    sets INT NOT NULL DEFAULT 0,
+    reps INT NOT NULL DEFAULT 0,
+    weight_kg DECIMAL(6,2) NOT NULL DEFAULT 0,
+    rounds INT NOT NULL DEFAULT 0,
+    work_seconds INT NOT NULL DEFAULT 0,
+    rest_seconds INT NOT NULL DEFAULT 0,
+    notes TEXT DEFAULT NULL,
+    INDEX idx_workout_blocks_task (task_id, position),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE SET NULL
+);
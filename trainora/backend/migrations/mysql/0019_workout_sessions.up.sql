@@ -0,0 +1,48 @@
+-- Protokollierte Trainings. Eine Session gehört zu höchstens einem Eintrag im
+-- Wochenplan; wird der Eintrag ersetzt oder gelöscht, bleibt sie mit dem Titel
+-- der Aufgabe erhalten. finished_at NULL = läuft noch.
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    schedule_id INT DEFAULT NULL,
+    title VARCHAR(255) NOT NULL,
+    notes TEXT DEFAULT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME DEFAULT NULL,
+    UNIQUE KEY idx_workout_sessions_schedule (schedule_id),
+    INDEX idx_workout_sessions_user (user_id, started_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (schedule_id) REFERENCES task_schedule(id) ON DELETE SET NULL
+);
+
+-- Tatsächlich absolvierte Sätze. rpe NULL = nicht angegeben.
+CREATE TABLE IF NOT EXISTS workout_sets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    session_id INT NOT NULL,
+    position INT NOT NULL,
+    exercise_id INT DEFAULT NULL,
+    exercise_name VARCHAR(255) NOT NULL,
+    reps INT NOT NULL,
+    weight_kg DECIMAL(6,2) NOT NULL DEFAULT 0,
+    rpe DECIMAL(3,1) DEFAULT NULL,
+    notes TEXT DEFAULT NULL,
+    INDEX idx_workout_sets_session (session_id, position),
+    FOREIGN KEY (session_id) REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE SET NULL
+);
+
+-- Persönliche Rekorde. Jede Zeile ist eine Verbesserung; der aktuelle Rekord
+-- je Übung und Art ist die neueste Zeile.
+CREATE TABLE IF NOT EXISTS personal_records (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    exercise_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value DECIMAL(8,2) NOT NULL,
+    session_id INT NOT NULL,
+    achieved_at DATETIME NOT NULL,
+    INDEX idx_personal_records_user (user_id, exercise_id, kind),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES workout_sessions(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Vorheriger Validator eines Remember-Me-Tokens. Parallele Anfragen mit dem
+-- alten Cookie gelten kurz nach dem Austausch noch als gültig.
+ALTER TABLE remember_tokens ADD COLUMN previous_validator_hash CHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE remember_tokens ADD COLUMN rotated_at TIMESTAMP NULL DEFAULT NULL;
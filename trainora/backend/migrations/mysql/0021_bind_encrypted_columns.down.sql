@@ -0,0 +1,2 @@
+-- Gebundene Chiffrate bleiben erhalten, sie lassen sich nicht gefahrlos
+-- wieder lösen.
@@ -0,0 +1,3 @@
+-- Altwerte der verschlüsselten users-Spalten werden an user_id und Spalte
+-- gebunden neu verschlüsselt. Das übernimmt die in sqlstore registrierte
+-- Datenmigration; danach werden ungebundene Werte nur noch abgelehnt.
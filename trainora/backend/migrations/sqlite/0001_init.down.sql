@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS task_schedule_updated_at;
+DROP TABLE IF EXISTS task_schedule;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS users;
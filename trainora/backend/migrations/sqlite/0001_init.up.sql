@@ -0,0 +1,52 @@
+-- SQLite-Variante von mysql/0001_init.up.sql. ENUM-Spalten werden über
+-- CHECK-Constraints abgebildet, ON UPDATE CURRENT_TIMESTAMP über einen Trigger.
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    remember_token VARCHAR(64) DEFAULT NULL,
+
+    birthday_encrypted BLOB DEFAULT NULL,
+    height_cm_encrypted BLOB DEFAULT NULL,
+    weight_kg_encrypted BLOB DEFAULT NULL,
+    goal_encrypted BLOB DEFAULT NULL,
+    activity_level_encrypted BLOB DEFAULT NULL,
+
+    allergies_encrypted BLOB DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    setup_completed TEXT DEFAULT 'no' CHECK (setup_completed IN ('yes', 'no'))
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    instructions TEXT,
+    estimated_duration_minutes INT,
+    created_by INTEGER DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS task_schedule (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    task_id INTEGER NOT NULL,
+    weekday TINYINT NOT NULL, -- 0 = Sonntag, 6 = Samstag
+    day_period TEXT NOT NULL CHECK (day_period IN ('morning', 'noon', 'afternoon', 'evening', 'anytime')),
+    week_start_date DATE NOT NULL,
+    feedback TEXT DEFAULT NULL,
+    feedback_option TEXT DEFAULT 'none' CHECK (feedback_option IN ('none', 'too_hard', 'didnt_like', 'not_possible')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS task_schedule_updated_at
+AFTER UPDATE ON task_schedule
+FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE task_schedule SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
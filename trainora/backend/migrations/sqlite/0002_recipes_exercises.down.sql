@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS exercises;
+DROP TABLE IF EXISTS recipes;
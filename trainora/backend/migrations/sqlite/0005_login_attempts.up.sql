@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN failed_login_count INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP NULL DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER DEFAULT NULL,
+    login VARCHAR(255) NOT NULL,
+    ip VARCHAR(64) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    attempted_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_login_attempts_ip ON login_attempts (ip, attempted_at);
+CREATE INDEX idx_login_attempts_user ON login_attempts (user_id, attempted_at);
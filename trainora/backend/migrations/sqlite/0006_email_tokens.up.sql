@@ -0,0 +1,15 @@
+-- Bestätigung der E-Mail-Adresse
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL DEFAULT NULL;
+
+-- Einmal-Tokens für Mail-Links (E-Mail-Bestätigung, Passwort zurücksetzen).
+-- Das Token selbst ist signiert, gespeichert wird nur der Hash seiner Nonce.
+CREATE TABLE IF NOT EXISTS email_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    nonce_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,18 @@
+-- TOTP-Zwei-Faktor-Authentifizierung. Das Secret ist wie die Profildaten
+-- verschlüsselt; totp_enabled_at ist NULL, solange die Einrichtung nicht
+-- bestätigt wurde. totp_last_step verhindert, dass ein Code zweimal gilt.
+ALTER TABLE users ADD COLUMN totp_secret_encrypted BLOB DEFAULT NULL;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP NULL DEFAULT NULL;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Wiederherstellungscodes, nur als Hash gespeichert
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_recovery_codes_user ON recovery_codes (user_id);
@@ -0,0 +1,26 @@
+-- Zusätzlich vergebene Rollen (coach, admin); jeder Benutzer ist "user"
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Von einem Admin deaktivierte Konten können sich nicht mehr anmelden
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP NULL DEFAULT NULL;
+
+-- Protokoll der Wochenplan-Generierung, damit Fehlschläge sichtbar werden
+CREATE TABLE IF NOT EXISTS generation_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    week_start_date DATE NOT NULL,
+    triggered_by VARCHAR(20) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    error TEXT DEFAULT NULL,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_generation_jobs_status ON generation_jobs (status, started_at);
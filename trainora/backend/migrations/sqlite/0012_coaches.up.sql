@@ -0,0 +1,39 @@
+-- Beziehung zwischen Coach und Klient; aktiv erst nach Annahme der Einladung
+CREATE TABLE IF NOT EXISTS coach_clients (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    coach_id INTEGER NOT NULL,
+    client_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMP NULL DEFAULT NULL,
+    UNIQUE (coach_id, client_id),
+    FOREIGN KEY (coach_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Wochen, die ein Coach vor der Anzeige freigeben muss. Ohne Eintrag ist
+-- die Woche sichtbar, approved_at = NULL hält sie zurück.
+CREATE TABLE IF NOT EXISTS plan_approvals (
+    user_id INTEGER NOT NULL,
+    week_start_date DATE NOT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    approved_by INTEGER NULL DEFAULT NULL,
+    approved_at TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (user_id, week_start_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Kommentare zu einzelnen Einträgen im Wochenplan
+CREATE TABLE IF NOT EXISTS schedule_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (schedule_id) REFERENCES task_schedule(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_coach_clients_client ON coach_clients (client_id);
+CREATE INDEX idx_schedule_comments_schedule ON schedule_comments (schedule_id);
@@ -0,0 +1,5 @@
+-- Gelöschte Konten bleiben bis zum Ende der Karenzzeit wiederherstellbar
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL;
+
+-- Aufgaben gelöschter Konten blieben bisher mit created_by = NULL zurück
+DELETE FROM tasks WHERE created_by IS NULL;
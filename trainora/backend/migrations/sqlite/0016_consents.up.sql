@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_consents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind VARCHAR(40) NOT NULL,
+    version INT NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    given_at TIMESTAMP NOT NULL,
+    withdrawn_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_consents_user ON user_consents (user_id, kind);
@@ -0,0 +1,9 @@
+ALTER TABLE exercises ADD COLUMN description TEXT;
+UPDATE exercises SET description = instructions;
+DROP INDEX idx_exercises_slug;
+ALTER TABLE exercises DROP COLUMN contraindications;
+ALTER TABLE exercises DROP COLUMN instructions;
+ALTER TABLE exercises DROP COLUMN difficulty;
+ALTER TABLE exercises DROP COLUMN equipment;
+ALTER TABLE exercises DROP COLUMN muscle_groups;
+ALTER TABLE exercises DROP COLUMN slug;
@@ -0,0 +1,22 @@
+-- Strukturierte Trainings: eine Aufgabe besteht aus geordneten Blöcken.
+-- kind "sets" sind Sätze mit Wiederholungen und Gewicht, "interval" Runden aus
+-- Belastung und Pause. exercise_name bleibt erhalten, wenn die Übung gelöscht wird.
+CREATE TABLE IF NOT EXISTS workout_blocks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    exercise_id INTEGER DEFAULT NULL,
+    exercise_name VARCHAR(255) NOT NULL,
+    sets INTEGER NOT NULL DEFAULT 0,
+    reps INTEGER NOT NULL DEFAULT 0,
+    weight_kg REAL NOT NULL DEFAULT 0,
+    rounds INTEGER NOT NULL DEFAULT 0,
+    work_seconds INTEGER NOT NULL DEFAULT 0,
+    rest_seconds INTEGER NOT NULL DEFAULT 0,
+    notes TEXT DEFAULT NULL,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_workout_blocks_task ON workout_blocks (task_id, position);
@@ -0,0 +1,51 @@
+-- Protokollierte Trainings. Eine Session gehört zu höchstens einem Eintrag im
+-- Wochenplan; wird der Eintrag ersetzt oder gelöscht, bleibt sie mit dem Titel
+-- der Aufgabe erhalten. finished_at NULL = läuft noch.
+CREATE TABLE IF NOT EXISTS workout_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    schedule_id INTEGER DEFAULT NULL,
+    title VARCHAR(255) NOT NULL,
+    notes TEXT DEFAULT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (schedule_id) REFERENCES task_schedule(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX idx_workout_sessions_schedule ON workout_sessions (schedule_id);
+CREATE INDEX idx_workout_sessions_user ON workout_sessions (user_id, started_at);
+
+-- Tatsächlich absolvierte Sätze. rpe NULL = nicht angegeben.
+CREATE TABLE IF NOT EXISTS workout_sets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    exercise_id INTEGER DEFAULT NULL,
+    exercise_name VARCHAR(255) NOT NULL,
+    reps INTEGER NOT NULL,
+    weight_kg REAL NOT NULL DEFAULT 0,
+    rpe REAL DEFAULT NULL,
+    notes TEXT DEFAULT NULL,
+    FOREIGN KEY (session_id) REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_workout_sets_session ON workout_sets (session_id, position);
+
+-- Persönliche Rekorde. Jede Zeile ist eine Verbesserung; der aktuelle Rekord
+-- je Übung und Art ist die neueste Zeile.
+CREATE TABLE IF NOT EXISTS personal_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    exercise_id INTEGER NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value REAL NOT NULL,
+    session_id INTEGER NOT NULL,
+    achieved_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES workout_sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_personal_records_user ON personal_records (user_id, exercise_id, kind);
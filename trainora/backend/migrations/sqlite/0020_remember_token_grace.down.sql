@@ -0,0 +1,2 @@
+ALTER TABLE remember_tokens DROP COLUMN rotated_at;
+ALTER TABLE remember_tokens DROP COLUMN previous_validator_hash;
@@ -0,0 +1,333 @@
+// Package oidc meldet Benutzer über einen OpenID-Connect-Provider an
+// (Authorization Code Flow mit PKCE). ID-Tokens werden gegen die
+// veröffentlichten Schlüssel (JWKS) des Providers geprüft.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"trainora/config"
+	"trainora/jwt"
+)
+
+var (
+	ErrUnknownKey = errors.New("oidc: Schlüssel des Providers unbekannt")
+	ErrInvalidID  = errors.New("oidc: ID-Token ungültig")
+)
+
+// leeway gleicht Uhrabweichungen zwischen Provider und Backend aus
+const leeway = time.Minute
+
+// jwksRefresh ist der Mindestabstand, in dem die Schlüssel bei unbekannter
+// kid neu geladen werden
+const jwksRefresh = time.Minute
+
+// Claims sind die Angaben aus dem ID-Token
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	Expiry            int64    `json:"exp"`
+	NotBefore         int64    `json:"nbf"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// Client spricht mit genau einem Provider. Discovery und Schlüssel werden
+// beim ersten Gebrauch geladen und zwischengespeichert, damit das Backend
+// auch startet, wenn der Provider gerade nicht erreichbar ist.
+type Client struct {
+	cfg  config.OIDC
+	http *http.Client
+
+	mu          sync.Mutex
+	meta        *metadata
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// New erzeugt den Client aus der Konfiguration
+func New(cfg config.OIDC) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Name ist der Anzeigename des Providers
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+// Issuer ist die Kennung des Providers, unter der Identitäten gespeichert werden
+func (c *Client) Issuer() string {
+	return c.cfg.Issuer
+}
+
+// AuthURL liefert die Adresse, zu der der Browser für den Login geschickt wird
+func (c *Client) AuthURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", PKCEChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange tauscht den Code aus dem Callback gegen Tokens und liefert die
+// geprüften Claims des ID-Tokens
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := c.doJSON(req, &tokens); err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("oidc: keine ID-Token in der Antwort (%s)", tokens.Error)
+	}
+	return c.verifyIDToken(ctx, meta, tokens.IDToken, nonce)
+}
+
+func (c *Client) verifyIDToken(ctx context.Context, meta *metadata, raw, nonce string) (*Claims, error) {
+	algs := meta.SigningAlgs
+	if len(algs) == 0 {
+		algs = []string{"RS256"}
+	}
+	payload, err := jwt.Verify(raw, algs, func(h jwt.Header) (crypto.PublicKey, error) {
+		return c.key(ctx, meta, h.Kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidID
+	}
+	now := time.Now()
+	timeErr := jwt.CheckTime(claims.Expiry, claims.NotBefore, now, leeway)
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return nil, fmt.Errorf("%w: falscher Aussteller", ErrInvalidID)
+	case !claims.Audience.contains(c.cfg.ClientID):
+		return nil, fmt.Errorf("%w: falsche Zielgruppe", ErrInvalidID)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != c.cfg.ClientID:
+		return nil, fmt.Errorf("%w: falsche azp", ErrInvalidID)
+	case timeErr != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidID, timeErr)
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(leeway)):
+		return nil, fmt.Errorf("%w: in der Zukunft ausgestellt", ErrInvalidID)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: falsche Nonce", ErrInvalidID)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: sub fehlt", ErrInvalidID)
+	}
+	return &claims, nil
+}
+
+// metadata lädt das Discovery-Dokument des Providers
+func (c *Client) metadata(ctx context.Context) (*metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil {
+		return c.meta, nil
+	}
+
+	wellKnown := strings.TrimSuffix(c.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	if err := c.doJSON(req, &meta); err != nil {
+		return nil, fmt.Errorf("oidc: Discovery fehlgeschlagen: %w", err)
+	}
+	if meta.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: Provider meldet Issuer %q statt %q", meta.Issuer, c.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: Discovery-Dokument unvollständig")
+	}
+	c.meta = &meta
+	return c.meta, nil
+}
+
+// key liefert den Schlüssel zur kid. Unbekannte kids lösen ein Neuladen der
+// JWKS aus, weil Provider ihre Schlüssel rotieren.
+func (c *Client) key(ctx context.Context, meta *metadata, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+	if time.Since(c.keysFetched) < jwksRefresh {
+		return nil, ErrUnknownKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwt.JWKS
+	if err := c.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("oidc: JWKS nicht ladbar: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = pub
+	}
+	c.keys = keys
+	c.keysFetched = time.Now()
+
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// lookup sucht einen Schlüssel; ohne kid nur, wenn es genau einen gibt
+func (c *Client) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+func (c *Client) doJSON(req *http.Request, v interface{}) error {
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d: %s", req.URL, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// RandomString erzeugt einen zufälligen URL-sicheren Wert für state und nonce
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PKCEChallenge berechnet die S256-Challenge zum Code-Verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// audience akzeptiert "aud" als String oder als Liste
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// flexBool akzeptiert true/false auch als String, wie ihn manche Provider
+// für email_verified senden
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/config"
+	"trainora/jwt"
+	"trainora/oidc"
+	"trainora/oidc/devidp"
+)
+
+// testProvider startet den Test-Provider auf einem freien Port
+type testProvider struct {
+	cfg    config.OIDC
+	idp    *devidp.Provider
+	client *oidc.Client
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.OIDC{
+		Issuer:       "http://" + ln.Addr().String() + "/idp",
+		ClientID:     "trainora-test",
+		ClientSecret: "geheim",
+		RedirectURL:  "http://localhost:5173/api/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+	idp, err := devidp.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	idp.Mount(app.Group("/idp"))
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return &testProvider{cfg: cfg, idp: idp, client: oidc.New(cfg)}
+}
+
+// authorize meldet email beim Provider an und liefert den Code aus der
+// Weiterleitung zurück zum Client
+func (p *testProvider) authorize(t *testing.T, nonce, verifier, email string) string {
+	t.Helper()
+	authURL, err := p.client.AuthURL(context.Background(), "state", nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := u.Query()
+	form.Set("email", email)
+	u.RawQuery = ""
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.PostForm(u.String(), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), p.cfg.RedirectURL) {
+		t.Fatalf("Weiterleitung = %q (HTTP %d)", resp.Header.Get("Location"), resp.StatusCode)
+	}
+	return location.Query().Get("code")
+}
+
+// claims sind gültige Claims eines ID-Tokens für den Test-Client
+func (p *testProvider) claims(nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":            p.cfg.Issuer,
+		"sub":            "abc123",
+		"aud":            p.cfg.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "anna@example.org",
+		"email_verified": true,
+	}
+}
+
+func TestExchange(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	code := p.authorize(t, "nonce-1", "verifier-1", "Anna@Example.org")
+	claims, err := p.client.Exchange(ctx, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject == "" || claims.Email != "Anna@Example.org" || !bool(claims.EmailVerified) || claims.PreferredUsername != "Anna" {
+		t.Errorf("Claims = %+v", claims)
+	}
+
+	// Codes gelten nur einmal
+	if _, err := p.client.Exchange(ctx, code, "verifier-1", "nonce-1"); err == nil {
+		t.Error("Code zweimal eingelöst")
+	}
+
+	code = p.authorize(t, "nonce-2", "verifier-2", "anna@example.org")
+	if _, err := p.client.Exchange(ctx, code, "verifier-2", "andere-nonce"); !errors.Is(err, oidc.ErrInvalidID) {
+		t.Errorf("falsche Nonce: %v, want ErrInvalidID", err)
+	}
+
+	code = p.authorize(t, "nonce-3", "verifier-3", "anna@example.org")
+	if _, err := p.client.Exchange(ctx, code, "anderer-verifier", "nonce-3"); err == nil {
+		t.Error("Code ohne passenden PKCE-Verifier eingelöst")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newTestProvider(t)
+	other, err := devidp.New(p.cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		modify func(map[string]interface{})
+		signer *devidp.Provider
+		ok     bool
+	}{
+		{name: "gültig", ok: true},
+		{name: "aud als Liste", modify: func(c map[string]interface{}) {
+			c["aud"] = []string{p.cfg.ClientID}
+		}, ok: true},
+		{name: "mehrere aud mit azp", modify: func(c map[string]interface{}) {
+			c["aud"] = []string{"fremd", p.cfg.ClientID}
+			c["azp"] = p.cfg.ClientID
+		}, ok: true},
+		{name: "abgelaufen innerhalb leeway", modify: func(c map[string]interface{}) {
+			c["exp"] = now.Add(-30 * time.Second).Unix()
+		}, ok: true},
+		{name: "falscher Aussteller", modify: func(c map[string]interface{}) {
+			c["iss"] = p.cfg.Issuer + "/fremd"
+		}},
+		{name: "ohne Aussteller", modify: func(c map[string]interface{}) { delete(c, "iss") }},
+		{name: "falsche Zielgruppe", modify: func(c map[string]interface{}) { c["aud"] = "fremd" }},
+		{name: "mehrere aud ohne azp", modify: func(c map[string]interface{}) {
+			c["aud"] = []string{"fremd", p.cfg.ClientID}
+		}},
+		{name: "mehrere aud mit fremder azp", modify: func(c map[string]interface{}) {
+			c["aud"] = []string{"fremd", p.cfg.ClientID}
+			c["azp"] = "fremd"
+		}},
+		{name: "falsche Nonce", modify: func(c map[string]interface{}) { c["nonce"] = "fremd" }},
+		{name: "ohne Nonce", modify: func(c map[string]interface{}) { delete(c, "nonce") }},
+		{name: "abgelaufen", modify: func(c map[string]interface{}) {
+			c["exp"] = now.Add(-2 * time.Minute).Unix()
+		}},
+		{name: "ohne exp", modify: func(c map[string]interface{}) { delete(c, "exp") }},
+		{name: "nbf in der Zukunft", modify: func(c map[string]interface{}) {
+			c["nbf"] = now.Add(2 * time.Minute).Unix()
+		}},
+		{name: "in der Zukunft ausgestellt", modify: func(c map[string]interface{}) {
+			c["iat"] = now.Add(2 * time.Minute).Unix()
+		}},
+		{name: "ohne sub", modify: func(c map[string]interface{}) { delete(c, "sub") }},
+		{name: "fremder Schlüssel", signer: other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := p.claims("nonce")
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+			signer := p.idp
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+			token, err := signer.SignIDToken(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := p.client.VerifyIDToken(context.Background(), token, "nonce")
+			if tt.ok {
+				if err != nil || got.Subject != "abc123" {
+					t.Errorf("VerifyIDToken = %+v, %v", got, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("VerifyIDToken = %+v, want Fehler", got)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenAlgorithm(t *testing.T) {
+	p := newTestProvider(t)
+	valid, err := p.idp.SignIDToken(p.claims("nonce"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, payload, _ := strings.Cut(valid, ".")
+	payload, _, _ = strings.Cut(payload, ".")
+
+	// "none" und HS256 mit der client_id als Secret stehen nicht in der
+	// Discovery des Providers
+	none := "eyJhbGciOiJub25lIn0." + payload + "."
+	hs, err := jwt.SignHS256(p.claims("nonce"), []byte(p.cfg.ClientSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, token := range map[string]string{"none": none, "HS256": hs} {
+		if _, err := p.client.VerifyIDToken(context.Background(), token, "nonce"); !errors.Is(err, jwt.ErrAlgorithm) {
+			t.Errorf("%s: %v, want ErrAlgorithm", name, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package devidp ist ein minimaler OpenID-Connect-Provider für Entwicklung
+// und Tests. Er meldet ohne Passwort jeden an, der eine E-Mail-Adresse
+// eingibt – daher niemals in Produktion einschalten (OIDC_DEV_IDP).
+package devidp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"html/template"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/config"
+	"trainora/jwt"
+	"trainora/oidc"
+)
+
+const (
+	codeTTL  = time.Minute
+	tokenTTL = 5 * time.Minute
+	keyID    = "dev-1"
+)
+
+// Provider hält Signaturschlüssel und offene Autorisierungscodes im Speicher;
+// nach einem Neustart gibt es einen neuen Schlüssel
+type Provider struct {
+	cfg config.OIDC
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+type authCode struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	email       string
+	name        string
+	expires     time.Time
+}
+
+// New erzeugt den Provider für den konfigurierten Client
+func New(cfg config.OIDC) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{cfg: cfg, key: key, codes: map[string]authCode{}}, nil
+}
+
+// Mount registriert die Endpunkte; r muss unter der Issuer-Adresse liegen
+func (p *Provider) Mount(r fiber.Router) {
+	r.Get("/.well-known/openid-configuration", p.discovery)
+	r.Get("/jwks", p.jwks)
+	r.Get("/authorize", p.authorizeForm)
+	r.Post("/authorize", p.authorize)
+	r.Post("/token", p.token)
+}
+
+func (p *Provider) discovery(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"issuer":                                p.cfg.Issuer,
+		"authorization_endpoint":                p.cfg.Issuer + "/authorize",
+		"token_endpoint":                        p.cfg.Issuer + "/token",
+		"jwks_uri":                              p.cfg.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+	})
+}
+
+func (p *Provider) jwks(c *fiber.Ctx) error {
+	return c.JSON(jwt.JWKS{Keys: []jwt.JWK{jwt.RSAJWK(&p.key.PublicKey, keyID)}})
+}
+
+var loginPage = template.Must(template.New("login").Parse(`<!doctype html>
+<html lang="de">
+<head><meta charset="utf-8"><title>Trainora Test-Login</title>
+<style>body{font-family:sans-serif;max-width:24rem;margin:4rem auto}input{display:block;width:100%;margin:.4rem 0 1rem;padding:.4rem}</style>
+</head>
+<body>
+<h2>Test-Login</h2>
+<p>Lokaler Test-Provider – es wird kein Passwort geprüft.</p>
+<form method="post">
+{{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">
+{{end}}<label>E-Mail<input name="email" type="email" value="test@trainora.local" required autofocus></label>
+<label>Name<input name="name" value="Test Benutzer"></label>
+<button type="submit">Anmelden</button>
+</form>
+</body>
+</html>
+`))
+
+// checkAuthRequest prüft die Parameter einer Autorisierungsanfrage
+func (p *Provider) checkAuthRequest(get func(string) string) string {
+	switch {
+	case get("response_type") != "code":
+		return "response_type muss code sein"
+	case get("client_id") != p.cfg.ClientID:
+		return "unbekannte client_id"
+	case get("redirect_uri") != p.cfg.RedirectURL:
+		return "redirect_uri nicht registriert"
+	case get("code_challenge") == "" || get("code_challenge_method") != "S256":
+		return "PKCE mit S256 ist Pflicht"
+	case !strings.Contains(" "+get("scope")+" ", " openid "):
+		return "scope openid fehlt"
+	}
+	return ""
+}
+
+func (p *Provider) authorizeForm(c *fiber.Ctx) error {
+	if msg := p.checkAuthRequest(func(k string) string { return c.Query(k) }); msg != "" {
+		return c.Status(400).SendString(msg)
+	}
+	params := map[string]string{}
+	for _, k := range []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "code_challenge", "code_challenge_method"} {
+		params[k] = c.Query(k)
+	}
+	c.Type("html", "utf-8")
+	return loginPage.Execute(c.Response().BodyWriter(), map[string]interface{}{"Params": params})
+}
+
+func (p *Provider) authorize(c *fiber.Ctx) error {
+	if msg := p.checkAuthRequest(func(k string) string { return c.FormValue(k) }); msg != "" {
+		return c.Status(400).SendString(msg)
+	}
+	// Fiber verwendet den Puffer der Anfrage wieder, gespeicherte Werte
+	// müssen kopiert werden
+	form := func(k string) string { return strings.Clone(c.FormValue(k)) }
+	email := strings.TrimSpace(form("email"))
+	if email == "" {
+		return c.Status(400).SendString("E-Mail fehlt")
+	}
+
+	code, err := oidc.RandomString()
+	if err != nil {
+		return c.Status(500).SendString("Code konnte nicht erzeugt werden")
+	}
+	now := time.Now()
+	p.mu.Lock()
+	for k, old := range p.codes {
+		if now.After(old.expires) {
+			delete(p.codes, k)
+		}
+	}
+	p.codes[code] = authCode{
+		redirectURI: form("redirect_uri"),
+		challenge:   form("code_challenge"),
+		nonce:       form("nonce"),
+		email:       email,
+		name:        form("name"),
+		expires:     now.Add(codeTTL),
+	}
+	p.mu.Unlock()
+
+	q := url.Values{}
+	q.Set("code", code)
+	if state := c.FormValue("state"); state != "" {
+		q.Set("state", state)
+	}
+	return c.Redirect(c.FormValue("redirect_uri") + "?" + q.Encode())
+}
+
+func (p *Provider) token(c *fiber.Ctx) error {
+	if !p.clientAuthenticated(c) {
+		return c.Status(401).JSON(fiber.Map{"error": "invalid_client"})
+	}
+	if c.FormValue("grant_type") != "authorization_code" {
+		return c.Status(400).JSON(fiber.Map{"error": "unsupported_grant_type"})
+	}
+
+	// Codes gelten nur einmal
+	p.mu.Lock()
+	code, ok := p.codes[c.FormValue("code")]
+	delete(p.codes, c.FormValue("code"))
+	p.mu.Unlock()
+
+	now := time.Now()
+	if !ok || now.After(code.expires) || code.redirectURI != c.FormValue("redirect_uri") {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_grant"})
+	}
+	challenge := oidc.PKCEChallenge(c.FormValue("code_verifier"))
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(code.challenge)) != 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid_grant", "error_description": "PKCE-Prüfung fehlgeschlagen"})
+	}
+
+	// Gleiche E-Mail ergibt immer dasselbe sub
+	sum := sha256.Sum256([]byte(strings.ToLower(code.email)))
+	username, _, _ := strings.Cut(code.email, "@")
+	idToken, err := p.SignIDToken(map[string]interface{}{
+		"iss":                p.cfg.Issuer,
+		"sub":                hex.EncodeToString(sum[:12]),
+		"aud":                p.cfg.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(tokenTTL).Unix(),
+		"nonce":              code.nonce,
+		"email":              code.email,
+		"email_verified":     true,
+		"name":               code.name,
+		"preferred_username": username,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "server_error"})
+	}
+
+	accessToken, _ := oidc.RandomString()
+	return c.JSON(fiber.Map{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// SignIDToken signiert beliebige Claims mit dem Schlüssel des Providers. Tests
+// erzeugen damit ID-Tokens, die der Provider selbst nie ausstellen würde.
+func (p *Provider) SignIDToken(claims map[string]interface{}) (string, error) {
+	return jwt.SignRS256(claims, p.key, keyID)
+}
+
+// clientAuthenticated prüft client_secret_basic oder client_secret_post
+func (p *Provider) clientAuthenticated(c *fiber.Ctx) bool {
+	id, secret := c.FormValue("client_id"), c.FormValue("client_secret")
+	if auth := c.Get(fiber.HeaderAuthorization); strings.HasPrefix(auth, "Basic ") {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+		if err != nil {
+			return false
+		}
+		user, pass, _ := strings.Cut(string(raw), ":")
+		id, _ = url.QueryUnescape(user)
+		secret, _ = url.QueryUnescape(pass)
+	}
+	return subtle.ConstantTimeCompare([]byte(id), []byte(p.cfg.ClientID)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(p.cfg.ClientSecret)) == 1
+}
@@ -0,0 +1,12 @@
+package oidc
+
+import "context"
+
+// VerifyIDToken prüft ein ID-Token wie Exchange, ohne Code-Austausch
+func (c *Client) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyIDToken(ctx, meta, raw, nonce)
+}
@@ -0,0 +1,39 @@
+package password
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed common-passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords bildet jedes gesperrte Passwort (kleingeschrieben) auf
+// seinen Rang in der Liste ab; je kleiner, desto verbreiteter
+var commonPasswords = loadBlocklist(commonPasswordsFile)
+
+func loadBlocklist(data string) map[string]int {
+	list := make(map[string]int)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.ToLower(line)
+		if _, ok := list[line]; ok {
+			continue
+		}
+		list[line] = len(list) + 1
+	}
+	return list
+}
+
+// isCommon meldet, ob das Passwort (auch in Leetspeak) auf der Sperrliste steht
+func isCommon(pw string) bool {
+	lower := strings.ToLower(pw)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	_, ok := commonPasswords[string(unleet([]rune(lower)))]
+	return ok
+}
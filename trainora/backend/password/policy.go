@@ -0,0 +1,60 @@
+// Package password prüft neue Passwörter gegen die Passwort-Richtlinie:
+// Mindestlänge, geschätzte Stärke und eine mitgelieferte Liste häufiger
+// Passwörter.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"trainora/config"
+)
+
+// maxBytes ist die Grenze von bcrypt; längere Passwörter würden stillschweigend
+// abgeschnitten
+const maxBytes = 72
+
+var (
+	ErrTooLong  = errors.New("Passwort darf höchstens 72 Bytes lang sein")
+	ErrCommon   = errors.New("Dieses Passwort ist zu verbreitet, bitte ein anderes wählen")
+	ErrPersonal = errors.New("Passwort darf nicht Benutzername oder E-Mail enthalten")
+	ErrWeak     = errors.New("Passwort ist zu leicht zu erraten, z. B. mehr Wörter oder Zeichen verwenden")
+)
+
+// Policy ist die Passwort-Richtlinie
+type Policy struct {
+	MinLength int
+	MinScore  int
+}
+
+// NewPolicy erzeugt die Richtlinie aus der Konfiguration
+func NewPolicy(cfg config.Password) Policy {
+	return Policy{MinLength: cfg.MinLength, MinScore: cfg.MinScore}
+}
+
+// Check prüft ein neues Passwort. userInputs sind Angaben wie Benutzername
+// und E-Mail, die nicht im Passwort vorkommen sollen. Die Fehlermeldungen
+// können direkt an den Benutzer gehen.
+func (p Policy) Check(pw string, userInputs ...string) error {
+	if utf8.RuneCountInString(pw) < p.MinLength {
+		return fmt.Errorf("Passwort muss mindestens %d Zeichen lang sein", p.MinLength)
+	}
+	if len(pw) > maxBytes {
+		return ErrTooLong
+	}
+	if isCommon(pw) {
+		return ErrCommon
+	}
+	lower := strings.ToLower(pw)
+	for _, in := range userInputs {
+		if len(in) >= 3 && strings.Contains(lower, strings.ToLower(in)) {
+			return ErrPersonal
+		}
+	}
+	if Strength(pw, userInputs...) < p.MinScore {
+		return ErrWeak
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Die Schätzung folgt der Idee von zxcvbn: Das Passwort wird in bekannte
+// Muster (häufige Passwörter, Benutzerdaten, Wiederholungen, Folgen,
+// Tastaturreihen, Jahreszahlen) zerlegt. Jedes Muster kostet nur wenige Bits,
+// alle übrigen Zeichen werden wie bei Brute Force bewertet. Die günstigste
+// Zerlegung ergibt die geschätzte Anzahl an Rateversuchen.
+
+// leetMap übersetzt typische Ersetzungen zurück in Buchstaben
+var leetMap = map[rune]rune{
+	'@': 'a', '4': 'a', '3': 'e', '1': 'i', '!': 'i',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't',
+}
+
+// keyboardRows sind Tastaturreihen (QWERTZ und QWERTY) und die Zahlenreihe
+var keyboardRows = []string{
+	"1234567890", "qwertzuiopü", "asdfghjklöä", "yxcvbnm",
+	"qwertyuiop", "zxcvbnm",
+}
+
+type match struct {
+	start, end int // [start, end) in Runen
+	bits       float64
+}
+
+// Strength schätzt die Stärke eines Passworts von 0 (trivial) bis 4 (sehr
+// stark). userInputs sind Angaben wie Benutzername oder E-Mail, die ein
+// Angreifer kennt.
+func Strength(pw string, userInputs ...string) int {
+	log10 := bits(pw, userInputs) * math.Log10(2)
+	switch {
+	case log10 < 3:
+		return 0
+	case log10 < 6:
+		return 1
+	case log10 < 8:
+		return 2
+	case log10 < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// bits liefert log2 der geschätzten Rateversuche
+func bits(pw string, userInputs []string) float64 {
+	runes := []rune(pw)
+	if len(runes) == 0 {
+		return 0
+	}
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	charBits := math.Log2(float64(cardinality(runes)))
+	matches := dictionaryMatches(runes, lower, userInputs)
+	matches = append(matches, repeatMatches(lower, charBits)...)
+	matches = append(matches, sequenceMatches(lower)...)
+	matches = append(matches, keyboardMatches(lower)...)
+	matches = append(matches, yearMatches(lower)...)
+
+	// best[k] = günstigste Zerlegung der ersten k Zeichen
+	best := make([]float64, len(runes)+1)
+	for k := 1; k <= len(runes); k++ {
+		best[k] = best[k-1] + charBits
+		for _, m := range matches {
+			if m.end == k && best[m.start]+m.bits < best[k] {
+				best[k] = best[m.start] + m.bits
+			}
+		}
+	}
+	return best[len(runes)]
+}
+
+// cardinality ist die Größe des Zeichenvorrats, aus dem das Passwort stammt
+func cardinality(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < 128:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	if lower {
+		n += 26
+	}
+	if upper {
+		n += 26
+	}
+	if digit {
+		n += 10
+	}
+	if symbol {
+		n += 33
+	}
+	if other {
+		n += 100
+	}
+	return n
+}
+
+func dictionaryMatches(runes, lower []rune, userInputs []string) []match {
+	inputs := make(map[string]bool)
+	for _, in := range userInputs {
+		in = strings.ToLower(in)
+		for _, part := range strings.FieldsFunc(in, func(r rune) bool {
+			return r == '@' || r == '.' || r == '_' || r == '-' || r == '+'
+		}) {
+			if len([]rune(part)) >= 3 {
+				inputs[part] = true
+			}
+		}
+	}
+
+	plain := unleet(lower)
+	var matches []match
+	for i := range lower {
+		for j := i + 3; j <= len(lower); j++ {
+			word := string(lower[i:j])
+			unleeted := string(plain[i:j])
+
+			var base float64
+			switch {
+			case inputs[word] || inputs[unleeted]:
+				base = 1
+			case j-i >= 4 && commonPasswords[word] > 0:
+				base = math.Log2(float64(commonPasswords[word]) + 1)
+			case j-i >= 4 && commonPasswords[unleeted] > 0:
+				base = math.Log2(float64(commonPasswords[unleeted])+1) + 1
+			default:
+				continue
+			}
+			matches = append(matches, match{i, j, base + uppercaseBits(runes[i:j])})
+		}
+	}
+	return matches
+}
+
+// uppercaseBits bewertet die Großschreibung eines Wortes: nur der erste
+// Buchstabe groß ist kaum schwerer zu raten als alles klein
+func uppercaseBits(word []rune) float64 {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 0:
+		return 0
+	case upper == 1 && unicode.IsUpper(word[0]), upper == len(word):
+		return 1
+	default:
+		return float64(upper) + 1
+	}
+}
+
+func unleet(lower []rune) []rune {
+	out := make([]rune, len(lower))
+	for i, r := range lower {
+		if plain, ok := leetMap[r]; ok {
+			out[i] = plain
+		} else {
+			out[i] = r
+		}
+	}
+	return out
+}
+
+// repeatMatches findet Wiederholungen wie "aaaa"
+func repeatMatches(lower []rune, charBits float64) []match {
+	var matches []match
+	for i := 0; i < len(lower); {
+		j := i + 1
+		for j < len(lower) && lower[j] == lower[i] {
+			j++
+		}
+		if j-i >= 3 {
+			matches = append(matches, match{i, j, charBits + math.Log2(float64(j-i))})
+		}
+		i = j
+	}
+	return matches
+}
+
+// sequenceMatches findet auf- und absteigende Folgen wie "abcd" oder "4321"
+func sequenceMatches(lower []rune) []match {
+	var matches []match
+	for i := 0; i+2 < len(lower); {
+		delta := lower[i+1] - lower[i]
+		if delta != 1 && delta != -1 {
+			i++
+			continue
+		}
+		j := i + 2
+		for j < len(lower) && lower[j]-lower[j-1] == delta {
+			j++
+		}
+		if j-i >= 3 {
+			matches = append(matches, match{i, j, 4 + math.Log2(float64(j-i))})
+		}
+		i = j - 1
+	}
+	return matches
+}
+
+// keyboardMatches findet Ausschnitte aus Tastaturreihen wie "qwert" oder "lkjh"
+func keyboardMatches(lower []rune) []match {
+	var matches []match
+	for i := range lower {
+		for j := i + 3; j <= len(lower); j++ {
+			part := string(lower[i:j])
+			reversed := reverse(lower[i:j])
+			for _, row := range keyboardRows {
+				if strings.Contains(row, part) || strings.Contains(row, reversed) {
+					matches = append(matches, match{i, j, 5 + math.Log2(float64(j-i))})
+					break
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// yearMatches findet Jahreszahlen von 1900 bis 2099
+func yearMatches(lower []rune) []match {
+	var matches []match
+	for i := 0; i+4 <= len(lower); i++ {
+		year := string(lower[i : i+4])
+		if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) &&
+			unicode.IsDigit(lower[i+2]) && unicode.IsDigit(lower[i+3]) {
+			matches = append(matches, match{i, i + 4, math.Log2(200)})
+		}
+	}
+	return matches
+}
+
+func reverse(r []rune) string {
+	out := make([]rune, len(r))
+	for i, c := range r {
+		out[len(r)-1-i] = c
+	}
+	return string(out)
+}
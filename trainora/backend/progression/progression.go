@@ -0,0 +1,204 @@
+// Package progression schlägt aus protokollierten Trainings Lasten und
+// Wiederholungen für die nächste Woche vor. Die Regeln sind bewusst einfach
+// und deterministisch: Doppelprogression im Wiederholungsbereich RepMin bis
+// RepMax, Deload nach wiederholtem Scheitern. Das LLM übernimmt nur die
+// Struktur des Plans, die Zahlen kommen von hier.
+package progression
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"trainora/store"
+)
+
+const (
+	// RepMin und RepMax begrenzen den Wiederholungsbereich der
+	// Doppelprogression mit Gewicht
+	RepMin = 8
+	RepMax = 12
+	// Increment ist die Gewichtssteigerung in kg, zugleich das Raster für
+	// reduzierte Gewichte
+	Increment = 2.5
+	// FailuresBeforeDeload ist die Zahl der Sessions in Folge ohne Erfolg,
+	// nach der die Last reduziert wird
+	FailuresBeforeDeload = 2
+	// DeloadFactor reduziert das Gewicht, BodyweightDeloadFactor die
+	// Wiederholungen bei Übungen mit Körpergewicht
+	DeloadFactor           = 0.9
+	BodyweightDeloadFactor = 0.8
+)
+
+// Empfehlungen für eine Übung
+const (
+	IncreaseWeight = "increase_weight"
+	IncreaseReps   = "increase_reps"
+	Hold           = "hold"
+	Deload         = "deload"
+)
+
+// Target ist der Vorschlag für eine Übung in der nächsten Woche
+type Target struct {
+	ExerciseID   int64
+	ExerciseName string
+	Sets         int
+	Reps         int
+	WeightKG     float64 // 0 = Körpergewicht
+	Action       string
+	Reason       string
+}
+
+// performance ist das Ergebnis einer Übung in einer Session. Gezählt werden
+// nur die Sätze mit dem höchsten Gewicht, Aufwärmsätze fallen so heraus.
+type performance struct {
+	weight float64
+	reps   []int
+}
+
+func (p performance) minReps() int {
+	m := p.reps[0]
+	for _, r := range p.reps[1:] {
+		m = min(m, r)
+	}
+	return m
+}
+
+func (p performance) maxReps() int {
+	m := p.reps[0]
+	for _, r := range p.reps[1:] {
+		m = max(m, r)
+	}
+	return m
+}
+
+// collect fasst die Sätze je Übung und Session zusammen, neueste Session
+// zuerst. Sätze ohne Übung aus dem Katalog zählen nicht.
+func collect(sessions []store.WorkoutSession) (map[int64][]performance, map[int64]string) {
+	history := map[int64][]performance{}
+	names := map[int64]string{}
+	for _, w := range sessions {
+		current := map[int64]*performance{}
+		var order []int64
+		for _, s := range w.Sets {
+			if s.ExerciseID == 0 || s.Reps < 1 {
+				continue
+			}
+			p, ok := current[s.ExerciseID]
+			switch {
+			case !ok:
+				current[s.ExerciseID] = &performance{weight: s.WeightKG, reps: []int{s.Reps}}
+				order = append(order, s.ExerciseID)
+			case s.WeightKG > p.weight:
+				p.weight, p.reps = s.WeightKG, []int{s.Reps}
+			case s.WeightKG == p.weight:
+				p.reps = append(p.reps, s.Reps)
+			}
+			if _, ok := names[s.ExerciseID]; !ok {
+				names[s.ExerciseID] = s.ExerciseName
+			}
+		}
+		for _, id := range order {
+			history[id] = append(history[id], *current[id])
+		}
+	}
+	return history, names
+}
+
+// Recommend berechnet je Übung ein Ziel aus sessions, die neueste zuerst
+// sortiert sein müssen. Das Ergebnis ist nach Übungsname sortiert.
+func Recommend(sessions []store.WorkoutSession) []Target {
+	history, names := collect(sessions)
+	targets := make([]Target, 0, len(history))
+	for id, perfs := range history {
+		var t Target
+		if perfs[0].weight > 0 {
+			t = weighted(perfs)
+		} else {
+			t = bodyweight(perfs)
+		}
+		t.ExerciseID, t.ExerciseName = id, names[id]
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].ExerciseName != targets[j].ExerciseName {
+			return targets[i].ExerciseName < targets[j].ExerciseName
+		}
+		return targets[i].ExerciseID < targets[j].ExerciseID
+	})
+	return targets
+}
+
+// weighted wendet die Doppelprogression an: erst die Wiederholungen bis
+// RepMax steigern, dann das Gewicht erhöhen und bei RepMin neu beginnen
+func weighted(perfs []performance) Target {
+	last := perfs[0]
+	t := Target{Sets: len(last.reps), Reps: RepMin, WeightKG: last.weight}
+
+	// Sessions in Folge, in denen ein Satz mit demselben Gewicht RepMin verfehlt hat
+	failures := 0
+	for _, p := range perfs {
+		if p.weight != last.weight || p.minReps() >= RepMin {
+			break
+		}
+		failures++
+	}
+
+	// Das reduzierte Gewicht liegt im Raster von Increment und damit immer
+	// unter dem letzten. Leichte Lasten lassen sich so nicht reduzieren, sie
+	// bleiben gleich.
+	deload := math.Floor(last.weight*DeloadFactor/Increment) * Increment
+
+	switch {
+	case last.minReps() >= RepMax:
+		t.WeightKG = last.weight + Increment
+		t.Action = IncreaseWeight
+		t.Reason = fmt.Sprintf("Alle Sätze mit %d Wiederholungen geschafft, das Gewicht steigt um %s kg",
+			RepMax, strings.Replace(fmt.Sprint(Increment), ".", ",", 1))
+	case failures >= FailuresBeforeDeload && deload > 0:
+		t.WeightKG = deload
+		t.Action = Deload
+		t.Reason = fmt.Sprintf("%d Trainings in Folge unter %d Wiederholungen, das Gewicht sinkt", failures, RepMin)
+	case failures > 0:
+		t.Action = Hold
+		t.Reason = fmt.Sprintf("Unter %d Wiederholungen, das Gewicht bleibt", RepMin)
+	default:
+		t.Reps = min(last.minReps()+1, RepMax)
+		t.Action = IncreaseReps
+		t.Reason = "Eine Wiederholung mehr pro Satz"
+	}
+	return t
+}
+
+// bodyweight steigert die Wiederholungen; stagniert die beste Serie
+// mehrmals in Folge, werden sie reduziert
+func bodyweight(perfs []performance) Target {
+	last := perfs[0]
+	t := Target{Sets: len(last.reps)}
+
+	stalls := 0
+	for i := 0; i+1 < len(perfs) && perfs[i].weight == 0 && perfs[i+1].weight == 0; i++ {
+		// Eine Steigerung oder ein Training nach einem Deload beendet die Serie
+		cur, prev := perfs[i].maxReps(), perfs[i+1].maxReps()
+		if cur > prev || cur <= deloadReps(prev) {
+			break
+		}
+		stalls++
+	}
+
+	if stalls >= FailuresBeforeDeload {
+		t.Reps = deloadReps(last.maxReps())
+		t.Action = Deload
+		t.Reason = fmt.Sprintf("%d Trainings in Folge ohne Steigerung, weniger Wiederholungen", stalls)
+		return t
+	}
+	t.Reps = last.maxReps() + 1
+	t.Action = IncreaseReps
+	t.Reason = "Eine Wiederholung mehr als deine beste Serie"
+	return t
+}
+
+func deloadReps(reps int) int {
+	return max(1, int(math.Round(float64(reps)*BodyweightDeloadFactor)))
+}
@@ -0,0 +1,177 @@
+package progression
+
+import (
+	"testing"
+
+	"trainora/store"
+)
+
+// session baut eine abgeschlossene Session aus Sätzen, set einen Satz mit
+// Übung, Wiederholungen und Gewicht
+func session(sets ...store.WorkoutSet) store.WorkoutSession {
+	return store.WorkoutSession{Sets: sets}
+}
+
+func set(exerciseID int64, reps int, weight float64) store.WorkoutSet {
+	names := map[int64]string{1: "Bankdrücken", 2: "Liegestütz", 3: "Seitheben"}
+	return store.WorkoutSet{ExerciseID: exerciseID, ExerciseName: names[exerciseID], Reps: reps, WeightKG: weight}
+}
+
+func TestRecommend(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []store.WorkoutSession // neueste zuerst
+		want     []Target
+	}{
+		{
+			name: "leerer Verlauf",
+			want: []Target{},
+		},
+		{
+			name: "Sätze ohne Katalog-Übung zählen nicht",
+			sessions: []store.WorkoutSession{
+				session(set(0, 10, 20), store.WorkoutSet{ExerciseID: 1, Reps: 0, WeightKG: 20}),
+			},
+			want: []Target{},
+		},
+		{
+			name: "Wiederholungen steigen",
+			sessions: []store.WorkoutSession{
+				session(set(1, 10, 40), set(1, 9, 40), set(1, 9, 40)),
+			},
+			want: []Target{{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 3, Reps: 10, WeightKG: 40, Action: IncreaseReps}},
+		},
+		{
+			name: "Gewicht steigt bei RepMax, Aufwärmsätze zählen nicht",
+			sessions: []store.WorkoutSession{
+				session(set(1, 15, 20), set(1, 12, 40), set(1, 12, 40), set(1, 13, 40)),
+				session(set(1, 11, 40), set(1, 10, 40), set(1, 10, 40)),
+			},
+			want: []Target{{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 3, Reps: RepMin, WeightKG: 42.5, Action: IncreaseWeight}},
+		},
+		{
+			name: "einmal gescheitert, Gewicht bleibt",
+			sessions: []store.WorkoutSession{
+				session(set(1, 8, 40), set(1, 7, 40)),
+				session(set(1, 12, 37.5), set(1, 12, 37.5)),
+			},
+			want: []Target{{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 2, Reps: RepMin, WeightKG: 40, Action: Hold}},
+		},
+		{
+			name: "Deload nach wiederholtem Scheitern",
+			sessions: []store.WorkoutSession{
+				session(set(1, 7, 40), set(1, 6, 40)),
+				session(set(1, 8, 40), set(1, 7, 40)),
+			},
+			want: []Target{{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 2, Reps: RepMin, WeightKG: 35, Action: Deload}},
+		},
+		{
+			name: "Scheitern mit anderem Gewicht beendet die Serie",
+			sessions: []store.WorkoutSession{
+				session(set(1, 7, 40)),
+				session(set(1, 7, 42.5)),
+			},
+			want: []Target{{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 1, Reps: RepMin, WeightKG: 40, Action: Hold}},
+		},
+		{
+			name: "leichte Last wird auf das Raster reduziert",
+			sessions: []store.WorkoutSession{
+				session(set(3, 6, 5)),
+				session(set(3, 7, 5)),
+			},
+			want: []Target{{ExerciseID: 3, ExerciseName: "Seitheben", Sets: 1, Reps: RepMin, WeightKG: 2.5, Action: Deload}},
+		},
+		{
+			name: "kleinste Last bleibt statt zu steigen",
+			sessions: []store.WorkoutSession{
+				session(set(3, 6, 2.5)),
+				session(set(3, 7, 2.5)),
+			},
+			want: []Target{{ExerciseID: 3, ExerciseName: "Seitheben", Sets: 1, Reps: RepMin, WeightKG: 2.5, Action: Hold}},
+		},
+		{
+			name: "Last unter dem Raster bleibt",
+			sessions: []store.WorkoutSession{
+				session(set(3, 5, 1)),
+				session(set(3, 5, 1)),
+				session(set(3, 6, 1)),
+			},
+			want: []Target{{ExerciseID: 3, ExerciseName: "Seitheben", Sets: 1, Reps: RepMin, WeightKG: 1, Action: Hold}},
+		},
+		{
+			name: "Körpergewicht steigt um eine Wiederholung",
+			sessions: []store.WorkoutSession{
+				session(set(2, 15, 0), set(2, 12, 0)),
+				session(set(2, 14, 0)),
+			},
+			want: []Target{{ExerciseID: 2, ExerciseName: "Liegestütz", Sets: 2, Reps: 16, Action: IncreaseReps}},
+		},
+		{
+			name: "Körpergewicht nach Stagnation reduziert",
+			sessions: []store.WorkoutSession{
+				session(set(2, 15, 0)),
+				session(set(2, 15, 0)),
+				session(set(2, 15, 0)),
+			},
+			want: []Target{{ExerciseID: 2, ExerciseName: "Liegestütz", Sets: 1, Reps: 12, Action: Deload}},
+		},
+		{
+			name: "Training nach Deload beendet die Stagnation",
+			sessions: []store.WorkoutSession{
+				session(set(2, 12, 0)),
+				session(set(2, 15, 0)),
+				session(set(2, 15, 0)),
+				session(set(2, 15, 0)),
+			},
+			want: []Target{{ExerciseID: 2, ExerciseName: "Liegestütz", Sets: 1, Reps: 13, Action: IncreaseReps}},
+		},
+		{
+			name: "mehrere Übungen nach Name sortiert",
+			sessions: []store.WorkoutSession{
+				session(set(3, 10, 5), set(2, 10, 0), set(1, 10, 40)),
+			},
+			want: []Target{
+				{ExerciseID: 1, ExerciseName: "Bankdrücken", Sets: 1, Reps: 11, WeightKG: 40, Action: IncreaseReps},
+				{ExerciseID: 2, ExerciseName: "Liegestütz", Sets: 1, Reps: 11, Action: IncreaseReps},
+				{ExerciseID: 3, ExerciseName: "Seitheben", Sets: 1, Reps: 11, WeightKG: 5, Action: IncreaseReps},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Recommend(tt.sessions)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Recommend() = %+v, want %+v", got, tt.want)
+			}
+			for i, g := range got {
+				if g.Reason == "" {
+					t.Errorf("target %d: empty reason", i)
+				}
+				g.Reason = ""
+				if g != tt.want[i] {
+					t.Errorf("target %d = %+v, want %+v", i, g, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Ein Deload darf das Gewicht nie erhöhen und nie über dem letzten liegen
+func TestDeloadNeverExceedsLastWeight(t *testing.T) {
+	for w := 0.5; w <= 200; w += 0.5 {
+		got := Recommend([]store.WorkoutSession{
+			session(set(1, 5, w)),
+			session(set(1, 5, w)),
+		})
+		if len(got) != 1 {
+			t.Fatalf("weight %g: got %d targets", w, len(got))
+		}
+		if got[0].WeightKG > w {
+			t.Errorf("weight %g: target %g exceeds last weight", w, got[0].WeightKG)
+		}
+		if got[0].Action == Deload && got[0].WeightKG >= w {
+			t.Errorf("weight %g: deload to %g does not reduce", w, got[0].WeightKG)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"trainora/crypto"
+	"trainora/routes"
+	"trainora/store"
+	"trainora/store/sqlstore"
+)
+
+// runRole führt den Unterbefehl "role" aus. Damit wird der erste Admin
+// angelegt, alle weiteren Rollen vergeben Admins über /api/admin.
+//
+//	trainora role <benutzer>                Rollen anzeigen
+//	trainora role <benutzer> grant <rolle>  Rolle vergeben
+//	trainora role <benutzer> revoke <rolle> Rolle entziehen
+//
+// <benutzer> ist der Benutzername oder die E-Mail-Adresse.
+func runRole(args []string) {
+	if len(args) != 1 && len(args) != 3 {
+		fmt.Fprintln(os.Stderr, "Aufruf: trainora role <benutzer> [grant|revoke <rolle>]")
+		os.Exit(2)
+	}
+
+	fieldCipher, err := crypto.NewFieldCipher(os.Getenv("SECRET_KEY"))
+	if err != nil {
+		log.Fatalf("❌ Ungültiger SECRET_KEY: %v", err)
+	}
+	driver := sqlstore.Driver()
+	db := sqlstore.Open(driver)
+	defer db.Close()
+	st := sqlstore.New(db, fieldCipher)
+
+	ctx := context.Background()
+	user, err := st.Users.ByLogin(ctx, args[0])
+	if err != nil {
+		log.Fatalf("❌ Benutzer %q nicht gefunden: %v", args[0], err)
+	}
+	roles, err := st.Roles.ByUser(ctx, user.ID)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	if len(args) == 3 {
+		role := args[2]
+		if role == store.RoleUser || !slices.Contains(store.Roles, role) {
+			log.Fatalf("❌ Ungültige Rolle %q. Erlaubt: %s, %s", role, store.RoleCoach, store.RoleAdmin)
+		}
+		switch args[1] {
+		case "grant":
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		case "revoke":
+			roles = slices.DeleteFunc(roles, func(r string) bool { return r == role })
+		default:
+			fmt.Fprintf(os.Stderr, "Unbekannter Befehl %q. Erlaubt: grant, revoke\n", args[1])
+			os.Exit(2)
+		}
+		// Wie über /api/admin endet mit der Rolle auch die Betreuung
+		if args[1] == "revoke" && role == store.RoleCoach {
+			if err := routes.EndAllCoaching(ctx, st, user.ID); err != nil {
+				log.Fatalf("❌ Betreuungen konnten nicht beendet werden: %v", err)
+			}
+		}
+		if err := st.Roles.Set(ctx, user.ID, roles); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Rollen von %s aktualisiert", user.Username)
+	}
+
+	fmt.Printf("%s: %s\n", user.Username, strings.Join(append([]string{store.RoleUser}, roles...), ", "))
+}
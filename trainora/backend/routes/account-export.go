@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mime"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/dataexport"
+	"trainora/store"
+)
+
+const (
+	// syncExportLimit ist die Zahl an Planeinträgen, bis zu der der Export
+	// direkt in der Anfrage erstellt wird; größere laufen im Hintergrund
+	syncExportLimit = 500
+	// staleExportAfter gibt einen Export frei, dessen Hintergrund-Job
+	// z. B. durch einen Neustart verloren ging
+	staleExportAfter = time.Hour
+	// dataExportRetention ist die Aufbewahrungsdauer nicht abgeholter Exporte
+	dataExportRetention = dataExportTTL
+)
+
+// RegisterExportRoutes registriert den Datenexport (Art. 15/20 DSGVO). Der
+// Export gilt nur für Browser-Sessions; der Download läuft über einen
+// Einmal-Link und braucht keine Session.
+func RegisterExportRoutes(api fiber.Router, st *store.Store, mails *AccountMailer) {
+	api.Get("/account/export", AuthMiddleware(st), exportHandler(st, mails))
+	api.Get("/account/export/download", exportDownloadHandler(st, mails))
+}
+
+// exportHandler liefert den Download-Link, wenn der Export fertig ist, und
+// startet sonst einen neuen. Große Exporte laufen im Hintergrund, der Link
+// kommt dann per Mail.
+func exportHandler(st *store.Store, mails *AccountMailer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		userID := Current(c).ID
+		now := time.Now()
+
+		export, err := st.DataExports.ByUser(ctx, userID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if export != nil {
+			switch {
+			case export.Status == store.JobSucceeded:
+				return exportReady(c, mails, userID)
+			case export.Status == store.JobRunning && now.Sub(export.CreatedAt) < staleExportAfter:
+				return c.Status(202).JSON(fiber.Map{
+					"status":  "pending",
+					"message": "Dein Export wird erstellt. Du bekommst eine Mail, sobald er fertig ist.",
+				})
+			}
+		}
+
+		count, err := st.Plans.Count(ctx, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := st.DataExports.Start(ctx, userID, now); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Export konnte nicht gestartet werden"})
+		}
+		recordAudit(c, st, userID, store.AuditExportRequested, map[string]string{"plan_entries": strconv.Itoa(count)})
+
+		if count > syncExportLimit {
+			go runDataExport(context.Background(), st, mails, userID)
+			log.Printf("📦 Datenexport für Benutzer %d im Hintergrund gestartet (%d Planeinträge)", userID, count)
+			return c.Status(202).JSON(fiber.Map{
+				"status":  "pending",
+				"message": "Dein Export wird erstellt. Du bekommst eine Mail, sobald er fertig ist.",
+			})
+		}
+
+		if err := buildDataExport(ctx, st, userID); err != nil {
+			log.Printf("❌ Datenexport für Benutzer %d fehlgeschlagen: %v", userID, err)
+			return c.Status(500).JSON(fiber.Map{"error": "Export konnte nicht erstellt werden"})
+		}
+		return exportReady(c, mails, userID)
+	}
+}
+
+func exportReady(c *fiber.Ctx, mails *AccountMailer, userID int64) error {
+	link, err := mails.DataExportLink(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Download-Link konnte nicht erstellt werden"})
+	}
+	return c.JSON(fiber.Map{
+		"status":       "ready",
+		"download_url": link,
+		"expires_in":   int(dataExportTTL.Seconds()),
+	})
+}
+
+// buildDataExport erstellt das Archiv und speichert das Ergebnis. Ein Fehler
+// wird am Export vermerkt, damit ein neuer Versuch möglich ist.
+func buildDataExport(ctx context.Context, st *store.Store, userID int64) error {
+	archive, buildErr := dataexport.Build(ctx, st, userID, time.Now())
+	errMsg := ""
+	if buildErr != nil {
+		archive, errMsg = nil, buildErr.Error()
+	}
+	if err := st.DataExports.Finish(ctx, userID, time.Now(), archive, errMsg); err != nil {
+		return err
+	}
+	return buildErr
+}
+
+func runDataExport(ctx context.Context, st *store.Store, mails *AccountMailer, userID int64) {
+	if err := buildDataExport(ctx, st, userID); err != nil {
+		log.Printf("❌ Datenexport für Benutzer %d fehlgeschlagen: %v", userID, err)
+		return
+	}
+	user, err := st.Users.ByID(ctx, userID)
+	if err != nil {
+		log.Printf("❌ Benutzer %d für Export-Mail nicht gefunden: %v", userID, err)
+		return
+	}
+	if err := mails.SendDataExport(ctx, user); err != nil {
+		log.Printf("❌ Export-Mail an Benutzer %d konnte nicht gesendet werden: %v", userID, err)
+		return
+	}
+	log.Printf("📦 Datenexport für Benutzer %d fertig", userID)
+}
+
+// exportDownloadHandler liefert das Archiv genau einmal aus und löscht es
+// danach
+func exportDownloadHandler(st *store.Store, mails *AccountMailer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		userID, err := mails.consumeToken(ctx, c.Query("token"), store.TokenDataExport)
+		if errors.Is(err, errInvalidMailToken) {
+			return c.Status(410).JSON(fiber.Map{"error": "Der Download-Link ist ungültig, abgelaufen oder wurde bereits verwendet"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		archive, err := st.DataExports.Archive(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(410).JSON(fiber.Map{"error": "Der Export ist nicht mehr vorhanden, bitte fordere einen neuen an"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Export konnte nicht geladen werden"})
+		}
+		if err := st.DataExports.Delete(ctx, userID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		c.Set(fiber.HeaderContentType, "application/zip")
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+			"filename": "trainora-export-" + time.Now().Format("2006-01-02") + ".zip",
+		}))
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		recordAudit(c, st, userID, store.AuditExportDownloaded, nil)
+		log.Printf("📦 Datenexport von Benutzer %d heruntergeladen", userID)
+		return c.Send(archive)
+	}
+}
@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"trainora/crypto"
+	"trainora/mail"
+	"trainora/store"
+)
+
+// Gültigkeit der Links in Mails
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	passwordResetTTL = time.Hour
+	dataExportTTL    = 24 * time.Hour
+)
+
+var errInvalidMailToken = errors.New("Link ungültig oder abgelaufen")
+
+// AccountMailer verschickt Mails mit signierten Einmal-Links für die
+// E-Mail-Bestätigung und das Zurücksetzen des Passworts
+type AccountMailer struct {
+	st      *store.Store
+	mailer  mail.Mailer
+	signer  *crypto.Signer
+	baseURL string
+}
+
+// NewAccountMailer erzeugt den AccountMailer; baseURL ist die öffentliche
+// Adresse des Frontends
+func NewAccountMailer(st *store.Store, mailer mail.Mailer, signer *crypto.Signer, baseURL string) *AccountMailer {
+	return &AccountMailer{st: st, mailer: mailer, signer: signer, baseURL: baseURL}
+}
+
+// mailTokenPayload ist der signierte Inhalt eines Mail-Links. Die Nonce macht
+// das Token einmalig, in der Datenbank liegt nur ihr Hash.
+type mailTokenPayload struct {
+	UserID  int64  `json:"u"`
+	Purpose string `json:"p"`
+	Nonce   string `json:"n"`
+	Expires int64  `json:"e"`
+}
+
+func hashNonce(nonce string) string {
+	sum := sha256.Sum256([]byte(nonce))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *AccountMailer) issueToken(ctx context.Context, userID int64, purpose string, ttl time.Duration) (string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(ttl)
+	payload, err := json.Marshal(mailTokenPayload{UserID: userID, Purpose: purpose, Nonce: nonce, Expires: expires.Unix()})
+	if err != nil {
+		return "", err
+	}
+	err = m.st.EmailTokens.Create(ctx, store.EmailToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		NonceHash: hashNonce(nonce),
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return "", err
+	}
+	return m.signer.Sign(payload), nil
+}
+
+// parseToken prüft Signatur, Zweck und Ablauf, ohne das Token zu entwerten
+func (m *AccountMailer) parseToken(token, purpose string) (*mailTokenPayload, error) {
+	data, err := m.signer.Verify(token)
+	if err != nil {
+		return nil, errInvalidMailToken
+	}
+	var payload mailTokenPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errInvalidMailToken
+	}
+	if payload.Purpose != purpose || time.Now().Unix() >= payload.Expires {
+		return nil, errInvalidMailToken
+	}
+	return &payload, nil
+}
+
+// consumeToken prüft das Token wie parseToken und entwertet es
+func (m *AccountMailer) consumeToken(ctx context.Context, token, purpose string) (int64, error) {
+	payload, err := m.parseToken(token, purpose)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := m.st.EmailTokens.Consume(ctx, hashNonce(payload.Nonce), purpose, time.Now())
+	if errors.Is(err, store.ErrNotFound) || (err == nil && userID != payload.UserID) {
+		return 0, errInvalidMailToken
+	}
+	return userID, err
+}
+
+// SendVerification verschickt den Link zur Bestätigung der E-Mail-Adresse.
+// Ältere, noch offene Links werden dabei ungültig.
+func (m *AccountMailer) SendVerification(ctx context.Context, user *store.User) error {
+	if err := m.st.EmailTokens.DeleteByUser(ctx, user.ID, store.TokenVerifyEmail); err != nil {
+		return err
+	}
+	token, err := m.issueToken(ctx, user.ID, store.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	link := m.baseURL + "/api/verify-email?token=" + url.QueryEscape(token)
+	return m.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Bitte bestätige deine E-Mail-Adresse",
+		Body: fmt.Sprintf("Hallo %s,\n\nwillkommen bei Trainora! Bitte bestätige deine E-Mail-Adresse über folgenden Link:\n\n%s\n\n"+
+			"Der Link ist 48 Stunden gültig. Falls du dich nicht registriert hast, kannst du diese Mail ignorieren.\n",
+			user.Username, link),
+	})
+}
+
+// SendPasswordReset verschickt den Link zum Zurücksetzen des Passworts
+func (m *AccountMailer) SendPasswordReset(ctx context.Context, user *store.User) error {
+	if err := m.st.EmailTokens.DeleteByUser(ctx, user.ID, store.TokenPasswordReset); err != nil {
+		return err
+	}
+	token, err := m.issueToken(ctx, user.ID, store.TokenPasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	link := m.baseURL + "/reset-password?token=" + url.QueryEscape(token)
+	return m.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Passwort zurücksetzen",
+		Body: fmt.Sprintf("Hallo %s,\n\nüber folgenden Link kannst du ein neues Passwort vergeben:\n\n%s\n\n"+
+			"Der Link ist eine Stunde gültig und kann nur einmal verwendet werden. "+
+			"Falls du kein neues Passwort angefordert hast, kannst du diese Mail ignorieren.\n",
+			user.Username, link),
+	})
+}
+
+// DataExportLink erzeugt den Einmal-Link zum Herunterladen des Datenexports.
+// Ein neuer Link entwertet den vorherigen.
+func (m *AccountMailer) DataExportLink(ctx context.Context, userID int64) (string, error) {
+	if err := m.st.EmailTokens.DeleteByUser(ctx, userID, store.TokenDataExport); err != nil {
+		return "", err
+	}
+	token, err := m.issueToken(ctx, userID, store.TokenDataExport, dataExportTTL)
+	if err != nil {
+		return "", err
+	}
+	return m.baseURL + "/api/account/export/download?token=" + url.QueryEscape(token), nil
+}
+
+// SendDataExport meldet per Mail, dass ein im Hintergrund erstellter
+// Datenexport bereitliegt
+func (m *AccountMailer) SendDataExport(ctx context.Context, user *store.User) error {
+	link, err := m.DataExportLink(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return m.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Dein Datenexport ist fertig",
+		Body: fmt.Sprintf("Hallo %s,\n\ndein Datenexport steht zum Herunterladen bereit:\n\n%s\n\n"+
+			"Der Link ist 24 Stunden gültig und kann nur einmal verwendet werden. "+
+			"Danach wird das Archiv gelöscht; du kannst jederzeit einen neuen Export anfordern.\n",
+			user.Username, link),
+	})
+}
+
+// SendDeletionNotice bestätigt die Löschung des Kontos und enthält den Link
+// zur Wiederherstellung. Er gilt bis zur endgültigen Löschung um purgeAt.
+func (m *AccountMailer) SendDeletionNotice(ctx context.Context, user *store.User, purgeAt time.Time) error {
+	if err := m.st.EmailTokens.DeleteByUser(ctx, user.ID, store.TokenRestore); err != nil {
+		return err
+	}
+	token, err := m.issueToken(ctx, user.ID, store.TokenRestore, time.Until(purgeAt))
+	if err != nil {
+		return err
+	}
+	link := m.baseURL + "/api/account/restore?token=" + url.QueryEscape(token)
+	return m.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Dein Konto wird gelöscht",
+		Body: fmt.Sprintf("Hallo %s,\n\ndein Trainora-Konto ist zur Löschung vorgemerkt und wird am %s mit allen Daten endgültig gelöscht.\n\n"+
+			"Falls du es dir anders überlegst, kannst du es bis dahin über folgenden Link wiederherstellen:\n\n%s\n",
+			user.Username, purgeAt.Format("02.01.2006 um 15:04 Uhr"), link),
+	})
+}
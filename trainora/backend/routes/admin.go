@@ -0,0 +1,326 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/consent"
+	"trainora/store"
+)
+
+// maxAdminPageSize begrenzt die Seitengröße der Admin-Listen
+const maxAdminPageSize = 200
+
+// RegisterAdminRoutes registriert die Verwaltung für Admins. Die Routen
+// gelten nur für Browser-Sessions, API-Tokens haben keinen Admin-Scope.
+func RegisterAdminRoutes(api fiber.Router, st *store.Store) {
+	admin := api.Group("/admin", AuthMiddleware(st), RequireRole(store.RoleAdmin))
+	admin.Get("/users", listUsersHandler(st))
+	admin.Put("/users/:id/roles", setRolesHandler(st))
+	admin.Post("/users/:id/disable", disableUserHandler(st))
+	admin.Post("/users/:id/enable", enableUserHandler(st))
+	admin.Post("/users/:id/restore", restoreUserHandler(st))
+	admin.Post("/users/:id/regenerate-plan", regeneratePlanHandler(st))
+	admin.Get("/generation-failures", generationFailuresHandler(st))
+}
+
+type adminUserResponse struct {
+	ID              int64     `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	Roles           []string  `json:"roles"`
+	SetupCompleted  bool      `json:"setup_completed"`
+	EmailVerifiedAt time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	LockedUntil     time.Time `json:"locked_until"`
+	DisabledAt      time.Time `json:"disabled_at"`
+	DeletedAt       time.Time `json:"deleted_at"`
+}
+
+func newAdminUserResponse(ctx context.Context, st *store.Store, u store.User) (adminUserResponse, error) {
+	granted, err := st.Roles.ByUser(ctx, u.ID)
+	if err != nil {
+		return adminUserResponse{}, err
+	}
+	return adminUserResponse{
+		ID:              u.ID,
+		Username:        u.Username,
+		Email:           u.Email,
+		Roles:           append([]string{store.RoleUser}, granted...),
+		SetupCompleted:  u.SetupCompleted,
+		EmailVerifiedAt: u.EmailVerifiedAt,
+		CreatedAt:       u.CreatedAt,
+		LockedUntil:     u.LockedUntil,
+		DisabledAt:      u.DisabledAt,
+		DeletedAt:       u.DeletedAt,
+	}, nil
+}
+
+// pageParams liest offset und limit aus der Query
+func pageParams(c *fiber.Ctx, defaultLimit int) (int, int) {
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+	limit := c.QueryInt("limit", defaultLimit)
+	if limit < 1 || limit > maxAdminPageSize {
+		limit = defaultLimit
+	}
+	return offset, limit
+}
+
+func listUsersHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		offset, limit := pageParams(c, 50)
+
+		users, total, err := st.Users.List(ctx, offset, limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Benutzer konnten nicht geladen werden"})
+		}
+		list := make([]adminUserResponse, 0, len(users))
+		for _, u := range users {
+			resp, err := newAdminUserResponse(ctx, st, u)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "Benutzer konnten nicht geladen werden"})
+			}
+			list = append(list, resp)
+		}
+		return c.JSON(fiber.Map{"users": list, "total": total, "offset": offset, "limit": limit})
+	}
+}
+
+// adminTarget lädt den Benutzer aus dem Pfad. Ist der zweite Rückgabewert
+// nicht nil, wurde bereits geantwortet.
+func adminTarget(c *fiber.Ctx, st *store.Store) (*store.User, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return nil, c.Status(400).JSON(fiber.Map{"error": "Ungültige Benutzer-ID"})
+	}
+	user, err := st.Users.ByID(c.UserContext(), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Benutzer nicht gefunden"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	return user, nil
+}
+
+func setRolesHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Roles []string `json:"roles"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		for _, role := range input.Roles {
+			if !slices.Contains(store.Roles, role) {
+				return c.Status(400).JSON(fiber.Map{"error": "Unbekannte Rolle: " + role, "roles": store.Roles})
+			}
+		}
+
+		user, err := adminTarget(c, st)
+		if user == nil {
+			return err
+		}
+		// Sonst könnte sich der letzte Admin versehentlich selbst aussperren
+		admin := Current(c)
+		if user.ID == admin.ID && !slices.Contains(input.Roles, store.RoleAdmin) {
+			return c.Status(400).JSON(fiber.Map{"error": "Die eigene Admin-Rolle kann nicht entzogen werden"})
+		}
+
+		ctx := c.UserContext()
+		previous, err := st.Roles.ByUser(ctx, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		// Ohne Rolle endet die Betreuung, zurückgehaltene Wochen werden sichtbar
+		if slices.Contains(previous, store.RoleCoach) && !slices.Contains(input.Roles, store.RoleCoach) {
+			if err := EndAllCoaching(ctx, st, user.ID); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "Betreuungen konnten nicht beendet werden"})
+			}
+			log.Printf("🛡️ Betreuungen von Coach %d wurden mit der Rolle beendet", user.ID)
+		}
+		if err := st.Roles.Set(ctx, user.ID, input.Roles); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Rollen konnten nicht gespeichert werden"})
+		}
+		recordAudit(c, st, user.ID, store.AuditRolesChanged, map[string]string{"roles": strings.Join(input.Roles, ",")})
+		log.Printf("🛡️ Admin %d hat die Rollen von Benutzer %d auf %v gesetzt", admin.ID, user.ID, input.Roles)
+
+		resp, err := newAdminUserResponse(ctx, st, *user)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(resp)
+	}
+}
+
+// disableUserHandler sperrt ein Konto dauerhaft. Sessions verlieren bei der
+// nächsten Anfrage ihre Gültigkeit, Remember- und Refresh-Tokens werden
+// gelöscht. Betreut der Benutzer Klienten, endet die Betreuung.
+func disableUserHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := adminTarget(c, st)
+		if user == nil {
+			return err
+		}
+		admin := Current(c)
+		if user.ID == admin.ID {
+			return c.Status(400).JSON(fiber.Map{"error": "Das eigene Konto kann nicht deaktiviert werden"})
+		}
+		if user.Disabled() {
+			return c.JSON(fiber.Map{"message": "Konto ist bereits deaktiviert"})
+		}
+
+		ctx := c.UserContext()
+		if err := st.Users.SetDisabled(ctx, user.ID, time.Now()); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Konto konnte nicht deaktiviert werden"})
+		}
+		if err := st.RememberTokens.DeleteByUser(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := st.RefreshTokens.DeleteByUser(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := EndAllCoaching(ctx, st, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Betreuungen konnten nicht beendet werden"})
+		}
+		recordAudit(c, st, user.ID, store.AuditAccountDisabled, nil)
+		log.Printf("🛡️ Admin %d hat Benutzer %d deaktiviert", admin.ID, user.ID)
+		return c.JSON(fiber.Map{"message": "Konto deaktiviert"})
+	}
+}
+
+func enableUserHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := adminTarget(c, st)
+		if user == nil {
+			return err
+		}
+		if !user.Disabled() {
+			return c.JSON(fiber.Map{"message": "Konto ist bereits aktiv"})
+		}
+		if err := st.Users.SetDisabled(c.UserContext(), user.ID, time.Time{}); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Konto konnte nicht aktiviert werden"})
+		}
+		recordAudit(c, st, user.ID, store.AuditAccountEnabled, nil)
+		log.Printf("🛡️ Admin %d hat Benutzer %d wieder aktiviert", Current(c).ID, user.ID)
+		return c.JSON(fiber.Map{"message": "Konto aktiviert"})
+	}
+}
+
+// restoreUserHandler stellt ein vom Benutzer gelöschtes Konto innerhalb der
+// Karenzzeit wieder her, z. B. wenn die Mail mit dem Link verloren ging
+func restoreUserHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, err := adminTarget(c, st)
+		if user == nil {
+			return err
+		}
+		if !user.Deleted() {
+			return c.JSON(fiber.Map{"message": "Konto ist nicht gelöscht"})
+		}
+		if err := st.Users.SetDeleted(c.UserContext(), user.ID, time.Time{}); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Konto konnte nicht wiederhergestellt werden"})
+		}
+		recordAudit(c, st, user.ID, store.AuditAccountRestored, nil)
+		log.Printf("🛡️ Admin %d hat das gelöschte Konto von Benutzer %d wiederhergestellt", Current(c).ID, user.ID)
+		return c.JSON(fiber.Map{"message": "Konto wiederhergestellt"})
+	}
+}
+
+// regeneratePlanHandler erstellt den Wochenplan eines Benutzers neu. Die
+// Generierung dauert lange und läuft daher im Hintergrund; das Ergebnis
+// steht im Job-Protokoll.
+func regeneratePlanHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Week string `json:"week"` // "current" (Standard) oder "next"
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&input); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+			}
+		}
+		var weekStartDate string
+		switch input.Week {
+		case "", "current":
+			weekStartDate = getWeekStartDateOllama(time.Now()).Format("2006-01-02")
+		case "next":
+			weekStartDate = getNextWeekStartDate()
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "week muss \"current\" oder \"next\" sein"})
+		}
+
+		user, err := adminTarget(c, st)
+		if user == nil {
+			return err
+		}
+		if !user.SetupCompleted {
+			return c.Status(409).JSON(fiber.Map{"error": "Der Benutzer hat das Setup noch nicht abgeschlossen"})
+		}
+		consented, err := hasConsent(c.UserContext(), st, user.ID, consent.HealthData)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !consented {
+			return c.Status(409).JSON(fiber.Map{"error": "Der Benutzer hat der Verarbeitung seiner Gesundheitsdaten nicht zugestimmt"})
+		}
+
+		job, err := startGenerationJob(c.UserContext(), st, user.ID, weekStartDate, store.TriggerAdmin)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		go runGenerationJob(context.Background(), st, job, true)
+
+		recordAudit(c, st, user.ID, store.AuditPlanRegenerated, map[string]string{"week_start_date": weekStartDate})
+		log.Printf("🛡️ Admin %d hat den Wochenplan %s von Benutzer %d neu angestoßen", Current(c).ID, weekStartDate, user.ID)
+		return c.Status(202).JSON(fiber.Map{
+			"message":         "Generierung gestartet",
+			"job_id":          job.ID,
+			"week_start_date": weekStartDate,
+		})
+	}
+}
+
+type generationJobResponse struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	WeekStartDate string    `json:"week_start_date"`
+	TriggeredBy   string    `json:"triggered_by"`
+	Error         string    `json:"error"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+}
+
+func generationFailuresHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, limit := pageParams(c, 50)
+		jobs, err := st.GenerationJobs.Failures(c.UserContext(), limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Jobs konnten nicht geladen werden"})
+		}
+		list := make([]generationJobResponse, 0, len(jobs))
+		for _, j := range jobs {
+			list = append(list, generationJobResponse{
+				ID:            j.ID,
+				UserID:        j.UserID,
+				WeekStartDate: j.WeekStartDate,
+				TriggeredBy:   j.TriggeredBy,
+				Error:         j.Error,
+				StartedAt:     j.StartedAt,
+				FinishedAt:    j.FinishedAt,
+			})
+		}
+		return c.JSON(fiber.Map{"failures": list})
+	}
+}
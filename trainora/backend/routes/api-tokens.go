@@ -0,0 +1,404 @@
+package routes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/apitoken"
+	"trainora/config"
+	"trainora/store"
+)
+
+const (
+	// maxPersonalTokenDays ist die längste wählbare Laufzeit eines
+	// persönlichen Zugriffstokens; 0 bedeutet unbegrenzt
+	maxPersonalTokenDays = 365
+	// personalTokenTouch ist der Mindestabstand, in dem last_used_at
+	// aktualisiert wird, damit nicht jede Anfrage schreibt
+	personalTokenTouch = time.Minute
+)
+
+// RegisterTokenRoutes registriert die Token-Endpunkte für Apps und Skripte:
+// Login mit Passwort, Erneuern und Widerrufen der JWT-Access-Tokens sowie
+// die Verwaltung persönlicher Zugriffstokens. Letztere sind nur aus einer
+// Browser-Session erreichbar.
+func RegisterTokenRoutes(api fiber.Router, st *store.Store, cfg config.Config) {
+	limiter := loginLimiter{st: st, cfg: cfg.Login}
+	api.Post("/token", passwordTokenHandler(st, limiter))
+	api.Post("/token/refresh", refreshTokenHandler(st))
+	api.Post("/token/revoke", revokeTokenHandler(st))
+
+	api.Get("/tokens", AuthMiddleware(st), listPersonalTokensHandler(st))
+	api.Post("/tokens", AuthMiddleware(st), createPersonalTokenHandler(st))
+	api.Delete("/tokens/:id", AuthMiddleware(st), deletePersonalTokenHandler(st))
+}
+
+// bearerToken liest den Token aus "Authorization: Bearer <token>"
+func bearerToken(c *fiber.Ctx) string {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// bearerAuth meldet den Benutzer eines Access-Tokens oder persönlichen
+// Zugriffstokens an. Fehler werden nach RFC 6750 im Header
+// WWW-Authenticate beschrieben.
+func bearerAuth(c *fiber.Ctx, st *store.Store, token string, scopes []string) error {
+	if len(scopes) == 0 {
+		return c.Status(403).JSON(fiber.Map{"error": "Diese Aktion ist nur nach Anmeldung im Browser möglich"})
+	}
+
+	ctx := c.UserContext()
+	now := time.Now()
+	var user *store.User
+	var granted []string
+
+	if apitoken.IsPersonal(token) {
+		t, err := st.PersonalTokens.ByHash(ctx, apitoken.Hash(token))
+		if errors.Is(err, store.ErrNotFound) || (err == nil && !t.ExpiresAt.IsZero() && !t.ExpiresAt.After(now)) {
+			return invalidBearer(c, "Ungültiger Token")
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		user, err = st.Users.ByID(ctx, t.UserID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && (user.Disabled() || user.Deleted())) {
+			return invalidBearer(c, "Ungültiger Token")
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if now.Sub(t.LastUsedAt) > personalTokenTouch {
+			_ = st.PersonalTokens.Touch(ctx, t.ID, now)
+		}
+		granted = t.Scopes
+	} else {
+		claims, err := apitoken.ParseAccess(token, now)
+		if errors.Is(err, apitoken.ErrExpired) {
+			return invalidBearer(c, "Token abgelaufen")
+		}
+		if err != nil {
+			return invalidBearer(c, "Ungültiger Token")
+		}
+		// Access-Tokens aus der Zeit vor einer Passwortänderung gelten nicht mehr
+		user, err = sessionUser(c, st, claims.UserID(), claims.IssuedAt)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if user == nil {
+			return invalidBearer(c, "Ungültiger Token")
+		}
+		granted = claims.Scopes()
+	}
+
+	if !apitoken.Covers(granted, scopes...) {
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="insufficient_scope", scope="`+strings.Join(scopes, " ")+`"`)
+		return c.Status(403).JSON(fiber.Map{
+			"error":           "Dem Token fehlt die nötige Berechtigung",
+			"required_scopes": scopes,
+		})
+	}
+
+	if err := setCurrentUser(c, st, user, granted); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	return c.Next()
+}
+
+func invalidBearer(c *fiber.Ctx, msg string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	return c.Status(401).JSON(fiber.Map{"error": msg})
+}
+
+// passwordTokenHandler meldet eine App mit Benutzername/E-Mail und Passwort
+// an. Es gelten dieselben Limits wie beim Login im Browser; mit aktivierter
+// 2FA muss der Code in derselben Anfrage mitgeschickt werden.
+func passwordTokenHandler(st *store.Store, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Login    string `json:"login"`
+			Password string `json:"password"`
+			Code     string `json:"code"`
+			Scope    string `json:"scope"` // durch Leerzeichen getrennt, leer = alle
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		scopes := apitoken.Scopes
+		if input.Scope != "" {
+			var err error
+			scopes, err = apitoken.ParseScopes(strings.Fields(input.Scope))
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+
+		ctx := c.UserContext()
+		now := time.Now()
+		user, attempt, err := checkPassword(c, st, limiter, input.Login, input.Password, now)
+		if user == nil {
+			return err
+		}
+
+		tf, err := enabledTwoFactor(ctx, st, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if tf != nil {
+			if input.Code == "" {
+				return c.Status(401).JSON(fiber.Map{
+					"error":               "Bitte Code aus der Authenticator-App angeben",
+					"two_factor_required": true,
+				})
+			}
+			ok, err := checkSecondFactor(ctx, st, user.ID, tf, input.Code, true, now)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			if !ok {
+				lock, err := limiter.recordFailure(ctx, attempt, user)
+				if err != nil {
+					return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+				}
+				if lock > 0 {
+					return tooManyAttempts(c, lock)
+				}
+				return c.Status(401).JSON(fiber.Map{"error": "Code ungültig"})
+			}
+		}
+
+		attempt.Success = true
+		if err := limiter.recordSuccess(ctx, attempt, user); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		recordLogin(c, st, user.ID, loginAPIToken, false)
+
+		family := make([]byte, 16)
+		if _, err := rand.Read(family); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Token konnte nicht erstellt werden"})
+		}
+		return issueTokenPair(c, st, user.ID, hex.EncodeToString(family), scopes, now)
+	}
+}
+
+// issueTokenPair antwortet mit einem neuen Access- und Refresh-Token
+func issueTokenPair(c *fiber.Ctx, st *store.Store, userID int64, family string, scopes []string, now time.Time) error {
+	access, err := apitoken.IssueAccess(userID, scopes, now)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Token konnte nicht erstellt werden"})
+	}
+	refresh, hash, err := apitoken.NewRefresh()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Token konnte nicht erstellt werden"})
+	}
+	_, err = st.RefreshTokens.Create(c.UserContext(), store.RefreshToken{
+		UserID:    userID,
+		TokenHash: hash,
+		Family:    family,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(apitoken.RefreshTTL),
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    int(apitoken.AccessTTL.Seconds()),
+		"refresh_token": refresh,
+		"scope":         strings.Join(scopes, " "),
+	})
+}
+
+// refreshTokenHandler tauscht einen Refresh-Token gegen ein neues Paar. Wird
+// ein bereits benutzter Token erneut vorgelegt, wurde er vermutlich
+// gestohlen; dann wird die ganze Familie widerrufen.
+func refreshTokenHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		ctx := c.UserContext()
+		now := time.Now()
+		t, err := st.RefreshTokens.ByHash(ctx, apitoken.Hash(input.RefreshToken))
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(401).JSON(fiber.Map{"error": "Ungültiger Refresh-Token"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !t.ExpiresAt.After(now) {
+			return c.Status(401).JSON(fiber.Map{"error": "Refresh-Token abgelaufen"})
+		}
+
+		fresh := t.UsedAt.IsZero()
+		if fresh {
+			if fresh, err = st.RefreshTokens.Use(ctx, t.ID, now); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+		}
+		if !fresh {
+			log.Printf("⚠️ Refresh-Token von Benutzer %d wiederverwendet, alle Nachfolger widerrufen", t.UserID)
+			if err := st.RefreshTokens.DeleteFamily(ctx, t.Family); err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			return c.Status(401).JSON(fiber.Map{"error": "Ungültiger Refresh-Token"})
+		}
+
+		return issueTokenPair(c, st, t.UserID, t.Family, t.Scopes, now)
+	}
+}
+
+// revokeTokenHandler meldet eine App ab. Die Antwort verrät nicht, ob der
+// Token existierte.
+func revokeTokenHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		ctx := c.UserContext()
+		t, err := st.RefreshTokens.ByHash(ctx, apitoken.Hash(input.RefreshToken))
+		if err == nil {
+			err = st.RefreshTokens.DeleteFamily(ctx, t.Family)
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"message": "Token widerrufen"})
+	}
+}
+
+type personalTokenResponse struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func newPersonalTokenResponse(t store.PersonalToken) personalTokenResponse {
+	scopes := t.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return personalTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     scopes,
+		CreatedAt:  t.CreatedAt,
+		LastUsedAt: t.LastUsedAt,
+		ExpiresAt:  t.ExpiresAt,
+	}
+}
+
+func listPersonalTokensHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		list, err := st.PersonalTokens.ListByUser(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		tokens := make([]personalTokenResponse, 0, len(list))
+		for _, t := range list {
+			tokens = append(tokens, newPersonalTokenResponse(t))
+		}
+		return c.JSON(fiber.Map{"tokens": tokens, "available_scopes": apitoken.Scopes})
+	}
+}
+
+// createPersonalTokenHandler erstellt einen Zugriffstoken. Der Token selbst
+// wird nur in dieser Antwort angezeigt.
+func createPersonalTokenHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Name          string   `json:"name"`
+			Scopes        []string `json:"scopes"`
+			ExpiresInDays int      `json:"expires_in_days"` // 0 = unbegrenzt
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		userID := Current(c).ID
+
+		input.Name = strings.TrimSpace(input.Name)
+		if input.Name == "" || len(input.Name) > 100 {
+			return c.Status(400).JSON(fiber.Map{"error": "Name muss 1 bis 100 Zeichen lang sein"})
+		}
+		scopes, err := apitoken.ParseScopes(input.Scopes)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if len(scopes) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Mindestens eine Berechtigung auswählen"})
+		}
+		if input.ExpiresInDays < 0 || input.ExpiresInDays > maxPersonalTokenDays {
+			return c.Status(400).JSON(fiber.Map{"error": "Laufzeit muss zwischen 0 und " + strconv.Itoa(maxPersonalTokenDays) + " Tagen liegen"})
+		}
+
+		token, hash, err := apitoken.NewPersonal()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Token konnte nicht erstellt werden"})
+		}
+		now := time.Now()
+		t := store.PersonalToken{
+			UserID:    userID,
+			Name:      input.Name,
+			TokenHash: hash,
+			Scopes:    scopes,
+			CreatedAt: now,
+		}
+		if input.ExpiresInDays > 0 {
+			t.ExpiresAt = now.AddDate(0, 0, input.ExpiresInDays)
+		}
+		if t.ID, err = st.PersonalTokens.Create(c.UserContext(), t); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"message": "Token erstellt. Er wird nur jetzt angezeigt.",
+			"token":   token,
+			"details": newPersonalTokenResponse(t),
+		})
+	}
+}
+
+func deletePersonalTokenHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+		}
+		err = st.PersonalTokens.Delete(c.UserContext(), userID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Token nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"message": "Token widerrufen"})
+	}
+}
@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/apitoken"
+)
+
+// bearer schickt eine Anfrage mit "Authorization: Bearer token"
+func (ta *testApp) bearer(t *testing.T, method, path, token string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	resp, err := ta.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// tokenPair meldet eine App mit Passwort an und liefert Access- und Refresh-Token
+func (ta *testApp) tokenPair(t *testing.T, scope string) (string, string) {
+	t.Helper()
+	body := wantStatus(t, ta.do(t, "POST", "/api/token", map[string]string{
+		"login":    "anna",
+		"password": strongPassword,
+		"scope":    scope,
+	}), 200)
+	access, _ := body["access_token"].(string)
+	refresh, _ := body["refresh_token"].(string)
+	if access == "" || refresh == "" {
+		t.Fatalf("/api/token = %v", body)
+	}
+	return access, refresh
+}
+
+func (ta *testApp) refresh(t *testing.T, token string, status int) string {
+	t.Helper()
+	body := wantStatus(t, ta.do(t, "POST", "/api/token/refresh", map[string]string{"refresh_token": token}), status)
+	next, _ := body["refresh_token"].(string)
+	return next
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	_, first := ta.tokenPair(t, "")
+
+	second := ta.refresh(t, first, 200)
+	third := ta.refresh(t, second, 200)
+	if second == first || third == second {
+		t.Fatal("Refresh-Token nicht ausgetauscht")
+	}
+
+	// Ein zweites Mal vorgelegt gilt first als gestohlen: die ganze Familie
+	// einschließlich des aktuellen Nachfolgers wird widerrufen
+	ta.refresh(t, first, 401)
+	ta.refresh(t, third, 401)
+
+	// Andere Anmeldungen derselben App bilden eigene Familien
+	_, other := ta.tokenPair(t, "")
+	_, another := ta.tokenPair(t, "")
+	ta.refresh(t, ta.refresh(t, other, 200), 200)
+	ta.refresh(t, other, 401)
+	ta.refresh(t, another, 200)
+}
+
+func TestRefreshTokenKeepsScopes(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	_, refresh := ta.tokenPair(t, apitoken.ScopeReadPlan)
+
+	body := wantStatus(t, ta.do(t, "POST", "/api/token/refresh", map[string]string{"refresh_token": refresh}), 200)
+	if body["scope"] != apitoken.ScopeReadPlan {
+		t.Errorf("scope = %v, want %s", body["scope"], apitoken.ScopeReadPlan)
+	}
+	access, _ := body["access_token"].(string)
+	wantStatus(t, ta.bearer(t, "GET", "/api/me", access), 403)
+}
+
+func TestRevokeToken(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	_, first := ta.tokenPair(t, "")
+	second := ta.refresh(t, first, 200)
+
+	// Widerrufen mit einem alten Token trifft auch den aktuellen
+	wantStatus(t, ta.do(t, "POST", "/api/token/revoke", map[string]string{"refresh_token": first}), 200)
+	ta.refresh(t, second, 401)
+	wantStatus(t, ta.do(t, "POST", "/api/token/revoke", map[string]string{"refresh_token": "unbekannt"}), 200)
+}
+
+func TestBearerScopes(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+
+	profile, _ := ta.tokenPair(t, apitoken.ScopeReadProfile)
+	me := wantStatus(t, ta.bearer(t, "GET", "/api/me", profile), 200)
+	if me["username"] != "anna" {
+		t.Errorf("/api/me = %v", me)
+	}
+
+	plan, _ := ta.tokenPair(t, apitoken.ScopeReadPlan)
+	resp := ta.bearer(t, "GET", "/api/me", plan)
+	body := wantStatus(t, resp, 403)
+	if got := resp.Header.Get(fiber.HeaderWWWAuthenticate); !strings.Contains(got, `error="insufficient_scope"`) ||
+		!strings.Contains(got, `scope="`+apitoken.ScopeReadProfile+`"`) {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+	if scopes, _ := body["required_scopes"].([]any); len(scopes) != 1 || scopes[0] != apitoken.ScopeReadProfile {
+		t.Errorf("required_scopes = %v", body["required_scopes"])
+	}
+
+	// Routen ohne Scope sind nur aus dem Browser erreichbar, auch mit allen Scopes
+	all, _ := ta.tokenPair(t, "")
+	wantStatus(t, ta.bearer(t, "GET", "/api/devices", all), 403)
+	wantStatus(t, ta.bearer(t, "GET", "/api/tokens", all), 403)
+
+	wantStatus(t, ta.do(t, "POST", "/api/token", map[string]string{
+		"login": "anna", "password": strongPassword, "scope": "admin",
+	}), 400)
+
+	resp = ta.bearer(t, "GET", "/api/me", "kaputt")
+	wantStatus(t, resp, 401)
+	if got := resp.Header.Get(fiber.HeaderWWWAuthenticate); got != `Bearer error="invalid_token"` {
+		t.Errorf("WWW-Authenticate = %q", got)
+	}
+}
+
+func TestPersonalTokenScopes(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	resp := ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess := cookie(resp, "session_id")
+
+	create := func(scopes ...string) (string, float64) {
+		t.Helper()
+		body := wantStatus(t, ta.do(t, "POST", "/api/tokens", map[string]any{"name": "Skript", "scopes": scopes}, sess), 201)
+		token, _ := body["token"].(string)
+		details, _ := body["details"].(map[string]any)
+		id, _ := details["id"].(float64)
+		return token, id
+	}
+
+	profile, id := create(apitoken.ScopeReadProfile)
+	wantStatus(t, ta.bearer(t, "GET", "/api/me", profile), 200)
+	feedback, _ := create(apitoken.ScopeWriteFeedback)
+	wantStatus(t, ta.bearer(t, "GET", "/api/me", feedback), 403)
+
+	// Persönliche Tokens verwalten sich nicht selbst
+	wantStatus(t, ta.bearer(t, "GET", "/api/tokens", profile), 403)
+
+	wantStatus(t, ta.do(t, "POST", "/api/tokens", map[string]any{"name": "Skript", "scopes": []string{"admin"}}, sess), 400)
+	wantStatus(t, ta.do(t, "POST", "/api/tokens", map[string]any{"name": "Skript"}, sess), 400)
+
+	wantStatus(t, ta.do(t, "DELETE", "/api/tokens/"+strconv.FormatInt(int64(id), 10), nil, sess), 200)
+	wantStatus(t, ta.bearer(t, "GET", "/api/me", profile), 401)
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/store"
+)
+
+// RegisterActivityRoutes registriert die Kontoaktivität, also das
+// Audit-Log aus Sicht des Benutzers
+func RegisterActivityRoutes(api fiber.Router, st *store.Store) {
+	api.Get("/account/activity", AuthMiddleware(st), activityHandler(st))
+}
+
+// recordAudit hängt ein Ereignis zum Konto userID an das Audit-Log an.
+// Handelnder ist der angemeldete Benutzer, ohne Anmeldung (Login, Links aus
+// Mails) das Konto selbst. Ein Fehler wird nur geloggt, damit die eigentliche
+// Aktion nicht am Protokoll scheitert.
+func recordAudit(c *fiber.Ctx, st *store.Store, userID int64, eventType string, metadata map[string]string) {
+	actorID := userID
+	if user, ok := c.Locals(currentUserKey{}).(*CurrentUser); ok {
+		actorID = user.ID
+	}
+	err := st.AuditEvents.Record(c.UserContext(), store.AuditEvent{
+		UserID:    userID,
+		ActorID:   actorID,
+		Type:      eventType,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Metadata:  metadata,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("❌ Audit-Ereignis %s für Benutzer %d konnte nicht gespeichert werden: %v", eventType, userID, err)
+	}
+}
+
+// Anmeldewege im Audit-Log
+const (
+	loginPassword  = "password"
+	loginTwoFactor = "two_factor"
+	loginOIDC      = "oidc"
+	loginRemember  = "remember_me"
+	loginAPIToken  = "api_token"
+)
+
+func recordLogin(c *fiber.Ctx, st *store.Store, userID int64, method string, remember bool) {
+	recordAudit(c, st, userID, store.AuditLogin, map[string]string{
+		"method":   method,
+		"remember": strconv.FormatBool(remember),
+	})
+}
+
+type activityResponse struct {
+	Type string `json:"type"`
+	// Actor ist "self", "admin" bei Aktionen eines Admins oder "system"
+	Actor     string            `json:"actor"`
+	IP        string            `json:"ip"`
+	UserAgent string            `json:"user_agent"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+func activityHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		offset, limit := pageParams(c, 50)
+
+		events, total, err := st.AuditEvents.ByUser(c.UserContext(), userID, offset, limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Aktivitäten konnten nicht geladen werden"})
+		}
+		list := make([]activityResponse, 0, len(events))
+		for _, e := range events {
+			actor := "self"
+			switch e.ActorID {
+			case 0:
+				actor = "system"
+			case userID:
+			default:
+				actor = "admin"
+			}
+			metadata := e.Metadata
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			list = append(list, activityResponse{
+				Type:      e.Type,
+				Actor:     actor,
+				IP:        e.IP,
+				UserAgent: e.UserAgent,
+				Metadata:  metadata,
+				CreatedAt: e.CreatedAt,
+			})
+		}
+		return c.JSON(fiber.Map{"events": list, "total": total, "offset": offset, "limit": limit})
+	}
+}
@@ -1,143 +1,346 @@
 package routes
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
-	
+
+	"trainora/apitoken"
+	"trainora/config"
 	"trainora/session"
+	"trainora/store"
 )
 
-func RegisterAuthRoutes(api fiber.Router) {
-	api.Post("/login", loginHandler)
-	api.Get("/logout", logoutHandler)
-	api.Get("/me", AuthMiddleware, MeHandler)
+func RegisterAuthRoutes(api fiber.Router, st *store.Store, cfg config.Config) {
+	limiter := loginLimiter{st: st, cfg: cfg.Login}
+	api.Post("/login", loginHandler(st, limiter))
+	api.Post("/login/2fa", twoFactorLoginHandler(st, limiter))
+	api.Post("/logout", logoutHandler(st))
+	api.Get("/me", AuthMiddleware(st, apitoken.ScopeReadProfile), MeHandler)
 }
 
-func generateRememberToken() (string, error) {
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(bytes), nil
-}
+// AuthMiddleware lässt nur eingeloggte Benutzer durch. Fehlt die Session,
+// wird sie aus dem Remember-Me-Cookie wiederhergestellt und dessen
+// Validator dabei ausgetauscht. Sessions, die vor der letzten
+// Passwortänderung entstanden sind, werden verworfen.
+//
+// Routen mit scopes akzeptieren zusätzlich API-Tokens im Header
+// "Authorization: Bearer", sofern der Token alle genannten Berechtigungen
+// hat. Ohne scopes bleibt die Route Browser-Sessions vorbehalten. Der
+// angemeldete Benutzer ist danach über Current abrufbar.
+func AuthMiddleware(st *store.Store, scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token := bearerToken(c); token != "" {
+			return bearerAuth(c, st, token, scopes)
+		}
+
+		sess, _ := session.Store.Get(c)
+		if sess.Get("user_id") != nil {
+			user, err := sessionUser(c, st, sess.Get("user_id"), sess.Get("auth_at"))
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			if user != nil {
+				// Das Gerät wurde unter /api/devices abgemeldet
+				revoked, err := rememberedDeviceRevoked(c, st, sess, user.ID)
+				if err != nil {
+					return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+				}
+				if revoked {
+					user = nil
+				}
+			}
+			if user != nil {
+				if err := setCurrentUser(c, st, user, nil); err != nil {
+					return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+				}
+				return c.Next()
+			}
+			_ = sess.Reset()
+		}
+
+		if c.Cookies(rememberCookieName) == "" {
+			return c.Status(401).JSON(fiber.Map{"error": "Nicht eingeloggt"})
+		}
+
+		token, err := consumeRememberToken(c, st)
+		if err != nil {
+			clearRememberCookie(c)
+			return c.Status(401).JSON(fiber.Map{"error": "Ungültiger Token"})
+		}
+		userID := token.UserID
+		user, err := st.Users.ByID(c.UserContext(), userID)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && (user.Disabled() || user.Deleted())) {
+			clearRememberCookie(c)
+			return c.Status(401).JSON(fiber.Map{"error": "Ungültiger Token"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := setCurrentUser(c, st, user, nil); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		if err := sess.Regenerate(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht gespeichert werden"})
+		}
+		sess.Set("user_id", userID)
+		sess.Set("auth_at", time.Now().Unix())
+		sess.Set(rememberSessionKey, token.ID)
+		sess.Save()
+		recordLogin(c, st, userID, loginRemember, true)
 
-func AuthMiddleware(c *fiber.Ctx) error {
-	sess, _ := session.Store.Get(c)
-	if sess.Get("user_id") != nil {
 		return c.Next()
 	}
+}
 
-	token := c.Cookies("remember_token")
-	if token == "" {
-		return c.Status(401).JSON(fiber.Map{"error": "Nicht eingeloggt"})
+// sessionUser lädt den Benutzer einer Session. nil bedeutet, dass die
+// Session nicht mehr gilt: Der Benutzer existiert nicht mehr, wurde
+// deaktiviert, hat sein Konto gelöscht oder hat sein Passwort seit dem Login (authAt, Unix-Sekunden)
+// geändert.
+func sessionUser(c *fiber.Ctx, st *store.Store, rawUserID, authAt interface{}) (*store.User, error) {
+	userID, err := parseUserID(rawUserID)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := st.Users.ByID(c.UserContext(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
 	}
-
-	var userID int
-	err := Db.QueryRow("SELECT id FROM users WHERE remember_token = ?", token).Scan(&userID)
 	if err != nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Ungültiger Token"})
+		return nil, err
+	}
+	if user.Disabled() || user.Deleted() {
+		return nil, nil
 	}
+	at, _ := authAt.(int64)
+	if !user.PasswordChangedAt.IsZero() && user.PasswordChangedAt.Unix() > at {
+		return nil, nil
+	}
+	return user, nil
+}
 
-	sess.Set("user_id", userID)
-	sess.Save()
+// parseUserID liest die user_id aus der Session. Je nach Session-Speicher
+// kommt sie als int64 oder nach einem Umweg über JSON als float64 zurück.
+func parseUserID(val interface{}) (int64, error) {
+	var id int64
+	switch v := val.(type) {
+	case int:
+		id = int64(v)
+	case int64:
+		id = v
+	case float64:
+		id = int64(v)
+	case string:
+		id, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if id <= 0 {
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "Ungültiger user_id Typ")
+	}
+	return id, nil
+}
+
+func loginHandler(st *store.Store, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Login    string `json:"login"`
+			Password string `json:"password"`
+		}
+
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
 
-	return c.Next()
+		ctx := c.UserContext()
+		now := time.Now()
+		user, attempt, err := checkPassword(c, st, limiter, input.Login, input.Password, now)
+		if user == nil {
+			return err
+		}
+
+		// Mit aktivierter 2FA ist der Login erst nach dem zweiten Faktor
+		// erfolgreich, bis dahin bleibt die Session im Wartezustand
+		remember := c.Query("remember") == "true"
+		tf, err := st.TwoFactor.ByUser(ctx, user.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if tf != nil && !tf.EnabledAt.IsZero() {
+			return startTwoFactorLogin(c, user.ID, remember, now)
+		}
+
+		attempt.Success = true
+		if err := limiter.recordSuccess(ctx, attempt, user); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return completeLogin(c, st, user, remember, now, loginPassword)
+	}
 }
 
-func loginHandler(c *fiber.Ctx) error {
-	var input struct {
-		Login    string `json:"login"`
-		Password string `json:"password"`
+// checkPassword prüft Login und Passwort unter Beachtung der Limits pro IP
+// und Konto. Schlägt die Prüfung fehl, ist die Antwort bereits geschrieben
+// und user ist nil.
+func checkPassword(c *fiber.Ctx, st *store.Store, limiter loginLimiter, login, password string, now time.Time) (*store.User, store.LoginAttempt, error) {
+	ctx := c.UserContext()
+	attempt := newLoginAttempt(c, login, now)
+
+	// Zu viele Fehlversuche von dieser IP
+	retryAfter, err := limiter.ipRetryAfter(ctx, attempt.IP, now)
+	if err != nil {
+		return nil, attempt, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if retryAfter > 0 {
+		return nil, attempt, tooManyAttempts(c, retryAfter)
+	}
+
+	user, err := st.Users.ByLogin(ctx, login)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, attempt, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if user == nil {
+		compareDummyHash(password)
+		if _, err := limiter.recordFailure(ctx, attempt, nil); err != nil {
+			return nil, attempt, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return nil, attempt, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Benutzername/E-Mail oder Passwort falsch"})
 	}
+	attempt.UserID = user.ID
 
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+	// Gesperrte Konten werden auch mit richtigem Passwort abgewiesen
+	if user.LockedUntil.After(now) {
+		return nil, attempt, tooManyAttempts(c, user.LockedUntil.Sub(now))
 	}
 
-	var id int
-	var hash string
-	var setupCompleted string
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		lock, err := limiter.recordFailure(ctx, attempt, user)
+		if err != nil {
+			return nil, attempt, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if lock > 0 {
+			return nil, attempt, tooManyAttempts(c, lock)
+		}
+		return nil, attempt, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Benutzername/E-Mail oder Passwort falsch"})
+	}
 
-	err := Db.QueryRow(
-		"SELECT id, password_hash, setup_completed FROM users WHERE username = ? OR email = ?",
-		input.Login, input.Login,
-	).Scan(&id, &hash, &setupCompleted)
+	// Erst nach richtigem Passwort verraten, dass das Konto deaktiviert oder
+	// gelöscht ist
+	if user.Disabled() {
+		return nil, attempt, accountDisabled(c)
+	}
+	if user.Deleted() {
+		return nil, attempt, accountDeleted(c)
+	}
+	return user, attempt, nil
+}
 
-	if err != nil || bcrypt.CompareHashAndPassword([]byte(hash), []byte(input.Password)) != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Benutzername/E-Mail oder Passwort falsch"})
+// newLoginAttempt erfasst die Eckdaten eines Login-Versuchs
+func newLoginAttempt(c *fiber.Ctx, login string, now time.Time) store.LoginAttempt {
+	attempt := store.LoginAttempt{
+		Login:       login,
+		IP:          c.IP(),
+		UserAgent:   c.Get(fiber.HeaderUserAgent),
+		AttemptedAt: now,
+	}
+	if len(attempt.Login) > 255 {
+		attempt.Login = attempt.Login[:255]
+	}
+	if len(attempt.UserAgent) > 255 {
+		attempt.UserAgent = attempt.UserAgent[:255]
 	}
+	return attempt
+}
 
+// startSession meldet den Benutzer in der Session an
+func startSession(c *fiber.Ctx, st *store.Store, userID int64, remember bool, now time.Time) error {
 	sess, err := session.Store.Get(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht geladen werden"})
+		return err
 	}
 
-	sess.Set("user_id", id)
-	if err := sess.Save(); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht gespeichert werden"})
+	// Eine vor dem Login bekannte oder untergeschobene Session-ID wird
+	// verworfen, damit sie nicht mit angemeldet ist (Session Fixation)
+	if err := sess.Regenerate(); err != nil {
+		return err
 	}
+	clearTwoFactorPending(sess)
+	sess.Set("user_id", userID)
+	sess.Set("auth_at", now.Unix())
+	sess.Delete(rememberSessionKey)
 
-	remember := c.Query("remember") == "true"
+	// Jedes Gerät erhält einen eigenen Token, andere Geräte bleiben angemeldet
 	if remember {
-		token, err := generateRememberToken()
-		if err == nil {
-			_, _ = Db.Exec("UPDATE users SET remember_token = ? WHERE id = ?", token, id)
-
-			c.Cookie(&fiber.Cookie{
-				Name:     "remember_token",
-				Value:    token,
-				HTTPOnly: true,
-				Secure:   false, // Setze auf true bei HTTPS!
-				SameSite: "Lax",
-				Path:     "/",
-				MaxAge:   60 * 60 * 24 * 30, // 30 Tage
-			})
+		if id, err := issueRememberToken(c, st, userID); err == nil {
+			sess.Set(rememberSessionKey, id)
 		}
 	}
+	return sess.Save()
+}
+
+// completeLogin meldet den Benutzer an und antwortet mit den Daten, die das
+// Frontend nach dem Login braucht. method landet im Audit-Log.
+func completeLogin(c *fiber.Ctx, st *store.Store, user *store.User, remember bool, now time.Time, method string) error {
+	if err := startSession(c, st, user.ID, remember, now); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht gespeichert werden"})
+	}
+	recordLogin(c, st, user.ID, method, remember)
+
+	setupCompleted := "no"
+	if user.SetupCompleted {
+		setupCompleted = "yes"
+	}
 
 	// 👇 Hier den setupCompleted-Wert mit zurückgeben
 	return c.JSON(fiber.Map{
 		"message":         "Login erfolgreich",
-		"user_id":         id,
+		"user_id":         user.ID,
 		"setup_completed": setupCompleted, // <-- wichtig für Frontend
+		"email_verified":  !user.EmailVerifiedAt.IsZero(),
 	})
 }
 
-func logoutHandler(c *fiber.Ctx) error {
-	sess, _ := session.Store.Get(c)
-	userID := sess.Get("user_id")
-	sess.Destroy()
-
-	if userID != nil {
-		_, _ = Db.Exec("UPDATE users SET remember_token = NULL WHERE id = ?", userID)
-	}
+func logoutHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sess, _ := session.Store.Get(c)
+		if userID, err := parseUserID(sess.Get("user_id")); err == nil {
+			recordAudit(c, st, userID, store.AuditLogout, nil)
+		}
+		sess.Destroy()
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "remember_token",
-		Value:    "",
-		MaxAge:   -1,
-		Path:     "/",
-		HTTPOnly: true,
-		Secure:   false,
-		SameSite: "Lax",
-	})
+		// Nur den Token dieses Geräts widerrufen
+		if selector := rememberSelector(c); selector != "" {
+			if t, err := st.RememberTokens.BySelector(c.UserContext(), selector); err == nil {
+				_ = st.RememberTokens.Delete(c.UserContext(), t.UserID, t.ID)
+			}
+		}
+		clearRememberCookie(c)
 
-	return c.JSON(fiber.Map{"message": "Erfolgreich ausgeloggt"})
+		return c.JSON(fiber.Map{"message": "Erfolgreich ausgeloggt"})
+	}
 }
 
 func MeHandler(c *fiber.Ctx) error {
-	sess, err := session.Store.Get(c)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Session-Fehler"})
-	}
+	user := Current(c)
+	return c.JSON(fiber.Map{
+		"user_id":         user.ID,
+		"username":        user.Username,
+		"setup_completed": user.SetupCompleted,
+		"roles":           user.Roles,
+	})
+}
 
-	userID := sess.Get("user_id")
-	if userID == nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Nicht eingeloggt"})
-	}
+func accountDisabled(c *fiber.Ctx) error {
+	return c.Status(403).JSON(fiber.Map{
+		"error": "Dieses Konto wurde deaktiviert",
+		"code":  "account_disabled",
+	})
+}
 
-	return c.JSON(fiber.Map{"user_id": userID})
+func accountDeleted(c *fiber.Ctx) error {
+	return c.Status(403).JSON(fiber.Map{
+		"error": "Dieses Konto wird gelöscht. Über den Link in der Bestätigungsmail kannst du es wiederherstellen.",
+		"code":  "account_deleted",
+	})
 }
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+func TestLogin(t *testing.T) {
+	ta := newTestApp(t)
+	id := ta.createUser(t, "anna", strongPassword)
+
+	for _, login := range []string{"anna", "anna@example.org"} {
+		resp := ta.do(t, "POST", "/api/login", map[string]string{"login": login, "password": strongPassword})
+		body := wantStatus(t, resp, 200)
+		if body["user_id"] != float64(id) {
+			t.Errorf("user_id = %v, want %d", body["user_id"], id)
+		}
+		sess := cookie(resp, "session_id")
+		if sess == nil {
+			t.Fatal("Login ohne Session-Cookie")
+		}
+		if cookie(resp, rememberCookieName) != nil {
+			t.Error("Remember-Cookie ohne ?remember=true")
+		}
+
+		me := wantStatus(t, ta.do(t, "GET", "/api/me", nil, sess), 200)
+		if me["username"] != "anna" {
+			t.Errorf("/api/me = %v", me)
+		}
+	}
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil), 401)
+}
+
+func TestLoginRegeneratesSession(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	creds := map[string]string{"login": "anna", "password": strongPassword}
+
+	// Eine untergeschobene Session-ID wird beim Login nicht übernommen
+	planted := &http.Cookie{Name: "session_id", Value: "untergeschoben"}
+	resp := ta.do(t, "POST", "/api/login", creds, planted)
+	wantStatus(t, resp, 200)
+	first := cookie(resp, "session_id")
+	if first == nil || first.Value == planted.Value {
+		t.Fatalf("Session-ID nicht erneuert: %v", first)
+	}
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, planted), 401)
+
+	// Ein erneuter Login vergibt eine neue ID und verwirft die alte
+	resp = ta.do(t, "POST", "/api/login?remember=true", creds, first)
+	wantStatus(t, resp, 200)
+	second := cookie(resp, "session_id")
+	if second == nil || second.Value == first.Value {
+		t.Fatalf("Session-ID nicht erneuert: %v", second)
+	}
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, first), 401)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, second), 200)
+
+	// Auch die Anmeldung per Remember-Cookie übernimmt keine fremde ID
+	resp = ta.do(t, "GET", "/api/me", nil, planted, cookie(resp, rememberCookieName))
+	wantStatus(t, resp, 200)
+	if restored := cookie(resp, "session_id"); restored == nil || restored.Value == planted.Value {
+		t.Errorf("Session-ID nicht erneuert: %v", restored)
+	}
+}
+
+func TestLoginWrongCredentials(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": "falsch"}), 401)
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "bob", "password": strongPassword}), 401)
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	ta := newTestApp(t)
+	id := ta.createUser(t, "anna", strongPassword)
+
+	wrong := map[string]string{"login": "anna", "password": "falsch"}
+	for i := 1; i < testConfig.Login.MaxPerAccount; i++ {
+		wantStatus(t, ta.do(t, "POST", "/api/login", wrong), 401)
+	}
+	resp := ta.do(t, "POST", "/api/login", wrong)
+	wantStatus(t, resp, 429)
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("429 ohne Retry-After")
+	}
+
+	// Auch das richtige Passwort hilft während der Sperre nicht
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword}), 429)
+	user, err := ta.st.Users.ByID(t.Context(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.LockedUntil.After(time.Now()) {
+		t.Errorf("LockedUntil = %v", user.LockedUntil)
+	}
+}
+
+func TestRememberCookie(t *testing.T) {
+	ta := newTestApp(t)
+	id := ta.createUser(t, "anna", strongPassword)
+
+	resp := ta.do(t, "POST", "/api/login?remember=true", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	remember := cookie(resp, rememberCookieName)
+	if remember == nil {
+		t.Fatal("Login ohne Remember-Cookie")
+	}
+
+	// Ohne Session stellt der Cookie sie wieder her und wird ausgetauscht
+	resp = ta.do(t, "GET", "/api/me", nil, remember)
+	wantStatus(t, resp, 200)
+	rotated := cookie(resp, rememberCookieName)
+	if rotated == nil || rotated.Value == remember.Value {
+		t.Fatalf("Remember-Cookie nicht ausgetauscht: %v", rotated)
+	}
+	if cookie(resp, "session_id") == nil {
+		t.Error("keine neue Session")
+	}
+
+	// Gleichzeitige Anfragen mit dem alten Cookie gelten noch kurz
+	resp = ta.do(t, "GET", "/api/me", nil, remember)
+	wantStatus(t, resp, 200)
+	if cookie(resp, rememberCookieName) != nil {
+		t.Error("alter Cookie wurde erneut ausgetauscht")
+	}
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, rotated), 200)
+
+	// Ein alter Cookie nach der Schonfrist gilt als gestohlen: rotated wird
+	// hier durch einen Austausch ersetzt, der schon länger zurückliegt
+	tokens, err := ta.st.RememberTokens.ListByUser(t.Context(), id, time.Now())
+	if err != nil || len(tokens) != 1 {
+		t.Fatalf("ListByUser = %+v, %v", tokens, err)
+	}
+	token := tokens[0]
+	past := time.Now().Add(-2 * rememberRotationGrace)
+	if err := ta.st.RememberTokens.Rotate(t.Context(), token.ID, token.ValidatorHash, "neu", past, token.ExpiresAt); err != nil {
+		t.Fatal(err)
+	}
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, rotated), 401)
+	if _, err := ta.st.RememberTokens.BySelector(t.Context(), token.Selector); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Token nach Wiederverwendung: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+
+	resp := ta.do(t, "POST", "/api/login?remember=true", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess, remember := cookie(resp, "session_id"), cookie(resp, rememberCookieName)
+
+	wantStatus(t, ta.do(t, "POST", "/api/logout", nil, sess, remember), 200)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, sess), 401)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, remember), 401)
+}
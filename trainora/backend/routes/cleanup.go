@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"trainora/store"
+)
+
+// loginAttemptRetention ist die Aufbewahrungsdauer des Login-Protokolls
+const loginAttemptRetention = 30 * 24 * time.Hour
+
+// generationJobRetention ist die Aufbewahrungsdauer des Generierungs-Protokolls
+const generationJobRetention = 90 * 24 * time.Hour
+
+// StartCleanupJobs löscht einmal pro Stunde abgelaufene Remember- und
+// Refresh-Tokens, alte Login-Versuche und Generierungs-Jobs sowie nicht
+// abgeholte Datenexporte. Konten, deren Löschung länger als deletionGrace
+// zurückliegt, werden endgültig entfernt.
+func StartCleanupJobs(st *store.Store, deletionGrace time.Duration) {
+	go func() {
+		for {
+			ctx := context.Background()
+			now := time.Now()
+			if err := st.RememberTokens.DeleteExpired(ctx, now); err != nil {
+				log.Printf("❌ Abgelaufene Remember-Tokens konnten nicht gelöscht werden: %v", err)
+			}
+			if err := st.RefreshTokens.DeleteExpired(ctx, now); err != nil {
+				log.Printf("❌ Abgelaufene Refresh-Tokens konnten nicht gelöscht werden: %v", err)
+			}
+			if err := st.LoginAttempts.DeleteBefore(ctx, now.Add(-loginAttemptRetention)); err != nil {
+				log.Printf("❌ Alte Login-Versuche konnten nicht gelöscht werden: %v", err)
+			}
+			if err := st.GenerationJobs.DeleteBefore(ctx, now.Add(-generationJobRetention)); err != nil {
+				log.Printf("❌ Alte Generierungs-Jobs konnten nicht gelöscht werden: %v", err)
+			}
+			if err := st.DataExports.DeleteBefore(ctx, now.Add(-dataExportRetention)); err != nil {
+				log.Printf("❌ Alte Datenexporte konnten nicht gelöscht werden: %v", err)
+			}
+			purgeDeletedAccounts(ctx, st, now.Add(-deletionGrace))
+			time.Sleep(time.Hour)
+		}
+	}()
+}
+
+// purgeDeletedAccounts entfernt die vor before gelöschten Konten. Schlägt ein
+// Konto fehl, bleibt es für den nächsten Durchlauf stehen.
+func purgeDeletedAccounts(ctx context.Context, st *store.Store, before time.Time) {
+	ids, err := st.Users.DeletedBefore(ctx, before)
+	if err != nil {
+		log.Printf("❌ Gelöschte Konten konnten nicht geladen werden: %v", err)
+		return
+	}
+	for _, id := range ids {
+		if err := st.Users.Delete(ctx, id); err != nil {
+			log.Printf("❌ Konto %d konnte nicht endgültig gelöscht werden: %v", id, err)
+			continue
+		}
+		log.Printf("🗑️ Konto %d endgültig gelöscht", id)
+	}
+}
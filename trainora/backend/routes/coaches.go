@@ -0,0 +1,453 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/store"
+)
+
+// RegisterCoachRoutes registriert die Betreuung durch Coaches. Coaches laden
+// Klienten ein, sehen nach der Annahme deren Wochenpläne samt Feedback,
+// bearbeiten und kommentieren Einträge und geben neu generierte Wochen frei.
+// Klienten nehmen Einladungen an und entziehen den Zugriff wieder.
+func RegisterCoachRoutes(api fiber.Router, st *store.Store) {
+	coach := api.Group("/coach", AuthMiddleware(st), RequireRole(store.RoleCoach))
+	coach.Post("/invitations", inviteClientHandler(st))
+	coach.Get("/clients", listClientsHandler(st))
+	coach.Delete("/clients/:clientId", endCoachingHandler(st))
+	coach.Get("/clients/:clientId/week-plan", clientWeekPlanHandler(st))
+	coach.Post("/clients/:clientId/week-plan/approve", approveWeekHandler(st))
+	coach.Patch("/clients/:clientId/schedule/:scheduleId", editEntryHandler(st))
+	coach.Delete("/clients/:clientId/schedule/:scheduleId", deleteEntryHandler(st))
+	coach.Post("/clients/:clientId/schedule/:scheduleId/comments", commentEntryHandler(st))
+
+	api.Get("/my-coaches", AuthMiddleware(st), listCoachesHandler(st))
+	api.Post("/my-coaches/:coachId/accept", AuthMiddleware(st), acceptCoachHandler(st))
+	api.Delete("/my-coaches/:coachId", AuthMiddleware(st), revokeCoachHandler(st))
+}
+
+type coachLinkResponse struct {
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+func inviteClientHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Login string `json:"login"` // Benutzername oder E-Mail des Klienten
+		}
+		if err := c.BodyParser(&input); err != nil || strings.TrimSpace(input.Login) == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		ctx := c.UserContext()
+		coachID := Current(c).ID
+		client, err := st.Users.ByLogin(ctx, strings.TrimSpace(input.Login))
+		if errors.Is(err, store.ErrNotFound) || (err == nil && (client.Disabled() || client.Deleted())) {
+			return c.Status(404).JSON(fiber.Map{"error": "Benutzer nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if client.ID == coachID {
+			return c.Status(400).JSON(fiber.Map{"error": "Du kannst dich nicht selbst betreuen"})
+		}
+
+		_, err = st.Coaches.Invite(ctx, coachID, client.ID, time.Now())
+		if errors.Is(err, store.ErrConflict) {
+			return c.Status(409).JSON(fiber.Map{"error": "Es besteht bereits eine Einladung oder Betreuung"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Einladung konnte nicht gespeichert werden"})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Einladung verschickt", "client_id": client.ID})
+	}
+}
+
+func listClientsHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		links, err := st.Coaches.ListByCoach(c.UserContext(), Current(c).ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Klienten konnten nicht geladen werden"})
+		}
+		clients := make([]coachLinkResponse, 0, len(links))
+		for _, l := range links {
+			clients = append(clients, coachLinkResponse{
+				UserID:     l.ClientID,
+				Username:   l.ClientName,
+				Status:     l.Status,
+				CreatedAt:  l.CreatedAt,
+				AcceptedAt: l.AcceptedAt,
+			})
+		}
+		return c.JSON(fiber.Map{"clients": clients})
+	}
+}
+
+func endCoachingHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID, err := strconv.ParseInt(c.Params("clientId"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Klienten-ID"})
+		}
+		return endCoaching(c, st, Current(c).ID, clientID)
+	}
+}
+
+// endCoaching löst eine Beziehung. Hat der Klient danach keinen Coach mehr,
+// werden zurückgehaltene Wochen sofort sichtbar.
+func endCoaching(c *fiber.Ctx, st *store.Store, coachID, clientID int64) error {
+	ctx := c.UserContext()
+	err := st.Coaches.Delete(ctx, coachID, clientID)
+	if errors.Is(err, store.ErrNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Keine Betreuung gefunden"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Betreuung konnte nicht beendet werden"})
+	}
+
+	if err := releaseUncoached(ctx, st, clientID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	return c.JSON(fiber.Map{"message": "Betreuung beendet"})
+}
+
+// releaseUncoached gibt die zurückgehaltenen Wochen eines Klienten frei,
+// wenn er keinen aktiven Coach mehr hat
+func releaseUncoached(ctx context.Context, st *store.Store, clientID int64) error {
+	coached, err := st.Coaches.HasActiveCoach(ctx, clientID)
+	if err != nil || coached {
+		return err
+	}
+	return st.Plans.ReleaseApprovals(ctx, clientID)
+}
+
+// EndAllCoaching löst alle Beziehungen und offenen Einladungen eines
+// Coaches, wenn ihm die Rolle entzogen oder sein Konto deaktiviert oder
+// gelöscht wird. Zurückgehaltene Wochen seiner Klienten werden sichtbar.
+func EndAllCoaching(ctx context.Context, st *store.Store, coachID int64) error {
+	links, err := st.Coaches.ListByCoach(ctx, coachID)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := st.Coaches.Delete(ctx, coachID, l.ClientID); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if err := releaseUncoached(ctx, st, l.ClientID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coachedClient liest den Klienten aus dem Pfad und prüft, ob der Coach ihn
+// aktiv betreut. Ist der zweite Rückgabewert nicht nil, wurde bereits
+// geantwortet.
+func coachedClient(c *fiber.Ctx, st *store.Store) (int64, error) {
+	clientID, err := strconv.ParseInt(c.Params("clientId"), 10, 64)
+	if err != nil || clientID <= 0 {
+		return 0, c.Status(400).JSON(fiber.Map{"error": "Ungültige Klienten-ID"})
+	}
+	active, err := st.Coaches.IsActive(c.UserContext(), Current(c).ID, clientID)
+	if err != nil {
+		return 0, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if !active {
+		return 0, c.Status(404).JSON(fiber.Map{"error": "Klient nicht gefunden"})
+	}
+	return clientID, nil
+}
+
+// weekParam liest den Wochenbeginn aus ?week=YYYY-MM-DD; Standard ist die
+// aktuelle Woche, andere Tage werden auf den Montag zurückgesetzt
+func weekParam(c *fiber.Ctx) (string, bool) {
+	week := c.Query("week")
+	if week == "" {
+		return getWeekStartDateGFDB(time.Now()), true
+	}
+	t, err := time.Parse("2006-01-02", week)
+	if err != nil {
+		return "", false
+	}
+	return getWeekStartDateGFDB(t), true
+}
+
+func clientWeekPlanHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID, err := coachedClient(c, st)
+		if clientID == 0 {
+			return err
+		}
+		weekStartDate, ok := weekParam(c)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "week muss im Format YYYY-MM-DD angegeben werden"})
+		}
+
+		ctx := c.UserContext()
+		weekPlan, err := loadWeekPlan(ctx, st, clientID, weekStartDate)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Laden des Wochenplans"})
+		}
+		approval, err := approvalStatus(ctx, st, clientID, weekStartDate)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{
+			"client_id":       clientID,
+			"week_start_date": weekStartDate,
+			"week_plan":       weekPlan,
+			"approval":        approval,
+		})
+	}
+}
+
+// approvalStatus ist "none" (keine Freigabe nötig), "pending" oder "approved"
+func approvalStatus(ctx context.Context, st *store.Store, userID int64, weekStartDate string) (fiber.Map, error) {
+	approval, err := st.Plans.Approval(ctx, userID, weekStartDate)
+	if errors.Is(err, store.ErrNotFound) {
+		return fiber.Map{"status": "none"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if approval.Pending() {
+		return fiber.Map{"status": "pending", "requested_at": approval.RequestedAt}, nil
+	}
+	return fiber.Map{
+		"status":       "approved",
+		"requested_at": approval.RequestedAt,
+		"approved_at":  approval.ApprovedAt,
+	}, nil
+}
+
+func approveWeekHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID, err := coachedClient(c, st)
+		if clientID == 0 {
+			return err
+		}
+		weekStartDate, ok := weekParam(c)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "week muss im Format YYYY-MM-DD angegeben werden"})
+		}
+
+		err = st.Plans.Approve(c.UserContext(), clientID, weekStartDate, Current(c).ID, time.Now())
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Für diese Woche steht keine Freigabe aus"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Woche konnte nicht freigegeben werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Woche freigegeben", "week_start_date": weekStartDate})
+	}
+}
+
+// clientEntry lädt den Eintrag aus dem Pfad, der zum Klienten gehören muss
+func clientEntry(c *fiber.Ctx, st *store.Store, clientID int64) (*store.ScheduledTask, error) {
+	scheduleID, err := strconv.ParseInt(c.Params("scheduleId"), 10, 64)
+	if err != nil {
+		return nil, c.Status(400).JSON(fiber.Map{"error": "Ungültige Eintrags-ID"})
+	}
+	entry, err := st.Plans.Entry(c.UserContext(), clientID, scheduleID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Eintrag nicht gefunden"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	return entry, nil
+}
+
+// editEntryHandler ändert nur die übergebenen Felder eines Eintrags
+func editEntryHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Title       *string `json:"title"`
+			Description *string `json:"description"`
+			Duration    *int    `json:"duration"`
+			Weekday     *int    `json:"weekday"`
+			DayPeriod   *string `json:"day_period"`
+			// Workout ersetzt die Trainingsstruktur, [] entfernt sie
+			Workout *[]WorkoutBlock `json:"workout"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		clientID, err := coachedClient(c, st)
+		if clientID == 0 {
+			return err
+		}
+		entry, err := clientEntry(c, st, clientID)
+		if entry == nil {
+			return err
+		}
+
+		if input.Title != nil {
+			entry.Task.Title = strings.TrimSpace(*input.Title)
+		}
+		if input.Description != nil {
+			entry.Task.Description = *input.Description
+		}
+		if input.Duration != nil {
+			entry.Task.Duration = *input.Duration
+		}
+		if input.Weekday != nil {
+			entry.Weekday = *input.Weekday
+		}
+		if input.DayPeriod != nil {
+			entry.DayPeriod = *input.DayPeriod
+		}
+		if input.Workout != nil {
+			// Nur Übungen, die auch der Klient sieht
+			exercises, _, err := exerciseIndex(c.UserContext(), st, clientID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			workout, err := parseWorkout(*input.Workout, exercises)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			entry.Task.Workout = workout
+		}
+
+		switch {
+		case entry.Task.Title == "" || len(entry.Task.Title) > 255:
+			return c.Status(400).JSON(fiber.Map{"error": "Der Titel muss 1 bis 255 Zeichen lang sein"})
+		case len(entry.Task.Description) > 5000:
+			return c.Status(400).JSON(fiber.Map{"error": "Die Beschreibung darf höchstens 5000 Zeichen lang sein"})
+		case entry.Task.Duration < 0 || entry.Task.Duration > 600:
+			return c.Status(400).JSON(fiber.Map{"error": "Die Dauer muss zwischen 0 und 600 Minuten liegen"})
+		case entry.Weekday < 0 || entry.Weekday > 6:
+			return c.Status(400).JSON(fiber.Map{"error": "weekday muss zwischen 0 (Sonntag) und 6 (Samstag) liegen"})
+		case !slices.Contains(store.DayPeriods, entry.DayPeriod):
+			return c.Status(400).JSON(fiber.Map{"error": "Unbekannte Tageszeit", "allowed": store.DayPeriods})
+		}
+
+		err = st.Plans.UpdateEntry(c.UserContext(), clientID, *entry)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eintrag nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Eintrag konnte nicht gespeichert werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Eintrag gespeichert"})
+	}
+}
+
+func deleteEntryHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID, err := coachedClient(c, st)
+		if clientID == 0 {
+			return err
+		}
+		scheduleID, err := strconv.ParseInt(c.Params("scheduleId"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eintrags-ID"})
+		}
+
+		err = st.Plans.DeleteEntry(c.UserContext(), clientID, scheduleID)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eintrag nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Eintrag konnte nicht gelöscht werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Eintrag gelöscht"})
+	}
+}
+
+func commentEntryHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Body string `json:"body"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		input.Body = strings.TrimSpace(input.Body)
+		if input.Body == "" || len(input.Body) > 1000 {
+			return c.Status(400).JSON(fiber.Map{"error": "Der Kommentar muss 1 bis 1000 Zeichen lang sein"})
+		}
+
+		clientID, err := coachedClient(c, st)
+		if clientID == 0 {
+			return err
+		}
+		entry, err := clientEntry(c, st, clientID)
+		if entry == nil {
+			return err
+		}
+
+		id, err := st.Comments.Create(c.UserContext(), store.Comment{
+			ScheduleID: entry.ScheduleID,
+			AuthorID:   Current(c).ID,
+			Body:       input.Body,
+			CreatedAt:  time.Now(),
+		})
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Kommentar konnte nicht gespeichert werden"})
+		}
+		return c.Status(201).JSON(fiber.Map{"message": "Kommentar gespeichert", "id": id})
+	}
+}
+
+func listCoachesHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		links, err := st.Coaches.ListByClient(c.UserContext(), Current(c).ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Coaches konnten nicht geladen werden"})
+		}
+		coaches := make([]coachLinkResponse, 0, len(links))
+		for _, l := range links {
+			coaches = append(coaches, coachLinkResponse{
+				UserID:     l.CoachID,
+				Username:   l.CoachName,
+				Status:     l.Status,
+				CreatedAt:  l.CreatedAt,
+				AcceptedAt: l.AcceptedAt,
+			})
+		}
+		return c.JSON(fiber.Map{"coaches": coaches})
+	}
+}
+
+func acceptCoachHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		coachID, err := strconv.ParseInt(c.Params("coachId"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Coach-ID"})
+		}
+		err = st.Coaches.Accept(c.UserContext(), coachID, Current(c).ID, time.Now())
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Keine offene Einladung gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Einladung konnte nicht angenommen werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Einladung angenommen"})
+	}
+}
+
+// revokeCoachHandler lehnt eine Einladung ab oder entzieht einem Coach den
+// Zugriff
+func revokeCoachHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		coachID, err := strconv.ParseInt(c.Params("coachId"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Coach-ID"})
+		}
+		return endCoaching(c, st, coachID, Current(c).ID)
+	}
+}
@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/consent"
+	"trainora/store"
+)
+
+// errNoHealthConsent verhindert die Plan-Generierung ohne Einwilligung
+var errNoHealthConsent = errors.New("keine Einwilligung in die Verarbeitung der Gesundheitsdaten")
+
+// RegisterConsentRoutes registriert die Einwilligungstexte und die
+// Einwilligungen des angemeldeten Benutzers
+func RegisterConsentRoutes(api fiber.Router, st *store.Store) {
+	api.Get("/consents/documents", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"documents": consent.Current()})
+	})
+	api.Get("/consents/documents/:kind/:version", documentHandler)
+	api.Get("/consents", AuthMiddleware(st), listConsentsHandler(st))
+	api.Post("/consents", AuthMiddleware(st), giveConsentsHandler(st))
+	api.Delete("/consents/:kind", AuthMiddleware(st), withdrawConsentHandler(st))
+}
+
+// documentHandler liefert auch frühere Fassungen, damit nachvollziehbar
+// bleibt, wozu ein Benutzer eingewilligt hat
+func documentHandler(c *fiber.Ctx) error {
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Ungültige Version"})
+	}
+	doc, ok := consent.Find(c.Params("kind"), version)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Dokument nicht gefunden"})
+	}
+	return c.JSON(doc)
+}
+
+// checkConsentInput prüft, dass nur in aktuelle Fassungen eingewilligt wird
+func checkConsentInput(given map[string]int) error {
+	for kind, version := range given {
+		current := consent.CurrentVersion(kind)
+		if current == 0 {
+			return errors.New("Unbekannte Einwilligung: " + kind)
+		}
+		if version != current {
+			return errors.New("Die Einwilligung bezieht sich nicht auf die aktuelle Fassung von " + kind)
+		}
+	}
+	return nil
+}
+
+// missingConsents liefert die für step nötigen Arten, die weder in aktueller
+// Fassung vorliegen noch mit given erteilt werden
+func missingConsents(active []store.Consent, given map[string]int, step string) []string {
+	var missing []string
+	for _, kind := range consent.Required(step) {
+		if _, ok := given[kind]; ok {
+			continue
+		}
+		if slices.ContainsFunc(active, func(a store.Consent) bool {
+			return a.Kind == kind && a.Version == consent.CurrentVersion(kind)
+		}) {
+			continue
+		}
+		missing = append(missing, kind)
+	}
+	return missing
+}
+
+// consentRequired antwortet mit den fehlenden Einwilligungen und ihren Texten
+func consentRequired(c *fiber.Ctx, status int, msg string, missing []string) error {
+	var docs []consent.Document
+	for _, doc := range consent.Current() {
+		if slices.Contains(missing, doc.Kind) {
+			docs = append(docs, doc)
+		}
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error":     msg,
+		"code":      "consent_required",
+		"missing":   missing,
+		"documents": docs,
+	})
+}
+
+// giveConsents speichert die erteilten Einwilligungen; given muss zuvor mit
+// checkConsentInput geprüft worden sein
+func giveConsents(c *fiber.Ctx, st *store.Store, userID int64, given map[string]int) error {
+	now := time.Now()
+	for _, kind := range consent.Kinds {
+		version, ok := given[kind]
+		if !ok {
+			continue
+		}
+		err := st.Consents.Give(c.UserContext(), store.Consent{
+			UserID:  userID,
+			Kind:    kind,
+			Version: version,
+			IP:      c.IP(),
+			GivenAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		recordAudit(c, st, userID, store.AuditConsentGiven, map[string]string{"kind": kind, "version": strconv.Itoa(version)})
+	}
+	return nil
+}
+
+// hasConsent meldet, ob eine Einwilligung in die aktuelle Fassung vorliegt
+func hasConsent(ctx context.Context, st *store.Store, userID int64, kind string) (bool, error) {
+	active, err := st.Consents.Active(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(active, func(a store.Consent) bool {
+		return a.Kind == kind && a.Version == consent.CurrentVersion(kind)
+	}), nil
+}
+
+// requireHealthConsent prüft vor einer Plan-Generierung die Einwilligung.
+// Ist der erste Rückgabewert false, wurde bereits geantwortet.
+func requireHealthConsent(c *fiber.Ctx, st *store.Store, userID int64) (bool, error) {
+	ok, err := hasConsent(c.UserContext(), st, userID, consent.HealthData)
+	if err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if !ok {
+		return false, consentRequired(c, 403, "Ohne Einwilligung in die Verarbeitung deiner Gesundheitsdaten werden keine Pläne erstellt",
+			[]string{consent.HealthData})
+	}
+	return true, nil
+}
+
+type consentResponse struct {
+	consent.Document
+	// Given ist true, wenn in die aktuelle Fassung eingewilligt wurde
+	Given bool `json:"given"`
+	// GivenVersion ist die Fassung der gültigen Einwilligung, 0 = keine
+	GivenVersion int       `json:"given_version"`
+	GivenAt      time.Time `json:"given_at"`
+}
+
+func listConsentsHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		active, err := st.Consents.Active(c.UserContext(), Current(c).ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		var list []consentResponse
+		for _, doc := range consent.Current() {
+			resp := consentResponse{Document: doc}
+			for _, a := range active {
+				if a.Kind == doc.Kind {
+					resp.Given = a.Version == doc.Version
+					resp.GivenVersion = a.Version
+					resp.GivenAt = a.GivenAt
+				}
+			}
+			list = append(list, resp)
+		}
+		return c.JSON(fiber.Map{"consents": list})
+	}
+}
+
+func giveConsentsHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Consents map[string]int `json:"consents"` // Art → Fassung
+		}
+		if err := c.BodyParser(&input); err != nil || len(input.Consents) == 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		if err := checkConsentInput(input.Consents); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := giveConsents(c, st, Current(c).ID, input.Consents); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Einwilligung konnte nicht gespeichert werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Einwilligung gespeichert"})
+	}
+}
+
+// withdrawConsentHandler widerruft eine Einwilligung. Ohne Einwilligung in
+// die Verarbeitung der Gesundheitsdaten erstellt das LLM keine Pläne mehr.
+func withdrawConsentHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		kind := c.Params("kind")
+		if consent.CurrentVersion(kind) == 0 {
+			return c.Status(404).JSON(fiber.Map{"error": "Unbekannte Einwilligung"})
+		}
+		if kind == consent.Privacy {
+			return c.Status(400).JSON(fiber.Map{"error": "Die Datenschutzerklärung kann nicht widerrufen werden. Lösche stattdessen dein Konto."})
+		}
+
+		userID := Current(c).ID
+		err := st.Consents.Withdraw(c.UserContext(), userID, kind, time.Now())
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Keine gültige Einwilligung vorhanden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		recordAudit(c, st, userID, store.AuditConsentWithdrawn, map[string]string{"kind": kind})
+		log.Printf("🔏 Benutzer %d hat die Einwilligung %s widerrufen", userID, kind)
+		return c.JSON(fiber.Map{"message": "Einwilligung widerrufen. Es werden keine neuen Pläne mehr erstellt."})
+	}
+}
@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/csrf"
+
+	"trainora/config"
+	"trainora/session"
+)
+
+const csrfContextKey = "csrf_token"
+
+// csrfExempt meldet Pfade, die nicht über Cookies authentifizieren: die
+// Token-Endpunkte der API (nicht aber /api/tokens) und das Formular des
+// Test-Providers
+func csrfExempt(path string) bool {
+	path = strings.ToLower(path)
+	return path == "/api/token" || strings.HasPrefix(path, "/api/token/") || strings.HasPrefix(path, "/api/dev-idp/")
+}
+
+// CSRFMiddleware schützt alle verändernden Anfragen mit Cookies. Der Token
+// liegt in der Session (Synchronizer Token) und zusätzlich in einem Cookie;
+// das Frontend holt ihn über GET /api/csrf und schickt ihn im Header
+// X-Csrf-Token mit. Anfragen mit "Authorization: Bearer" senden keine
+// Cookies mit Wirkung und brauchen keinen Token.
+//
+// Muss nach session.Init erzeugt werden, damit der richtige Session-Store
+// verwendet wird.
+func CSRFMiddleware(cfg config.Session) fiber.Handler {
+	return csrf.New(csrf.Config{
+		Next: func(c *fiber.Ctx) bool {
+			// Sichere Methoden brauchen keinen Token; ausgestellt wird er nur
+			// über /api/csrf, damit nicht jeder GET eine Session anlegt
+			if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead || c.Method() == fiber.MethodOptions {
+				return c.Path() != "/api/csrf"
+			}
+			return bearerToken(c) != "" || csrfExempt(c.Path())
+		},
+		Session:        session.Store,
+		CookieName:     "csrf_token",
+		CookieSecure:   cfg.CookieSecure,
+		CookieSameSite: cfg.CookieSameSite,
+		CookieHTTPOnly: true,
+		Expiration:     cfg.Expiration,
+		ContextKey:     csrfContextKey,
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "CSRF-Token fehlt oder ist ungültig, bitte Seite neu laden",
+				"code":  "csrf_invalid",
+			})
+		},
+	})
+}
+
+// RegisterCSRFRoutes registriert die Ausgabe des CSRF-Tokens
+func RegisterCSRFRoutes(api fiber.Router) {
+	api.Get("/csrf", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"csrf_token": c.Locals(csrfContextKey)})
+	})
+}
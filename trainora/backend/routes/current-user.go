@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/store"
+)
+
+// CurrentUser ist der von AuthMiddleware angemeldete Benutzer. Er wird pro
+// Anfrage einmal aus der Datenbank geladen und in c.Locals abgelegt.
+type CurrentUser struct {
+	ID             int64
+	Username       string
+	SetupCompleted bool
+	// Roles enthält immer store.RoleUser und die vergebenen Rollen
+	Roles []string
+	// Scopes sind die Berechtigungen des API-Tokens, nil bei Browser-Sessions
+	Scopes []string
+}
+
+// currentUserKey ist der Schlüssel in c.Locals; ein eigener Typ verhindert
+// Kollisionen mit anderen Middlewares
+type currentUserKey struct{}
+
+func setCurrentUser(c *fiber.Ctx, st *store.Store, user *store.User, scopes []string) error {
+	granted, err := st.Roles.ByUser(c.UserContext(), user.ID)
+	if err != nil {
+		return err
+	}
+	c.Locals(currentUserKey{}, &CurrentUser{
+		ID:             user.ID,
+		Username:       user.Username,
+		SetupCompleted: user.SetupCompleted,
+		Roles:          append([]string{store.RoleUser}, granted...),
+		Scopes:         scopes,
+	})
+	return nil
+}
+
+// HasRole meldet, ob der Benutzer die Rolle hat
+func (u *CurrentUser) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// Current liefert den angemeldeten Benutzer; seine ID ist immer gültig. Die
+// Route muss hinter AuthMiddleware liegen. Fehlt der Benutzer, ist das ein
+// Programmierfehler: Der Panic wird von der Recover-Middleware als 500
+// beantwortet, statt mit user_id 0 weiterzuarbeiten.
+func Current(c *fiber.Ctx) *CurrentUser {
+	user, ok := c.Locals(currentUserKey{}).(*CurrentUser)
+	if !ok || user.ID <= 0 {
+		panic("routes.Current: Route " + c.Path() + " liegt nicht hinter AuthMiddleware")
+	}
+	return user
+}
+
+// RequireRole lässt nur Benutzer mit einer der Rollen durch. Die Middleware
+// muss hinter AuthMiddleware liegen.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := Current(c)
+		for _, role := range roles {
+			if user.HasRole(role) {
+				return c.Next()
+			}
+		}
+		return c.Status(403).JSON(fiber.Map{"error": "Keine Berechtigung für diese Aktion"})
+	}
+}
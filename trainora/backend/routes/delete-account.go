@@ -1,56 +1,103 @@
 package routes
 
 import (
+	"log"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"trainora/session"
+	"trainora/store"
 )
 
-// Diese Funktion registriert die Route
-func RegisterDeleteAccountRoute(api fiber.Router) {
-	api.Delete("/delete-account", AuthMiddleware, DeleteAccountHandler)
+// RegisterDeleteAccountRoute registriert das Löschen und Wiederherstellen des
+// Kontos. Ein gelöschtes Konto bleibt für die Karenzzeit grace erhalten und
+// wird danach von StartCleanupJobs endgültig entfernt.
+func RegisterDeleteAccountRoute(api fiber.Router, st *store.Store, mails *AccountMailer, grace time.Duration) {
+	api.Delete("/delete-account", AuthMiddleware(st), DeleteAccountHandler(st, mails, grace))
+
+	// Link aus der Mail: stellt das Konto wieder her und leitet zum Login weiter
+	api.Get("/account/restore", func(c *fiber.Ctx) error {
+		restored := "true"
+		if err := restoreAccount(c, st, mails, c.Query("token")); err != nil {
+			restored = "false"
+		}
+		return c.Redirect(mails.baseURL + "/login?account_restored=" + restored)
+	})
+
+	// Für API-Clients: Token im Body, Antwort als JSON
+	api.Post("/account/restore", func(c *fiber.Ctx) error {
+		var input struct {
+			Token string `json:"token"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		if err := restoreAccount(c, st, mails, input.Token); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"message": "Konto wiederhergestellt"})
+	})
 }
 
-// Handler, der den eingeloggten Account löscht
-func DeleteAccountHandler(c *fiber.Ctx) error {
-	sess, err := session.Store.Get(c)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht geladen werden"})
-	}
+// DeleteAccountHandler merkt den eingeloggten Account zur Löschung vor. Alle
+// Anmeldungen werden beendet; per Mail kommt ein Link zur Wiederherstellung.
+func DeleteAccountHandler(st *store.Store, mails *AccountMailer, grace time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sess, err := session.Store.Get(c)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht geladen werden"})
+		}
 
-	userID := sess.Get("user_id")
-	if userID == nil {
-		return c.Status(401).JSON(fiber.Map{"error": "Nicht eingeloggt"})
-	}
+		ctx := c.UserContext()
+		user, err := st.Users.ByID(ctx, Current(c).ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
 
-	// Hier wird die Datenbankverbindung verwendet, um den Account zu löschen
-	_, err = Db.Exec("DELETE FROM users WHERE id = ?", userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Account konnte nicht gelöscht werden"})
-	}
+		// Andere Sessions verlieren ihre Gültigkeit über sessionUser, Tokens
+		// für neue Anmeldungen werden sofort entfernt
+		now := time.Now()
+		if err := st.Users.SetDeleted(ctx, user.ID, now); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Account konnte nicht gelöscht werden"})
+		}
+		if err := st.RememberTokens.DeleteByUser(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := st.RefreshTokens.DeleteByUser(ctx, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		// Klienten warten sonst auf Freigaben, die nicht mehr kommen
+		if err := EndAllCoaching(ctx, st, user.ID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
 
-	// Hier wird die Datenbankverbindung verwendet, um die Rezepte zu löschen
-	_, err = Db.Exec("DELETE FROM recipes WHERE user_id = ?", userID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Rezepte konnten nicht gelöscht werden"})
+		purgeAt := now.Add(grace)
+		recordAudit(c, st, user.ID, store.AuditAccountDeleted, map[string]string{"purge_at": purgeAt.UTC().Format(time.RFC3339)})
+		if err := mails.SendDeletionNotice(ctx, user, purgeAt); err != nil {
+			log.Printf("❌ Löschbestätigung an Benutzer %d fehlgeschlagen: %v", user.ID, err)
+		}
+		log.Printf("🗑️ Benutzer %d hat sein Konto gelöscht, endgültig ab %s", user.ID, purgeAt.Format(time.RFC3339))
+
+		sess.Destroy()
+		clearRememberCookie(c)
+
+		return c.JSON(fiber.Map{
+			"message":  "Account zur Löschung vorgemerkt",
+			"purge_at": purgeAt,
+		})
 	}
+}
 
-	// Hier wird die Datenbankverbindung verwendet, um die Übungen zu löschen
-	_, err = Db.Exec("DELETE FROM exercises WHERE user_id = ?", userID)
+func restoreAccount(c *fiber.Ctx, st *store.Store, mails *AccountMailer, token string) error {
+	ctx := c.UserContext()
+	userID, err := mails.consumeToken(ctx, token, store.TokenRestore)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Übungen konnten nicht gelöscht werden"})
+		return err
 	}
-
-	sess.Destroy()
-
-	c.Cookie(&fiber.Cookie{
-		Name:     "remember_token",
-		Value:    "",
-		MaxAge:   -1,
-		Path:     "/",
-		HTTPOnly: true,
-		Secure:   false, // bei HTTPS auf true setzen
-		SameSite: "Lax",
-	})
-
-	return c.JSON(fiber.Map{"message": "Account erfolgreich gelöscht"})
+	if err := st.Users.SetDeleted(ctx, userID, time.Time{}); err != nil {
+		return err
+	}
+	recordAudit(c, st, userID, store.AuditAccountRestored, nil)
+	log.Printf("♻️ Konto von Benutzer %d wiederhergestellt", userID)
+	return nil
 }
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/session"
+	"trainora/store"
+)
+
+// RegisterDeviceRoutes registriert die Verwaltung der angemeldeten Geräte
+// (Remember-Me-Tokens)
+func RegisterDeviceRoutes(api fiber.Router, st *store.Store) {
+	api.Get("/devices", AuthMiddleware(st), listDevicesHandler(st))
+	api.Delete("/devices/:id", AuthMiddleware(st), revokeDeviceHandler(st))
+}
+
+type deviceResponse struct {
+	ID         int64     `json:"id"`
+	Device     string    `json:"device"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func listDevicesHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+
+		tokens, err := st.RememberTokens.ListByUser(c.UserContext(), userID, time.Now())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Geräte konnten nicht geladen werden"})
+		}
+
+		current := rememberSelector(c)
+		devices := make([]deviceResponse, 0, len(tokens))
+		for _, t := range tokens {
+			devices = append(devices, deviceResponse{
+				ID:         t.ID,
+				Device:     t.Device,
+				CreatedAt:  t.CreatedAt,
+				LastUsedAt: t.LastUsedAt,
+				ExpiresAt:  t.ExpiresAt,
+				Current:    t.Selector == current,
+			})
+		}
+		return c.JSON(fiber.Map{"devices": devices})
+	}
+}
+
+// revokeDeviceHandler meldet ein Gerät ab. Mit dem Token verliert auch die
+// Session des Geräts ihre Gültigkeit, AuthMiddleware verwirft sie bei der
+// nächsten Anfrage.
+func revokeDeviceHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Geräte-ID"})
+		}
+
+		// Für das aktuelle Gerät zusätzlich den Cookie entfernen
+		isCurrent := false
+		if selector := rememberSelector(c); selector != "" {
+			if t, err := st.RememberTokens.BySelector(c.UserContext(), selector); err == nil && t.ID == id {
+				isCurrent = true
+			}
+		}
+
+		err = st.RememberTokens.Delete(c.UserContext(), userID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Gerät nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Gerät konnte nicht abgemeldet werden"})
+		}
+
+		if isCurrent {
+			if sess, err := session.Store.Get(c); err == nil {
+				sess.Destroy()
+			}
+			clearRememberCookie(c)
+		}
+		return c.JSON(fiber.Map{"message": "Gerät abgemeldet"})
+	}
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// device meldet anna wie in einem eigenen Browser mit "Angemeldet bleiben" an
+func (ta *testApp) device(t *testing.T) (sess, remember *http.Cookie) {
+	t.Helper()
+	resp := ta.do(t, "POST", "/api/login?remember=true", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess, remember = cookie(resp, "session_id"), cookie(resp, rememberCookieName)
+	if sess == nil || remember == nil {
+		t.Fatal("Login ohne Session- oder Remember-Cookie")
+	}
+	return sess, remember
+}
+
+func TestRevokeDevice(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	laptopSess, laptopRemember := ta.device(t)
+	phoneSess, phoneRemember := ta.device(t)
+
+	body := wantStatus(t, ta.do(t, "GET", "/api/devices", nil, phoneSess, phoneRemember), 200)
+	devices, _ := body["devices"].([]any)
+	if len(devices) != 2 {
+		t.Fatalf("devices = %v", body["devices"])
+	}
+	var laptopID any
+	for _, d := range devices {
+		if d := d.(map[string]any); d["current"] != true {
+			laptopID = d["id"]
+		}
+	}
+
+	wantStatus(t, ta.do(t, "DELETE", fmt.Sprintf("/api/devices/%v", laptopID), nil, phoneSess, phoneRemember), 200)
+
+	// Die Session des abgemeldeten Geräts gilt nicht mehr, auch nicht
+	// zusammen mit seinem Cookie
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, laptopSess), 401)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, laptopSess, laptopRemember), 401)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, laptopRemember), 401)
+
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, phoneSess, phoneRemember), 200)
+}
+
+func TestRevokeCurrentDevice(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+	sess, remember := ta.device(t)
+
+	body := wantStatus(t, ta.do(t, "GET", "/api/devices", nil, sess, remember), 200)
+	id := body["devices"].([]any)[0].(map[string]any)["id"]
+	wantStatus(t, ta.do(t, "DELETE", fmt.Sprintf("/api/devices/%v", id), nil, sess, remember), 200)
+
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, sess), 401)
+}
@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/exercise"
+	"trainora/store"
+)
+
+// RegisterExerciseRoutes registriert den Übungskatalog und die eigenen
+// Übungen des angemeldeten Benutzers
+func RegisterExerciseRoutes(api fiber.Router, st *store.Store) {
+	api.Get("/exercises/filters", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"muscle_groups": exercise.MuscleGroups,
+			"equipment":     exercise.Equipment,
+			"difficulties":  exercise.Difficulties,
+		})
+	})
+	api.Get("/exercises", AuthMiddleware(st), searchExercisesHandler(st))
+	api.Get("/exercises/:id", AuthMiddleware(st), getExerciseHandler(st))
+	api.Post("/exercises", AuthMiddleware(st), createExerciseHandler(st))
+	api.Put("/exercises/:id", AuthMiddleware(st), updateExerciseHandler(st))
+	api.Delete("/exercises/:id", AuthMiddleware(st), deleteExerciseHandler(st))
+}
+
+type exerciseResponse struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	MuscleGroups      []string  `json:"muscle_groups"`
+	Equipment         []string  `json:"equipment"`
+	Difficulty        string    `json:"difficulty"`
+	Instructions      string    `json:"instructions"`
+	Contraindications []string  `json:"contraindications"`
+	Custom            bool      `json:"custom"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newExerciseResponse(e store.Exercise) exerciseResponse {
+	nonNil := func(list []string) []string {
+		if list == nil {
+			return []string{}
+		}
+		return list
+	}
+	return exerciseResponse{
+		ID:                e.ID,
+		Name:              e.Name,
+		MuscleGroups:      nonNil(e.MuscleGroups),
+		Equipment:         nonNil(e.Equipment),
+		Difficulty:        e.Difficulty,
+		Instructions:      e.Instructions,
+		Contraindications: nonNil(e.Contraindications),
+		Custom:            e.Custom(),
+		CreatedAt:         e.CreatedAt,
+	}
+}
+
+// searchExercisesHandler durchsucht Katalog und eigene Übungen. Filter:
+// q (Name), muscle_group, equipment, difficulty, own=true
+func searchExercisesHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		offset, limit := pageParams(c, 50)
+		f := store.ExerciseFilter{
+			Query:       c.Query("q"),
+			MuscleGroup: c.Query("muscle_group"),
+			Equipment:   c.Query("equipment"),
+			Difficulty:  c.Query("difficulty"),
+			OwnOnly:     c.QueryBool("own"),
+			Offset:      offset,
+			Limit:       limit,
+		}
+		switch {
+		case f.MuscleGroup != "" && !exercise.Known(exercise.MuscleGroups, f.MuscleGroup):
+			return c.Status(400).JSON(fiber.Map{"error": "Unbekannte Muskelgruppe"})
+		case f.Equipment != "" && !exercise.Known(exercise.Equipment, f.Equipment):
+			return c.Status(400).JSON(fiber.Map{"error": "Unbekanntes Gerät"})
+		case f.Difficulty != "" && !exercise.Known(exercise.Difficulties, f.Difficulty):
+			return c.Status(400).JSON(fiber.Map{"error": "Unbekannter Schwierigkeitsgrad"})
+		}
+
+		list, total, err := st.Exercises.Search(c.UserContext(), Current(c).ID, f)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		exercises := make([]exerciseResponse, 0, len(list))
+		for _, e := range list {
+			exercises = append(exercises, newExerciseResponse(e))
+		}
+		return c.JSON(fiber.Map{"exercises": exercises, "total": total, "offset": offset, "limit": limit})
+	}
+}
+
+// visibleExercise lädt eine Übung aus dem Katalog oder eine eigene. Ist der
+// erste Rückgabewert nil, wurde bereits geantwortet.
+func visibleExercise(c *fiber.Ctx, st *store.Store) (*store.Exercise, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+	}
+	e, err := st.Exercises.ByID(c.UserContext(), id)
+	// Fremde eigene Übungen verhalten sich wie nicht vorhandene
+	if errors.Is(err, store.ErrNotFound) || (err == nil && e.Custom() && e.UserID != Current(c).ID) {
+		return nil, c.Status(404).JSON(fiber.Map{"error": "Übung nicht gefunden"})
+	}
+	if err != nil {
+		return nil, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	return e, nil
+}
+
+func getExerciseHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		e, err := visibleExercise(c, st)
+		if e == nil {
+			return err
+		}
+		return c.JSON(newExerciseResponse(*e))
+	}
+}
+
+type exerciseInput struct {
+	Name              string   `json:"name"`
+	MuscleGroups      []string `json:"muscle_groups"`
+	Equipment         []string `json:"equipment"`
+	Difficulty        string   `json:"difficulty"`
+	Instructions      string   `json:"instructions"`
+	Contraindications []string `json:"contraindications"`
+}
+
+// parseExercise liest und prüft eine eigene Übung aus dem Body. Ist der
+// erste Rückgabewert nil, wurde bereits geantwortet.
+func parseExercise(c *fiber.Ctx) (*store.Exercise, error) {
+	var input exerciseInput
+	if err := c.BodyParser(&input); err != nil {
+		return nil, c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+	}
+	e := &store.Exercise{
+		UserID:            Current(c).ID,
+		Name:              input.Name,
+		MuscleGroups:      input.MuscleGroups,
+		Equipment:         input.Equipment,
+		Difficulty:        input.Difficulty,
+		Instructions:      input.Instructions,
+		Contraindications: input.Contraindications,
+	}
+	if e.Difficulty == "" {
+		e.Difficulty = exercise.Beginner
+	}
+	if err := exercise.Validate(e); err != nil {
+		return nil, c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return e, nil
+}
+
+func createExerciseHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		e, err := parseExercise(c)
+		if e == nil {
+			return err
+		}
+		id, err := st.Exercises.Create(c.UserContext(), *e)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Übung konnte nicht gespeichert werden"})
+		}
+		created, err := st.Exercises.ByID(c.UserContext(), id)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.Status(201).JSON(newExerciseResponse(*created))
+	}
+}
+
+func updateExerciseHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+		}
+		e, err := parseExercise(c)
+		if e == nil {
+			return err
+		}
+		e.ID = id
+		// Katalog-Übungen lassen sich nicht ändern
+		err = st.Exercises.Update(c.UserContext(), *e)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eigene Übung nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Übung konnte nicht gespeichert werden"})
+		}
+		updated, err := st.Exercises.ByID(c.UserContext(), id)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(newExerciseResponse(*updated))
+	}
+}
+
+func deleteExerciseHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+		}
+		err = st.Exercises.Delete(c.UserContext(), Current(c).ID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eigene Übung nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"message": "Übung gelöscht"})
+	}
+}
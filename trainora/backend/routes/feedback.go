@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/apitoken"
+	"trainora/store"
+)
+
+// RegisterFeedbackRoutes registriert das Feedback zu eingeplanten Aufgaben
+func RegisterFeedbackRoutes(api fiber.Router, st *store.Store) {
+	api.Post("/feedback", AuthMiddleware(st, apitoken.ScopeWriteFeedback), feedbackHandler(st))
+}
+
+func feedbackHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			ScheduleID     int64  `json:"schedule_id"`
+			FeedbackOption string `json:"feedback_option"`
+			Feedback       string `json:"feedback"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.ScheduleID <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		if input.FeedbackOption == "" {
+			input.FeedbackOption = "none"
+		}
+		if !validFeedbackOption(input.FeedbackOption) {
+			return c.Status(400).JSON(fiber.Map{"error": "Unbekannte Feedback-Option", "allowed": store.FeedbackOptions})
+		}
+		if len(input.Feedback) > 1000 {
+			return c.Status(400).JSON(fiber.Map{"error": "Feedback darf höchstens 1000 Zeichen lang sein"})
+		}
+
+		err := st.Plans.SetFeedback(c.UserContext(), Current(c).ID, input.ScheduleID, input.FeedbackOption, input.Feedback)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eintrag nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Feedback konnte nicht gespeichert werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Feedback gespeichert"})
+	}
+}
+
+func validFeedbackOption(option string) bool {
+	for _, o := range store.FeedbackOptions {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,14 @@
 package routes
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"trainora/session"
+	"trainora/apitoken"
+	"trainora/store"
 )
 
 type Recipe struct {
@@ -19,11 +21,23 @@ type Recipe struct {
 }
 
 type Task struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Duration    int    `json:"duration"`   // Hier passt der Name, nur in DB heißt es estimated_duration_minutes
-	DayPeriod   string `json:"day_period"`
+	ID             int            `json:"id"`
+	ScheduleID     int64          `json:"schedule_id"` // für Feedback zu diesem Eintrag
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Duration       int            `json:"duration"` // Hier passt der Name, nur in DB heißt es estimated_duration_minutes
+	DayPeriod      string         `json:"day_period"`
+	Feedback       string         `json:"feedback,omitempty"`
+	FeedbackOption string         `json:"feedback_option,omitempty"`
+	Comments       []TaskComment  `json:"comments,omitempty"` // Kommentare des Coaches
+	Workout        []WorkoutBlock `json:"workout,omitempty"`  // Trainingsstruktur, falls vorhanden
+}
+
+type TaskComment struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 func getWeekStartDateGFDB(t time.Time) string {
@@ -33,59 +47,34 @@ func getWeekStartDateGFDB(t time.Time) string {
     return monday.Format("2006-01-02")
 }
 
-func RegisterGetRoutes(api fiber.Router, db *sql.DB) {
-    api.Get("/get-week-plan", AuthMiddleware, func(c *fiber.Ctx) error {
-		// Session abrufen
-		sess, err := session.Store.Get(c)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Session nicht gefunden"})
-		}
-
-		// User-ID aus Session parsen
-		userID, err := parseUserID(sess.Get("user_id"))
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Nicht eingeloggt"})
-		}
+func RegisterGetRoutes(api fiber.Router, st *store.Store) {
+    api.Get("/get-week-plan", AuthMiddleware(st, apitoken.ScopeReadPlan), func(c *fiber.Ctx) error {
+		// Angemeldeter Benutzer (Session oder API-Token)
+		userID := Current(c).ID
 
 		// Aktuellen Wochenbeginn berechnen
         weekStartDate := getWeekStartDateGFDB(time.Now())
 
-		// SQL-Abfrage für geplante Tasks der aktuellen Woche
-        rows, err := db.Query(`
-            SELECT ts.weekday, t.title, t.description, t.estimated_duration_minutes, ts.day_period
-            FROM task_schedule ts
-            JOIN tasks t ON ts.task_id = t.id
-            WHERE ts.user_id = ? AND ts.week_start_date = ?
-            ORDER BY ts.weekday ASC, FIELD(ts.day_period, 'morning','noon','afternoon','evening','anytime')
-        `, userID, weekStartDate)
+		// Hält ein Coach die Woche zurück, bleibt der Plan leer
+		approval, err := st.Plans.Approval(c.UserContext(), userID, weekStartDate)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Laden des Wochenplans"})
+		}
+		if approval != nil && approval.Pending() {
+			return c.JSON(fiber.Map{
+				"week_plan":        buildWeekPlan(nil, nil),
+				"pending_approval": true,
+			})
+		}
+
+		// Geplante Tasks der aktuellen Woche laden
+		weekPlan, err := loadWeekPlan(c.UserContext(), st, userID, weekStartDate)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error":    "Fehler beim Laden des Wochenplans",
 				"db_error": err.Error(),
 			})
 		}
-		defer rows.Close()
-
-		// week_plan mit 0–6 initialisieren
-		weekPlan := make(map[string][]Task)
-		for i := 0; i < 7; i++ {
-			weekPlan[strconv.Itoa(i)] = []Task{}
-		}
-
-		// Zeilen durchgehen und strukturieren
-		for rows.Next() {
-			var weekday int
-			var t Task
-			err := rows.Scan(&weekday, &t.Title, &t.Description, &t.Duration, &t.DayPeriod)
-			if err != nil {
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error":      "Fehler beim Verarbeiten der Daten",
-					"scan_error": err.Error(),
-				})
-			}
-			dayKey := strconv.Itoa(weekday)
-			weekPlan[dayKey] = append(weekPlan[dayKey], t)
-		}
 
 		return c.JSON(fiber.Map{
 			"week_plan": weekPlan,
@@ -93,18 +82,52 @@ func RegisterGetRoutes(api fiber.Router, db *sql.DB) {
 	})
 }
 
-// Helper-Funktion: UserID sicher in int64 konvertieren
-func parseUserID(val interface{}) (int64, error) {
-	switch v := val.(type) {
-	case int:
-		return int64(v), nil
-	case int64:
-		return v, nil
-	case float64:
-		return int64(v), nil
-	case string:
-		return strconv.ParseInt(v, 10, 64)
-	default:
-		return 0, fiber.NewError(fiber.StatusUnauthorized, "Ungültiger user_id Typ")
+// loadWeekPlan lädt die Einträge einer Woche samt Kommentaren
+func loadWeekPlan(ctx context.Context, st *store.Store, userID int64, weekStartDate string) (map[string][]Task, error) {
+	scheduled, err := st.Plans.Week(ctx, userID, weekStartDate)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := st.Comments.Week(ctx, userID, weekStartDate)
+	if err != nil {
+		return nil, err
+	}
+	return buildWeekPlan(scheduled, comments), nil
+}
+
+// buildWeekPlan ordnet die Einträge den Wochentagen "0" bis "6" zu
+func buildWeekPlan(scheduled []store.ScheduledTask, comments []store.Comment) map[string][]Task {
+	// week_plan mit 0–6 initialisieren
+	weekPlan := make(map[string][]Task)
+	for i := 0; i < 7; i++ {
+		weekPlan[strconv.Itoa(i)] = []Task{}
+	}
+
+	bySchedule := make(map[int64][]TaskComment)
+	for _, cm := range comments {
+		bySchedule[cm.ScheduleID] = append(bySchedule[cm.ScheduleID], TaskComment{
+			ID:        cm.ID,
+			Author:    cm.AuthorName,
+			Body:      cm.Body,
+			CreatedAt: cm.CreatedAt,
+		})
+	}
+
+	// Einträge nach Wochentag strukturieren
+	for _, s := range scheduled {
+		dayKey := strconv.Itoa(s.Weekday)
+		weekPlan[dayKey] = append(weekPlan[dayKey], Task{
+			ID:             int(s.Task.ID),
+			ScheduleID:     s.ScheduleID,
+			Title:          s.Task.Title,
+			Description:    s.Task.Description,
+			Duration:       s.Task.Duration,
+			DayPeriod:      s.DayPeriod,
+			Feedback:       s.Feedback,
+			FeedbackOption: s.FeedbackOption,
+			Comments:       bySchedule[s.ScheduleID],
+			Workout:        newWorkoutResponse(s.Task.Workout),
+		})
 	}
+	return weekPlan
 }
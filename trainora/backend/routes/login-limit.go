@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"trainora/config"
+	"trainora/store"
+)
+
+// loginLimiter begrenzt Fehlversuche pro IP und pro Konto in einem Sliding
+// Window. Wird das Limit eines Kontos erreicht, wird es gesperrt; die
+// Sperrdauer verdoppelt sich mit jedem weiteren Fehlversuch.
+type loginLimiter struct {
+	st  *store.Store
+	cfg config.Login
+}
+
+// ipRetryAfter liefert die Wartezeit, falls die IP ihr Limit erreicht hat
+func (l loginLimiter) ipRetryAfter(ctx context.Context, ip string, now time.Time) (time.Duration, error) {
+	count, oldest, err := l.st.LoginAttempts.FailuresByIP(ctx, ip, now.Add(-l.cfg.Window))
+	if err != nil || count < l.cfg.MaxPerIP {
+		return 0, err
+	}
+	// Sobald der älteste Fehlversuch aus dem Fenster fällt, ist wieder ein Versuch frei
+	return oldest.Add(l.cfg.Window).Sub(now), nil
+}
+
+// lockDuration berechnet die Sperre für die Anzahl aufeinanderfolgender Fehlversuche
+func (l loginLimiter) lockDuration(consecutive int) time.Duration {
+	exp := consecutive - l.cfg.MaxPerAccount
+	if exp < 0 {
+		exp = 0
+	}
+	d := float64(l.cfg.LockoutBase) * math.Pow(2, float64(exp))
+	if d > float64(l.cfg.LockoutMax) {
+		return l.cfg.LockoutMax
+	}
+	return time.Duration(d)
+}
+
+// recordFailure protokolliert einen Fehlversuch. Für bestehende Konten wird
+// der Zähler erhöht und das Konto bei Erreichen des Limits gesperrt; die
+// Rückgabe ist dann die Sperrdauer.
+func (l loginLimiter) recordFailure(ctx context.Context, attempt store.LoginAttempt, user *store.User) (time.Duration, error) {
+	if err := l.st.LoginAttempts.Record(ctx, attempt); err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, nil
+	}
+
+	now := attempt.AttemptedAt
+	consecutive := user.FailedLogins + 1
+	count, _, err := l.st.LoginAttempts.FailuresByUser(ctx, user.ID, now.Add(-l.cfg.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	var lock time.Duration
+	var lockedUntil time.Time
+	if count >= l.cfg.MaxPerAccount {
+		lock = l.lockDuration(consecutive)
+		lockedUntil = now.Add(lock)
+	}
+	return lock, l.st.Users.SetLoginFailures(ctx, user.ID, consecutive, lockedUntil)
+}
+
+// recordSuccess protokolliert einen erfolgreichen Login und setzt den Zähler zurück
+func (l loginLimiter) recordSuccess(ctx context.Context, attempt store.LoginAttempt, user *store.User) error {
+	if err := l.st.LoginAttempts.Record(ctx, attempt); err != nil {
+		return err
+	}
+	if user.FailedLogins == 0 && user.LockedUntil.IsZero() {
+		return nil
+	}
+	return l.st.Users.SetLoginFailures(ctx, user.ID, 0, time.Time{})
+}
+
+// reauthenticate prüft mit check ein Geheimnis, das ein angemeldeter
+// Benutzer erneut eingibt, z. B. das aktuelle Passwort. Fehlversuche zählen
+// wie beim Login für das Konto und sperren es bei Erreichen des Limits.
+// Schlägt die Prüfung fehl, ist die Antwort bereits geschrieben und ok false;
+// denied antwortet auf einen einzelnen Fehlversuch.
+func (l loginLimiter) reauthenticate(c *fiber.Ctx, user *store.User, check func() (bool, error), denied func() error) (bool, error) {
+	now := time.Now()
+	if user.LockedUntil.After(now) {
+		return false, tooManyAttempts(c, user.LockedUntil.Sub(now))
+	}
+
+	ok, err := check()
+	if err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if ok {
+		return true, nil
+	}
+
+	attempt := newLoginAttempt(c, user.Username, now)
+	attempt.UserID = user.ID
+	lock, err := l.recordFailure(c.UserContext(), attempt, user)
+	if err != nil {
+		return false, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if lock > 0 {
+		return false, tooManyAttempts(c, lock)
+	}
+	return false, denied()
+}
+
+// tooManyAttempts antwortet mit 429 und Retry-After in Sekunden
+func tooManyAttempts(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       "Zu viele fehlgeschlagene Anmeldeversuche. Bitte später erneut versuchen.",
+		"retry_after": seconds,
+	})
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyHash gleicht die Antwortzeit für unbekannte Konten an, damit
+// sich existierende Benutzernamen nicht über die Laufzeit erkennen lassen
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("trainora-dummy"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
@@ -0,0 +1,333 @@
+package routes
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"trainora/oidc"
+	"trainora/session"
+	"trainora/store"
+)
+
+// oidcFlowTTL ist die Zeit, die der Benutzer beim Provider verbringen darf
+const oidcFlowTTL = 10 * time.Minute
+
+// RegisterOIDCRoutes registriert den Login über OpenID Connect. Ist kein
+// Provider konfiguriert (client nil), meldet nur /oidc/config den Status.
+func RegisterOIDCRoutes(api fiber.Router, st *store.Store, client *oidc.Client, baseURL string) {
+	api.Get("/oidc/config", func(c *fiber.Ctx) error {
+		if client == nil {
+			return c.JSON(fiber.Map{"enabled": false})
+		}
+		return c.JSON(fiber.Map{"enabled": true, "name": client.Name()})
+	})
+	if client == nil {
+		return
+	}
+
+	api.Get("/oidc/login", oidcStartHandler(client, baseURL, false))
+	api.Get("/oidc/link", AuthMiddleware(st), oidcStartHandler(client, baseURL, true))
+	api.Get("/oidc/callback", oidcCallbackHandler(st, client, baseURL))
+	api.Get("/oidc/identities", AuthMiddleware(st), listIdentitiesHandler(st))
+	api.Delete("/oidc/identities/:id", AuthMiddleware(st), unlinkIdentityHandler(st))
+}
+
+// oidcStartHandler leitet zum Provider weiter. state, nonce und der
+// PKCE-Verifier bleiben in der Session und werden im Callback geprüft.
+func oidcStartHandler(client *oidc.Client, baseURL string, link bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		sess, err := session.Store.Get(c)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht geladen werden"})
+		}
+
+		var linkUserID int64
+		if link {
+			linkUserID = Current(c).ID
+		}
+
+		state, err1 := oidc.RandomString()
+		nonce, err2 := oidc.RandomString()
+		verifier, err3 := oidc.RandomString()
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Zufallswerte konnten nicht erzeugt werden"})
+		}
+
+		authURL, err := client.AuthURL(c.UserContext(), state, nonce, verifier)
+		if err != nil {
+			log.Printf("❌ OIDC-Provider nicht erreichbar: %v", err)
+			return c.Redirect(baseURL + "/login?oidc_error=provider")
+		}
+
+		sess.Set("oidc_state", state)
+		sess.Set("oidc_nonce", nonce)
+		sess.Set("oidc_verifier", verifier)
+		sess.Set("oidc_remember", c.Query("remember") == "true")
+		sess.Set("oidc_link_user", linkUserID)
+		sess.Set("oidc_expires", time.Now().Add(oidcFlowTTL).Unix())
+		if err := sess.Save(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht gespeichert werden"})
+		}
+		return c.Redirect(authURL)
+	}
+}
+
+func oidcCallbackHandler(st *store.Store, client *oidc.Client, baseURL string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		fail := func(reason string) error {
+			return c.Redirect(baseURL + "/login?oidc_error=" + url.QueryEscape(reason))
+		}
+
+		sess, err := session.Store.Get(c)
+		if err != nil {
+			return fail("server")
+		}
+		state, _ := sess.Get("oidc_state").(string)
+		nonce, _ := sess.Get("oidc_nonce").(string)
+		verifier, _ := sess.Get("oidc_verifier").(string)
+		remember, _ := sess.Get("oidc_remember").(bool)
+		linkUserID, _ := sess.Get("oidc_link_user").(int64)
+		expires, _ := sess.Get("oidc_expires").(int64)
+
+		// Der Flow gilt nur einmal
+		for _, k := range []string{"oidc_state", "oidc_nonce", "oidc_verifier", "oidc_remember", "oidc_link_user", "oidc_expires"} {
+			sess.Delete(k)
+		}
+		if err := sess.Save(); err != nil {
+			return fail("server")
+		}
+
+		now := time.Now()
+		if state == "" || now.Unix() > expires ||
+			subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+			return fail("state")
+		}
+		if c.Query("error") != "" {
+			return fail("denied")
+		}
+
+		ctx := c.UserContext()
+		claims, err := client.Exchange(ctx, c.Query("code"), verifier, nonce)
+		if err != nil {
+			log.Printf("❌ OIDC-Login fehlgeschlagen: %v", err)
+			return fail("provider")
+		}
+
+		user, reason, err := resolveIdentity(ctx, st, client.Issuer(), claims, linkUserID, now)
+		if err != nil {
+			log.Printf("❌ OIDC-Identität konnte nicht zugeordnet werden: %v", err)
+			return fail("server")
+		}
+		if reason != "" {
+			if linkUserID != 0 {
+				return c.Redirect(baseURL + "/settings?oidc_error=" + reason)
+			}
+			return fail(reason)
+		}
+		if linkUserID != 0 {
+			return c.Redirect(baseURL + "/settings?oidc_linked=true")
+		}
+		if user.Disabled() {
+			return fail("disabled")
+		}
+		if user.Deleted() {
+			return fail("deleted")
+		}
+
+		// Der Provider ersetzt nur das Passwort, nicht den zweiten Faktor
+		tf, err := enabledTwoFactor(ctx, st, user.ID)
+		if err != nil {
+			return fail("server")
+		}
+		if tf != nil {
+			if err := setTwoFactorPending(c, user.ID, remember, now); err != nil {
+				return fail("server")
+			}
+			return c.Redirect(baseURL + "/login?two_factor=required")
+		}
+
+		if err := startSession(c, st, user.ID, remember, now); err != nil {
+			return fail("server")
+		}
+		recordLogin(c, st, user.ID, loginOIDC, remember)
+		if user.SetupCompleted {
+			return c.Redirect(baseURL + "/dashboard")
+		}
+		return c.Redirect(baseURL + "/setup")
+	}
+}
+
+// resolveIdentity findet oder erstellt den Benutzer zur Identität. Ein
+// nicht leerer reason ist ein erwarteter Fehler, der dem Benutzer angezeigt wird.
+func resolveIdentity(ctx context.Context, st *store.Store, issuer string, claims *oidc.Claims, linkUserID int64, now time.Time) (*store.User, string, error) {
+	identity, err := st.Identities.BySubject(ctx, issuer, claims.Subject)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, "", err
+	}
+
+	// Bereits verknüpft
+	if identity != nil {
+		if linkUserID != 0 && identity.UserID != linkUserID {
+			return nil, "linked_elsewhere", nil
+		}
+		_ = st.Identities.Touch(ctx, identity.ID, now)
+		user, err := st.Users.ByID(ctx, identity.UserID)
+		return user, "", err
+	}
+
+	// Verknüpfen aus den Einstellungen heraus
+	if linkUserID != 0 {
+		return linkIdentity(ctx, st, linkUserID, issuer, claims, now)
+	}
+
+	if claims.Email == "" {
+		return nil, "email_missing", nil
+	}
+	existing, err := st.Users.ByLogin(ctx, claims.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, "", err
+	}
+	if existing != nil && existing.Email == claims.Email {
+		// Automatisch verknüpft wird nur, wenn beide Seiten die Adresse
+		// bestätigt haben. Sonst könnte jemand ein Konto mit fremder Adresse
+		// anlegen und später mitbenutzen.
+		if !bool(claims.EmailVerified) || existing.EmailVerifiedAt.IsZero() {
+			return nil, "email_taken", nil
+		}
+		return linkIdentity(ctx, st, existing.ID, issuer, claims, now)
+	}
+	if existing != nil || !bool(claims.EmailVerified) {
+		return nil, "email_taken", nil
+	}
+
+	// Neues Konto ohne nutzbares Passwort; ein Passwort lässt sich später über
+	// "Passwort vergessen" setzen
+	username, err := freeUsername(ctx, st, claims)
+	if err != nil {
+		return nil, "", err
+	}
+	random, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+	userID, err := st.Users.Create(ctx, username, claims.Email, string(hash))
+	if errors.Is(err, store.ErrConflict) {
+		return nil, "email_taken", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if err := st.Users.MarkEmailVerified(ctx, userID, now); err != nil {
+		return nil, "", err
+	}
+	return linkIdentity(ctx, st, userID, issuer, claims, now)
+}
+
+func linkIdentity(ctx context.Context, st *store.Store, userID int64, issuer string, claims *oidc.Claims, now time.Time) (*store.User, string, error) {
+	id, err := st.Identities.Create(ctx, store.Identity{
+		UserID:    userID,
+		Issuer:    issuer,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: now,
+	})
+	if errors.Is(err, store.ErrConflict) {
+		return nil, "linked_elsewhere", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	_ = st.Identities.Touch(ctx, id, now)
+	user, err := st.Users.ByID(ctx, userID)
+	return user, "", err
+}
+
+// freeUsername leitet einen freien Benutzernamen aus den Claims ab
+func freeUsername(ctx context.Context, st *store.Store, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return -1
+	}, base)
+	if len(base) < 3 {
+		base = "user" + base
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	name := base
+	for i := 2; ; i++ {
+		exists, err := st.Users.UsernameExists(ctx, name)
+		if err != nil || !exists {
+			return name, err
+		}
+		name = base + strconv.Itoa(i)
+	}
+}
+
+type identityResponse struct {
+	ID          int64     `json:"id"`
+	Issuer      string    `json:"issuer"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+func listIdentitiesHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		list, err := st.Identities.ListByUser(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		identities := make([]identityResponse, 0, len(list))
+		for _, i := range list {
+			identities = append(identities, identityResponse{
+				ID:          i.ID,
+				Issuer:      i.Issuer,
+				Email:       i.Email,
+				CreatedAt:   i.CreatedAt,
+				LastLoginAt: i.LastLoginAt,
+			})
+		}
+		return c.JSON(fiber.Map{"identities": identities})
+	}
+}
+
+func unlinkIdentityHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+		}
+		err = st.Identities.Delete(c.UserContext(), userID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Verknüpfung nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"message": "Verknüpfung gelöst"})
+	}
+}
@@ -0,0 +1,245 @@
+package routes
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/config"
+	"trainora/oidc"
+	"trainora/oidc/devidp"
+)
+
+// withOIDC startet den Test-Provider auf einem freien Port und registriert
+// den Login über ihn in der App
+func (ta *testApp) withOIDC(t *testing.T) *oidc.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.OIDC{
+		Issuer:       "http://" + ln.Addr().String() + "/idp",
+		ClientID:     "trainora-test",
+		ClientSecret: "geheim",
+		RedirectURL:  testConfig.BaseURL + "/api/oidc/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+	idp, err := devidp.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	idp.Mount(app.Group("/idp"))
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	client := oidc.New(cfg)
+	RegisterOIDCRoutes(ta.app.Group("/api"), ta.st, client, testConfig.BaseURL)
+	return client
+}
+
+// oidcLogin durchläuft den Login beim Test-Provider als email und liefert
+// die Antwort des Callbacks
+func (ta *testApp) oidcLogin(t *testing.T, email string, cookies ...*http.Cookie) *http.Response {
+	t.Helper()
+	resp := ta.do(t, "GET", "/api/oidc/login", nil, cookies...)
+	if resp.StatusCode != fiber.StatusFound {
+		t.Fatalf("/api/oidc/login = %d", resp.StatusCode)
+	}
+	flow := cookie(resp, "session_id")
+	authURL, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := authURL.Query()
+	form.Set("email", email)
+	authURL.RawQuery = ""
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	idpResp, err := noRedirect.PostForm(authURL.String(), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idpResp.Body.Close()
+	callback, err := url.Parse(idpResp.Header.Get("Location"))
+	if err != nil || callback.Path != "/api/oidc/callback" {
+		t.Fatalf("Weiterleitung des Providers = %q", idpResp.Header.Get("Location"))
+	}
+	return ta.do(t, "GET", callback.Path+"?"+callback.RawQuery, nil, flow)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ta := newTestApp(t)
+	ta.withOIDC(t)
+
+	resp := ta.oidcLogin(t, "neu@example.org")
+	if got := resp.Header.Get("Location"); got != testConfig.BaseURL+"/setup" {
+		t.Fatalf("Callback leitet nach %q", got)
+	}
+	sess := cookie(resp, "session_id")
+	me := wantStatus(t, ta.do(t, "GET", "/api/me", nil, sess), 200)
+	if me["username"] != "neu" {
+		t.Errorf("/api/me = %v", me)
+	}
+	user, err := ta.st.Users.ByLogin(t.Context(), "neu@example.org")
+	if err != nil || user.EmailVerifiedAt.IsZero() {
+		t.Errorf("Benutzer = %+v, %v", user, err)
+	}
+
+	// Der zweite Login findet dieselbe Identität
+	resp = ta.oidcLogin(t, "neu@example.org")
+	if got := resp.Header.Get("Location"); got != testConfig.BaseURL+"/setup" {
+		t.Fatalf("Callback leitet nach %q", got)
+	}
+	list, total, err := ta.st.Users.List(t.Context(), 0, 10)
+	if err != nil || total != 1 {
+		t.Errorf("Benutzer = %+v, %d, %v", list, total, err)
+	}
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	ta := newTestApp(t)
+	ta.withOIDC(t)
+
+	resp := ta.do(t, "GET", "/api/oidc/login", nil)
+	flow := cookie(resp, "session_id")
+	resp = ta.do(t, "GET", "/api/oidc/callback?code=x&state=falsch", nil, flow)
+	if got := resp.Header.Get("Location"); !strings.HasSuffix(got, "oidc_error=state") {
+		t.Errorf("Callback mit falschem state leitet nach %q", got)
+	}
+}
+
+func TestOIDCAutoLinkNeedsVerifiedEmail(t *testing.T) {
+	ta := newTestApp(t)
+	ta.withOIDC(t)
+	ta.createUser(t, "anna", strongPassword)
+
+	// Die Adresse von anna ist unbestätigt: Wer sie beim Provider angibt,
+	// bekommt keinen Zugriff auf das Konto
+	resp := ta.oidcLogin(t, "anna@example.org")
+	if got := resp.Header.Get("Location"); !strings.HasSuffix(got, "oidc_error=email_taken") {
+		t.Fatalf("Callback leitet nach %q", got)
+	}
+	if cookie(resp, "session_id") != nil {
+		wantStatus(t, ta.do(t, "GET", "/api/me", nil, cookie(resp, "session_id")), 401)
+	}
+}
+
+func TestResolveIdentity(t *testing.T) {
+	const issuer = "https://idp.example.org"
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		// verified: Adresse des bestehenden Kontos anna@example.org bestätigt
+		verified    bool
+		claims      oidc.Claims
+		wantLinked  bool // mit dem bestehenden Konto verknüpft
+		wantCreated bool // neues Konto angelegt
+		wantReason  string
+	}{
+		{
+			name:       "beide Seiten bestätigt",
+			verified:   true,
+			claims:     oidc.Claims{Subject: "s1", Email: "anna@example.org", EmailVerified: true},
+			wantLinked: true,
+		},
+		{
+			name:       "Konto unbestätigt",
+			claims:     oidc.Claims{Subject: "s1", Email: "anna@example.org", EmailVerified: true},
+			wantReason: "email_taken",
+		},
+		{
+			name:       "Provider unbestätigt",
+			verified:   true,
+			claims:     oidc.Claims{Subject: "s1", Email: "anna@example.org"},
+			wantReason: "email_taken",
+		},
+		{
+			name:       "neue Adresse unbestätigt",
+			claims:     oidc.Claims{Subject: "s1", Email: "neu@example.org"},
+			wantReason: "email_taken",
+		},
+		{
+			name:        "neue Adresse bestätigt",
+			claims:      oidc.Claims{Subject: "s1", Email: "neu@example.org", EmailVerified: true, PreferredUsername: "anna"},
+			wantCreated: true,
+		},
+		{
+			name:       "ohne Adresse",
+			claims:     oidc.Claims{Subject: "s1", EmailVerified: true},
+			wantReason: "email_missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t)
+			ctx := t.Context()
+			annaID := ta.createUser(t, "anna", strongPassword)
+			if tt.verified {
+				if err := ta.st.Users.MarkEmailVerified(ctx, annaID, now); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user, reason, err := resolveIdentity(ctx, ta.st, issuer, &tt.claims, 0, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason != tt.wantReason {
+				t.Fatalf("reason = %q, want %q", reason, tt.wantReason)
+			}
+			identity, _ := ta.st.Identities.BySubject(ctx, issuer, tt.claims.Subject)
+			switch {
+			case tt.wantLinked:
+				if user == nil || user.ID != annaID || identity == nil || identity.UserID != annaID {
+					t.Errorf("nicht mit anna verknüpft: %+v, %+v", user, identity)
+				}
+			case tt.wantCreated:
+				if user == nil || user.ID == annaID || user.Username != "anna2" || user.EmailVerifiedAt.IsZero() {
+					t.Errorf("neues Konto = %+v", user)
+				}
+			default:
+				if user != nil || identity != nil {
+					t.Errorf("Benutzer %+v, Identität %+v trotz %q", user, identity, reason)
+				}
+				if _, total, _ := ta.st.Users.List(ctx, 0, 10); total != 1 {
+					t.Errorf("%d Konten, want 1", total)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveIdentityLinked(t *testing.T) {
+	const issuer = "https://idp.example.org"
+	ta := newTestApp(t)
+	ctx := t.Context()
+	now := time.Now()
+	annaID := ta.createUser(t, "anna", strongPassword)
+	bobID := ta.createUser(t, "bob", strongPassword)
+
+	claims := &oidc.Claims{Subject: "s1", Email: "anna@idp.example.org"}
+	user, reason, err := resolveIdentity(ctx, ta.st, issuer, claims, annaID, now)
+	if err != nil || reason != "" || user.ID != annaID {
+		t.Fatalf("Verknüpfen = %+v, %q, %v", user, reason, err)
+	}
+
+	// Verknüpfte Identitäten melden ohne E-Mail-Abgleich an
+	user, reason, err = resolveIdentity(ctx, ta.st, issuer, &oidc.Claims{Subject: "s1"}, 0, now)
+	if err != nil || reason != "" || user.ID != annaID {
+		t.Errorf("Login = %+v, %q, %v", user, reason, err)
+	}
+
+	// Dieselbe Identität lässt sich nicht zusätzlich mit bob verknüpfen
+	_, reason, err = resolveIdentity(ctx, ta.st, issuer, claims, bobID, now)
+	if err != nil || reason != "linked_elsewhere" {
+		t.Errorf("Verknüpfen mit bob = %q, %v", reason, err)
+	}
+}
@@ -2,105 +2,119 @@ package routes
 
 import (
 	"bytes"
-	"database/sql"
-	"encoding/base64"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"trainora/session"
-	"crypto/aes"
-	"crypto/cipher"
+	"trainora/consent"
+	"trainora/store"
 )
 
 // Funktion, um Wochenbeginn (Montag) zu berechnen
 func getWeekStartDateOllama(t time.Time) time.Time {
-    weekday := int(t.Weekday())
-    // In Go: Sonntag = 0, Montag = 1, ..., Samstag = 6
-    // Wir wollen auf Montag zurücksetzen
-    daysToSubtract := (weekday + 6) % 7
-    return t.AddDate(0, 0, -daysToSubtract)
+	weekday := int(t.Weekday())
+	// In Go: Sonntag = 0, Montag = 1, ..., Samstag = 6
+	// Wir wollen auf Montag zurücksetzen
+	daysToSubtract := (weekday + 6) % 7
+	return t.AddDate(0, 0, -daysToSubtract)
 }
 
 // Hilfsfunktion: Wochenbeginn für nächste Woche berechnen
 func getNextWeekStartDate() string {
-    now := time.Now()
-    weekday := int(now.Weekday())
-    daysToSubtract := (weekday + 6) % 7
-    monday := now.AddDate(0, 0, -daysToSubtract)
-    nextMonday := monday.AddDate(0, 0, 7)
-    return nextMonday.Format("2006-01-02")
+	now := time.Now()
+	weekday := int(now.Weekday())
+	daysToSubtract := (weekday + 6) % 7
+	monday := now.AddDate(0, 0, -daysToSubtract)
+	nextMonday := monday.AddDate(0, 0, 7)
+	return nextMonday.Format("2006-01-02")
 }
 
-func decryptText(enc string) (string, error) {
-	key := os.Getenv("SECRET_KEY")
-	if len(key) != 64 {
-		return "", errors.New("Ungültiger Schlüssel in .env")
+// startGenerationJob protokolliert den Beginn einer Plan-Generierung
+func startGenerationJob(ctx context.Context, st *store.Store, userID int64, weekStartDate, triggeredBy string) (store.GenerationJob, error) {
+	job := store.GenerationJob{
+		UserID:        userID,
+		WeekStartDate: weekStartDate,
+		TriggeredBy:   triggeredBy,
+		StartedAt:     time.Now(),
 	}
-	keyBytes, _ := hex.DecodeString(key)
-	ciphertext, err := base64.StdEncoding.DecodeString(enc)
-	if err != nil {
-		return "", err
-	}
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
+	id, err := st.GenerationJobs.Start(ctx, job)
+	job.ID = id
+	return job, err
+}
+
+// runGenerationJob generiert den Plan eines Jobs und hält das Ergebnis fest,
+// damit Admins fehlgeschlagene Läufe einsehen können
+func runGenerationJob(ctx context.Context, st *store.Store, job store.GenerationJob, replace bool) error {
+	// Ohne Einwilligung gehen keine Gesundheitsdaten an das LLM
+	consented, err := hasConsent(ctx, st, job.UserID, consent.HealthData)
+	if err == nil && !consented {
+		err = errNoHealthConsent
 	}
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
+
+	// Klienten mit Coach sehen den neuen Plan erst nach dessen Freigabe. Die
+	// Freigabe wird mit dem Plan gespeichert, ein fehlgeschlagener Lauf hält
+	// den bisherigen Plan nicht zurück.
+	coached := false
+	if err == nil {
+		coached, err = st.Coaches.HasActiveCoach(ctx, job.UserID)
 	}
-	nonceSize := aesGCM.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return "", errors.New("Ciphertext zu kurz")
+	if err == nil {
+		var approvalAt time.Time
+		if coached {
+			approvalAt = time.Now()
+		}
+		err = generateWeekPlan(ctx, st, job.UserID, job.WeekStartDate, replace, approvalAt)
 	}
-	nonce, ct := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plain, err := aesGCM.Open(nil, nonce, ct, nil)
+	errMsg := ""
 	if err != nil {
-		return "", err
+		errMsg = err.Error()
+		log.Printf("❌ Wochenplan für Benutzer %d (%s) konnte nicht generiert werden: %v", job.UserID, job.WeekStartDate, err)
 	}
-	return string(plain), nil
+	if ferr := st.GenerationJobs.Finish(ctx, job.ID, time.Now(), errMsg); ferr != nil {
+		log.Printf("❌ Generierungs-Job %d konnte nicht abgeschlossen werden: %v", job.ID, ferr)
+	}
+	return err
 }
 
-func generateWeekPlan(db *sql.DB, userID int64, weekStartDate string) error {
-    // Nutzerdaten laden und entschlüsseln (wie in /after-setup)
-    var (
-        birthdayEnc, heightEnc, weightEnc, goalEnc, activityEnc, allergiesEnc string
-    )
-    err := db.QueryRow(`SELECT birthday_encrypted, height_cm_encrypted, weight_kg_encrypted, goal_encrypted, activity_level_encrypted, allergies_encrypted FROM users WHERE id = ?`, userID).
-        Scan(&birthdayEnc, &heightEnc, &weightEnc, &goalEnc, &activityEnc, &allergiesEnc)
-    if err != nil {
-        return err
-    }
+// generateWeekPlan lässt das LLM einen Wochenplan erstellen. Mit replace
+// ersetzt er einen vorhandenen Plan der Woche; ist approvalAt gesetzt, wartet
+// der Plan auf die Freigabe durch den Coach.
+func generateWeekPlan(ctx context.Context, st *store.Store, userID int64, weekStartDate string, replace bool, approvalAt time.Time) error {
+	// Nutzerdaten laden, der Store entschlüsselt die Profilfelder
+	profile, err := st.Users.Profile(ctx, userID)
+	if err != nil {
+		return err
+	}
 
-    birthdayStr, err := decryptText(birthdayEnc)
-    if err != nil { return err }
-    heightStr, err := decryptText(heightEnc)
-    if err != nil { return err }
-    weightStr, err := decryptText(weightEnc)
-    if err != nil { return err }
-    goalStr, _ := decryptText(goalEnc)
-    activityStr, _ := decryptText(activityEnc)
-    allergyStr, _ := decryptText(allergiesEnc)
+	birthdayDate, err := time.Parse("2006-01-02", profile.Birthday)
+	if err != nil {
+		return err
+	}
+	age := time.Now().Year() - birthdayDate.Year()
+	if time.Now().YearDay() < birthdayDate.YearDay() {
+		age--
+	}
 
-    birthday, err := time.Parse("2006-01-02", birthdayStr)
-    if err != nil { return err }
-    age := time.Now().Year() - birthday.Year()
-    if time.Now().YearDay() < birthday.YearDay() { age-- }
-    height, err := strconv.Atoi(heightStr)
-    if err != nil { return err }
-    weight, err := strconv.ParseFloat(weightStr, 64)
-    if err != nil { return err }
+	// Übungen aus dem Katalog und eigene Übungen, auf die das LLM verweisen darf
+	exercises, exerciseList, err := exerciseIndex(ctx, st, userID)
+	if err != nil {
+		return err
+	}
+	// Sätze, Wiederholungen und Gewichte kommen aus dem Trainingsprotokoll
+	targets, targetList, err := progressionTargets(ctx, st, userID, exercises)
+	if err != nil {
+		return err
+	}
 
-    prompt := fmt.Sprintf(`You are a health coach. The user is %d years old, weighs %.1f kg, is %d cm tall,
+	prompt := fmt.Sprintf(`You are a health coach. The user is %d years old, weighs %.1f kg, is %d cm tall,
     has the goal "%s", an activity level of "%s", and the following allergies: "%s".
     The user wants to live a healthier lifestyle.
 
@@ -111,7 +125,14 @@ func generateWeekPlan(db *sql.DB, userID int64, weekStartDate string) error {
     - "description": a detailed description in German
     - "duration": estimated duration in minutes
     - "day_period": one of the following time periods: "morning", "noon", "afternoon", "evening", or "anytime"
-
+    - "workout": for strength or interval training the exercises in order, otherwise an empty list. Each entry is either
+      {"type": "sets", "exercise_id": 1, "sets": 3, "reps": 10, "weight_kg": 0, "rest_seconds": 60}
+      or {"type": "interval", "exercise_id": 1, "rounds": 8, "work_seconds": 20, "rest_seconds": 10}.
+      Use weight_kg 0 for bodyweight exercises. Only use exercise_id values from this list:
+%s
+      The user's training log sets the following targets. When you include one of these exercises
+      as "sets", use exactly these sets, reps and weight_kg:
+%s
     Return the response strictly as a JSON object with the following format:
 
     {
@@ -121,7 +142,8 @@ func generateWeekPlan(db *sql.DB, userID int64, weekStartDate string) error {
             "title": "...",
             "description": "...",
             "duration": 10,
-            "day_period": "morning"
+            "day_period": "morning",
+            "workout": []
         }
         ],
         "1": [],
@@ -135,153 +157,150 @@ func generateWeekPlan(db *sql.DB, userID int64, weekStartDate string) error {
 
     Do not include any explanation or extra text outside the JSON.
     Only output the JSON object.
-    `, age, weight, height, goalStr, activityStr, allergyStr)
-
-    type OllamaRequest struct {
-        Model     string `json:"model"`
-        Prompt    string `json:"prompt"`
-        KeepAlive string `json:"keep_alive"`
-    }
-    ollamaPayload := OllamaRequest{
-        Model:     "gemma3:12b",
-        Prompt:    prompt,
-        KeepAlive: "24h",
-    }
-    payloadBytes, _ := json.Marshal(ollamaPayload)
-    resp, err := http.Post("http://ollama:11434/api/generate", "application/json", bytes.NewBuffer(payloadBytes))
-    if err != nil { return err }
-    defer resp.Body.Close()
+    `, age, float64(profile.WeightKG), profile.HeightCM, profile.Goal, profile.ActivityLevel, profile.Allergies,
+		workoutPromptList(exerciseList), progressionPromptList(targetList))
 
-    decoder := json.NewDecoder(resp.Body)
-    var responseText strings.Builder
-    for {
-        var chunk struct {
-            Response string `json:"response"`
-        }
-        err := decoder.Decode(&chunk)
-        if err == io.EOF { break }
-        if err != nil { return err }
-        responseText.WriteString(chunk.Response)
-    }
-
-    raw := responseText.String()
-    start := strings.Index(raw, "{")
-    end := strings.LastIndex(raw, "}")
-    if start == -1 || end == -1 || end <= start {
-        return errors.New("Keine gültige JSON-Antwort gefunden")
-    }
-    jsonPart := raw[start : end+1]
+	type OllamaRequest struct {
+		Model     string `json:"model"`
+		Prompt    string `json:"prompt"`
+		KeepAlive string `json:"keep_alive"`
+	}
+	ollamaPayload := OllamaRequest{
+		Model:     "gemma3:12b",
+		Prompt:    prompt,
+		KeepAlive: "24h",
+	}
+	payloadBytes, _ := json.Marshal(ollamaPayload)
+	resp, err := http.Post("http://ollama:11434/api/generate", "application/json", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-    type Task struct {
-        Title       string `json:"title"`
-        Description string `json:"description"`
-        Duration    int    `json:"duration"`
-        DayPeriod   string `json:"day_period"`
-    }
-    type WeekPlan map[string][]Task
+	decoder := json.NewDecoder(resp.Body)
+	var responseText strings.Builder
+	for {
+		var chunk struct {
+			Response string `json:"response"`
+		}
+		err := decoder.Decode(&chunk)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		responseText.WriteString(chunk.Response)
+	}
 
-    type OllamaResponse struct {
-        WeekPlan WeekPlan `json:"week_plan"`
-    }
+	raw := responseText.String()
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start == -1 || end == -1 || end <= start {
+		return errors.New("Keine gültige JSON-Antwort gefunden")
+	}
+	jsonPart := raw[start : end+1]
 
-    var ollamaResp OllamaResponse
-    err = json.Unmarshal([]byte(jsonPart), &ollamaResp)
-    if err != nil { return err }
+	type Task struct {
+		Title       string         `json:"title"`
+		Description string         `json:"description"`
+		Duration    int            `json:"duration"`
+		DayPeriod   string         `json:"day_period"`
+		Workout     []WorkoutBlock `json:"workout"`
+	}
+	type WeekPlan map[string][]Task
 
-    tx, err := db.Begin()
-    if err != nil { return err }
+	type OllamaResponse struct {
+		WeekPlan WeekPlan `json:"week_plan"`
+	}
 
-    for dayStr, tasks := range ollamaResp.WeekPlan {
-        weekday, err := strconv.Atoi(dayStr)
-        if err != nil {
-            tx.Rollback()
-            return err
-        }
-        for _, task := range tasks {
-            res, err := tx.Exec(`INSERT INTO tasks (title, description, estimated_duration_minutes, created_by) VALUES (?, ?, ?, ?)`,
-                task.Title, task.Description, task.Duration, userID)
-            if err != nil {
-                tx.Rollback()
-                return err
-            }
-            taskID, err := res.LastInsertId()
-            if err != nil {
-                tx.Rollback()
-                return err
-            }
-            // weekStartDate ist hier der Parameter!
-            _, err = tx.Exec(`
-                INSERT INTO task_schedule (user_id, task_id, weekday, day_period, week_start_date, feedback_option)
-                VALUES (?, ?, ?, ?, ?, 'none')
-            `, userID, taskID, weekday, task.DayPeriod, weekStartDate)
-            if err != nil {
-                tx.Rollback()
-                return err
-            }
-        }
-    }
+	var ollamaResp OllamaResponse
+	err = json.Unmarshal([]byte(jsonPart), &ollamaResp)
+	if err != nil {
+		return err
+	}
 
-    if err := tx.Commit(); err != nil {
-        return err
-    }
+	var planned []store.ScheduledTask
+	for dayStr, tasks := range ollamaResp.WeekPlan {
+		weekday, err := strconv.Atoi(dayStr)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			// Ungültige Blöcke werden verworfen, die Aufgabe bleibt erhalten
+			var workout []store.WorkoutBlock
+			for _, b := range task.Workout {
+				block, err := toWorkoutBlock(b, exercises)
+				if err != nil {
+					log.Printf("⚠️ Trainingsblock für Benutzer %d verworfen: %v", userID, err)
+					continue
+				}
+				applyTarget(&block, targets)
+				if len(workout) < maxWorkoutBlocks {
+					workout = append(workout, block)
+				}
+			}
+			planned = append(planned, store.ScheduledTask{
+				Task: store.Task{
+					Title:       task.Title,
+					Description: task.Description,
+					Duration:    task.Duration,
+					Workout:     workout,
+				},
+				Weekday:   weekday,
+				DayPeriod: task.DayPeriod,
+			})
+		}
+	}
 
-    return nil
+	// Tasks, Einplanungen und Freigabe werden in einer Transaktion gespeichert
+	if replace {
+		return st.Plans.ReplaceWeek(ctx, userID, weekStartDate, planned, approvalAt)
+	}
+	return st.Plans.SaveWeek(ctx, userID, weekStartDate, planned, approvalAt)
 }
 
-func RegisterOllamaRoutes(api fiber.Router, db *sql.DB) {
+func RegisterOllamaRoutes(api fiber.Router, st *store.Store) {
 	ollama := api.Group("/ollama")
-	ollama.Post("/after-setup", AuthMiddleware, func(c *fiber.Ctx) error {
-		sess, _ := session.Store.Get(c)
-		userIDRaw := sess.Get("user_id")
-		var userID int64
-		switch v := userIDRaw.(type) {
-			case int: userID = int64(v)
-			case int64: userID = v
-			case float64: userID = int64(v)
-			case string:
-				parsed, err := strconv.ParseInt(v, 10, 64)
-				if err != nil { /* Fehlerbehandlung */ }
-				userID = parsed
-			default:
-				// Fehlerbehandlung
-		}
+	ollama.Post("/after-setup", AuthMiddleware(st), func(c *fiber.Ctx) error {
+		userID := Current(c).ID
 		weekStartDate := getWeekStartDateOllama(time.Now()).Format("2006-01-02")
-		err := generateWeekPlan(db, userID, weekStartDate)
+		if ok, err := requireHealthConsent(c, st, userID); !ok {
+			return err
+		}
+		job, err := startGenerationJob(c.UserContext(), st, userID, weekStartDate, store.TriggerAfterSetup)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		err = runGenerationJob(c.UserContext(), st, job, false)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Fehler beim Generieren des Wochenplans", "details": err.Error()})
 		}
 		return c.JSON(fiber.Map{"message": "Wochenplan erfolgreich generiert"})
 	})
 
-	ollama.Post("/generate-next-week", AuthMiddleware, func(c *fiber.Ctx) error {
-		sess, _ := session.Store.Get(c)
-		userIDRaw := sess.Get("user_id")
-		var userID int64
-		switch v := userIDRaw.(type) {
-			case int: userID = int64(v)
-			case int64: userID = v
-			case float64: userID = int64(v)
-			case string:
-				parsed, err := strconv.ParseInt(v, 10, 64)
-				if err != nil { /* Fehlerbehandlung */ }
-				userID = parsed
-			default:
-				// Fehlerbehandlung
-		}
+	ollama.Post("/generate-next-week", AuthMiddleware(st), func(c *fiber.Ctx) error {
+		userID := Current(c).ID
 		nextWeekStart := getNextWeekStartDate()
 
 		// Prüfen, ob schon Einträge existieren
-		var count int
-		err := db.QueryRow(`SELECT COUNT(*) FROM task_schedule WHERE user_id = ? AND week_start_date = ?`, userID, nextWeekStart).Scan(&count)
+		exists, err := st.Plans.HasWeek(c.UserContext(), userID, nextWeekStart)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler", "db_error": err.Error()})
 		}
-		if count > 0 {
+		if exists {
 			return c.JSON(fiber.Map{"message": "Plan für nächste Woche existiert bereits"})
 		}
 
 		// Nur wenn noch kein Plan existiert, generieren!
-		err = generateWeekPlan(db, userID, nextWeekStart)
+		if ok, err := requireHealthConsent(c, st, userID); !ok {
+			return err
+		}
+		job, err := startGenerationJob(c.UserContext(), st, userID, nextWeekStart, store.TriggerNextWeek)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		err = runGenerationJob(c.UserContext(), st, job, false)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Fehler beim Generieren des Wochenplans", "details": err.Error()})
 		}
@@ -0,0 +1,196 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"trainora/config"
+	"trainora/password"
+	"trainora/session"
+	"trainora/store"
+)
+
+// RegisterPasswordRoutes registriert "Passwort vergessen", das Zurücksetzen
+// über den Link aus der Mail und das Ändern im eingeloggten Zustand
+func RegisterPasswordRoutes(api fiber.Router, st *store.Store, mails *AccountMailer, policy password.Policy, cfg config.Config) {
+	limiter := loginLimiter{st: st, cfg: cfg.Login}
+	api.Post("/password/forgot", forgotPasswordHandler(st, mails))
+	api.Post("/password/reset", resetPasswordHandler(st, mails, policy))
+	api.Post("/password/change", AuthMiddleware(st), changePasswordHandler(st, policy, limiter))
+}
+
+func forgotPasswordHandler(st *store.Store, mails *AccountMailer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Email string `json:"email"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.Email == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		// Die Antwort ist immer gleich, damit sich nicht herausfinden lässt,
+		// welche Adressen registriert sind
+		response := fiber.Map{"message": "Falls ein Konto mit dieser Adresse existiert, wurde eine Mail versendet"}
+
+		user, err := st.Users.ByLogin(c.UserContext(), input.Email)
+		if errors.Is(err, store.ErrNotFound) || (err == nil && user.Email != input.Email) {
+			return c.JSON(response)
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		if err := mails.SendPasswordReset(c.UserContext(), user); err != nil {
+			log.Printf("❌ Passwort-Mail an Benutzer %d fehlgeschlagen: %v", user.ID, err)
+		}
+		return c.JSON(response)
+	}
+}
+
+func resetPasswordHandler(st *store.Store, mails *AccountMailer, policy password.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		// Richtlinie vor dem Entwerten prüfen, damit ein abgelehntes Passwort
+		// nicht den Link verbraucht
+		ctx := c.UserContext()
+		payload, err := mails.parseToken(input.Token, store.TokenPasswordReset)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		user, err := st.Users.ByID(ctx, payload.UserID)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(400).JSON(fiber.Map{"error": errInvalidMailToken.Error()})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if err := policy.Check(input.Password, user.Username, user.Email); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		userID, err := mails.consumeToken(ctx, input.Token, store.TokenPasswordReset)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := setPassword(c, st, userID, input.Password); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Passwort konnte nicht gespeichert werden"})
+		}
+
+		// Eine Login-Sperre aufheben. Der Link kam per Mail, damit ist die
+		// Adresse zugleich bestätigt.
+		_ = st.Users.SetLoginFailures(ctx, userID, 0, time.Time{})
+		_ = st.Users.MarkEmailVerified(ctx, userID, time.Now())
+		recordAudit(c, st, userID, store.AuditPasswordReset, nil)
+
+		return c.JSON(fiber.Map{"message": "Passwort wurde geändert"})
+	}
+}
+
+func changePasswordHandler(st *store.Store, policy password.Policy, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			CurrentPassword string `json:"current_password"`
+			NewPassword     string `json:"new_password"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		userID := Current(c).ID
+		user, err := st.Users.ByID(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		// Falsche Passwörter zählen wie beim Login, sonst ließe sich das
+		// Passwort mit einer gestohlenen Session unbegrenzt erraten
+		ok, err := limiter.reauthenticate(c, user,
+			func() (bool, error) {
+				return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)) == nil, nil
+			},
+			func() error {
+				return c.Status(403).JSON(fiber.Map{"error": "Aktuelles Passwort ist falsch"})
+			})
+		if !ok {
+			return err
+		}
+		if err := policy.Check(input.NewPassword, user.Username, user.Email); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Hat dieses Gerät "Angemeldet bleiben" genutzt, bekommt es nach dem
+		// Widerruf aller Tokens einen neuen
+		remembered := rememberSelector(c) != ""
+		if err := setPassword(c, st, userID, input.NewPassword); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Passwort konnte nicht gespeichert werden"})
+		}
+		if remembered {
+			if err := rememberDevice(c, st, userID); err != nil {
+				log.Printf("❌ Neuer Remember-Token für Benutzer %d fehlgeschlagen: %v", userID, err)
+			}
+		}
+		recordAudit(c, st, userID, store.AuditPasswordChanged, nil)
+
+		return c.JSON(fiber.Map{"message": "Passwort wurde geändert"})
+	}
+}
+
+// setPassword speichert ein neues Passwort und meldet alle anderen Sessions,
+// Geräte und Apps (Refresh-Tokens) ab. Die Session dieser Anfrage bleibt
+// gültig, persönliche Zugriffstokens ebenfalls.
+func setPassword(c *fiber.Ctx, st *store.Store, userID int64, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	// Auf Sekunden gekürzt, da die Spalte keine Sekundenbruchteile speichert
+	ctx := c.UserContext()
+	now := time.Now().Truncate(time.Second)
+	if err := st.Users.SetPasswordHash(ctx, userID, string(hash), now); err != nil {
+		return err
+	}
+	if err := st.RememberTokens.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := st.RefreshTokens.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	_ = st.EmailTokens.DeleteByUser(ctx, userID, store.TokenPasswordReset)
+
+	sess, err := session.Store.Get(c)
+	if err != nil {
+		return err
+	}
+	if sess.Get("user_id") != nil {
+		sess.Set("auth_at", now.Unix())
+		sess.Delete(rememberSessionKey)
+		return sess.Save()
+	}
+	return nil
+}
+
+// rememberDevice gibt dem Gerät dieser Session einen neuen Remember-Me-Token
+func rememberDevice(c *fiber.Ctx, st *store.Store, userID int64) error {
+	id, err := issueRememberToken(c, st, userID)
+	if err != nil {
+		return err
+	}
+	sess, err := session.Store.Get(c)
+	if err != nil {
+		return err
+	}
+	sess.Set(rememberSessionKey, id)
+	return sess.Save()
+}
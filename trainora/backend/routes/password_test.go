@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangePassword(t *testing.T) {
+	ta := newTestApp(t)
+	ta.createUser(t, "anna", strongPassword)
+
+	resp := ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess := cookie(resp, "session_id")
+
+	const newPassword = "Wolke-Bergpfad-Kastanie-19"
+	wantStatus(t, ta.do(t, "POST", "/api/password/change", map[string]string{
+		"current_password": strongPassword,
+		"new_password":     newPassword,
+	}, sess), 200)
+	wantStatus(t, ta.do(t, "GET", "/api/me", nil, sess), 200)
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword}), 401)
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": newPassword}), 200)
+}
+
+func TestChangePasswordLocksAccount(t *testing.T) {
+	ta := newTestApp(t)
+	id := ta.createUser(t, "anna", strongPassword)
+
+	resp := ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess := cookie(resp, "session_id")
+
+	wrong := map[string]string{"current_password": "falsch", "new_password": "Wolke-Bergpfad-Kastanie-19"}
+	for i := 1; i < testConfig.Login.MaxPerAccount; i++ {
+		wantStatus(t, ta.do(t, "POST", "/api/password/change", wrong, sess), 403)
+	}
+	resp = ta.do(t, "POST", "/api/password/change", wrong, sess)
+	wantStatus(t, resp, 429)
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("429 ohne Retry-After")
+	}
+
+	// Während der Sperre hilft auch das richtige Passwort nicht
+	right := map[string]string{"current_password": strongPassword, "new_password": "Wolke-Bergpfad-Kastanie-19"}
+	wantStatus(t, ta.do(t, "POST", "/api/password/change", right, sess), 429)
+	wantStatus(t, ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword}), 429)
+
+	user, err := ta.st.Users.ByID(t.Context(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.LockedUntil.After(time.Now()) {
+		t.Errorf("LockedUntil = %v", user.LockedUntil)
+	}
+}
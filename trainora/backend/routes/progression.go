@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/progression"
+	"trainora/store"
+)
+
+// progressionWeeks ist der Zeitraum protokollierter Trainings, aus dem die
+// Ziele berechnet werden
+const progressionWeeks = 8
+
+type progressionTarget struct {
+	ExerciseID   int64   `json:"exercise_id"`
+	ExerciseName string  `json:"exercise_name"`
+	Sets         int     `json:"sets"`
+	Reps         int     `json:"reps"`
+	WeightKG     float64 `json:"weight_kg"`
+	Action       string  `json:"action"`
+	Reason       string  `json:"reason"`
+}
+
+// progressionTargets berechnet die Ziele für die nächste Woche. Übungen, die
+// der Benutzer nicht mehr sieht, fallen weg; die Zahlen werden auf die
+// Grenzen der Trainingsblöcke beschränkt.
+func progressionTargets(ctx context.Context, st *store.Store, userID int64, exercises map[int64]store.Exercise) (map[int64]progression.Target, []progression.Target, error) {
+	sessions, err := st.Workouts.FinishedSince(ctx, userID, time.Now().AddDate(0, 0, -7*progressionWeeks))
+	if err != nil {
+		return nil, nil, err
+	}
+	index := map[int64]progression.Target{}
+	var list []progression.Target
+	for _, t := range progression.Recommend(sessions) {
+		e, ok := exercises[t.ExerciseID]
+		if !ok {
+			continue
+		}
+		t.ExerciseName = e.Name
+		t.Sets = min(t.Sets, maxSets)
+		t.Reps = min(t.Reps, maxReps)
+		t.WeightKG = min(t.WeightKG, maxWeightKG)
+		index[t.ExerciseID] = t
+		list = append(list, t)
+	}
+	return index, list, nil
+}
+
+// applyTarget überschreibt Sätze, Wiederholungen und Gewicht eines
+// Satzblocks mit dem Ziel seiner Übung. Intervalle bleiben unverändert.
+func applyTarget(block *store.WorkoutBlock, targets map[int64]progression.Target) {
+	t, ok := targets[block.ExerciseID]
+	if !ok || block.Kind != store.WorkoutSets {
+		return
+	}
+	block.Sets, block.Reps, block.WeightKG = t.Sets, t.Reps, t.WeightKG
+}
+
+// progressionPromptList beschreibt die Ziele für das LLM, eins pro Zeile
+func progressionPromptList(targets []progression.Target) string {
+	if len(targets) == 0 {
+		return "    none\n"
+	}
+	var b strings.Builder
+	for _, t := range targets {
+		fmt.Fprintf(&b, "    %d: %s, %d sets of %d reps, weight_kg %g\n", t.ExerciseID, t.ExerciseName, t.Sets, t.Reps, t.WeightKG)
+	}
+	return b.String()
+}
+
+// progressionHandler liefert die Ziele, mit denen die nächste Woche geplant wird
+func progressionHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		userID := Current(c).ID
+		exercises, _, err := exerciseIndex(ctx, st, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		_, list, err := progressionTargets(ctx, st, userID, exercises)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		targets := make([]progressionTarget, 0, len(list))
+		for _, t := range list {
+			targets = append(targets, progressionTarget{
+				ExerciseID:   t.ExerciseID,
+				ExerciseName: t.ExerciseName,
+				Sets:         t.Sets,
+				Reps:         t.Reps,
+				WeightKG:     t.WeightKG,
+				Action:       t.Action,
+				Reason:       t.Reason,
+			})
+		}
+		return c.JSON(fiber.Map{"targets": targets, "weeks": progressionWeeks})
+	}
+}
@@ -1,84 +1,135 @@
 package routes
 
 import (
-    "database/sql"
-    "os"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
-    "github.com/gofiber/fiber/v2"
-    "golang.org/x/crypto/bcrypt"
-    _ "github.com/go-sql-driver/mysql"
-    "github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"trainora/consent"
+	"trainora/password"
+	"trainora/store"
+)
+
+// Spaltenbreiten von users.username und users.email
+const (
+	maxUsernameLength = 100
+	maxEmailLength    = 255
 )
 
-func init() {
-    // .env laden (falls nicht schon geschehen)
-    _ = godotenv.Load()
-    user := os.Getenv("MYSQL_USER")
-    pass := os.Getenv("MYSQL_PASSWORD")
-    host := os.Getenv("MYSQL_HOST")
-    port := os.Getenv("MYSQL_PORT")
-    name := os.Getenv("MYSQL_DB")
-    dsn := user + ":" + pass + "@tcp(" + host + ":" + port + ")/" + name + "?parseTime=true"
-    var err error
-    Db, err = sql.Open("mysql", dsn)
-    if err != nil {
-        panic("DB-Verbindung fehlgeschlagen: " + err.Error())
-    }
+func RegisterUserRoutes(api fiber.Router, st *store.Store, mails *AccountMailer, policy password.Policy) {
+	api.Post("/register", registerHandler(st, mails, policy))
+	api.Get("/check-email", checkEmailHandler(st))
+	api.Get("/check-username", checkUsernameHandler(st))
 }
 
-func RegisterUserRoutes(api fiber.Router) {
-    api.Post("/register", registerHandler)
+func checkEmailHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		exists, err := st.Users.EmailExists(c.UserContext(), c.Query("email"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"exists": exists})
+	}
 }
 
-func CheckEmail(c *fiber.Ctx) error {
-    email := c.Query("email")
-    var exists bool
-    err := Db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email=?)", email).Scan(&exists)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
-    }
-    return c.JSON(fiber.Map{"exists": exists})
+func checkUsernameHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		exists, err := st.Users.UsernameExists(c.UserContext(), c.Query("username"))
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"exists": exists})
+	}
 }
 
-func CheckUsername(c *fiber.Ctx) error {
-    username := c.Query("username")
-    var exists bool
-    err := Db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username=?)", username).Scan(&exists)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
-    }
-    return c.JSON(fiber.Map{"exists": exists})
+func registerHandler(st *store.Store, mails *AccountMailer, policy password.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Username string         `json:"username"`
+			Email    string         `json:"email"`
+			Password string         `json:"password"`
+			Consents map[string]int `json:"consents"` // Art → Fassung
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
+		}
+
+		input.Username = strings.TrimSpace(input.Username)
+		if err := checkUsername(input.Username); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		email, err := checkEmail(input.Email)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		input.Email = email
+
+		if err := checkConsentInput(input.Consents); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if missing := missingConsents(nil, input.Consents, consent.StepRegister); len(missing) > 0 {
+			return consentRequired(c, 400, "Bitte stimme der Datenschutzerklärung zu", missing)
+		}
+
+		if err := policy.Check(input.Password, input.Username, input.Email); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		// Passwort hashen (bcrypt)
+		hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Hashing failed"})
+		}
+
+		// In DB speichern
+		userID, err := st.Users.Create(c.UserContext(), input.Username, input.Email, string(hash))
+		if errors.Is(err, store.ErrConflict) {
+			return c.Status(400).JSON(fiber.Map{"error": "Benutzername oder E-Mail bereits vergeben"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		if err := giveConsents(c, st, userID, input.Consents); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		// Bestätigungsmail; ein Fehler beim Versand verhindert die Registrierung nicht,
+		// der Link kann später erneut angefordert werden
+		user := &store.User{ID: userID, Username: input.Username, Email: input.Email}
+		if err := mails.SendVerification(c.UserContext(), user); err != nil {
+			log.Printf("❌ Bestätigungsmail an Benutzer %d fehlgeschlagen: %v", userID, err)
+		}
+
+		return c.JSON(fiber.Map{"message": "Registrierung erfolgreich", "user": input.Username})
+	}
 }
 
-func registerHandler(c *fiber.Ctx) error {
-    var input struct {
-        Username string `json:"username"`
-        Email    string `json:"email"`
-        Password string `json:"password"`
-    }
-    if err := c.BodyParser(&input); err != nil {
-        return c.Status(400).JSON(fiber.Map{"error": "Invalid input"})
-    }
-
-    // Passwort hashen (bcrypt)
-    hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Hashing failed"})
-    }
-
-    // In DB speichern
-   encryptedBirthday, err := encryptText("2000-01-01")
-    if err != nil {
-        return c.Status(500).JSON(fiber.Map{"error": "Verschlüsselung fehlgeschlagen"})
-    }
-
-    _, err = Db.Exec(
-        "INSERT INTO users (username, email, password_hash, birthday_encrypted) VALUES (?, ?, ?, ?)",
-        input.Username, input.Email, string(hash), encryptedBirthday,
-    )
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": err.Error()}) // <-- gibt die echte DB-Fehlermeldung zurück
+// checkUsername lehnt leere und zu lange Benutzernamen sowie Steuerzeichen ab
+func checkUsername(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxUsernameLength {
+		return fmt.Errorf("Der Benutzername muss zwischen 1 und %d Zeichen lang sein", maxUsernameLength)
 	}
+	if !utf8.ValidString(name) || strings.ContainsFunc(name, unicode.IsControl) {
+		return errors.New("Der Benutzername enthält ungültige Zeichen")
+	}
+	return nil
+}
 
-    return c.JSON(fiber.Map{"message": "Registrierung erfolgreich", "user": input.Username})
-}
\ No newline at end of file
+// checkEmail liefert die bereinigte Adresse. Erlaubt ist nur die reine
+// Adresse ohne Anzeigenamen, damit keine Kopfzeilen in die Mail gelangen.
+func checkEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > maxEmailLength {
+		return "", errors.New("Bitte gib eine gültige E-Mail-Adresse an")
+	}
+	return email, nil
+}
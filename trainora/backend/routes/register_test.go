@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"trainora/consent"
+)
+
+const strongPassword = "Hafer-Kompass-Zitrone-73"
+
+func registration(username, email string) map[string]any {
+	return map[string]any{
+		"username": username,
+		"email":    email,
+		"password": strongPassword,
+		"consents": map[string]int{consent.Privacy: consent.CurrentVersion(consent.Privacy)},
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ta := newTestApp(t)
+
+	body := wantStatus(t, ta.do(t, "POST", "/api/register", registration("  anna ", " anna@example.org ")), 200)
+	if body["user"] != "anna" {
+		t.Errorf("user = %v, want anna", body["user"])
+	}
+
+	user, err := ta.st.Users.ByLogin(t.Context(), "anna@example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "anna" || !user.EmailVerifiedAt.IsZero() || user.PasswordHash == strongPassword {
+		t.Errorf("gespeicherter Benutzer = %+v", user)
+	}
+	active, err := ta.st.Consents.Active(t.Context(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].Kind != consent.Privacy {
+		t.Errorf("Einwilligungen = %+v", active)
+	}
+
+	files, err := os.ReadDir(ta.mailDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("%d Mails verschickt, want 1", len(files))
+	}
+	msg, err := os.ReadFile(ta.mailDir + "/" + files[0].Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(msg), "anna@example.org") || !strings.Contains(string(msg), testConfig.BaseURL) {
+		t.Errorf("Bestätigungsmail ohne Empfänger oder Link:\n%s", msg)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	ta := newTestApp(t)
+	wantStatus(t, ta.do(t, "POST", "/api/register", registration("anna", "anna@example.org")), 200)
+
+	wantStatus(t, ta.do(t, "POST", "/api/register", registration("anna", "other@example.org")), 400)
+	wantStatus(t, ta.do(t, "POST", "/api/register", registration("other", "anna@example.org")), 400)
+}
+
+func TestRegisterRejectsInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]any
+	}{
+		{"leerer Benutzername", registration("   ", "anna@example.org")},
+		{"zu langer Benutzername", registration(strings.Repeat("a", maxUsernameLength+1), "anna@example.org")},
+		{"Steuerzeichen im Benutzernamen", registration("an\nna", "anna@example.org")},
+		{"ungültige E-Mail", registration("anna", "anna")},
+		{"E-Mail mit Anzeigename", registration("anna", "Anna <anna@example.org>")},
+		{"E-Mail mit Zeilenumbruch", registration("anna", "anna@example.org\r\nBcc: x@example.org")},
+		{"zu lange E-Mail", registration("anna", strings.Repeat("a", maxEmailLength)+"@example.org")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ta := newTestApp(t)
+			wantStatus(t, ta.do(t, "POST", "/api/register", tt.input), 400)
+			if _, n, _ := ta.st.Users.List(t.Context(), 0, 10); n != 0 {
+				t.Errorf("%d Benutzer angelegt", n)
+			}
+		})
+	}
+}
+
+func TestRegisterRequiresConsent(t *testing.T) {
+	ta := newTestApp(t)
+	input := registration("anna", "anna@example.org")
+	delete(input, "consents")
+
+	body := wantStatus(t, ta.do(t, "POST", "/api/register", input), 400)
+	if body["missing"] == nil {
+		t.Errorf("Antwort ohne fehlende Einwilligungen: %v", body)
+	}
+}
+
+func TestRegisterWeakPassword(t *testing.T) {
+	ta := newTestApp(t)
+	for _, pw := range []string{"kurz", "aaaaaaaaaaaa", "anna-anna-anna-2026"} {
+		input := registration("anna", "anna@example.org")
+		input["password"] = pw
+		wantStatus(t, ta.do(t, "POST", "/api/register", input), 400)
+	}
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/session"
+	"trainora/store"
+)
+
+// Der Remember-Me-Cookie hat die Form selector:validator. Der Selector findet
+// den Datensatz, der Validator wird nur gehasht gespeichert und bei jeder
+// Nutzung ausgetauscht.
+const (
+	rememberCookieName = "remember_token"
+	rememberDuration   = 30 * 24 * time.Hour // 30 Tage
+	// rememberRotationGrace ist die Zeit nach einem Austausch, in der der
+	// alte Validator noch gilt. Lädt der Browser nach Ablauf der Session
+	// mehrere Seiten gleichzeitig, tragen alle Anfragen denselben Cookie.
+	rememberRotationGrace = 30 * time.Second
+	// rememberSessionKey verweist in der Session auf den Token des Geräts.
+	// Wird das Gerät abgemeldet, verliert damit auch die Session ihre
+	// Gültigkeit.
+	rememberSessionKey = "remember_id"
+)
+
+var errInvalidRememberToken = errors.New("Ungültiger Token")
+
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func hashValidator(validator string) string {
+	sum := sha256.Sum256([]byte(validator))
+	return hex.EncodeToString(sum[:])
+}
+
+func setRememberCookie(c *fiber.Ctx, selector, validator string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     rememberCookieName,
+		Value:    selector + ":" + validator,
+		HTTPOnly: true,
+		Secure:   session.Config.CookieSecure,
+		SameSite: session.Config.CookieSameSite,
+		Path:     "/",
+		MaxAge:   int(rememberDuration.Seconds()),
+	})
+}
+
+func clearRememberCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     rememberCookieName,
+		Value:    "",
+		MaxAge:   -1,
+		Path:     "/",
+		HTTPOnly: true,
+		Secure:   session.Config.CookieSecure,
+		SameSite: session.Config.CookieSameSite,
+	})
+}
+
+// rememberSelector liefert den Selector aus dem Cookie oder ""
+func rememberSelector(c *fiber.Ctx) string {
+	selector, _, ok := strings.Cut(c.Cookies(rememberCookieName), ":")
+	if !ok {
+		return ""
+	}
+	return selector
+}
+
+// issueRememberToken legt für das aktuelle Gerät einen neuen Token an, setzt
+// den Cookie und liefert die ID des Tokens
+func issueRememberToken(c *fiber.Ctx, st *store.Store, userID int64) (int64, error) {
+	selector, err := randomHex(12)
+	if err != nil {
+		return 0, err
+	}
+	validator, err := randomHex(32)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	device := c.Get(fiber.HeaderUserAgent)
+	if len(device) > 255 {
+		device = device[:255]
+	}
+	id, err := st.RememberTokens.Create(c.UserContext(), store.RememberToken{
+		UserID:        userID,
+		Selector:      selector,
+		ValidatorHash: hashValidator(validator),
+		Device:        device,
+		CreatedAt:     now,
+		LastUsedAt:    now,
+		ExpiresAt:     now.Add(rememberDuration),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	setRememberCookie(c, selector, validator)
+	return id, nil
+}
+
+// rememberedDeviceRevoked meldet, ob die Session zu einem Gerät gehört,
+// dessen Remember-Me-Token inzwischen gelöscht wurde
+func rememberedDeviceRevoked(c *fiber.Ctx, st *store.Store, sess *session.Session, userID int64) (bool, error) {
+	if sess.Get(rememberSessionKey) == nil {
+		return false, nil
+	}
+	// Die ID liegt wie user_id in der Session
+	id, err := parseUserID(sess.Get(rememberSessionKey))
+	if err != nil {
+		return true, nil
+	}
+	_, err = st.RememberTokens.ByID(c.UserContext(), userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// consumeRememberToken prüft den Remember-Me-Cookie, tauscht den Validator
+// aus und liefert den Token. Der vorherige Validator gilt noch
+// rememberRotationGrace lang, ohne erneut ausgetauscht zu werden. Passt der
+// Validator sonst nicht zum Selector, wurde der Token vermutlich gestohlen und
+// bereits benutzt; dann werden alle Tokens des Benutzers widerrufen.
+func consumeRememberToken(c *fiber.Ctx, st *store.Store) (*store.RememberToken, error) {
+	selector, validator, ok := strings.Cut(c.Cookies(rememberCookieName), ":")
+	if !ok || selector == "" || validator == "" {
+		return nil, errInvalidRememberToken
+	}
+
+	ctx := c.UserContext()
+	token, err := st.RememberTokens.BySelector(ctx, selector)
+	if err != nil {
+		return nil, errInvalidRememberToken
+	}
+
+	now := time.Now()
+	if !token.ExpiresAt.After(now) {
+		_ = st.RememberTokens.Delete(ctx, token.UserID, token.ID)
+		return nil, errInvalidRememberToken
+	}
+
+	hash := hashValidator(validator)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(token.ValidatorHash)) != 1 {
+		if token.PreviousValidatorHash != "" && now.Sub(token.RotatedAt) < rememberRotationGrace &&
+			subtle.ConstantTimeCompare([]byte(hash), []byte(token.PreviousValidatorHash)) == 1 {
+			// Parallele Anfrage: der neue Cookie ist schon unterwegs
+			return token, nil
+		}
+		_ = st.RememberTokens.DeleteByUser(ctx, token.UserID)
+		return nil, errInvalidRememberToken
+	}
+
+	newValidator, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	err = st.RememberTokens.Rotate(ctx, token.ID, hash, hashValidator(newValidator), now, now.Add(rememberDuration))
+	if errors.Is(err, store.ErrConflict) {
+		// Eine parallele Anfrage hat den Validator gerade ausgetauscht
+		return token, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	setRememberCookie(c, selector, newValidator)
+	return token, nil
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	"trainora/apitoken"
+	"trainora/config"
+	"trainora/crypto"
+	"trainora/mail"
+	"trainora/password"
+	"trainora/store"
+	"trainora/store/memory"
+)
+
+// testConfig entspricht den Standardwerten aus config.Load
+var testConfig = config.Config{
+	BaseURL: "http://localhost:5173",
+	Login: config.Login{
+		Window:        15 * time.Minute,
+		MaxPerIP:      20,
+		MaxPerAccount: 5,
+		LockoutBase:   time.Minute,
+		LockoutMax:    24 * time.Hour,
+	},
+	Password: config.Password{MinLength: 10, MinScore: 3},
+}
+
+// testApp ist eine App mit Registrierung, Login, API-Tokens, Passwort, 2FA
+// und Geräteverwaltung auf einem In-Memory-Store.
+// Mails landen als Dateien in mailDir.
+type testApp struct {
+	app     *fiber.App
+	st      *store.Store
+	mailDir string
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	signer, err := crypto.NewSigner(strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := apitoken.Init(strings.Repeat("cd", 32)); err != nil {
+		t.Fatal(err)
+	}
+	ta := &testApp{app: fiber.New(), st: memory.New(), mailDir: t.TempDir()}
+	mails := NewAccountMailer(ta.st, &mail.FileMailer{Dir: ta.mailDir, From: "noreply@trainora.test"}, signer, testConfig.BaseURL)
+
+	api := ta.app.Group("/api")
+	RegisterUserRoutes(api, ta.st, mails, password.NewPolicy(testConfig.Password))
+	RegisterPasswordRoutes(api, ta.st, mails, password.NewPolicy(testConfig.Password), testConfig)
+	RegisterAuthRoutes(api, ta.st, testConfig)
+	RegisterTokenRoutes(api, ta.st, testConfig)
+	RegisterDeviceRoutes(api, ta.st)
+	RegisterTwoFactorRoutes(api, ta.st, testConfig)
+	return ta
+}
+
+// createUser legt einen Benutzer mit Passwort pw an, die E-Mail-Adresse ist
+// unbestätigt
+func (ta *testApp) createUser(t *testing.T, name, pw string) int64 {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := ta.st.Users.Create(t.Context(), name, name+"@example.org", string(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// do schickt eine Anfrage mit body als JSON und den Cookies an die App
+func (ta *testApp) do(t *testing.T, method, path string, body any, cookies ...*http.Cookie) *http.Response {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	resp, err := ta.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// wantStatus prüft den Statuscode und liefert die JSON-Antwort
+func wantStatus(t *testing.T, resp *http.Response, status int) map[string]any {
+	t.Helper()
+	var body map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s = %d %v, want %d", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, body, status)
+	}
+	return body
+}
+
+// cookie liefert den von resp gesetzten Cookie name oder nil
+func cookie(resp *http.Response, name string) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == name && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
@@ -1,23 +1,19 @@
 package routes
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
-	"encoding/hex"
-	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"trainora/session"
+	"trainora/apitoken"
+	"trainora/consent"
+	"trainora/store"
 )
 
-func RegisterSetupRoutes(api fiber.Router) {
-	api.Post("/setup", AuthMiddleware, handleSetupSubmission)
+func RegisterSetupRoutes(api fiber.Router, st *store.Store) {
+	api.Post("/setup", AuthMiddleware(st), handleSetupSubmission(st))
+	api.Get("/profile", AuthMiddleware(st, apitoken.ScopeReadProfile), profileHandler(st))
 }
 
 type SetupInput struct {
@@ -27,113 +23,122 @@ type SetupInput struct {
 	ActivityLevel string                         `json:"activity_level"`
 	Goal          string                         `json:"goal"`
 	Allergies     string                         `json:"allergies"`
+	// Consents sind die mit dem Setup erteilten Einwilligungen, Art → Fassung
+	Consents map[string]int `json:"consents"`
 }
 
-func handleSetupSubmission(c *fiber.Ctx) error {
-	sess, _ := session.Store.Get(c)
-	userIDRaw := sess.Get("user_id")
-
-	var userID int64
-	switch v := userIDRaw.(type) {
-	case int:
-		userID = int64(v)
-	case int64:
-		userID = v
-	case float64:
-		userID = int64(v)
-	case string:
-		parsed, err := strconv.ParseInt(v, 10, 64)
+func handleSetupSubmission(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+
+		var input SetupInput
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ungültige Daten"})
+		}
+
+		birthdayStr := fmt.Sprintf("%s-%s-%s", input.Birthday.Year, input.Birthday.Month, input.Birthday.Day)
+		_, err := time.Parse("2006-01-02", birthdayStr)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Ungültige user_id"})
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültiges Geburtsdatum"})
 		}
-		userID = parsed
-	default:
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Ungültiger user_id Typ"})
-	}
-	if userID == 0 {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Nicht eingeloggt"})
-	}
 
-	var input SetupInput
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Ungültige Daten"})
-	}
+		// Gesundheitsdaten nur mit Einwilligung in die aktuelle Fassung
+		if err := checkConsentInput(input.Consents); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		active, err := st.Consents.Active(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if missing := missingConsents(active, input.Consents, consent.StepSetup); len(missing) > 0 {
+			return consentRequired(c, 400, "Bitte stimme der Verarbeitung deiner Gesundheitsdaten zu", missing)
+		}
+		if err := giveConsents(c, st, userID, input.Consents); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
 
-	birthdayStr := fmt.Sprintf("%s-%s-%s", input.Birthday.Year, input.Birthday.Month, input.Birthday.Day)
-	_, err := time.Parse("2006-01-02", birthdayStr)
-	if err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "Ungültiges Geburtsdatum"})
-	}
+		// Für das Audit-Log: welche Felder sich gegenüber dem Stand vorher ändern
+		old, err := st.Users.Profile(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
 
-	// Verschlüsseln aller Felder
-	encBirthday, err := encryptText(birthdayStr)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Geburtstag"})
+		// Alle Felder werden vom Store an user_id und Spalte gebunden verschlüsselt
+		profile := store.Profile{
+			Birthday:      birthdayStr,
+			HeightCM:      input.Height,
+			WeightKG:      input.Weight,
+			ActivityLevel: input.ActivityLevel,
+			Goal:          input.Goal,
+			Allergies:     input.Allergies,
+		}
+		err = st.Users.SaveProfile(c.UserContext(), userID, profile)
+
+		if err != nil {
+			fmt.Printf("DB Update Fehler: %v\n", err)
+			return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Speichern der Daten"})
+		}
+
+		if !Current(c).SetupCompleted {
+			recordAudit(c, st, userID, store.AuditSetupCompleted, nil)
+		} else if fields := changedProfileFields(*old, profile); len(fields) > 0 {
+			recordAudit(c, st, userID, store.AuditProfileUpdated, map[string]string{"fields": strings.Join(fields, ",")})
+		}
+
+		return c.JSON(fiber.Map{"message": "success"})
 	}
-	encHeight, err := encryptText(strconv.Itoa(input.Height))
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Größe"})
+}
+
+// changedProfileFields nennt die geänderten Felder. Die Werte selbst sind
+// Gesundheitsdaten und gehören nicht ins Audit-Log.
+func changedProfileFields(old, p store.Profile) []string {
+	var fields []string
+	if old.Birthday != p.Birthday {
+		fields = append(fields, "birthday")
 	}
-	encWeight, err := encryptText(strconv.Itoa(input.Weight))
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Gewicht"})
+	if old.HeightCM != p.HeightCM {
+		fields = append(fields, "height_cm")
 	}
-	encActivity, err := encryptText(input.ActivityLevel)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Aktivitätslevel"})
+	if old.WeightKG != p.WeightKG {
+		fields = append(fields, "weight_kg")
 	}
-	encGoal, err := encryptText(input.Goal)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Ziel"})
+	if old.ActivityLevel != p.ActivityLevel {
+		fields = append(fields, "activity_level")
 	}
-	encAllergies, err := encryptText(input.Allergies)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Verschlüsseln von Allergien"})
+	if old.Goal != p.Goal {
+		fields = append(fields, "goal")
 	}
-
-	_, err = Db.Exec(`
-		UPDATE users SET 
-			birthday_encrypted = ?, 
-			height_cm_encrypted = ?, 
-			weight_kg_encrypted = ?, 
-			activity_level_encrypted = ?, 
-			goal_encrypted = ?, 
-			allergies_encrypted = ?, 
-			setup_completed = 'yes' 
-		WHERE id = ?
-	`, encBirthday, encHeight, encWeight, encActivity, encGoal, encAllergies, userID)
-
-	if err != nil {
-		fmt.Printf("DB Update Fehler: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Fehler beim Speichern der Daten"})
+	if old.Allergies != p.Allergies {
+		fields = append(fields, "allergies")
 	}
-
-	return c.JSON(fiber.Map{"message": "success"})
+	return fields
 }
 
-func encryptText(plain string) (string, error) {
-	key := os.Getenv("SECRET_KEY")
-	if len(key) != 64 {
-		return "", errors.New("Ungültiger Schlüssel in .env")
-	}
-
-	keyBytes, _ := hex.DecodeString(key)
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
+// profileHandler liefert Konto- und Setup-Daten des angemeldeten Benutzers
+func profileHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		user, err := st.Users.ByID(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		profile, err := st.Users.Profile(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Profil konnte nicht geladen werden"})
+		}
 
-	nonce := make([]byte, aesGCM.NonceSize())
-	_, err = rand.Read(nonce)
-	if err != nil {
-		return "", err
+		return c.JSON(fiber.Map{
+			"user_id":         user.ID,
+			"username":        user.Username,
+			"email":           user.Email,
+			"email_verified":  !user.EmailVerifiedAt.IsZero(),
+			"setup_completed": user.SetupCompleted,
+			"birthday":        profile.Birthday,
+			"height_cm":       profile.HeightCM,
+			"weight_kg":       profile.WeightKG,
+			"activity_level":  profile.ActivityLevel,
+			"goal":            profile.Goal,
+			"allergies":       profile.Allergies,
+		})
 	}
-
-	ciphertext := aesGCM.Seal(nonce, nonce, []byte(plain), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
@@ -0,0 +1,379 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/config"
+	"trainora/crypto"
+	"trainora/session"
+	"trainora/store"
+)
+
+const (
+	totpIssuer = "Trainora"
+	// twoFactorPendingTTL ist die Zeit zwischen Passwort und zweitem Faktor
+	twoFactorPendingTTL = 5 * time.Minute
+	// maxTwoFactorAttempts falsche Codes, danach muss das Passwort erneut eingegeben werden
+	maxTwoFactorAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RegisterTwoFactorRoutes registriert Einrichtung und Verwaltung der
+// Zwei-Faktor-Authentifizierung. Der zweite Login-Schritt liegt bei den
+// Auth-Routen.
+func RegisterTwoFactorRoutes(api fiber.Router, st *store.Store, cfg config.Config) {
+	limiter := loginLimiter{st: st, cfg: cfg.Login}
+	tf := api.Group("/2fa", AuthMiddleware(st))
+	tf.Get("/", twoFactorStatusHandler(st))
+	tf.Post("/setup", twoFactorSetupHandler(st))
+	tf.Post("/enable", twoFactorEnableHandler(st))
+	tf.Post("/disable", twoFactorDisableHandler(st, limiter))
+	tf.Post("/recovery-codes", recoveryCodesHandler(st, limiter))
+}
+
+// setTwoFactorPending merkt sich nach dem ersten Faktor den Benutzer in der
+// Session, ohne ihn anzumelden
+func setTwoFactorPending(c *fiber.Ctx, userID int64, remember bool, now time.Time) error {
+	sess, err := session.Store.Get(c)
+	if err != nil {
+		return err
+	}
+	sess.Delete("user_id")
+	sess.Set("2fa_user_id", userID)
+	sess.Set("2fa_expires", now.Add(twoFactorPendingTTL).Unix())
+	sess.Set("2fa_remember", remember)
+	sess.Set("2fa_attempts", 0)
+	return sess.Save()
+}
+
+// startTwoFactorLogin fordert nach korrektem Passwort den zweiten Faktor an
+func startTwoFactorLogin(c *fiber.Ctx, userID int64, remember bool, now time.Time) error {
+	if err := setTwoFactorPending(c, userID, remember, now); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht gespeichert werden"})
+	}
+	return c.JSON(fiber.Map{
+		"message":             "Bitte Code aus der Authenticator-App eingeben",
+		"two_factor_required": true,
+	})
+}
+
+func clearTwoFactorPending(sess *session.Session) {
+	sess.Delete("2fa_user_id")
+	sess.Delete("2fa_expires")
+	sess.Delete("2fa_remember")
+	sess.Delete("2fa_attempts")
+}
+
+// twoFactorLoginHandler ist der zweite Login-Schritt: TOTP-Code oder
+// Wiederherstellungscode
+func twoFactorLoginHandler(st *store.Store, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Code string `json:"code"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+
+		sess, err := session.Store.Get(c)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Session konnte nicht geladen werden"})
+		}
+		now := time.Now()
+		userID, _ := sess.Get("2fa_user_id").(int64)
+		expires, _ := sess.Get("2fa_expires").(int64)
+		remember, _ := sess.Get("2fa_remember").(bool)
+		attempts, _ := sess.Get("2fa_attempts").(int)
+		if userID == 0 || now.Unix() > expires {
+			clearTwoFactorPending(sess)
+			sess.Save()
+			return c.Status(401).JSON(fiber.Map{"error": "Anmeldung abgelaufen, bitte erneut einloggen"})
+		}
+
+		ctx := c.UserContext()
+		user, err := st.Users.ByID(ctx, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if user.LockedUntil.After(now) {
+			clearTwoFactorPending(sess)
+			sess.Save()
+			return tooManyAttempts(c, user.LockedUntil.Sub(now))
+		}
+		if user.Disabled() {
+			clearTwoFactorPending(sess)
+			sess.Save()
+			return accountDisabled(c)
+		}
+		if user.Deleted() {
+			clearTwoFactorPending(sess)
+			sess.Save()
+			return accountDeleted(c)
+		}
+		tf, err := st.TwoFactor.ByUser(ctx, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		attempt := newLoginAttempt(c, user.Username, now)
+		attempt.UserID = user.ID
+
+		ok, err := checkSecondFactor(ctx, st, userID, tf, input.Code, true, now)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !ok {
+			// Falsche Codes zählen wie falsche Passwörter für die Kontosperre
+			lock, err := limiter.recordFailure(ctx, attempt, user)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			attempts++
+			if lock > 0 || attempts >= maxTwoFactorAttempts {
+				clearTwoFactorPending(sess)
+			} else {
+				sess.Set("2fa_attempts", attempts)
+			}
+			sess.Save()
+			if lock > 0 {
+				return tooManyAttempts(c, lock)
+			}
+			return c.Status(401).JSON(fiber.Map{"error": "Code ungültig"})
+		}
+
+		attempt.Success = true
+		if err := limiter.recordSuccess(ctx, attempt, user); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return completeLogin(c, st, user, remember, now, loginTwoFactor)
+	}
+}
+
+// checkSecondFactor prüft einen TOTP-Code und, falls erlaubt, einen
+// Wiederherstellungscode. Jeder Code gilt nur einmal.
+func checkSecondFactor(ctx context.Context, st *store.Store, userID int64, tf *store.TwoFactor, code string, allowRecovery bool, now time.Time) (bool, error) {
+	if step, ok := crypto.ValidateTOTP(tf.Secret, code, now); ok {
+		return st.TwoFactor.UseStep(ctx, userID, step)
+	}
+	if !allowRecovery {
+		return false, nil
+	}
+	err := st.TwoFactor.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), now)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// newRecoveryCodes erzeugt Wiederherstellungscodes im Format xxxx-xxxx-xxxx-xxxx
+// und liefert sie zusammen mit ihren Hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryEncoding.EncodeToString(b))
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalisiert die Eingabe (Groß-/Kleinschreibung,
+// Bindestriche, Leerzeichen) und hasht sie
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// enabledTwoFactor lädt die aktive 2FA-Einrichtung; nil, wenn 2FA aus ist
+func enabledTwoFactor(ctx context.Context, st *store.Store, userID int64) (*store.TwoFactor, error) {
+	tf, err := st.TwoFactor.ByUser(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && tf.EnabledAt.IsZero()) {
+		return nil, nil
+	}
+	return tf, err
+}
+
+func twoFactorStatusHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		tf, err := enabledTwoFactor(c.UserContext(), st, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if tf == nil {
+			return c.JSON(fiber.Map{"enabled": false})
+		}
+		left, err := st.TwoFactor.RecoveryCodesLeft(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"enabled": true, "enabled_at": tf.EnabledAt, "recovery_codes_left": left})
+	}
+}
+
+// twoFactorSetupHandler erzeugt ein neues Secret. Aktiv wird es erst, wenn
+// /2fa/enable mit einem gültigen Code aufgerufen wird.
+func twoFactorSetupHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		ctx := c.UserContext()
+		tf, err := enabledTwoFactor(ctx, st, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if tf != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Zwei-Faktor-Authentifizierung ist bereits aktiv"})
+		}
+		user, err := st.Users.ByID(ctx, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		secret, err := crypto.NewTOTPSecret()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Secret konnte nicht erzeugt werden"})
+		}
+		if err := st.TwoFactor.SetSecret(ctx, userID, secret); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{
+			"secret":      secret,
+			"otpauth_uri": crypto.TOTPURI(totpIssuer, user.Email, secret),
+		})
+	}
+}
+
+func twoFactorEnableHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			Code string `json:"code"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		userID := Current(c).ID
+
+		ctx := c.UserContext()
+		tf, err := st.TwoFactor.ByUser(ctx, userID)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(400).JSON(fiber.Map{"error": "Bitte zuerst /2fa/setup aufrufen"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !tf.EnabledAt.IsZero() {
+			return c.Status(400).JSON(fiber.Map{"error": "Zwei-Faktor-Authentifizierung ist bereits aktiv"})
+		}
+
+		now := time.Now()
+		ok, err := checkSecondFactor(ctx, st, userID, tf, input.Code, false, now)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "Code ungültig"})
+		}
+
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Wiederherstellungscodes konnten nicht erzeugt werden"})
+		}
+		if err := st.TwoFactor.Enable(ctx, userID, now, hashes); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		recordAudit(c, st, userID, store.AuditTwoFactorEnabled, nil)
+		return c.JSON(fiber.Map{
+			"message":        "Zwei-Faktor-Authentifizierung aktiviert",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// twoFactorDisableHandler schaltet 2FA ab; dafür ist ein frischer Code aus
+// der App nötig, Wiederherstellungscodes reichen nicht
+func twoFactorDisableHandler(st *store.Store, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tf, userID, err := verifyFreshCode(c, st, limiter)
+		if err != nil || tf == nil {
+			return err
+		}
+		if err := st.TwoFactor.Disable(c.UserContext(), userID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		recordAudit(c, st, userID, store.AuditTwoFactorDisabled, nil)
+		return c.JSON(fiber.Map{"message": "Zwei-Faktor-Authentifizierung deaktiviert"})
+	}
+}
+
+// recoveryCodesHandler ersetzt alle Wiederherstellungscodes durch neue
+func recoveryCodesHandler(st *store.Store, limiter loginLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tf, userID, err := verifyFreshCode(c, st, limiter)
+		if err != nil || tf == nil {
+			return err
+		}
+		codes, hashes, err := newRecoveryCodes()
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Wiederherstellungscodes konnten nicht erzeugt werden"})
+		}
+		if err := st.TwoFactor.ReplaceRecoveryCodes(c.UserContext(), userID, hashes); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"recovery_codes": codes})
+	}
+}
+
+// verifyFreshCode prüft den TOTP-Code im Body gegen die aktive Einrichtung.
+// Falsche Codes zählen wie beim Login für die Kontosperre. Schlägt die
+// Prüfung fehl, ist die Antwort bereits geschrieben und tf nil.
+func verifyFreshCode(c *fiber.Ctx, st *store.Store, limiter loginLimiter) (*store.TwoFactor, int64, error) {
+	var input struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return nil, 0, c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+	}
+	userID := Current(c).ID
+
+	ctx := c.UserContext()
+	tf, err := enabledTwoFactor(ctx, st, userID)
+	if err != nil {
+		return nil, 0, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	if tf == nil {
+		return nil, 0, c.Status(400).JSON(fiber.Map{"error": "Zwei-Faktor-Authentifizierung ist nicht aktiv"})
+	}
+	user, err := st.Users.ByID(ctx, userID)
+	if err != nil {
+		return nil, 0, c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+	}
+	ok, err := limiter.reauthenticate(c, user,
+		func() (bool, error) {
+			return checkSecondFactor(ctx, st, userID, tf, input.Code, false, time.Now())
+		},
+		func() error {
+			return c.Status(400).JSON(fiber.Map{"error": "Code ungültig"})
+		})
+	if !ok {
+		return nil, 0, err
+	}
+	return tf, userID, nil
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"trainora/crypto"
+)
+
+func TestTwoFactorFreshCodeLocksAccount(t *testing.T) {
+	ta := newTestApp(t)
+	id := ta.createUser(t, "anna", strongPassword)
+
+	resp := ta.do(t, "POST", "/api/login", map[string]string{"login": "anna", "password": strongPassword})
+	wantStatus(t, resp, 200)
+	sess := cookie(resp, "session_id")
+
+	secret, err := crypto.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ta.st.TwoFactor.SetSecret(t.Context(), id, secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := ta.st.TwoFactor.Enable(t.Context(), id, time.Now(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := map[string]string{"code": "falsch"}
+	for i := 1; i < testConfig.Login.MaxPerAccount; i++ {
+		wantStatus(t, ta.do(t, "POST", "/api/2fa/recovery-codes", wrong, sess), 400)
+	}
+	resp = ta.do(t, "POST", "/api/2fa/disable", wrong, sess)
+	wantStatus(t, resp, 429)
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("429 ohne Retry-After")
+	}
+
+	// Während der Sperre hilft auch ein gültiger Code nicht
+	code, err := crypto.TOTPCode(secret, crypto.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantStatus(t, ta.do(t, "POST", "/api/2fa/disable", map[string]string{"code": code}, sess), 429)
+	status := wantStatus(t, ta.do(t, "GET", "/api/2fa/", nil, sess), 200)
+	if status["enabled"] != true {
+		t.Errorf("2FA nach gesperrtem Abschalten: %v", status)
+	}
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/store"
+)
+
+// RegisterVerifyEmailRoutes registriert die Bestätigung der E-Mail-Adresse
+func RegisterVerifyEmailRoutes(api fiber.Router, st *store.Store, mails *AccountMailer) {
+	// Link aus der Mail: bestätigt und leitet zum Login weiter
+	api.Get("/verify-email", func(c *fiber.Ctx) error {
+		verified := "true"
+		if err := verifyEmail(c, st, mails, c.Query("token")); err != nil {
+			verified = "false"
+		}
+		return c.Redirect(mails.baseURL + "/login?email_verified=" + verified)
+	})
+
+	// Für API-Clients: Token im Body, Antwort als JSON
+	api.Post("/verify-email", func(c *fiber.Ctx) error {
+		var input struct {
+			Token string `json:"token"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		if err := verifyEmail(c, st, mails, input.Token); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.JSON(fiber.Map{"message": "E-Mail-Adresse bestätigt"})
+	})
+
+	api.Post("/verify-email/resend", AuthMiddleware(st), func(c *fiber.Ctx) error {
+		userID := Current(c).ID
+		user, err := st.Users.ByID(c.UserContext(), userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		if !user.EmailVerifiedAt.IsZero() {
+			return c.Status(400).JSON(fiber.Map{"error": "E-Mail-Adresse ist bereits bestätigt"})
+		}
+		if err := mails.SendVerification(c.UserContext(), user); err != nil {
+			log.Printf("❌ Bestätigungsmail an Benutzer %d fehlgeschlagen: %v", user.ID, err)
+			return c.Status(500).JSON(fiber.Map{"error": "Mail konnte nicht versendet werden"})
+		}
+		return c.JSON(fiber.Map{"message": "Bestätigungsmail versendet"})
+	})
+}
+
+func verifyEmail(c *fiber.Ctx, st *store.Store, mails *AccountMailer, token string) error {
+	userID, err := mails.consumeToken(c.UserContext(), token, store.TokenVerifyEmail)
+	if err != nil {
+		return err
+	}
+	return st.Users.MarkEmailVerified(c.UserContext(), userID, time.Now())
+}
@@ -0,0 +1,345 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+
+	"trainora/store"
+)
+
+// Grenzen eines protokollierten Trainings; Wiederholungen, Gewicht und
+// Notizen je Satz folgen den Grenzen der Trainingsblöcke
+const (
+	maxSessionSets      = 100
+	maxSessionNotes     = 1000
+	maxExerciseNameLen  = 255
+	maxRPE              = 10
+	defaultSessionLimit = 20
+)
+
+// RegisterWorkoutSessionRoutes registriert das Protokollieren von Trainings,
+// die persönlichen Rekorde und die daraus berechneten Ziele
+func RegisterWorkoutSessionRoutes(api fiber.Router, st *store.Store) {
+	api.Post("/workout-sessions", AuthMiddleware(st), startWorkoutSessionHandler(st))
+	api.Get("/workout-sessions", AuthMiddleware(st), listWorkoutSessionsHandler(st))
+	api.Get("/workout-sessions/:id", AuthMiddleware(st), getWorkoutSessionHandler(st))
+	api.Put("/workout-sessions/:id", AuthMiddleware(st), updateWorkoutSessionHandler(st))
+	api.Post("/workout-sessions/:id/finish", AuthMiddleware(st), finishWorkoutSessionHandler(st))
+	api.Get("/personal-records", AuthMiddleware(st), personalRecordsHandler(st))
+	api.Get("/progression", AuthMiddleware(st), progressionHandler(st))
+}
+
+// WorkoutSet ist ein absolvierter Satz in der API
+type WorkoutSet struct {
+	ID           int64   `json:"id,omitempty"`
+	ExerciseID   int64   `json:"exercise_id"` // 0 = frei benannte Übung
+	ExerciseName string  `json:"exercise_name"`
+	Reps         int     `json:"reps"`
+	WeightKG     float64 `json:"weight_kg"`
+	RPE          float64 `json:"rpe,omitempty"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+type workoutSessionSummary struct {
+	ID         int64     `json:"id"`
+	ScheduleID int64     `json:"schedule_id"` // 0 = Eintrag nicht mehr im Plan
+	Title      string    `json:"title"`
+	Notes      string    `json:"notes"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Finished   bool      `json:"finished"`
+}
+
+type workoutSessionResponse struct {
+	workoutSessionSummary
+	Sets []WorkoutSet `json:"sets"`
+}
+
+func newWorkoutSessionSummary(w store.WorkoutSession) workoutSessionSummary {
+	return workoutSessionSummary{
+		ID:         w.ID,
+		ScheduleID: w.ScheduleID,
+		Title:      w.Title,
+		Notes:      w.Notes,
+		StartedAt:  w.StartedAt,
+		FinishedAt: w.FinishedAt,
+		Finished:   w.Finished(),
+	}
+}
+
+func newWorkoutSessionResponse(w store.WorkoutSession) workoutSessionResponse {
+	sets := make([]WorkoutSet, 0, len(w.Sets))
+	for _, s := range w.Sets {
+		sets = append(sets, WorkoutSet{
+			ID:           s.ID,
+			ExerciseID:   s.ExerciseID,
+			ExerciseName: s.ExerciseName,
+			Reps:         s.Reps,
+			WeightKG:     s.WeightKG,
+			RPE:          s.RPE,
+			Notes:        s.Notes,
+		})
+	}
+	return workoutSessionResponse{newWorkoutSessionSummary(w), sets}
+}
+
+type personalRecordResponse struct {
+	ExerciseID   int64     `json:"exercise_id"`
+	ExerciseName string    `json:"exercise_name"`
+	Type         string    `json:"type"`
+	Value        float64   `json:"value"`
+	SessionID    int64     `json:"session_id"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
+
+func newPersonalRecordsResponse(records []store.PersonalRecord) []personalRecordResponse {
+	list := make([]personalRecordResponse, 0, len(records))
+	for _, r := range records {
+		list = append(list, personalRecordResponse{
+			ExerciseID:   r.ExerciseID,
+			ExerciseName: r.ExerciseName,
+			Type:         r.Kind,
+			Value:        r.Value,
+			SessionID:    r.SessionID,
+			AchievedAt:   r.AchievedAt,
+		})
+	}
+	return list
+}
+
+// toWorkoutSet prüft einen Satz. Übungen aus dem Katalog übernehmen den
+// Namen von dort, frei benannte Übungen zählen nicht für Rekorde.
+func toWorkoutSet(s WorkoutSet, exercises map[int64]store.Exercise) (store.WorkoutSet, error) {
+	set := store.WorkoutSet{
+		ExerciseID:   s.ExerciseID,
+		ExerciseName: strings.TrimSpace(s.ExerciseName),
+		Reps:         s.Reps,
+		WeightKG:     s.WeightKG,
+		RPE:          s.RPE,
+		Notes:        strings.TrimSpace(s.Notes),
+	}
+	if s.ExerciseID != 0 {
+		e, ok := exercises[s.ExerciseID]
+		if !ok {
+			return set, fmt.Errorf("Unbekannte Übung %d", s.ExerciseID)
+		}
+		set.ExerciseName = e.Name
+	}
+	switch {
+	case set.ExerciseName == "":
+		return set, errors.New("exercise_id oder exercise_name fehlt")
+	case utf8.RuneCountInString(set.ExerciseName) > maxExerciseNameLen:
+		return set, fmt.Errorf("exercise_name darf höchstens %d Zeichen lang sein", maxExerciseNameLen)
+	case set.Reps < 1 || set.Reps > maxReps:
+		return set, fmt.Errorf("reps muss zwischen 1 und %d liegen", maxReps)
+	case set.WeightKG < 0 || set.WeightKG > maxWeightKG:
+		return set, fmt.Errorf("weight_kg muss zwischen 0 und %d liegen", maxWeightKG)
+	// RPE in halben Schritten, 0 = nicht angegeben
+	case set.RPE != 0 && (set.RPE < 1 || set.RPE > maxRPE || set.RPE*2 != math.Trunc(set.RPE*2)):
+		return set, fmt.Errorf("rpe muss zwischen 1 und %d in halben Schritten liegen", maxRPE)
+	case utf8.RuneCountInString(set.Notes) > maxBlockNotes:
+		return set, fmt.Errorf("Notizen dürfen höchstens %d Zeichen lang sein", maxBlockNotes)
+	}
+	set.WeightKG = math.Round(set.WeightKG*100) / 100
+	return set, nil
+}
+
+// workoutSessionID liest die ID aus der URL. Ist der Rückgabewert 0, wurde
+// bereits geantwortet.
+func workoutSessionID(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, c.Status(400).JSON(fiber.Map{"error": "Ungültige ID"})
+	}
+	return id, nil
+}
+
+// workoutSessionError beantwortet die Fehler von Update und Finish
+func workoutSessionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": "Training nicht gefunden"})
+	case errors.Is(err, store.ErrConflict):
+		return c.Status(409).JSON(fiber.Map{"error": "Das Training ist bereits abgeschlossen"})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "Training konnte nicht gespeichert werden"})
+}
+
+// startWorkoutSessionHandler beginnt ein Training zu einem Eintrag im
+// Wochenplan. Gibt es dazu schon eins, antwortet er mit 409 und der
+// vorhandenen Session.
+func startWorkoutSessionHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var input struct {
+			ScheduleID int64  `json:"schedule_id"`
+			Notes      string `json:"notes"`
+		}
+		if err := c.BodyParser(&input); err != nil || input.ScheduleID <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		input.Notes = strings.TrimSpace(input.Notes)
+		if utf8.RuneCountInString(input.Notes) > maxSessionNotes {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Notizen dürfen höchstens %d Zeichen lang sein", maxSessionNotes)})
+		}
+
+		ctx := c.UserContext()
+		userID := Current(c).ID
+		entry, err := st.Plans.Entry(ctx, userID, input.ScheduleID)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Eintrag nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+
+		id, err := st.Workouts.Start(ctx, store.WorkoutSession{
+			UserID:     userID,
+			ScheduleID: entry.ScheduleID,
+			Title:      entry.Task.Title,
+			Notes:      input.Notes,
+			StartedAt:  time.Now(),
+		})
+		if errors.Is(err, store.ErrConflict) {
+			existing, err := st.Workouts.BySchedule(ctx, userID, entry.ScheduleID)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+			}
+			return c.Status(409).JSON(fiber.Map{
+				"error":   "Zu diesem Eintrag gibt es bereits ein Training",
+				"session": newWorkoutSessionResponse(*existing),
+			})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Training konnte nicht gestartet werden"})
+		}
+		w, err := st.Workouts.ByID(ctx, userID, id)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.Status(201).JSON(newWorkoutSessionResponse(*w))
+	}
+}
+
+func listWorkoutSessionsHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		offset, limit := pageParams(c, defaultSessionLimit)
+		list, total, err := st.Workouts.List(c.UserContext(), Current(c).ID, offset, limit)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		sessions := make([]workoutSessionSummary, 0, len(list))
+		for _, w := range list {
+			sessions = append(sessions, newWorkoutSessionSummary(w))
+		}
+		return c.JSON(fiber.Map{"sessions": sessions, "total": total, "offset": offset, "limit": limit})
+	}
+}
+
+func getWorkoutSessionHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := workoutSessionID(c)
+		if id == 0 {
+			return err
+		}
+		w, err := st.Workouts.ByID(c.UserContext(), Current(c).ID, id)
+		if errors.Is(err, store.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Training nicht gefunden"})
+		}
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(newWorkoutSessionResponse(*w))
+	}
+}
+
+// updateWorkoutSessionHandler ersetzt Notizen und Sätze eines laufenden
+// Trainings
+func updateWorkoutSessionHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := workoutSessionID(c)
+		if id == 0 {
+			return err
+		}
+		var input struct {
+			Notes string       `json:"notes"`
+			Sets  []WorkoutSet `json:"sets"`
+		}
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Ungültige Eingabe"})
+		}
+		input.Notes = strings.TrimSpace(input.Notes)
+		switch {
+		case utf8.RuneCountInString(input.Notes) > maxSessionNotes:
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Notizen dürfen höchstens %d Zeichen lang sein", maxSessionNotes)})
+		case len(input.Sets) > maxSessionSets:
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Ein Training hat höchstens %d Sätze", maxSessionSets)})
+		}
+
+		ctx := c.UserContext()
+		userID := Current(c).ID
+		exercises, _, err := exerciseIndex(ctx, st, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		w := store.WorkoutSession{ID: id, Notes: input.Notes}
+		for i, s := range input.Sets {
+			set, err := toWorkoutSet(s, exercises)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Satz %d: %s", i+1, err)})
+			}
+			w.Sets = append(w.Sets, set)
+		}
+
+		if err := st.Workouts.Update(ctx, userID, w); err != nil {
+			return workoutSessionError(c, err)
+		}
+		updated, err := st.Workouts.ByID(ctx, userID, id)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(newWorkoutSessionResponse(*updated))
+	}
+}
+
+// finishWorkoutSessionHandler schließt ein Training ab und meldet die dabei
+// aufgestellten persönlichen Rekorde
+func finishWorkoutSessionHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := workoutSessionID(c)
+		if id == 0 {
+			return err
+		}
+		ctx := c.UserContext()
+		userID := Current(c).ID
+		records, err := st.Workouts.Finish(ctx, userID, id, time.Now())
+		if err != nil {
+			return workoutSessionError(c, err)
+		}
+		w, err := st.Workouts.ByID(ctx, userID, id)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{
+			"session":     newWorkoutSessionResponse(*w),
+			"new_records": newPersonalRecordsResponse(records),
+		})
+	}
+}
+
+// personalRecordsHandler liefert den aktuellen Bestwert je Übung und Art
+func personalRecordsHandler(st *store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		records, err := st.Workouts.Records(c.UserContext(), Current(c).ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "DB-Fehler"})
+		}
+		return c.JSON(fiber.Map{"records": newPersonalRecordsResponse(records)})
+	}
+}
@@ -0,0 +1,154 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"trainora/store"
+)
+
+// Grenzen eines strukturierten Trainings
+const (
+	maxWorkoutBlocks = 20
+	maxSets          = 20
+	maxReps          = 100
+	maxWeightKG      = 500
+	maxRounds        = 50
+	maxWorkSeconds   = 3600
+	maxRestSeconds   = 600
+	maxBlockNotes    = 500
+	// maxIndexedExercises begrenzt die Übungen, die ins Prompt und in die
+	// Prüfung eines Trainings einfließen
+	maxIndexedExercises = 500
+)
+
+// WorkoutBlock ist ein Abschnitt eines strukturierten Trainings in der API.
+// Je nach type sind sets, reps und weight_kg oder rounds und work_seconds
+// gesetzt.
+type WorkoutBlock struct {
+	ID           int64   `json:"id,omitempty"`
+	Type         string  `json:"type"` // "sets" oder "interval"
+	ExerciseID   int64   `json:"exercise_id"`
+	ExerciseName string  `json:"exercise_name"`
+	Sets         int     `json:"sets,omitempty"`
+	Reps         int     `json:"reps,omitempty"`
+	WeightKG     float64 `json:"weight_kg,omitempty"` // fehlt = Körpergewicht
+	Rounds       int     `json:"rounds,omitempty"`
+	WorkSeconds  int     `json:"work_seconds,omitempty"`
+	RestSeconds  int     `json:"rest_seconds"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+func newWorkoutResponse(blocks []store.WorkoutBlock) []WorkoutBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+	list := make([]WorkoutBlock, 0, len(blocks))
+	for _, b := range blocks {
+		list = append(list, WorkoutBlock{
+			ID:           b.ID,
+			Type:         b.Kind,
+			ExerciseID:   b.ExerciseID,
+			ExerciseName: b.ExerciseName,
+			Sets:         b.Sets,
+			Reps:         b.Reps,
+			WeightKG:     b.WeightKG,
+			Rounds:       b.Rounds,
+			WorkSeconds:  b.WorkSeconds,
+			RestSeconds:  b.RestSeconds,
+			Notes:        b.Notes,
+		})
+	}
+	return list
+}
+
+// exerciseIndex liefert die für userID sichtbaren Übungen nach ID
+func exerciseIndex(ctx context.Context, st *store.Store, userID int64) (map[int64]store.Exercise, []store.Exercise, error) {
+	list, _, err := st.Exercises.Search(ctx, userID, store.ExerciseFilter{Limit: maxIndexedExercises})
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make(map[int64]store.Exercise, len(list))
+	for _, e := range list {
+		index[e.ID] = e
+	}
+	return index, list, nil
+}
+
+// toWorkoutBlock prüft einen Block gegen die Grenzen und die sichtbaren
+// Übungen. Der Name kommt immer aus dem Katalog.
+func toWorkoutBlock(b WorkoutBlock, exercises map[int64]store.Exercise) (store.WorkoutBlock, error) {
+	e, ok := exercises[b.ExerciseID]
+	if !ok {
+		return store.WorkoutBlock{}, fmt.Errorf("Unbekannte Übung %d", b.ExerciseID)
+	}
+	block := store.WorkoutBlock{
+		Kind:         b.Type,
+		ExerciseID:   e.ID,
+		ExerciseName: e.Name,
+		RestSeconds:  b.RestSeconds,
+		Notes:        strings.TrimSpace(b.Notes),
+	}
+	switch b.Type {
+	case store.WorkoutSets:
+		switch {
+		case b.Sets < 1 || b.Sets > maxSets:
+			return block, fmt.Errorf("sets muss zwischen 1 und %d liegen", maxSets)
+		case b.Reps < 1 || b.Reps > maxReps:
+			return block, fmt.Errorf("reps muss zwischen 1 und %d liegen", maxReps)
+		case b.WeightKG < 0 || b.WeightKG > maxWeightKG:
+			return block, fmt.Errorf("weight_kg muss zwischen 0 und %d liegen", maxWeightKG)
+		}
+		block.Sets, block.Reps, block.WeightKG = b.Sets, b.Reps, b.WeightKG
+	case store.WorkoutInterval:
+		switch {
+		case b.Rounds < 1 || b.Rounds > maxRounds:
+			return block, fmt.Errorf("rounds muss zwischen 1 und %d liegen", maxRounds)
+		case b.WorkSeconds < 1 || b.WorkSeconds > maxWorkSeconds:
+			return block, fmt.Errorf("work_seconds muss zwischen 1 und %d liegen", maxWorkSeconds)
+		}
+		block.Rounds, block.WorkSeconds = b.Rounds, b.WorkSeconds
+	default:
+		return block, errors.New(`type muss "sets" oder "interval" sein`)
+	}
+	switch {
+	case block.RestSeconds < 0 || block.RestSeconds > maxRestSeconds:
+		return block, fmt.Errorf("rest_seconds muss zwischen 0 und %d liegen", maxRestSeconds)
+	case utf8.RuneCountInString(block.Notes) > maxBlockNotes:
+		return block, fmt.Errorf("Notizen dürfen höchstens %d Zeichen lang sein", maxBlockNotes)
+	}
+	return block, nil
+}
+
+// parseWorkout prüft ein vollständiges Training, z. B. aus der Bearbeitung
+// durch einen Coach
+func parseWorkout(blocks []WorkoutBlock, exercises map[int64]store.Exercise) ([]store.WorkoutBlock, error) {
+	if len(blocks) > maxWorkoutBlocks {
+		return nil, fmt.Errorf("Ein Training hat höchstens %d Blöcke", maxWorkoutBlocks)
+	}
+	var list []store.WorkoutBlock
+	for i, b := range blocks {
+		block, err := toWorkoutBlock(b, exercises)
+		if err != nil {
+			return nil, fmt.Errorf("Block %d: %w", i+1, err)
+		}
+		list = append(list, block)
+	}
+	return list, nil
+}
+
+// workoutPromptList beschreibt die Übungen für das LLM, eine pro Zeile
+func workoutPromptList(exercises []store.Exercise) string {
+	var b strings.Builder
+	for _, e := range exercises {
+		equipment := "no equipment"
+		if len(e.Equipment) > 0 {
+			equipment = strings.Join(e.Equipment, ", ")
+		}
+		fmt.Fprintf(&b, "    %d: %s (%s; %s; %s)\n", e.ID, e.Name, strings.Join(e.MuscleGroups, ", "), equipment, e.Difficulty)
+	}
+	return b.String()
+}
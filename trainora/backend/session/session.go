@@ -1,6 +1,59 @@
 package session
 
-import "github.com/gofiber/fiber/v2/middleware/session"
+import (
+	"database/sql"
+	"fmt"
+	"time"
 
-// Store ist die zentrale Session-Instanz für das gesamte Projekt
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/session"
+	"github.com/gofiber/storage/redis/v3"
+
+	"trainora/config"
+	"trainora/store/sqlstore"
+)
+
+// Session ist die Sitzung einer einzelnen Anfrage
+type Session = session.Session
+
+// Store ist die zentrale Session-Instanz für das gesamte Projekt. Bis Init
+// aufgerufen wird, liegen die Sessions im Arbeitsspeicher.
 var Store = session.New()
+
+// Config ist die aktive Session-Konfiguration. Ihre Cookie-Einstellungen
+// gelten auch für weitere Cookies wie Remember-Me.
+var Config = config.Session{CookieName: "session_id", CookieSameSite: "Lax"}
+
+// Init baut den Session-Store aus der Konfiguration auf. db wird für den
+// Speicher "db" verwendet.
+func Init(cfg config.Session, db *sql.DB) error {
+	storage, err := newStorage(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	Store = session.New(session.Config{
+		Storage:        storage,
+		Expiration:     cfg.Expiration,
+		KeyLookup:      "cookie:" + cfg.CookieName,
+		CookieSecure:   cfg.CookieSecure,
+		CookieSameSite: cfg.CookieSameSite,
+		CookieHTTPOnly: true,
+	})
+	Config = cfg
+	return nil
+}
+
+func newStorage(cfg config.Session, db *sql.DB) (fiber.Storage, error) {
+	switch cfg.Storage {
+	case "db":
+		return sqlstore.NewSessionStorage(db, 10*time.Minute), nil
+	case "redis":
+		// redis.New bricht ab, wenn der Server nicht erreichbar ist
+		return redis.New(redis.Config{URL: cfg.RedisURL}), nil
+	case "memory":
+		return nil, nil // Fiber-Standard: Arbeitsspeicher
+	default:
+		return nil, fmt.Errorf("unbekannter SESSION_STORAGE %q (erlaubt: db, redis, memory)", cfg.Storage)
+	}
+}
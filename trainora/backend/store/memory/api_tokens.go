@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type personalTokenStore struct{ *data }
+
+func (s *personalTokenStore) Create(_ context.Context, t store.PersonalToken) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.personalTokens {
+		if existing.TokenHash == t.TokenHash {
+			return 0, store.ErrConflict
+		}
+	}
+	t.ID = s.nextID()
+	s.personalTokens[t.ID] = &t
+	return t.ID, nil
+}
+
+func (s *personalTokenStore) ByHash(_ context.Context, tokenHash string) (*store.PersonalToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.personalTokens {
+		if t.TokenHash == tokenHash {
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *personalTokenStore) ListByUser(_ context.Context, userID int64) ([]store.PersonalToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.PersonalToken
+	for _, t := range s.personalTokens {
+		if t.UserID == userID {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list, nil
+}
+
+func (s *personalTokenStore) Touch(_ context.Context, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.personalTokens[id]; ok {
+		t.LastUsedAt = at
+	}
+	return nil
+}
+
+func (s *personalTokenStore) Delete(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.personalTokens[id]
+	if !ok || t.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.personalTokens, id)
+	return nil
+}
+
+type refreshTokenStore struct{ *data }
+
+func (s *refreshTokenStore) Create(_ context.Context, t store.RefreshToken) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.refreshTokens {
+		if existing.TokenHash == t.TokenHash {
+			return 0, store.ErrConflict
+		}
+	}
+	t.ID = s.nextID()
+	s.refreshTokens[t.ID] = &t
+	return t.ID, nil
+}
+
+func (s *refreshTokenStore) ByHash(_ context.Context, tokenHash string) (*store.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.refreshTokens {
+		if t.TokenHash == tokenHash {
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *refreshTokenStore) Use(_ context.Context, id int64, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.refreshTokens[id]
+	if !ok || !t.UsedAt.IsZero() {
+		return false, nil
+	}
+	t.UsedAt = at
+	return true, nil
+}
+
+func (s *refreshTokenStore) DeleteFamily(_ context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.refreshTokens {
+		if t.Family == family {
+			delete(s.refreshTokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *refreshTokenStore) DeleteByUser(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.refreshTokens {
+		if t.UserID == userID {
+			delete(s.refreshTokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *refreshTokenStore) DeleteExpired(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.refreshTokens {
+		if !t.ExpiresAt.After(now) {
+			delete(s.refreshTokens, id)
+		}
+	}
+	return nil
+}
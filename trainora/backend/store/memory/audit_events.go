@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"context"
+	"maps"
+
+	"trainora/store"
+)
+
+type auditStore struct{ *data }
+
+func (s *auditStore) Record(_ context.Context, e store.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.nextID()
+	e.Metadata = maps.Clone(e.Metadata)
+	s.auditEvents = append(s.auditEvents, e)
+	return nil
+}
+
+func (s *auditStore) ByUser(_ context.Context, userID int64, offset, limit int) ([]store.AuditEvent, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Angehängt wird in zeitlicher Reihenfolge, also rückwärts lesen
+	var list []store.AuditEvent
+	for i := len(s.auditEvents) - 1; i >= 0; i-- {
+		if s.auditEvents[i].UserID == userID {
+			list = append(list, s.auditEvents[i])
+		}
+	}
+	total := len(list)
+	if offset > total {
+		offset = total
+	}
+	list = list[offset:]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, total, nil
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type coachStore struct{ *data }
+
+func (s *coachStore) find(coachID, clientID int64) *store.CoachLink {
+	for _, l := range s.coachLinks {
+		if l.CoachID == coachID && l.ClientID == clientID {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *coachStore) Invite(_ context.Context, coachID, clientID int64, at time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.find(coachID, clientID) != nil {
+		return 0, store.ErrConflict
+	}
+	id := s.nextID()
+	s.coachLinks[id] = &store.CoachLink{
+		ID:        id,
+		CoachID:   coachID,
+		ClientID:  clientID,
+		Status:    store.CoachPending,
+		CreatedAt: at,
+	}
+	return id, nil
+}
+
+func (s *coachStore) Accept(_ context.Context, coachID, clientID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.find(coachID, clientID)
+	if l == nil || l.Status != store.CoachPending {
+		return store.ErrNotFound
+	}
+	l.Status = store.CoachActive
+	l.AcceptedAt = at
+	return nil
+}
+
+func (s *coachStore) Delete(_ context.Context, coachID, clientID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.find(coachID, clientID)
+	if l == nil {
+		return store.ErrNotFound
+	}
+	delete(s.coachLinks, l.ID)
+	return nil
+}
+
+// list liefert die passenden Beziehungen mit Benutzernamen wie beim JOIN
+func (s *coachStore) list(match func(*store.CoachLink) bool, name func(store.CoachLink) string) []store.CoachLink {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.CoachLink
+	for _, l := range s.coachLinks {
+		if !match(l) {
+			continue
+		}
+		link := *l
+		if u, ok := s.users[link.CoachID]; ok {
+			link.CoachName = u.Username
+		}
+		if u, ok := s.users[link.ClientID]; ok {
+			link.ClientName = u.Username
+		}
+		list = append(list, link)
+	}
+	sort.Slice(list, func(i, j int) bool { return name(list[i]) < name(list[j]) })
+	return list
+}
+
+func (s *coachStore) ListByCoach(_ context.Context, coachID int64) ([]store.CoachLink, error) {
+	return s.list(
+		func(l *store.CoachLink) bool { return l.CoachID == coachID },
+		func(l store.CoachLink) string { return l.ClientName },
+	), nil
+}
+
+func (s *coachStore) ListByClient(_ context.Context, clientID int64) ([]store.CoachLink, error) {
+	return s.list(
+		func(l *store.CoachLink) bool { return l.ClientID == clientID },
+		func(l store.CoachLink) string { return l.CoachName },
+	), nil
+}
+
+// coachActive meldet wie der JOIN in sqlstore, ob die Beziehung aktiv ist und
+// der Coach noch betreuen darf
+func (d *data) coachActive(l *store.CoachLink) bool {
+	coach, ok := d.users[l.CoachID]
+	return ok && l.Status == store.CoachActive && !coach.Disabled() && !coach.Deleted() &&
+		slices.Contains(d.roles[l.CoachID], store.RoleCoach)
+}
+
+func (s *coachStore) IsActive(_ context.Context, coachID, clientID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.find(coachID, clientID)
+	return l != nil && s.coachActive(l), nil
+}
+
+func (s *coachStore) HasActiveCoach(_ context.Context, clientID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range s.coachLinks {
+		if l.ClientID == clientID && s.coachActive(l) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+
+	"trainora/store"
+)
+
+type commentStore struct{ *data }
+
+func (s *commentStore) Create(_ context.Context, cm store.Comment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cm.ID = s.nextID()
+	s.comments = append(s.comments, cm)
+	return cm.ID, nil
+}
+
+func (s *commentStore) Week(_ context.Context, userID int64, weekStartDate string) ([]store.Comment, error) {
+	return s.list(func(st store.ScheduledTask) bool {
+		return st.UserID == userID && st.WeekStartDate == weekStartDate
+	}), nil
+}
+
+func (s *commentStore) History(_ context.Context, userID int64) ([]store.Comment, error) {
+	return s.list(func(st store.ScheduledTask) bool { return st.UserID == userID }), nil
+}
+
+// list liefert die Kommentare zu den passenden Einträgen wie beim JOIN
+func (s *commentStore) list(match func(store.ScheduledTask) bool) []store.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matching := map[int64]bool{}
+	for _, st := range s.schedule {
+		if match(st) {
+			matching[st.ScheduleID] = true
+		}
+	}
+	var list []store.Comment
+	for _, cm := range s.comments {
+		if !matching[cm.ScheduleID] {
+			continue
+		}
+		if u, ok := s.users[cm.AuthorID]; ok {
+			cm.AuthorName = u.Username
+		}
+		list = append(list, cm)
+	}
+	return list
+}
+
+// deleteComments entfernt die Kommentare gelöschter Einträge (ON DELETE
+// CASCADE); der Aufrufer hält den Mutex
+func (d *data) deleteComments(removed map[int64]bool) {
+	kept := d.comments[:0]
+	for _, cm := range d.comments {
+		if !removed[cm.ScheduleID] {
+			kept = append(kept, cm)
+		}
+	}
+	d.comments = kept
+}
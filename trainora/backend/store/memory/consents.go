@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type consentStore struct{ *data }
+
+func (s *consentStore) Give(_ context.Context, c store.Consent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.ID = s.nextID()
+	c.WithdrawnAt = time.Time{}
+	s.consents = append(s.consents, c)
+	return nil
+}
+
+func (s *consentStore) Active(_ context.Context, userID int64) ([]store.Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := map[string]int{}
+	var list []store.Consent
+	for _, c := range s.consents {
+		if c.UserID != userID || !c.WithdrawnAt.IsZero() {
+			continue
+		}
+		if i, ok := latest[c.Kind]; ok {
+			list[i] = c
+			continue
+		}
+		latest[c.Kind] = len(list)
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func (s *consentStore) Withdraw(_ context.Context, userID int64, kind string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for i := range s.consents {
+		c := &s.consents[i]
+		if c.UserID == userID && c.Kind == kind && c.WithdrawnAt.IsZero() {
+			c.WithdrawnAt = at
+			found = true
+		}
+	}
+	if !found {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *consentStore) History(_ context.Context, userID int64) ([]store.Consent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.Consent
+	for _, c := range s.consents {
+		if c.UserID == userID {
+			list = append(list, c)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].GivenAt.Before(list[j].GivenAt) })
+	return list, nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"trainora/store"
+)
+
+type dataExportRow struct {
+	store.DataExport
+	archive []byte
+}
+
+type dataExportStore struct{ *data }
+
+func (s *dataExportStore) Start(_ context.Context, userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dataExports[userID] = &dataExportRow{DataExport: store.DataExport{
+		UserID:    userID,
+		Status:    store.JobRunning,
+		CreatedAt: at,
+	}}
+	return nil
+}
+
+func (s *dataExportStore) ByUser(_ context.Context, userID int64) (*store.DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.dataExports[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	export := e.DataExport
+	return &export, nil
+}
+
+func (s *dataExportStore) Finish(_ context.Context, userID int64, at time.Time, archive []byte, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.dataExports[userID]
+	if !ok {
+		return nil
+	}
+	e.FinishedAt = at
+	if errMsg != "" {
+		e.Status, e.Error = store.JobFailed, errMsg
+		return nil
+	}
+	e.Status = store.JobSucceeded
+	e.archive = append([]byte(nil), archive...)
+	return nil
+}
+
+func (s *dataExportStore) Archive(_ context.Context, userID int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.dataExports[userID]
+	if !ok || e.Status != store.JobSucceeded {
+		return nil, store.ErrNotFound
+	}
+	return append([]byte(nil), e.archive...), nil
+}
+
+func (s *dataExportStore) Delete(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.dataExports, userID)
+	return nil
+}
+
+func (s *dataExportStore) DeleteBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.dataExports {
+		if e.CreatedAt.Before(before) {
+			delete(s.dataExports, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"trainora/store"
+)
+
+type emailTokenRow struct {
+	store.EmailToken
+	used bool
+}
+
+type emailTokenStore struct{ *data }
+
+func (s *emailTokenStore) Create(_ context.Context, t store.EmailToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.emailTokens {
+		if existing.NonceHash == t.NonceHash {
+			return store.ErrConflict
+		}
+	}
+	s.emailTokens = append(s.emailTokens, emailTokenRow{EmailToken: t})
+	return nil
+}
+
+func (s *emailTokenStore) Consume(_ context.Context, nonceHash, purpose string, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.emailTokens {
+		t := &s.emailTokens[i]
+		if t.NonceHash == nonceHash && t.Purpose == purpose && !t.used && t.ExpiresAt.After(now) {
+			t.used = true
+			return t.UserID, nil
+		}
+	}
+	return 0, store.ErrNotFound
+}
+
+func (s *emailTokenStore) DeleteByUser(_ context.Context, userID int64, purpose string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.emailTokens[:0]
+	for _, t := range s.emailTokens {
+		if t.UserID == userID && t.Purpose == purpose && !t.used {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	s.emailTokens = kept
+	return nil
+}
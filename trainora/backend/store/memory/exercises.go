@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"trainora/store"
+)
+
+type exerciseStore struct{ *data }
+
+// matches bildet die WHERE-Bedingung von sqlstore nach
+func (s *exerciseStore) matches(e *store.Exercise, userID int64, f store.ExerciseFilter) bool {
+	switch {
+	case f.OwnOnly && e.UserID != userID,
+		e.UserID != 0 && e.UserID != userID,
+		f.Query != "" && !strings.Contains(strings.ToLower(e.Name), strings.ToLower(f.Query)),
+		f.MuscleGroup != "" && !slices.Contains(e.MuscleGroups, f.MuscleGroup),
+		f.Equipment != "" && !slices.Contains(e.Equipment, f.Equipment),
+		f.Difficulty != "" && e.Difficulty != f.Difficulty:
+		return false
+	}
+	return true
+}
+
+func (s *exerciseStore) Search(_ context.Context, userID int64, f store.ExerciseFilter) ([]store.Exercise, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var all []store.Exercise
+	for _, e := range s.exercises {
+		if s.matches(e, userID, f) {
+			all = append(all, *e)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return all[i].ID < all[j].ID
+	})
+	total := len(all)
+	if f.Offset >= total {
+		return nil, total, nil
+	}
+	return all[f.Offset:min(f.Offset+f.Limit, total)], total, nil
+}
+
+func (s *exerciseStore) ByID(_ context.Context, id int64) (*store.Exercise, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.exercises[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	cp := *e
+	return &cp, nil
+}
+
+func (s *exerciseStore) ListByUser(_ context.Context, userID int64) ([]store.Exercise, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.Exercise
+	for _, e := range s.exercises {
+		if e.UserID == userID {
+			list = append(list, *e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (s *exerciseStore) Create(_ context.Context, e store.Exercise) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.nextID()
+	e.Slug = ""
+	e.CreatedAt = time.Now()
+	s.exercises[e.ID] = &e
+	return e.ID, nil
+}
+
+func (s *exerciseStore) Update(_ context.Context, e store.Exercise) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.exercises[e.ID]
+	if !ok || old.UserID == 0 || old.UserID != e.UserID {
+		return store.ErrNotFound
+	}
+	e.Slug, e.CreatedAt = old.Slug, old.CreatedAt
+	*old = e
+	return nil
+}
+
+func (s *exerciseStore) Delete(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.exercises[id]
+	if !ok || e.UserID == 0 || e.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.exercises, id)
+	// Trainings und Sätze behalten nur den Namen der Übung, ihre Rekorde
+	// werden gelöscht
+	for i := range s.schedule {
+		unlinkExercise(s.schedule[i].Task.Workout, id)
+	}
+	for _, t := range s.tasks {
+		unlinkExercise(t.Workout, id)
+	}
+	for _, w := range s.workoutSessions {
+		for i := range w.Sets {
+			if w.Sets[i].ExerciseID == id {
+				w.Sets[i].ExerciseID = 0
+			}
+		}
+	}
+	s.deleteRecords(func(r store.PersonalRecord) bool { return r.ExerciseID == id })
+	return nil
+}
+
+func unlinkExercise(blocks []store.WorkoutBlock, id int64) {
+	for i := range blocks {
+		if blocks[i].ExerciseID == id {
+			blocks[i].ExerciseID = 0
+		}
+	}
+}
+
+func (s *exerciseStore) SyncCatalog(_ context.Context, list []store.Exercise) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range list {
+		e.UserID = 0
+		var existing *store.Exercise
+		for _, old := range s.exercises {
+			if old.Slug == e.Slug {
+				existing = old
+				break
+			}
+		}
+		if existing != nil {
+			e.ID, e.CreatedAt = existing.ID, existing.CreatedAt
+			*existing = e
+			continue
+		}
+		e.ID = s.nextID()
+		e.CreatedAt = time.Now()
+		s.exercises[e.ID] = &e
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type generationJobStore struct{ *data }
+
+func (s *generationJobStore) Start(_ context.Context, j store.GenerationJob) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j.ID = s.nextID()
+	j.Status = store.JobRunning
+	s.generationJobs = append(s.generationJobs, j)
+	return j.ID, nil
+}
+
+func (s *generationJobStore) Finish(_ context.Context, id int64, at time.Time, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.generationJobs {
+		if s.generationJobs[i].ID == id {
+			j := &s.generationJobs[i]
+			j.Status, j.Error, j.FinishedAt = store.JobSucceeded, errMsg, at
+			if errMsg != "" {
+				j.Status = store.JobFailed
+			}
+		}
+	}
+	return nil
+}
+
+func (s *generationJobStore) Failures(_ context.Context, limit int) ([]store.GenerationJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.GenerationJob
+	for _, j := range s.generationJobs {
+		if j.Status == store.JobFailed {
+			list = append(list, j)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (s *generationJobStore) DeleteBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.generationJobs[:0]
+	for _, j := range s.generationJobs {
+		if !j.StartedAt.Before(before) {
+			kept = append(kept, j)
+		}
+	}
+	s.generationJobs = kept
+	return nil
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type identityStore struct{ *data }
+
+func (s *identityStore) Create(_ context.Context, i store.Identity) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.identities {
+		if existing.Issuer == i.Issuer && existing.Subject == i.Subject {
+			return 0, store.ErrConflict
+		}
+	}
+	i.ID = s.nextID()
+	s.identities[i.ID] = &i
+	return i.ID, nil
+}
+
+func (s *identityStore) BySubject(_ context.Context, issuer, subject string) (*store.Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range s.identities {
+		if i.Issuer == issuer && i.Subject == subject {
+			identity := *i
+			return &identity, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *identityStore) ListByUser(_ context.Context, userID int64) ([]store.Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.Identity
+	for _, i := range s.identities {
+		if i.UserID == userID {
+			list = append(list, *i)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].CreatedAt.Before(list[b].CreatedAt) })
+	return list, nil
+}
+
+func (s *identityStore) Touch(_ context.Context, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, ok := s.identities[id]; ok {
+		i.LastLoginAt = at
+	}
+	return nil
+}
+
+func (s *identityStore) Delete(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.identities[id]
+	if !ok || i.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.identities, id)
+	return nil
+}
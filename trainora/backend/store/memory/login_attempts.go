@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"trainora/store"
+)
+
+type loginAttemptStore struct{ *data }
+
+func (s *loginAttemptStore) Record(_ context.Context, a store.LoginAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.loginAttempts = append(s.loginAttempts, a)
+	return nil
+}
+
+func (s *loginAttemptStore) failures(match func(store.LoginAttempt) bool, since time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	var oldest time.Time
+	for _, a := range s.loginAttempts {
+		if a.Success || !a.AttemptedAt.After(since) || !match(a) {
+			continue
+		}
+		count++
+		if oldest.IsZero() || a.AttemptedAt.Before(oldest) {
+			oldest = a.AttemptedAt
+		}
+	}
+	return count, oldest, nil
+}
+
+func (s *loginAttemptStore) FailuresByIP(_ context.Context, ip string, since time.Time) (int, time.Time, error) {
+	return s.failures(func(a store.LoginAttempt) bool { return a.IP == ip }, since)
+}
+
+func (s *loginAttemptStore) FailuresByUser(_ context.Context, userID int64, since time.Time) (int, time.Time, error) {
+	return s.failures(func(a store.LoginAttempt) bool { return a.UserID == userID }, since)
+}
+
+func (s *loginAttemptStore) DeleteBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.loginAttempts[:0]
+	for _, a := range s.loginAttempts {
+		if !a.AttemptedAt.Before(before) {
+			kept = append(kept, a)
+		}
+	}
+	s.loginAttempts = kept
+	return nil
+}
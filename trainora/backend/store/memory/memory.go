@@ -0,0 +1,99 @@
+// Package memory implementiert die Interfaces aus store im Arbeitsspeicher.
+// Gedacht für Unit-Tests der Handler ohne MySQL-Container.
+package memory
+
+import (
+	"sync"
+
+	"trainora/store"
+)
+
+// New erzeugt einen leeren In-Memory-Store
+func New() *store.Store {
+	db := &data{
+		users:          map[int64]*userRow{},
+		roles:          map[int64][]string{},
+		rememberTokens: map[int64]*store.RememberToken{},
+		twoFactor:      map[int64]*store.TwoFactor{},
+		identities:     map[int64]*store.Identity{},
+		personalTokens: map[int64]*store.PersonalToken{},
+		refreshTokens:  map[int64]*store.RefreshToken{},
+		coachLinks:     map[int64]*store.CoachLink{},
+		approvals:      map[approvalKey]*store.PlanApproval{},
+		tasks:          map[int64]*store.Task{},
+		recipes:        map[int64]*store.Recipe{},
+		exercises:      map[int64]*store.Exercise{},
+		dataExports:    map[int64]*dataExportRow{},
+
+		workoutSessions: map[int64]*store.WorkoutSession{},
+	}
+	return &store.Store{
+		Users:          &userStore{db},
+		Roles:          &roleStore{db},
+		RememberTokens: &rememberTokenStore{db},
+		LoginAttempts:  &loginAttemptStore{db},
+		AuditEvents:    &auditStore{db},
+		Consents:       &consentStore{db},
+		EmailTokens:    &emailTokenStore{db},
+		TwoFactor:      &twoFactorStore{db},
+		Identities:     &identityStore{db},
+		PersonalTokens: &personalTokenStore{db},
+		RefreshTokens:  &refreshTokenStore{db},
+		Coaches:        &coachStore{db},
+		Plans:          &planStore{db},
+		Comments:       &commentStore{db},
+		GenerationJobs: &generationJobStore{db},
+		DataExports:    &dataExportStore{db},
+		Tasks:          &taskStore{db},
+		Recipes:        &recipeStore{db},
+		Exercises:      &exerciseStore{db},
+		Workouts:       &workoutSessionStore{db},
+	}
+}
+
+// data hält alle Tabellen; ein Mutex schützt den gesamten Zustand
+type data struct {
+	mu sync.Mutex
+
+	lastID         int64
+	users          map[int64]*userRow
+	roles          map[int64][]string
+	rememberTokens map[int64]*store.RememberToken
+	loginAttempts  []store.LoginAttempt
+	auditEvents    []store.AuditEvent
+	consents       []store.Consent
+	emailTokens    []emailTokenRow
+	twoFactor      map[int64]*store.TwoFactor
+	recoveryCodes  []recoveryCodeRow
+	identities     map[int64]*store.Identity
+	personalTokens map[int64]*store.PersonalToken
+	refreshTokens  map[int64]*store.RefreshToken
+	coachLinks     map[int64]*store.CoachLink
+	tasks          map[int64]*store.Task
+	schedule       []store.ScheduledTask
+	approvals      map[approvalKey]*store.PlanApproval
+	comments       []store.Comment
+	generationJobs []store.GenerationJob
+	recipes        map[int64]*store.Recipe
+	exercises      map[int64]*store.Exercise
+	dataExports    map[int64]*dataExportRow
+
+	workoutSessions map[int64]*store.WorkoutSession
+	personalRecords []store.PersonalRecord
+}
+
+// approvalKey ist der Primärschlüssel von plan_approvals
+type approvalKey struct {
+	userID        int64
+	weekStartDate string
+}
+
+type userRow struct {
+	store.User
+	profile store.Profile
+}
+
+func (d *data) nextID() int64 {
+	d.lastID++
+	return d.lastID
+}
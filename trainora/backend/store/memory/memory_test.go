@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"trainora/store"
+	"trainora/store/memory"
+	"trainora/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) *store.Store {
+		return memory.New()
+	})
+}
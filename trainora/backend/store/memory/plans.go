@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type planStore struct{ *data }
+
+func periodIndex(period string) int {
+	for i, p := range store.DayPeriods {
+		if p == period {
+			return i
+		}
+	}
+	return len(store.DayPeriods)
+}
+
+func (s *planStore) Week(_ context.Context, userID int64, weekStartDate string) ([]store.ScheduledTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.ScheduledTask
+	for _, st := range s.schedule {
+		if st.UserID == userID && st.WeekStartDate == weekStartDate {
+			list = append(list, st)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Weekday != list[j].Weekday {
+			return list[i].Weekday < list[j].Weekday
+		}
+		return periodIndex(list[i].DayPeriod) < periodIndex(list[j].DayPeriod)
+	})
+	return list, nil
+}
+
+func (s *planStore) History(_ context.Context, userID int64) ([]store.ScheduledTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.ScheduledTask
+	for _, st := range s.schedule {
+		if st.UserID == userID {
+			list = append(list, st)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].WeekStartDate != list[j].WeekStartDate {
+			return list[i].WeekStartDate < list[j].WeekStartDate
+		}
+		if list[i].Weekday != list[j].Weekday {
+			return list[i].Weekday < list[j].Weekday
+		}
+		return periodIndex(list[i].DayPeriod) < periodIndex(list[j].DayPeriod)
+	})
+	return list, nil
+}
+
+func (s *planStore) Count(_ context.Context, userID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, st := range s.schedule {
+		if st.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *planStore) HasWeek(_ context.Context, userID int64, weekStartDate string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.schedule {
+		if st.UserID == userID && st.WeekStartDate == weekStartDate {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *planStore) SaveWeek(_ context.Context, userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saveWeek(userID, weekStartDate, tasks, approvalAt)
+	return nil
+}
+
+func (s *planStore) ReplaceWeek(_ context.Context, userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := map[int64]bool{}
+	kept := s.schedule[:0]
+	for _, st := range s.schedule {
+		if st.UserID == userID && st.WeekStartDate == weekStartDate {
+			delete(s.tasks, st.Task.ID)
+			removed[st.ScheduleID] = true
+			continue
+		}
+		kept = append(kept, st)
+	}
+	s.schedule = kept
+	s.deleteComments(removed)
+	s.unlinkSessions(removed)
+	s.saveWeek(userID, weekStartDate, tasks, approvalAt)
+	return nil
+}
+
+func (s *planStore) saveWeek(userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time) {
+	for _, st := range tasks {
+		task := st.Task
+		task.ID = s.nextID()
+		task.CreatedBy = userID
+		task.Workout = s.cloneWorkout(task.Workout)
+		s.tasks[task.ID] = &task
+
+		st.Task = task
+		st.ScheduleID = s.nextID()
+		st.UserID = userID
+		st.WeekStartDate = weekStartDate
+		st.FeedbackOption = "none"
+		s.schedule = append(s.schedule, st)
+	}
+	if !approvalAt.IsZero() {
+		s.approvals[approvalKey{userID, weekStartDate}] = &store.PlanApproval{
+			UserID:        userID,
+			WeekStartDate: weekStartDate,
+			RequestedAt:   approvalAt,
+		}
+	}
+}
+
+// cloneWorkout kopiert die Blöcke, damit Aufgabe und Einplanung keine
+// gemeinsamen Slices halten, und vergibt fehlende IDs
+func (d *data) cloneWorkout(blocks []store.WorkoutBlock) []store.WorkoutBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+	blocks = slices.Clone(blocks)
+	for i := range blocks {
+		if blocks[i].ID == 0 {
+			blocks[i].ID = d.nextID()
+		}
+	}
+	return blocks
+}
+
+func (s *planStore) SetFeedback(_ context.Context, userID, scheduleID int64, option, feedback string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.schedule {
+		if s.schedule[i].ScheduleID == scheduleID && s.schedule[i].UserID == userID {
+			s.schedule[i].FeedbackOption = option
+			s.schedule[i].Feedback = feedback
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (s *planStore) Entry(_ context.Context, userID, scheduleID int64) (*store.ScheduledTask, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.schedule {
+		if st.ScheduleID == scheduleID && st.UserID == userID {
+			return &st, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *planStore) UpdateEntry(_ context.Context, userID int64, entry store.ScheduledTask) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.schedule {
+		st := &s.schedule[i]
+		if st.ScheduleID != entry.ScheduleID || st.UserID != userID {
+			continue
+		}
+		st.Task.Title = entry.Task.Title
+		st.Task.Description = entry.Task.Description
+		st.Task.Duration = entry.Task.Duration
+		st.Task.Workout = s.cloneWorkout(entry.Task.Workout)
+		st.Weekday = entry.Weekday
+		st.DayPeriod = entry.DayPeriod
+		if t, ok := s.tasks[st.Task.ID]; ok {
+			*t = st.Task
+		}
+		return nil
+	}
+	return store.ErrNotFound
+}
+
+func (s *planStore) DeleteEntry(_ context.Context, userID, scheduleID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, st := range s.schedule {
+		if st.ScheduleID == scheduleID && st.UserID == userID {
+			delete(s.tasks, st.Task.ID)
+			s.schedule = append(s.schedule[:i], s.schedule[i+1:]...)
+			s.deleteComments(map[int64]bool{scheduleID: true})
+			s.unlinkSessions(map[int64]bool{scheduleID: true})
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (s *planStore) Approval(_ context.Context, userID int64, weekStartDate string) (*store.PlanApproval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.approvals[approvalKey{userID, weekStartDate}]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	approval := *a
+	return &approval, nil
+}
+
+func (s *planStore) Approve(_ context.Context, userID int64, weekStartDate string, coachID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.approvals[approvalKey{userID, weekStartDate}]
+	if !ok || !a.Pending() {
+		return store.ErrNotFound
+	}
+	a.ApprovedBy = coachID
+	a.ApprovedAt = at
+	return nil
+}
+
+func (s *planStore) ReleaseApprovals(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, a := range s.approvals {
+		if key.userID == userID && a.Pending() {
+			delete(s.approvals, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type recipeStore struct{ *data }
+
+func (s *recipeStore) ListByUser(_ context.Context, userID int64) ([]store.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.Recipe
+	for _, r := range s.recipes {
+		if r.UserID == userID {
+			list = append(list, *r)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list, nil
+}
+
+func (s *recipeStore) Create(_ context.Context, r store.Recipe) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = s.nextID()
+	r.CreatedAt = time.Now()
+	s.recipes[r.ID] = &r
+	return r.ID, nil
+}
+
+func (s *recipeStore) DeleteByUser(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, r := range s.recipes {
+		if r.UserID == userID {
+			delete(s.recipes, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type rememberTokenStore struct{ *data }
+
+func (s *rememberTokenStore) Create(_ context.Context, t store.RememberToken) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.rememberTokens {
+		if existing.Selector == t.Selector {
+			return 0, store.ErrConflict
+		}
+	}
+	t.ID = s.nextID()
+	s.rememberTokens[t.ID] = &t
+	return t.ID, nil
+}
+
+func (s *rememberTokenStore) BySelector(_ context.Context, selector string) (*store.RememberToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.rememberTokens {
+		if t.Selector == selector {
+			token := *t
+			return &token, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *rememberTokenStore) ByID(_ context.Context, userID, id int64) (*store.RememberToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.rememberTokens[id]
+	if !ok || t.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	token := *t
+	return &token, nil
+}
+
+func (s *rememberTokenStore) Rotate(_ context.Context, id int64, oldHash, newHash string, lastUsed, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.rememberTokens[id]
+	if !ok || t.ValidatorHash != oldHash {
+		return store.ErrConflict
+	}
+	t.PreviousValidatorHash, t.ValidatorHash = oldHash, newHash
+	t.RotatedAt = lastUsed
+	t.LastUsedAt = lastUsed
+	t.ExpiresAt = expiresAt
+	return nil
+}
+
+func (s *rememberTokenStore) ListByUser(_ context.Context, userID int64, now time.Time) ([]store.RememberToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.RememberToken
+	for _, t := range s.rememberTokens {
+		if t.UserID == userID && t.ExpiresAt.After(now) {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastUsedAt.After(list[j].LastUsedAt) })
+	return list, nil
+}
+
+func (s *rememberTokenStore) Delete(_ context.Context, userID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.rememberTokens[id]
+	if !ok || t.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.rememberTokens, id)
+	return nil
+}
+
+func (s *rememberTokenStore) DeleteByUser(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.rememberTokens {
+		if t.UserID == userID {
+			delete(s.rememberTokens, id)
+		}
+	}
+	return nil
+}
+
+func (s *rememberTokenStore) DeleteExpired(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.rememberTokens {
+		if !t.ExpiresAt.After(now) {
+			delete(s.rememberTokens, id)
+		}
+	}
+	return nil
+}
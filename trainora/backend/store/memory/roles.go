@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"trainora/store"
+)
+
+type roleStore struct{ *data }
+
+func (s *roleStore) ByUser(_ context.Context, userID int64) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.roles[userID]...), nil
+}
+
+func (s *roleStore) Set(_ context.Context, userID int64, roles []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var granted []string
+	for _, role := range roles {
+		if role != store.RoleUser {
+			granted = append(granted, role)
+		}
+	}
+	sort.Strings(granted)
+	if len(granted) == 0 {
+		delete(s.roles, userID)
+		return nil
+	}
+	s.roles[userID] = granted
+	return nil
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"trainora/store"
+)
+
+type taskStore struct{ *data }
+
+func (s *taskStore) ByID(_ context.Context, id int64) (*store.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	task := *t
+	return &task, nil
+}
+
+func (s *taskStore) ListByCreator(_ context.Context, userID int64) ([]store.Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.Task
+	for _, t := range s.tasks {
+		if t.CreatedBy == userID {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"trainora/store"
+)
+
+type recoveryCodeRow struct {
+	userID   int64
+	codeHash string
+	used     bool
+}
+
+type twoFactorStore struct{ *data }
+
+func (s *twoFactorStore) ByUser(_ context.Context, userID int64) (*store.TwoFactor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactor[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	c := *tf
+	return &c, nil
+}
+
+func (s *twoFactorStore) SetSecret(_ context.Context, userID int64, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return store.ErrNotFound
+	}
+	s.twoFactor[userID] = &store.TwoFactor{Secret: secret}
+	return nil
+}
+
+func (s *twoFactorStore) Enable(_ context.Context, userID int64, at time.Time, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactor[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	tf.EnabledAt = at
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+func (s *twoFactorStore) Disable(_ context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.twoFactor, userID)
+	s.replaceRecoveryCodes(userID, nil)
+	return nil
+}
+
+func (s *twoFactorStore) UseStep(_ context.Context, userID, step int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tf, ok := s.twoFactor[userID]
+	if !ok || tf.LastStep >= step {
+		return false, nil
+	}
+	tf.LastStep = step
+	return true, nil
+}
+
+func (s *twoFactorStore) ReplaceRecoveryCodes(_ context.Context, userID int64, codeHashes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+// replaceRecoveryCodes erwartet, dass der Mutex gehalten wird
+func (s *twoFactorStore) replaceRecoveryCodes(userID int64, codeHashes []string) {
+	kept := s.recoveryCodes[:0]
+	for _, rc := range s.recoveryCodes {
+		if rc.userID != userID {
+			kept = append(kept, rc)
+		}
+	}
+	for _, hash := range codeHashes {
+		kept = append(kept, recoveryCodeRow{userID: userID, codeHash: hash})
+	}
+	s.recoveryCodes = kept
+}
+
+func (s *twoFactorStore) UseRecoveryCode(_ context.Context, userID int64, codeHash string, _ time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.recoveryCodes {
+		rc := &s.recoveryCodes[i]
+		if rc.userID == userID && rc.codeHash == codeHash && !rc.used {
+			rc.used = true
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (s *twoFactorStore) RecoveryCodesLeft(_ context.Context, userID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, rc := range s.recoveryCodes {
+		if rc.userID == userID && !rc.used {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,339 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type userStore struct{ *data }
+
+func (s *userStore) Create(_ context.Context, username, email, passwordHash string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == username || u.Email == email {
+			return 0, store.ErrConflict
+		}
+	}
+	id := s.nextID()
+	s.users[id] = &userRow{
+		User: store.User{
+			ID:           id,
+			Username:     username,
+			Email:        email,
+			PasswordHash: passwordHash,
+			CreatedAt:    time.Now(),
+		},
+		profile: store.Profile{Birthday: "2000-01-01"},
+	}
+	return id, nil
+}
+
+func (s *userStore) ByID(_ context.Context, id int64) (*store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	user := u.User
+	return &user, nil
+}
+
+func (s *userStore) ByLogin(_ context.Context, login string) (*store.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == login || u.Email == login {
+			user := u.User
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (s *userStore) EmailExists(_ context.Context, email string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *userStore) UsernameExists(_ context.Context, username string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *userStore) SetPasswordHash(_ context.Context, id int64, hash string, changedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.PasswordHash = hash
+	u.PasswordChangedAt = changedAt
+	return nil
+}
+
+func (s *userStore) MarkEmailVerified(_ context.Context, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.EmailVerifiedAt = at
+	return nil
+}
+
+func (s *userStore) SetLoginFailures(_ context.Context, id int64, count int, lockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.FailedLogins = count
+	u.LockedUntil = lockedUntil
+	return nil
+}
+
+func (s *userStore) SetDisabled(_ context.Context, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.DisabledAt = at
+	return nil
+}
+
+func (s *userStore) SetDeleted(_ context.Context, id int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.DeletedAt = at
+	return nil
+}
+
+func (s *userStore) DeletedBefore(_ context.Context, before time.Time) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, u := range s.users {
+		if u.Deleted() && u.DeletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (s *userStore) List(_ context.Context, offset, limit int) ([]store.User, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.User
+	for _, u := range s.users {
+		list = append(list, u.User)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	total := len(list)
+	if offset > total {
+		offset = total
+	}
+	list = list[offset:]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, total, nil
+}
+
+func (s *userStore) Profile(_ context.Context, id int64) (*store.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	p := u.profile
+	return &p, nil
+}
+
+func (s *userStore) SaveProfile(_ context.Context, id int64, p store.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	u.profile = p
+	u.SetupCompleted = true
+	return nil
+}
+
+func (s *userStore) Delete(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, id)
+	delete(s.roles, id)
+	delete(s.dataExports, id)
+	delete(s.twoFactor, id)
+	for iid, i := range s.identities {
+		if i.UserID == id {
+			delete(s.identities, iid)
+		}
+	}
+	for tid, t := range s.personalTokens {
+		if t.UserID == id {
+			delete(s.personalTokens, tid)
+		}
+	}
+	for tid, t := range s.refreshTokens {
+		if t.UserID == id {
+			delete(s.refreshTokens, tid)
+		}
+	}
+	codes := s.recoveryCodes[:0]
+	for _, rc := range s.recoveryCodes {
+		if rc.userID != id {
+			codes = append(codes, rc)
+		}
+	}
+	s.recoveryCodes = codes
+	tokens := s.emailTokens[:0]
+	for _, t := range s.emailTokens {
+		if t.UserID != id {
+			tokens = append(tokens, t)
+		}
+	}
+	s.emailTokens = tokens
+	attempts := s.loginAttempts[:0]
+	for _, a := range s.loginAttempts {
+		if a.UserID != id {
+			attempts = append(attempts, a)
+		}
+	}
+	s.loginAttempts = attempts
+	events := s.auditEvents[:0]
+	for _, e := range s.auditEvents {
+		if e.UserID != id {
+			events = append(events, e)
+		}
+	}
+	s.auditEvents = events
+	consents := s.consents[:0]
+	for _, c := range s.consents {
+		if c.UserID != id {
+			consents = append(consents, c)
+		}
+	}
+	s.consents = consents
+	jobs := s.generationJobs[:0]
+	for _, j := range s.generationJobs {
+		if j.UserID != id {
+			jobs = append(jobs, j)
+		}
+	}
+	s.generationJobs = jobs
+	for tid, t := range s.rememberTokens {
+		if t.UserID == id {
+			delete(s.rememberTokens, tid)
+		}
+	}
+	// ON DELETE CASCADE bzw. SET NULL nachbilden
+	clients := map[int64]bool{}
+	for lid, l := range s.coachLinks {
+		if l.CoachID == id || l.ClientID == id {
+			delete(s.coachLinks, lid)
+		}
+		if l.CoachID == id {
+			clients[l.ClientID] = true
+		}
+	}
+	// Klienten ohne weiteren aktiven Coach warten nicht länger auf Freigaben
+	for _, l := range s.coachLinks {
+		if clients[l.ClientID] && s.coachActive(l) {
+			delete(clients, l.ClientID)
+		}
+	}
+	for key, a := range s.approvals {
+		if key.userID == id || (clients[key.userID] && a.Pending()) {
+			delete(s.approvals, key)
+		} else if a.ApprovedBy == id {
+			a.ApprovedBy = 0
+		}
+	}
+	for sid, w := range s.workoutSessions {
+		if w.UserID == id {
+			delete(s.workoutSessions, sid)
+		}
+	}
+	s.deleteRecords(func(r store.PersonalRecord) bool { return r.UserID == id })
+	removed := map[int64]bool{}
+	kept := s.schedule[:0]
+	for _, st := range s.schedule {
+		if st.UserID != id {
+			kept = append(kept, st)
+		} else {
+			removed[st.ScheduleID] = true
+		}
+	}
+	s.schedule = kept
+	s.deleteComments(removed)
+	comments := s.comments[:0]
+	for _, cm := range s.comments {
+		if cm.AuthorID != id {
+			comments = append(comments, cm)
+		}
+	}
+	s.comments = comments
+	for tid, t := range s.tasks {
+		if t.CreatedBy == id {
+			delete(s.tasks, tid)
+		}
+	}
+	for rid, r := range s.recipes {
+		if r.UserID == id {
+			delete(s.recipes, rid)
+		}
+	}
+	for eid, e := range s.exercises {
+		if e.UserID == id {
+			delete(s.exercises, eid)
+		}
+	}
+	return nil
+}
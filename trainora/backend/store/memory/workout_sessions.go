@@ -0,0 +1,242 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"trainora/store"
+)
+
+type workoutSessionStore struct{ *data }
+
+// cloneSession kopiert eine Session samt Sätzen, damit Aufrufer den Zustand
+// nicht verändern
+func cloneSession(w *store.WorkoutSession) store.WorkoutSession {
+	cp := *w
+	cp.Sets = append([]store.WorkoutSet(nil), w.Sets...)
+	return cp
+}
+
+func (s *workoutSessionStore) Start(_ context.Context, w store.WorkoutSession) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, old := range s.workoutSessions {
+		if old.ScheduleID != 0 && old.ScheduleID == w.ScheduleID {
+			return 0, store.ErrConflict
+		}
+	}
+	w.ID = s.nextID()
+	w.FinishedAt = time.Time{}
+	w.Sets = nil
+	s.workoutSessions[w.ID] = &w
+	return w.ID, nil
+}
+
+func (s *workoutSessionStore) ByID(_ context.Context, userID, id int64) (*store.WorkoutSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.workoutSessions[id]
+	if !ok || w.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	cp := cloneSession(w)
+	return &cp, nil
+}
+
+func (s *workoutSessionStore) BySchedule(_ context.Context, userID, scheduleID int64) (*store.WorkoutSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.workoutSessions {
+		if w.UserID == userID && w.ScheduleID == scheduleID {
+			cp := cloneSession(w)
+			return &cp, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+// byUser liefert die Sessions des Benutzers, älteste zuerst
+func (s *workoutSessionStore) byUser(userID int64) []store.WorkoutSession {
+	var list []store.WorkoutSession
+	for _, w := range s.workoutSessions {
+		if w.UserID == userID {
+			list = append(list, cloneSession(w))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].StartedAt.Equal(list[j].StartedAt) {
+			return list[i].StartedAt.Before(list[j].StartedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func (s *workoutSessionStore) List(_ context.Context, userID int64, offset, limit int) ([]store.WorkoutSession, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := s.byUser(userID)
+	total := len(all)
+	var list []store.WorkoutSession
+	for i := total - 1 - offset; i >= 0 && len(list) < limit; i-- {
+		w := all[i]
+		w.Sets = nil
+		list = append(list, w)
+	}
+	return list, total, nil
+}
+
+func (s *workoutSessionStore) History(_ context.Context, userID int64) ([]store.WorkoutSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.byUser(userID), nil
+}
+
+func (s *workoutSessionStore) FinishedSince(_ context.Context, userID int64, since time.Time) ([]store.WorkoutSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list []store.WorkoutSession
+	for _, w := range s.byUser(userID) {
+		if w.Finished() && !w.FinishedAt.Before(since) {
+			list = append(list, w)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if !list[i].FinishedAt.Equal(list[j].FinishedAt) {
+			return list[i].FinishedAt.After(list[j].FinishedAt)
+		}
+		return list[i].ID > list[j].ID
+	})
+	return list, nil
+}
+
+// running liefert eine laufende Session; ErrConflict, wenn sie abgeschlossen ist
+func (s *workoutSessionStore) running(userID, id int64) (*store.WorkoutSession, error) {
+	w, ok := s.workoutSessions[id]
+	if !ok || w.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	if w.Finished() {
+		return nil, store.ErrConflict
+	}
+	return w, nil
+}
+
+func (s *workoutSessionStore) Update(_ context.Context, userID int64, w store.WorkoutSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.running(userID, w.ID)
+	if err != nil {
+		return err
+	}
+	old.Notes = w.Notes
+	old.Sets = make([]store.WorkoutSet, 0, len(w.Sets))
+	for _, set := range w.Sets {
+		set.ID = s.nextID()
+		old.Sets = append(old.Sets, set)
+	}
+	return nil
+}
+
+func (s *workoutSessionStore) Finish(_ context.Context, userID, id int64, at time.Time) ([]store.PersonalRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.running(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w.FinishedAt = at
+
+	best := map[store.RecordKey]float64{}
+	for _, r := range s.currentRecords(userID) {
+		best[store.RecordKey{ExerciseID: r.ExerciseID, Kind: r.Kind}] = r.Value
+	}
+	records := store.NewRecords(w, best, at)
+	for i := range records {
+		records[i].ID = s.nextID()
+		s.personalRecords = append(s.personalRecords, records[i])
+	}
+	return records, nil
+}
+
+// recordHistory liefert die Verbesserungen mit dem aktuellen Übungsnamen,
+// älteste zuerst
+func (s *workoutSessionStore) recordHistory(userID int64) []store.PersonalRecord {
+	var list []store.PersonalRecord
+	for _, r := range s.personalRecords {
+		if r.UserID == userID {
+			if e, ok := s.exercises[r.ExerciseID]; ok {
+				r.ExerciseName = e.Name
+			}
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// currentRecords bildet die Abfrage der neuesten Zeile je Übung und Art nach
+func (s *workoutSessionStore) currentRecords(userID int64) []store.PersonalRecord {
+	latest := map[store.RecordKey]store.PersonalRecord{}
+	for _, r := range s.recordHistory(userID) {
+		latest[store.RecordKey{ExerciseID: r.ExerciseID, Kind: r.Kind}] = r
+	}
+	list := make([]store.PersonalRecord, 0, len(latest))
+	for _, r := range latest {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.ExerciseName != b.ExerciseName {
+			return a.ExerciseName < b.ExerciseName
+		}
+		if a.ExerciseID != b.ExerciseID {
+			return a.ExerciseID < b.ExerciseID
+		}
+		return a.Kind < b.Kind
+	})
+	return list
+}
+
+func (s *workoutSessionStore) Records(_ context.Context, userID int64) ([]store.PersonalRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.currentRecords(userID), nil
+}
+
+func (s *workoutSessionStore) RecordHistory(_ context.Context, userID int64) ([]store.PersonalRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recordHistory(userID), nil
+}
+
+// unlinkSessions bildet ON DELETE SET NULL für gelöschte Einträge im
+// Wochenplan nach
+func (d *data) unlinkSessions(removed map[int64]bool) {
+	for _, w := range d.workoutSessions {
+		if removed[w.ScheduleID] {
+			w.ScheduleID = 0
+		}
+	}
+}
+
+// deleteRecords entfernt die Rekorde, für die drop true liefert
+func (d *data) deleteRecords(drop func(r store.PersonalRecord) bool) {
+	kept := d.personalRecords[:0]
+	for _, r := range d.personalRecords {
+		if !drop(r) {
+			kept = append(kept, r)
+		}
+	}
+	d.personalRecords = kept
+}
@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"trainora/store"
+)
+
+type personalTokenStore struct {
+	db *sql.DB
+}
+
+const personalTokenColumns = "id, user_id, name, token_hash, scopes, created_at, last_used_at, expires_at"
+
+func scanPersonalToken(row interface{ Scan(...interface{}) error }) (*store.PersonalToken, error) {
+	var t store.PersonalToken
+	var scopes string
+	var lastUsed, expires sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &scopes, &t.CreatedAt, &lastUsed, &expires)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	t.Scopes = strings.Fields(scopes)
+	t.LastUsedAt = lastUsed.Time
+	t.ExpiresAt = expires.Time
+	return &t, nil
+}
+
+func (s *personalTokenStore) Create(ctx context.Context, t store.PersonalToken) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.Name, t.TokenHash, strings.Join(t.Scopes, " "), t.CreatedAt.UTC(), nullTime(t.ExpiresAt))
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *personalTokenStore) ByHash(ctx context.Context, tokenHash string) (*store.PersonalToken, error) {
+	return scanPersonalToken(s.db.QueryRowContext(ctx,
+		"SELECT "+personalTokenColumns+" FROM personal_access_tokens WHERE token_hash = ?", tokenHash))
+}
+
+func (s *personalTokenStore) ListByUser(ctx context.Context, userID int64) ([]store.PersonalToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+personalTokenColumns+" FROM personal_access_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.PersonalToken
+	for rows.Next() {
+		t, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+func (s *personalTokenStore) Touch(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?", at.UTC(), id)
+	return err
+}
+
+func (s *personalTokenStore) Delete(ctx context.Context, userID, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+type refreshTokenStore struct {
+	db *sql.DB
+}
+
+const refreshTokenColumns = "id, user_id, token_hash, family, scopes, created_at, expires_at, used_at"
+
+func (s *refreshTokenStore) Create(ctx context.Context, t store.RefreshToken) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, token_hash, family, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.TokenHash, t.Family, strings.Join(t.Scopes, " "), t.CreatedAt.UTC(), t.ExpiresAt.UTC())
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *refreshTokenStore) ByHash(ctx context.Context, tokenHash string) (*store.RefreshToken, error) {
+	var t store.RefreshToken
+	var scopes string
+	var usedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		"SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = ?", tokenHash).
+		Scan(&t.ID, &t.UserID, &t.TokenHash, &t.Family, &scopes, &t.CreatedAt, &t.ExpiresAt, &usedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	t.Scopes = strings.Fields(scopes)
+	t.UsedAt = usedAt.Time
+	return &t, nil
+}
+
+// Use setzt used_at nur, wenn es noch leer ist. Zwei parallele Anfragen mit
+// demselben Token können so nicht beide erfolgreich sein.
+func (s *refreshTokenStore) Use(ctx context.Context, id int64, at time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL", at.UTC(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *refreshTokenStore) DeleteFamily(ctx context.Context, family string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE family = ?", family)
+	return err
+}
+
+func (s *refreshTokenStore) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = ?", userID)
+	return err
+}
+
+func (s *refreshTokenStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at <= ?", now.UTC())
+	return err
+}
@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"trainora/store"
+)
+
+type auditStore struct {
+	db *sql.DB
+}
+
+func (s *auditStore) Record(ctx context.Context, e store.AuditEvent) error {
+	var actorID, metadata interface{}
+	if e.ActorID != 0 {
+		actorID = e.ActorID
+	}
+	// metadata ist ein JSON-Objekt im TEXT-Feld
+	if len(e.Metadata) > 0 {
+		data, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(data)
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_events (user_id, actor_id, event_type, ip, user_agent, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.UserID, actorID, e.Type, e.IP, e.UserAgent, metadata, e.CreatedAt.UTC())
+	return err
+}
+
+func (s *auditStore) ByUser(ctx context.Context, userID int64, offset, limit int) ([]store.AuditEvent, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_events WHERE user_id = ?", userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, actor_id, event_type, ip, user_agent, metadata, created_at
+		FROM audit_events WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []store.AuditEvent
+	for rows.Next() {
+		var e store.AuditEvent
+		var actorID sql.NullInt64
+		var metadata sql.NullString
+		if err := rows.Scan(&e.ID, &e.UserID, &actorID, &e.Type, &e.IP, &e.UserAgent, &metadata, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		e.ActorID = actorID.Int64
+		if metadata.Valid {
+			if err := json.Unmarshal([]byte(metadata.String), &e.Metadata); err != nil {
+				return nil, 0, err
+			}
+		}
+		list = append(list, e)
+	}
+	return list, total, rows.Err()
+}
@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type coachStore struct {
+	db *sql.DB
+}
+
+func (s *coachStore) Invite(ctx context.Context, coachID, clientID int64, at time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO coach_clients (coach_id, client_id, status, created_at) VALUES (?, ?, ?, ?)",
+		coachID, clientID, store.CoachPending, at.UTC())
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *coachStore) Accept(ctx context.Context, coachID, clientID int64, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE coach_clients SET status = ?, accepted_at = ?
+		WHERE coach_id = ? AND client_id = ? AND status = ?`,
+		store.CoachActive, at.UTC(), coachID, clientID, store.CoachPending)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *coachStore) Delete(ctx context.Context, coachID, clientID int64) error {
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM coach_clients WHERE coach_id = ? AND client_id = ?", coachID, clientID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+const coachLinkQuery = `
+	SELECT cc.id, cc.coach_id, co.username, cc.client_id, cl.username, cc.status, cc.created_at, cc.accepted_at
+	FROM coach_clients cc
+	JOIN users co ON co.id = cc.coach_id
+	JOIN users cl ON cl.id = cc.client_id`
+
+func (s *coachStore) list(ctx context.Context, query string, arg int64) ([]store.CoachLink, error) {
+	rows, err := s.db.QueryContext(ctx, coachLinkQuery+query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.CoachLink
+	for rows.Next() {
+		var l store.CoachLink
+		var acceptedAt sql.NullTime
+		err := rows.Scan(&l.ID, &l.CoachID, &l.CoachName, &l.ClientID, &l.ClientName, &l.Status, &l.CreatedAt, &acceptedAt)
+		if err != nil {
+			return nil, err
+		}
+		l.AcceptedAt = acceptedAt.Time
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+func (s *coachStore) ListByCoach(ctx context.Context, coachID int64) ([]store.CoachLink, error) {
+	return s.list(ctx, " WHERE cc.coach_id = ? ORDER BY cl.username", coachID)
+}
+
+func (s *coachStore) ListByClient(ctx context.Context, clientID int64) ([]store.CoachLink, error) {
+	return s.list(ctx, " WHERE cc.client_id = ? ORDER BY co.username", clientID)
+}
+
+// activeClientsQuery liefert die Klienten aktiver Beziehungen zu Coaches,
+// die noch angemeldet sein dürfen und die Rolle haben
+const activeClientsQuery = `
+	SELECT cc.client_id FROM coach_clients cc
+	JOIN users co ON co.id = cc.coach_id AND co.disabled_at IS NULL AND co.deleted_at IS NULL
+	JOIN user_roles r ON r.user_id = cc.coach_id AND r.role = '` + store.RoleCoach + `'
+	WHERE cc.status = '` + store.CoachActive + `'`
+
+func (s *coachStore) IsActive(ctx context.Context, coachID, clientID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS("+activeClientsQuery+" AND cc.client_id = ? AND cc.coach_id = ?)",
+		clientID, coachID).Scan(&exists)
+	return exists, err
+}
+
+func (s *coachStore) HasActiveCoach(ctx context.Context, clientID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS("+activeClientsQuery+" AND cc.client_id = ?)", clientID).Scan(&exists)
+	return exists, err
+}
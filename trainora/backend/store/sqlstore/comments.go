@@ -0,0 +1,58 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"trainora/store"
+)
+
+type commentStore struct {
+	db *sql.DB
+}
+
+func (s *commentStore) Create(ctx context.Context, cm store.Comment) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO schedule_comments (schedule_id, author_id, body, created_at) VALUES (?, ?, ?, ?)",
+		cm.ScheduleID, cm.AuthorID, cm.Body, cm.CreatedAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+const commentQuery = `
+	SELECT c.id, c.schedule_id, c.author_id, u.username, c.body, c.created_at
+	FROM schedule_comments c
+	JOIN task_schedule ts ON ts.id = c.schedule_id
+	JOIN users u ON u.id = c.author_id`
+
+func (s *commentStore) Week(ctx context.Context, userID int64, weekStartDate string) ([]store.Comment, error) {
+	return s.list(ctx, commentQuery+`
+		WHERE ts.user_id = ? AND ts.week_start_date = ?
+		ORDER BY c.created_at, c.id`, userID, weekStartDate)
+}
+
+func (s *commentStore) History(ctx context.Context, userID int64) ([]store.Comment, error) {
+	return s.list(ctx, commentQuery+`
+		WHERE ts.user_id = ?
+		ORDER BY c.created_at, c.id`, userID)
+}
+
+func (s *commentStore) list(ctx context.Context, query string, args ...interface{}) ([]store.Comment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Comment
+	for rows.Next() {
+		var cm store.Comment
+		if err := rows.Scan(&cm.ID, &cm.ScheduleID, &cm.AuthorID, &cm.AuthorName, &cm.Body, &cm.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, cm)
+	}
+	return list, rows.Err()
+}
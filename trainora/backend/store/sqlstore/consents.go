@@ -0,0 +1,82 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type consentStore struct {
+	db *sql.DB
+}
+
+func (s *consentStore) Give(ctx context.Context, c store.Consent) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO user_consents (user_id, kind, version, ip, given_at) VALUES (?, ?, ?, ?, ?)",
+		c.UserID, c.Kind, c.Version, c.IP, c.GivenAt.UTC())
+	return err
+}
+
+func (s *consentStore) list(ctx context.Context, query string, userID int64) ([]store.Consent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, kind, version, ip, given_at, withdrawn_at
+		FROM user_consents WHERE user_id = ?`+query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Consent
+	for rows.Next() {
+		var c store.Consent
+		var withdrawnAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Kind, &c.Version, &c.IP, &c.GivenAt, &withdrawnAt); err != nil {
+			return nil, err
+		}
+		c.WithdrawnAt = withdrawnAt.Time
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (s *consentStore) Active(ctx context.Context, userID int64) ([]store.Consent, error) {
+	all, err := s.list(ctx, " AND withdrawn_at IS NULL ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	// Ein Widerruf trifft alle Einträge einer Art, gültig ist also der neueste
+	latest := map[string]int{}
+	var list []store.Consent
+	for _, c := range all {
+		if i, ok := latest[c.Kind]; ok {
+			list[i] = c
+			continue
+		}
+		latest[c.Kind] = len(list)
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func (s *consentStore) Withdraw(ctx context.Context, userID int64, kind string, at time.Time) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE user_consents SET withdrawn_at = ? WHERE user_id = ? AND kind = ? AND withdrawn_at IS NULL",
+		at.UTC(), userID, kind)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *consentStore) History(ctx context.Context, userID int64) ([]store.Consent, error) {
+	return s.list(ctx, " ORDER BY given_at, id", userID)
+}
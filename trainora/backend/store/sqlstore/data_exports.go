@@ -0,0 +1,81 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/crypto"
+	"trainora/store"
+)
+
+type dataExportStore struct {
+	db     *sql.DB
+	cipher *crypto.FieldCipher
+}
+
+func (s *dataExportStore) Start(ctx context.Context, userID int64, at time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM data_exports WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO data_exports (user_id, status, created_at) VALUES (?, ?, ?)",
+		userID, store.JobRunning, at.UTC())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *dataExportStore) ByUser(ctx context.Context, userID int64) (*store.DataExport, error) {
+	e := store.DataExport{UserID: userID}
+	var finishedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx,
+		"SELECT status, COALESCE(error, ''), created_at, finished_at FROM data_exports WHERE user_id = ?", userID).
+		Scan(&e.Status, &e.Error, &e.CreatedAt, &finishedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	e.FinishedAt = finishedAt.Time
+	return &e, nil
+}
+
+func (s *dataExportStore) Finish(ctx context.Context, userID int64, at time.Time, archive []byte, errMsg string) error {
+	if errMsg != "" {
+		_, err := s.db.ExecContext(ctx,
+			"UPDATE data_exports SET status = ?, error = ?, finished_at = ? WHERE user_id = ?",
+			store.JobFailed, errMsg, at.UTC(), userID)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE data_exports SET status = ?, archive_encrypted = ?, finished_at = ? WHERE user_id = ?",
+		store.JobSucceeded, s.cipher.Bind(userID, "archive_encrypted").String(string(archive)), at.UTC(), userID)
+	return err
+}
+
+func (s *dataExportStore) Archive(ctx context.Context, userID int64) ([]byte, error) {
+	archive := crypto.EncryptedString{Binding: s.cipher.Bind(userID, "archive_encrypted")}
+	err := s.db.QueryRowContext(ctx,
+		"SELECT archive_encrypted FROM data_exports WHERE user_id = ? AND status = ?", userID, store.JobSucceeded).
+		Scan(&archive)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return []byte(archive.String), nil
+}
+
+func (s *dataExportStore) Delete(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM data_exports WHERE user_id = ?", userID)
+	return err
+}
+
+func (s *dataExportStore) DeleteBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM data_exports WHERE created_at < ?", before.UTC())
+	return err
+}
@@ -0,0 +1,116 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
+
+	"trainora/crypto"
+	"trainora/migrations"
+)
+
+// Unterstützte Werte für DB_DRIVER
+const (
+	MySQL  = "mysql"
+	SQLite = "sqlite"
+)
+
+// Driver liefert den konfigurierten Datenbanktreiber (DB_DRIVER), Standard ist MySQL
+func Driver() string {
+	switch os.Getenv("DB_DRIVER") {
+	case SQLite:
+		return SQLite
+	case "", MySQL:
+		return MySQL
+	default:
+		log.Fatalf("❌ Unbekannter DB_DRIVER %q (erlaubt: mysql, sqlite)", os.Getenv("DB_DRIVER"))
+		return ""
+	}
+}
+
+// Open verbindet sich mit der Datenbank des Treibers. MySQL wird über die
+// MYSQL_*-Variablen konfiguriert und bis zu zehnmal versucht, bevor der Start
+// abgebrochen wird. Die Session-Zeitzone ist UTC, damit CURRENT_TIMESTAMP und
+// aus Go geschriebene Zeitpunkte vergleichbar sind. SQLite nutzt die Datei aus SQLITE_PATH (Standard
+// trainora.db, ":memory:" für flüchtige Test-Datenbanken).
+func Open(driver string) *sql.DB {
+	if driver == SQLite {
+		return openSQLite()
+	}
+
+	dsn := os.Getenv("MYSQL_USER") + ":" + os.Getenv("MYSQL_PASSWORD") +
+		"@tcp(" + os.Getenv("MYSQL_HOST") + ":" + os.Getenv("MYSQL_PORT") + ")/" +
+		os.Getenv("MYSQL_DB") + "?parseTime=true&time_zone=%27%2B00%3A00%27"
+
+	maxRetries := 10
+	for i := 0; i < maxRetries; i++ {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			log.Printf("Versuch %d: DB-Verbindung fehlgeschlagen: %v", i+1, err)
+		} else if err = db.Ping(); err != nil {
+			log.Printf("Versuch %d: DB nicht erreichbar: %v", i+1, err)
+			db.Close()
+		} else {
+			log.Println("✅ DB-Verbindung erfolgreich")
+			return db
+		}
+		log.Println("⏳ Warte 2 Sekunden bis zum nächsten Versuch...")
+		time.Sleep(2 * time.Second)
+	}
+
+	log.Fatal("❌ Datenbank konnte nach mehreren Versuchen nicht erreicht werden.")
+	return nil
+}
+
+func openSQLite() *sql.DB {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "trainora.db"
+	}
+
+	// Fremdschlüssel sind in SQLite pro Verbindung abgeschaltet und müssen
+	// explizit aktiviert werden. _time_format speichert Zeitpunkte sortierbar
+	// wie CURRENT_TIMESTAMP.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		log.Fatalf("❌ SQLite-Datenbank %s konnte nicht geöffnet werden: %v", path, err)
+	}
+
+	// SQLite erlaubt nur einen Schreiber; eine Verbindung vermeidet
+	// SQLITE_BUSY und hält ":memory:"-Datenbanken über alle Abfragen bestehen
+	db.SetMaxOpenConns(1)
+
+	log.Printf("✅ SQLite-Datenbank %s geöffnet", path)
+	return db
+}
+
+// Migrate bringt das Schema auf den neuesten Stand
+func Migrate(db *sql.DB, driver string, cipher *crypto.FieldCipher) error {
+	migrator, err := NewMigrator(db, driver, cipher)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up()
+	return err
+}
+
+// NewMigrator lädt die Migrationen und registriert die Datenmigrationen, die
+// den FieldCipher brauchen
+func NewMigrator(db *sql.DB, driver string, cipher *crypto.FieldCipher) (*migrations.Migrator, error) {
+	migrator, err := migrations.New(db, driver)
+	if err != nil {
+		return nil, err
+	}
+	migrator.Register(bindEncryptedColumnsVersion, func(db *sql.DB) error {
+		return reencryptLegacyColumns(db, cipher)
+	})
+	return migrator, nil
+}
@@ -0,0 +1,54 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type emailTokenStore struct {
+	db *sql.DB
+}
+
+func (s *emailTokenStore) Create(ctx context.Context, t store.EmailToken) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO email_tokens (user_id, purpose, nonce_hash, expires_at) VALUES (?, ?, ?, ?)",
+		t.UserID, t.Purpose, t.NonceHash, t.ExpiresAt.UTC())
+	return conflict(err)
+}
+
+func (s *emailTokenStore) Consume(ctx context.Context, nonceHash, purpose string, now time.Time) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Das bedingte UPDATE stellt sicher, dass ein Token nur einmal gilt,
+	// auch wenn zwei Anfragen gleichzeitig eintreffen
+	res, err := tx.ExecContext(ctx, `
+		UPDATE email_tokens SET used_at = ?
+		WHERE nonce_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?`,
+		now.UTC(), nonceHash, purpose, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return 0, store.ErrNotFound
+	}
+
+	var userID int64
+	err = tx.QueryRowContext(ctx, "SELECT user_id FROM email_tokens WHERE nonce_hash = ?", nonceHash).Scan(&userID)
+	if err != nil {
+		return 0, notFound(err)
+	}
+	return userID, tx.Commit()
+}
+
+func (s *emailTokenStore) DeleteByUser(ctx context.Context, userID int64, purpose string) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM email_tokens WHERE user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose)
+	return err
+}
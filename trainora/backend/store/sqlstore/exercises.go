@@ -0,0 +1,233 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"trainora/store"
+)
+
+type exerciseStore struct {
+	db *sql.DB
+}
+
+const exerciseColumns = `id, COALESCE(user_id, 0), COALESCE(slug, ''), name, COALESCE(muscle_groups, '[]'),
+	COALESCE(equipment, '[]'), difficulty, COALESCE(instructions, ''), COALESCE(contraindications, '[]'), created_at`
+
+func scanExercise(row interface{ Scan(...interface{}) error }) (*store.Exercise, error) {
+	var e store.Exercise
+	var muscleGroups, equipment, contraindications string
+	if err := row.Scan(&e.ID, &e.UserID, &e.Slug, &e.Name, &muscleGroups, &equipment,
+		&e.Difficulty, &e.Instructions, &contraindications, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+	// Listen sind JSON-Arrays im TEXT-Feld
+	for _, f := range []struct {
+		data string
+		v    *[]string
+	}{{muscleGroups, &e.MuscleGroups}, {equipment, &e.Equipment}, {contraindications, &e.Contraindications}} {
+		if err := json.Unmarshal([]byte(f.data), f.v); err != nil {
+			return nil, err
+		}
+	}
+	return &e, nil
+}
+
+// exerciseLists liefert die Listen einer Übung als JSON in Spaltenreihenfolge
+func exerciseLists(e store.Exercise) (muscleGroups, equipment, contraindications string, err error) {
+	var data [3][]byte
+	for i, list := range [][]string{e.MuscleGroups, e.Equipment, e.Contraindications} {
+		if list == nil {
+			list = []string{}
+		}
+		if data[i], err = json.Marshal(list); err != nil {
+			return "", "", "", err
+		}
+	}
+	return string(data[0]), string(data[1]), string(data[2]), nil
+}
+
+// likeEscaper maskiert Platzhalter für LIKE ... ESCAPE '!'
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (s *exerciseStore) Search(ctx context.Context, userID int64, f store.ExerciseFilter) ([]store.Exercise, int, error) {
+	where := "(user_id IS NULL OR user_id = ?)"
+	args := []interface{}{userID}
+	if f.OwnOnly {
+		where = "user_id = ?"
+	}
+	if f.Query != "" {
+		where += " AND name LIKE ? ESCAPE '!'"
+		args = append(args, "%"+likeEscaper.Replace(f.Query)+"%")
+	}
+	// Die Werte stehen als "wert" im JSON-Array
+	if f.MuscleGroup != "" {
+		where += " AND muscle_groups LIKE ? ESCAPE '!'"
+		args = append(args, `%"`+likeEscaper.Replace(f.MuscleGroup)+`"%`)
+	}
+	if f.Equipment != "" {
+		where += " AND equipment LIKE ? ESCAPE '!'"
+		args = append(args, `%"`+likeEscaper.Replace(f.Equipment)+`"%`)
+	}
+	if f.Difficulty != "" {
+		where += " AND difficulty = ?"
+		args = append(args, f.Difficulty)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM exercises WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+exerciseColumns+" FROM exercises WHERE "+where+" ORDER BY name, id LIMIT ? OFFSET ?",
+		append(args, f.Limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []store.Exercise
+	for rows.Next() {
+		e, err := scanExercise(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, *e)
+	}
+	return list, total, rows.Err()
+}
+
+func (s *exerciseStore) ByID(ctx context.Context, id int64) (*store.Exercise, error) {
+	e, err := scanExercise(s.db.QueryRowContext(ctx,
+		"SELECT "+exerciseColumns+" FROM exercises WHERE id = ?", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return e, nil
+}
+
+func (s *exerciseStore) ListByUser(ctx context.Context, userID int64) ([]store.Exercise, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+exerciseColumns+" FROM exercises WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Exercise
+	for rows.Next() {
+		e, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *e)
+	}
+	return list, rows.Err()
+}
+
+func (s *exerciseStore) Create(ctx context.Context, e store.Exercise) (int64, error) {
+	muscleGroups, equipment, contraindications, err := exerciseLists(e)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO exercises (user_id, name, muscle_groups, equipment, difficulty, instructions, contraindications)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.UserID, e.Name, muscleGroups, equipment, e.Difficulty, e.Instructions, contraindications)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *exerciseStore) Update(ctx context.Context, e store.Exercise) error {
+	muscleGroups, equipment, contraindications, err := exerciseLists(e)
+	if err != nil {
+		return err
+	}
+	// Existenz vorab prüfen: MySQL meldet unveränderte Zeilen nicht als betroffen
+	var id int64
+	err = s.db.QueryRowContext(ctx, "SELECT id FROM exercises WHERE id = ? AND user_id = ?", e.ID, e.UserID).Scan(&id)
+	if err != nil {
+		return notFound(err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE exercises SET name = ?, muscle_groups = ?, equipment = ?, difficulty = ?, instructions = ?, contraindications = ?
+		WHERE id = ? AND user_id = ?`,
+		e.Name, muscleGroups, equipment, e.Difficulty, e.Instructions, contraindications, e.ID, e.UserID)
+	return err
+}
+
+// Delete löst die Übung aus Trainings und Sätzen, dort bleibt nur ihr Name
+// stehen. Ihre Rekorde werden gelöscht.
+func (s *exerciseStore) Delete(ctx context.Context, userID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE workout_blocks SET exercise_id = NULL
+		WHERE exercise_id IN (SELECT id FROM exercises WHERE id = ? AND user_id = ?)`, id, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE workout_sets SET exercise_id = NULL
+		WHERE exercise_id IN (SELECT id FROM exercises WHERE id = ? AND user_id = ?)`, id, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM personal_records
+		WHERE exercise_id IN (SELECT id FROM exercises WHERE id = ? AND user_id = ?)`, id, userID)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM exercises WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+func (s *exerciseStore) SyncCatalog(ctx context.Context, list []store.Exercise) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, e := range list {
+		muscleGroups, equipment, contraindications, err := exerciseLists(e)
+		if err != nil {
+			return err
+		}
+		var id int64
+		err = tx.QueryRowContext(ctx, "SELECT id FROM exercises WHERE slug = ?", e.Slug).Scan(&id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO exercises (slug, name, muscle_groups, equipment, difficulty, instructions, contraindications)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+				e.Slug, e.Name, muscleGroups, equipment, e.Difficulty, e.Instructions, contraindications)
+		case err == nil:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE exercises SET name = ?, muscle_groups = ?, equipment = ?, difficulty = ?, instructions = ?, contraindications = ?
+				WHERE id = ?`,
+				e.Name, muscleGroups, equipment, e.Difficulty, e.Instructions, contraindications, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
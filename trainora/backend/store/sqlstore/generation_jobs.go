@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type generationJobStore struct {
+	db *sql.DB
+}
+
+func (s *generationJobStore) Start(ctx context.Context, j store.GenerationJob) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO generation_jobs (user_id, week_start_date, triggered_by, status, started_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		j.UserID, j.WeekStartDate, j.TriggeredBy, store.JobRunning, j.StartedAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *generationJobStore) Finish(ctx context.Context, id int64, at time.Time, errMsg string) error {
+	status, msg := store.JobSucceeded, interface{}(nil)
+	if errMsg != "" {
+		status, msg = store.JobFailed, errMsg
+	}
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE generation_jobs SET status = ?, error = ?, finished_at = ? WHERE id = ?",
+		status, msg, at.UTC(), id)
+	return err
+}
+
+func (s *generationJobStore) Failures(ctx context.Context, limit int) ([]store.GenerationJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, week_start_date, triggered_by, status, COALESCE(error, ''), started_at, finished_at
+		FROM generation_jobs WHERE status = ?
+		ORDER BY started_at DESC, id DESC LIMIT ?`, store.JobFailed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.GenerationJob
+	for rows.Next() {
+		var j store.GenerationJob
+		var week time.Time
+		var finishedAt sql.NullTime
+		err := rows.Scan(&j.ID, &j.UserID, &week, &j.TriggeredBy, &j.Status, &j.Error, &j.StartedAt, &finishedAt)
+		if err != nil {
+			return nil, err
+		}
+		j.WeekStartDate = week.Format("2006-01-02")
+		j.FinishedAt = finishedAt.Time
+		list = append(list, j)
+	}
+	return list, rows.Err()
+}
+
+func (s *generationJobStore) DeleteBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM generation_jobs WHERE started_at < ?", before.UTC())
+	return err
+}
@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type identityStore struct {
+	db *sql.DB
+}
+
+const identityColumns = "id, user_id, issuer, subject, email, created_at, last_login_at"
+
+func scanIdentity(row interface{ Scan(...interface{}) error }) (*store.Identity, error) {
+	var i store.Identity
+	var lastLogin sql.NullTime
+	err := row.Scan(&i.ID, &i.UserID, &i.Issuer, &i.Subject, &i.Email, &i.CreatedAt, &lastLogin)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	i.LastLoginAt = lastLogin.Time
+	return &i, nil
+}
+
+func (s *identityStore) Create(ctx context.Context, i store.Identity) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO identities (user_id, issuer, subject, email, created_at) VALUES (?, ?, ?, ?, ?)",
+		i.UserID, i.Issuer, i.Subject, i.Email, i.CreatedAt.UTC())
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *identityStore) BySubject(ctx context.Context, issuer, subject string) (*store.Identity, error) {
+	return scanIdentity(s.db.QueryRowContext(ctx,
+		"SELECT "+identityColumns+" FROM identities WHERE issuer = ? AND subject = ?", issuer, subject))
+}
+
+func (s *identityStore) ListByUser(ctx context.Context, userID int64) ([]store.Identity, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+identityColumns+" FROM identities WHERE user_id = ? ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Identity
+	for rows.Next() {
+		i, err := scanIdentity(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *i)
+	}
+	return list, rows.Err()
+}
+
+func (s *identityStore) Touch(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE identities SET last_login_at = ? WHERE id = ?", at.UTC(), id)
+	return err
+}
+
+func (s *identityStore) Delete(ctx context.Context, userID, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM identities WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type loginAttemptStore struct {
+	db *sql.DB
+}
+
+func (s *loginAttemptStore) Record(ctx context.Context, a store.LoginAttempt) error {
+	var userID interface{}
+	if a.UserID != 0 {
+		userID = a.UserID
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO login_attempts (user_id, login, ip, user_agent, success, attempted_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		userID, a.Login, a.IP, a.UserAgent, a.Success, a.AttemptedAt.UTC())
+	return err
+}
+
+func (s *loginAttemptStore) failures(ctx context.Context, where string, arg interface{}, since time.Time) (int, time.Time, error) {
+	const filter = " = ? AND success = ? AND attempted_at > ?"
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM login_attempts WHERE "+where+filter,
+		arg, false, since.UTC()).Scan(&count)
+	if err != nil || count == 0 {
+		return count, time.Time{}, err
+	}
+
+	// Eigene Abfrage statt MIN(), da SQLite bei Aggregaten den Spaltentyp
+	// verliert und keinen Zeitpunkt zurückgibt
+	var oldest time.Time
+	err = s.db.QueryRowContext(ctx,
+		"SELECT attempted_at FROM login_attempts WHERE "+where+filter+" ORDER BY attempted_at LIMIT 1",
+		arg, false, since.UTC()).Scan(&oldest)
+	return count, oldest, err
+}
+
+func (s *loginAttemptStore) FailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error) {
+	return s.failures(ctx, "ip", ip, since)
+}
+
+func (s *loginAttemptStore) FailuresByUser(ctx context.Context, userID int64, since time.Time) (int, time.Time, error) {
+	return s.failures(ctx, "user_id", userID, since)
+}
+
+func (s *loginAttemptStore) DeleteBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE attempted_at < ?", before.UTC())
+	return err
+}
@@ -0,0 +1,317 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+// dayPeriodOrder sortiert nach Tageszeit. Ersetzt MySQLs FIELD(), das es in
+// SQLite nicht gibt.
+const dayPeriodOrder = `CASE ts.day_period
+	WHEN 'morning' THEN 0 WHEN 'noon' THEN 1 WHEN 'afternoon' THEN 2
+	WHEN 'evening' THEN 3 ELSE 4 END`
+
+type planStore struct {
+	db *sql.DB
+}
+
+func (s *planStore) Week(ctx context.Context, userID int64, weekStartDate string) ([]store.ScheduledTask, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ts.id, ts.weekday, ts.day_period, COALESCE(ts.feedback, ''), ts.feedback_option,
+		       t.id, t.title, COALESCE(t.description, ''), COALESCE(t.estimated_duration_minutes, 0)
+		FROM task_schedule ts
+		JOIN tasks t ON ts.task_id = t.id
+		WHERE ts.user_id = ? AND ts.week_start_date = ?
+		ORDER BY ts.weekday ASC, `+dayPeriodOrder+`
+	`, userID, weekStartDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.ScheduledTask
+	for rows.Next() {
+		st := store.ScheduledTask{UserID: userID, WeekStartDate: weekStartDate}
+		st.Task.CreatedBy = userID
+		err := rows.Scan(&st.ScheduleID, &st.Weekday, &st.DayPeriod, &st.Feedback, &st.FeedbackOption,
+			&st.Task.ID, &st.Task.Title, &st.Task.Description, &st.Task.Duration)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	err = attachWorkouts(ctx, s.db, list,
+		"SELECT task_id FROM task_schedule WHERE user_id = ? AND week_start_date = ?", userID, weekStartDate)
+	return list, err
+}
+
+func (s *planStore) History(ctx context.Context, userID int64) ([]store.ScheduledTask, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ts.id, ts.week_start_date, ts.weekday, ts.day_period, COALESCE(ts.feedback, ''), ts.feedback_option,
+		       t.id, t.title, COALESCE(t.description, ''), COALESCE(t.estimated_duration_minutes, 0)
+		FROM task_schedule ts
+		JOIN tasks t ON ts.task_id = t.id
+		WHERE ts.user_id = ?
+		ORDER BY ts.week_start_date ASC, ts.weekday ASC, `+dayPeriodOrder+`
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.ScheduledTask
+	for rows.Next() {
+		st := store.ScheduledTask{UserID: userID}
+		st.Task.CreatedBy = userID
+		var week time.Time
+		err := rows.Scan(&st.ScheduleID, &week, &st.Weekday, &st.DayPeriod, &st.Feedback, &st.FeedbackOption,
+			&st.Task.ID, &st.Task.Title, &st.Task.Description, &st.Task.Duration)
+		if err != nil {
+			return nil, err
+		}
+		st.WeekStartDate = week.Format("2006-01-02")
+		list = append(list, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	err = attachWorkouts(ctx, s.db, list, "SELECT task_id FROM task_schedule WHERE user_id = ?", userID)
+	return list, err
+}
+
+func (s *planStore) Count(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task_schedule WHERE user_id = ?", userID).Scan(&count)
+	return count, err
+}
+
+func (s *planStore) HasWeek(ctx context.Context, userID int64, weekStartDate string) (bool, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM task_schedule WHERE user_id = ? AND week_start_date = ?`,
+		userID, weekStartDate).Scan(&count)
+	return count > 0, err
+}
+
+func (s *planStore) SaveWeek(ctx context.Context, userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time) error {
+	return s.saveWeek(ctx, userID, weekStartDate, tasks, approvalAt, false)
+}
+
+func (s *planStore) ReplaceWeek(ctx context.Context, userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time) error {
+	return s.saveWeek(ctx, userID, weekStartDate, tasks, approvalAt, true)
+}
+
+func (s *planStore) saveWeek(ctx context.Context, userID int64, weekStartDate string, tasks []store.ScheduledTask, approvalAt time.Time, replace bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		// Jede Aufgabe gehört zu genau einer Einplanung, beide werden entfernt
+		_, err := tx.ExecContext(ctx, `
+			DELETE FROM workout_blocks WHERE task_id IN (
+				SELECT task_id FROM task_schedule WHERE user_id = ? AND week_start_date = ?
+			)`, userID, weekStartDate)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM tasks WHERE id IN (
+				SELECT task_id FROM task_schedule WHERE user_id = ? AND week_start_date = ?
+			)`, userID, weekStartDate)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM task_schedule WHERE user_id = ? AND week_start_date = ?", userID, weekStartDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, st := range tasks {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO tasks (title, description, estimated_duration_minutes, created_by) VALUES (?, ?, ?, ?)`,
+			st.Task.Title, st.Task.Description, st.Task.Duration, userID)
+		if err != nil {
+			return err
+		}
+		taskID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO task_schedule (user_id, task_id, weekday, day_period, week_start_date, feedback_option)
+			VALUES (?, ?, ?, ?, ?, 'none')
+		`, userID, taskID, st.Weekday, st.DayPeriod, weekStartDate)
+		if err != nil {
+			return err
+		}
+		if err := saveWorkout(ctx, tx, taskID, st.Task.Workout); err != nil {
+			return err
+		}
+	}
+
+	if !approvalAt.IsZero() {
+		if err := requestApproval(ctx, tx, userID, weekStartDate, approvalAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetFeedback prüft den Eintrag vorab, weil MySQL bei unverändertem
+// Feedback null geänderte Zeilen meldet
+func (s *planStore) SetFeedback(ctx context.Context, userID, scheduleID int64, option, feedback string) error {
+	var id int64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id FROM task_schedule WHERE id = ? AND user_id = ?", scheduleID, userID).Scan(&id)
+	if err != nil {
+		return notFound(err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE task_schedule SET feedback_option = ?, feedback = ? WHERE id = ?", option, feedback, id)
+	return err
+}
+
+func (s *planStore) Entry(ctx context.Context, userID, scheduleID int64) (*store.ScheduledTask, error) {
+	e := store.ScheduledTask{ScheduleID: scheduleID, UserID: userID}
+	e.Task.CreatedBy = userID
+	var week time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ts.weekday, ts.day_period, ts.week_start_date, COALESCE(ts.feedback, ''), ts.feedback_option,
+		       t.id, t.title, COALESCE(t.description, ''), COALESCE(t.estimated_duration_minutes, 0)
+		FROM task_schedule ts
+		JOIN tasks t ON ts.task_id = t.id
+		WHERE ts.id = ? AND ts.user_id = ?`, scheduleID, userID).
+		Scan(&e.Weekday, &e.DayPeriod, &week, &e.Feedback, &e.FeedbackOption,
+			&e.Task.ID, &e.Task.Title, &e.Task.Description, &e.Task.Duration)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	e.WeekStartDate = week.Format("2006-01-02")
+
+	list := []store.ScheduledTask{e}
+	if err := attachWorkouts(ctx, s.db, list, "?", e.Task.ID); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func (s *planStore) UpdateEntry(ctx context.Context, userID int64, entry store.ScheduledTask) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taskID int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT task_id FROM task_schedule WHERE id = ? AND user_id = ?", entry.ScheduleID, userID).Scan(&taskID)
+	if err != nil {
+		return notFound(err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE tasks SET title = ?, description = ?, estimated_duration_minutes = ? WHERE id = ?",
+		entry.Task.Title, entry.Task.Description, entry.Task.Duration, taskID)
+	if err != nil {
+		return err
+	}
+	if err := saveWorkout(ctx, tx, taskID, entry.Task.Workout); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE task_schedule SET weekday = ?, day_period = ? WHERE id = ?",
+		entry.Weekday, entry.DayPeriod, entry.ScheduleID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *planStore) DeleteEntry(ctx context.Context, userID, scheduleID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taskID int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT task_id FROM task_schedule WHERE id = ? AND user_id = ?", scheduleID, userID).Scan(&taskID)
+	if err != nil {
+		return notFound(err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_schedule WHERE id = ?", scheduleID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM workout_blocks WHERE task_id = ?", taskID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", taskID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// requestApproval setzt eine bereits freigegebene Woche wieder zurück, etwa
+// wenn der Plan neu generiert wird
+func requestApproval(ctx context.Context, tx *sql.Tx, userID int64, weekStartDate string, at time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		"DELETE FROM plan_approvals WHERE user_id = ? AND week_start_date = ?", userID, weekStartDate)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO plan_approvals (user_id, week_start_date, requested_at) VALUES (?, ?, ?)",
+		userID, weekStartDate, at.UTC())
+	return err
+}
+
+func (s *planStore) Approval(ctx context.Context, userID int64, weekStartDate string) (*store.PlanApproval, error) {
+	a := store.PlanApproval{UserID: userID, WeekStartDate: weekStartDate}
+	var approvedBy sql.NullInt64
+	var approvedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `
+		SELECT requested_at, approved_by, approved_at FROM plan_approvals
+		WHERE user_id = ? AND week_start_date = ?`, userID, weekStartDate).
+		Scan(&a.RequestedAt, &approvedBy, &approvedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	a.ApprovedBy = approvedBy.Int64
+	a.ApprovedAt = approvedAt.Time
+	return &a, nil
+}
+
+func (s *planStore) Approve(ctx context.Context, userID int64, weekStartDate string, coachID int64, at time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE plan_approvals SET approved_by = ?, approved_at = ?
+		WHERE user_id = ? AND week_start_date = ? AND approved_at IS NULL`,
+		coachID, at.UTC(), userID, weekStartDate)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *planStore) ReleaseApprovals(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM plan_approvals WHERE user_id = ? AND approved_at IS NULL", userID)
+	return err
+}
@@ -0,0 +1,57 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"trainora/store"
+)
+
+type recipeStore struct {
+	db *sql.DB
+}
+
+func (s *recipeStore) ListByUser(ctx context.Context, userID int64) ([]store.Recipe, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, title, COALESCE(ingredients, '[]'), COALESCE(instructions, ''), created_at
+		FROM recipes WHERE user_id = ? ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Recipe
+	for rows.Next() {
+		var r store.Recipe
+		var ingredients string
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Title, &ingredients, &r.Instructions, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		// ingredients ist ein JSON-Array im TEXT-Feld
+		if err := json.Unmarshal([]byte(ingredients), &r.Ingredients); err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
+
+func (s *recipeStore) Create(ctx context.Context, r store.Recipe) (int64, error) {
+	ingredients, err := json.Marshal(r.Ingredients)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO recipes (user_id, title, ingredients, instructions) VALUES (?, ?, ?, ?)",
+		r.UserID, r.Title, string(ingredients), r.Instructions)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *recipeStore) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM recipes WHERE user_id = ?", userID)
+	return err
+}
@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"trainora/crypto"
+)
+
+// encryptedUserColumns listet alle verschlüsselten Spalten der users-Tabelle
+var encryptedUserColumns = []string{
+	"birthday_encrypted",
+	"height_cm_encrypted",
+	"weight_kg_encrypted",
+	"activity_level_encrypted",
+	"goal_encrypted",
+	"allergies_encrypted",
+}
+
+// bindEncryptedColumnsVersion ist die Migration, in der reencryptLegacyColumns
+// einmalig läuft
+const bindEncryptedColumnsVersion = 21
+
+// reencryptLegacyColumns verschlüsselt alle Altwerte der users-Tabelle, die
+// noch ohne Associated Data gespeichert sind, neu und bindet sie dabei an
+// user_id und Spaltenname. Sie läuft nur einmal als Migration: Danach lehnt
+// FieldCipher.Decrypt ungebundene Werte ab, ein in eine andere Zeile
+// kopierter Altwert wird also nicht nachträglich an diese gebunden.
+//
+// Werte, die sich nicht entschlüsseln lassen, bleiben unverändert und werden
+// protokolliert. Schlägt jeder Altwert fehl, ist vermutlich SECRET_KEY
+// falsch; dann bricht die Migration ab, damit sie mit dem richtigen
+// Schlüssel erneut läuft.
+func reencryptLegacyColumns(db *sql.DB, cipher *crypto.FieldCipher) error {
+	query := "SELECT id, " + strings.Join(encryptedUserColumns, ", ") + " FROM users"
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		userID int64
+		values []sql.NullString
+	}
+	var users []pending
+
+	for rows.Next() {
+		p := pending{values: make([]sql.NullString, len(encryptedUserColumns))}
+		dest := []interface{}{&p.userID}
+		for i := range p.values {
+			dest = append(dest, &p.values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		users = append(users, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	migrated, failed := 0, 0
+	for _, u := range users {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		changed := false
+		for i, column := range encryptedUserColumns {
+			v := u.values[i]
+			if !v.Valid || v.String == "" || !crypto.IsLegacy(v.String) {
+				continue
+			}
+			plain, err := cipher.DecryptLegacy(v.String)
+			if err != nil {
+				log.Printf("⚠️ Altwert von Benutzer %d in %s nicht lesbar, bleibt ungebunden: %v", u.userID, column, err)
+				failed++
+				continue
+			}
+			enc := cipher.Bind(u.userID, column).String(plain)
+			// column stammt aus encryptedUserColumns, nicht aus Benutzereingaben
+			if _, err := tx.Exec("UPDATE users SET "+column+" = ? WHERE id = ?", enc, u.userID); err != nil {
+				tx.Rollback()
+				return err
+			}
+			changed = true
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		if changed {
+			migrated++
+		}
+	}
+
+	if failed > 0 && migrated == 0 {
+		return fmt.Errorf("kein Altwert lesbar (%d Fehler), SECRET_KEY prüfen", failed)
+	}
+	if migrated > 0 {
+		log.Printf("🔐 %d Benutzer auf spaltengebundene Verschlüsselung migriert", migrated)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package sqlstore_test
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"trainora/crypto"
+	"trainora/store/sqlstore"
+)
+
+var testKey = strings.Repeat("ab", 32)
+
+// legacyEncrypt verschlüsselt wie vor der Spaltenbindung ohne Associated Data
+func legacyEncrypt(t *testing.T, plain string) string {
+	t.Helper()
+	key, _ := hex.DecodeString(testKey)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), nil))
+}
+
+func setGoal(t *testing.T, db *sql.DB, userID int64, enc string) {
+	t.Helper()
+	if _, err := db.Exec("UPDATE users SET goal_encrypted = ? WHERE id = ?", enc, userID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReencryptLegacyColumnsRunsOnce(t *testing.T) {
+	fieldCipher, err := crypto.NewFieldCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SQLITE_PATH", ":memory:")
+	db := sqlstore.Open(sqlstore.SQLite)
+	defer db.Close()
+	migrator, err := sqlstore.NewMigrator(db, sqlstore.SQLite, fieldCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	st := sqlstore.New(db, fieldCipher)
+	ctx := context.Background()
+
+	anna, err := st.Users.Create(ctx, "anna", "anna@example.org", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := st.Users.Create(ctx, "bob", "bob@example.org", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stand vor der Migration: ein lesbarer und ein beschädigter Altwert
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	setGoal(t, db, anna, legacyEncrypt(t, "Marathon"))
+	setGoal(t, db, bob, "kaputt")
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up mit beschädigtem Altwert: %v", err)
+	}
+
+	profile, err := st.Users.Profile(ctx, anna)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Goal != "Marathon" {
+		t.Errorf("Goal = %q, want Marathon", profile.Goal)
+	}
+	if _, err := st.Users.Profile(ctx, bob); err == nil {
+		t.Error("beschädigter Altwert wurde gelesen")
+	}
+
+	// Nach der Migration wird ein später eingeschleuster Altwert weder
+	// gebunden noch gelesen
+	setGoal(t, db, bob, legacyEncrypt(t, "fremd"))
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlstore.Migrate(db, sqlstore.SQLite, fieldCipher); err != nil {
+		t.Fatal(err)
+	}
+	var enc string
+	if err := db.QueryRow("SELECT goal_encrypted FROM users WHERE id = ?", bob).Scan(&enc); err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.IsLegacy(enc) {
+		t.Errorf("Altwert nach abgeschlossener Migration gebunden: %q", enc)
+	}
+	if _, err := st.Users.Profile(ctx, bob); err == nil {
+		t.Error("Altwert nach abgeschlossener Migration gelesen")
+	}
+}
+
+func TestReencryptLegacyColumnsWrongKey(t *testing.T) {
+	fieldCipher, err := crypto.NewFieldCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SQLITE_PATH", ":memory:")
+	db := sqlstore.Open(sqlstore.SQLite)
+	defer db.Close()
+	if err := sqlstore.Migrate(db, sqlstore.SQLite, fieldCipher); err != nil {
+		t.Fatal(err)
+	}
+	anna, err := sqlstore.New(db, fieldCipher).Users.Create(context.Background(), "anna", "anna@example.org", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := sqlstore.NewMigrator(db, sqlstore.SQLite, fieldCipher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	setGoal(t, db, anna, legacyEncrypt(t, "Marathon"))
+
+	// Mit falschem Schlüssel gilt die Migration nicht als angewendet
+	otherCipher, err := crypto.NewFieldCipher(strings.Repeat("cd", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sqlstore.Migrate(db, sqlstore.SQLite, otherCipher); err == nil {
+		t.Fatal("Migration mit falschem Schlüssel erfolgreich")
+	}
+	if err := sqlstore.Migrate(db, sqlstore.SQLite, fieldCipher); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := sqlstore.New(db, fieldCipher).Users.Profile(context.Background(), anna)
+	if err != nil || profile.Goal != "Marathon" {
+		t.Errorf("Profile = %+v, %v", profile, err)
+	}
+}
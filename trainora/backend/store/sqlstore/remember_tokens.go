@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type rememberTokenStore struct {
+	db *sql.DB
+}
+
+const rememberTokenColumns = "id, user_id, selector, validator_hash, previous_validator_hash, device, created_at, last_used_at, expires_at, rotated_at"
+
+func scanRememberToken(row interface{ Scan(...interface{}) error }) (*store.RememberToken, error) {
+	var t store.RememberToken
+	var rotatedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.UserID, &t.Selector, &t.ValidatorHash, &t.PreviousValidatorHash,
+		&t.Device, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt, &rotatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	t.RotatedAt = rotatedAt.Time
+	return &t, nil
+}
+
+func (s *rememberTokenStore) Create(ctx context.Context, t store.RememberToken) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO remember_tokens (user_id, selector, validator_hash, device, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.Selector, t.ValidatorHash, t.Device, t.CreatedAt.UTC(), t.LastUsedAt.UTC(), t.ExpiresAt.UTC())
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *rememberTokenStore) BySelector(ctx context.Context, selector string) (*store.RememberToken, error) {
+	return scanRememberToken(s.db.QueryRowContext(ctx,
+		"SELECT "+rememberTokenColumns+" FROM remember_tokens WHERE selector = ?", selector))
+}
+
+func (s *rememberTokenStore) ByID(ctx context.Context, userID, id int64) (*store.RememberToken, error) {
+	return scanRememberToken(s.db.QueryRowContext(ctx,
+		"SELECT "+rememberTokenColumns+" FROM remember_tokens WHERE id = ? AND user_id = ?", id, userID))
+}
+
+func (s *rememberTokenStore) Rotate(ctx context.Context, id int64, oldHash, newHash string, lastUsed, expiresAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE remember_tokens
+		SET validator_hash = ?, previous_validator_hash = ?, rotated_at = ?, last_used_at = ?, expires_at = ?
+		WHERE id = ? AND validator_hash = ?`,
+		newHash, oldHash, lastUsed.UTC(), lastUsed.UTC(), expiresAt.UTC(), id, oldHash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *rememberTokenStore) ListByUser(ctx context.Context, userID int64, now time.Time) ([]store.RememberToken, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+rememberTokenColumns+" FROM remember_tokens WHERE user_id = ? AND expires_at > ? ORDER BY last_used_at DESC",
+		userID, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.RememberToken
+	for rows.Next() {
+		t, err := scanRememberToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *t)
+	}
+	return list, rows.Err()
+}
+
+func (s *rememberTokenStore) Delete(ctx context.Context, userID, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM remember_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *rememberTokenStore) DeleteByUser(ctx context.Context, userID int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM remember_tokens WHERE user_id = ?", userID)
+	return err
+}
+
+func (s *rememberTokenStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM remember_tokens WHERE expires_at <= ?", now.UTC())
+	return err
+}
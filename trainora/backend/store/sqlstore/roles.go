@@ -0,0 +1,51 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"trainora/store"
+)
+
+type roleStore struct {
+	db *sql.DB
+}
+
+func (s *roleStore) ByUser(ctx context.Context, userID int64) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT role FROM user_roles WHERE user_id = ? ORDER BY role", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (s *roleStore) Set(ctx context.Context, userID int64, roles []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role == store.RoleUser {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) VALUES (?, ?)", userID, role); err != nil {
+			return conflict(err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+// SessionStorage speichert Fiber-Sessions in der Tabelle sessions und
+// implementiert fiber.Storage. Abgelaufene Einträge werden beim Lesen
+// ignoriert und regelmäßig gelöscht.
+type SessionStorage struct {
+	db   *sql.DB
+	done chan struct{}
+}
+
+// NewSessionStorage erzeugt den Speicher und startet die Bereinigung
+func NewSessionStorage(db *sql.DB, gcInterval time.Duration) *SessionStorage {
+	s := &SessionStorage{db: db, done: make(chan struct{})}
+	go s.gc(gcInterval)
+	return s
+}
+
+func (s *SessionStorage) gc(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			_, err := s.db.Exec("DELETE FROM sessions WHERE expires_at != 0 AND expires_at <= ?", now.Unix())
+			if err != nil {
+				log.Printf("❌ Abgelaufene Sessions konnten nicht gelöscht werden: %v", err)
+			}
+		}
+	}
+}
+
+// Get liefert die Session-Daten oder nil, wenn die Session nicht existiert
+func (s *SessionStorage) Get(key string) ([]byte, error) {
+	if key == "" {
+		return nil, nil
+	}
+	var data []byte
+	var expiresAt int64
+	err := s.db.QueryRow("SELECT data, expires_at FROM sessions WHERE id = ?", key).Scan(&data, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != 0 && expiresAt <= time.Now().Unix() {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// Set speichert die Session-Daten; exp = 0 bedeutet kein Ablauf
+func (s *SessionStorage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+	var expiresAt int64
+	if exp != 0 {
+		expiresAt = time.Now().Add(exp).Unix()
+	}
+
+	// DELETE + INSERT statt Upsert, da sich die Syntax zwischen MySQL und
+	// SQLite unterscheidet
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM sessions WHERE id = ?", key); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO sessions (id, data, expires_at) VALUES (?, ?, ?)", key, val, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete entfernt eine Session
+func (s *SessionStorage) Delete(key string) error {
+	if key == "" {
+		return nil
+	}
+	_, err := s.db.Exec("DELETE FROM sessions WHERE id = ?", key)
+	return err
+}
+
+// Reset löscht alle Sessions
+func (s *SessionStorage) Reset() error {
+	_, err := s.db.Exec("DELETE FROM sessions")
+	return err
+}
+
+// Close beendet die Bereinigung; die Datenbankverbindung bleibt offen
+func (s *SessionStorage) Close() error {
+	close(s.done)
+	return nil
+}
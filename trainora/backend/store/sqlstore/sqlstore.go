@@ -0,0 +1,72 @@
+// Package sqlstore implementiert die Interfaces aus store für MySQL und
+// SQLite. Abfragen sind so formuliert, dass sie auf beiden Datenbanken laufen.
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"trainora/crypto"
+	"trainora/store"
+)
+
+// New erzeugt alle Stores auf Basis einer offenen Datenbankverbindung. Der
+// FieldCipher ver- und entschlüsselt die Profilspalten der users-Tabelle.
+func New(db *sql.DB, cipher *crypto.FieldCipher) *store.Store {
+	return &store.Store{
+		Users:          &userStore{db: db, cipher: cipher},
+		Roles:          &roleStore{db: db},
+		RememberTokens: &rememberTokenStore{db: db},
+		LoginAttempts:  &loginAttemptStore{db: db},
+		AuditEvents:    &auditStore{db: db},
+		Consents:       &consentStore{db: db},
+		EmailTokens:    &emailTokenStore{db: db},
+		TwoFactor:      &twoFactorStore{db: db, cipher: cipher},
+		Identities:     &identityStore{db: db},
+		PersonalTokens: &personalTokenStore{db: db},
+		RefreshTokens:  &refreshTokenStore{db: db},
+		Coaches:        &coachStore{db: db},
+		Plans:          &planStore{db: db},
+		Comments:       &commentStore{db: db},
+		GenerationJobs: &generationJobStore{db: db},
+		DataExports:    &dataExportStore{db: db, cipher: cipher},
+		Tasks:          &taskStore{db: db},
+		Recipes:        &recipeStore{db: db},
+		Exercises:      &exerciseStore{db: db},
+		Workouts:       &workoutSessionStore{db: db},
+	}
+}
+
+// notFound übersetzt sql.ErrNoRows in store.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// conflict übersetzt Verletzungen eindeutiger Schlüssel in store.ErrConflict
+func conflict(err error) error {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == 1062 {
+		return store.ErrConflict
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) && liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return store.ErrConflict
+	}
+	return err
+}
+
+// nullTime speichert den Nullwert von time.Time als NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
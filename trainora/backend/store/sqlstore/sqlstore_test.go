@@ -0,0 +1,26 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"trainora/crypto"
+	"trainora/store"
+	"trainora/store/sqlstore"
+	"trainora/store/storetest"
+)
+
+func TestSQLite(t *testing.T) {
+	cipher, err := crypto.NewFieldCipher(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storetest.Run(t, func(t *testing.T) *store.Store {
+		t.Setenv("SQLITE_PATH", ":memory:")
+		db := sqlstore.Open(sqlstore.SQLite)
+		t.Cleanup(func() { db.Close() })
+		if err := sqlstore.Migrate(db, sqlstore.SQLite, cipher); err != nil {
+			t.Fatal(err)
+		}
+		return sqlstore.New(db, cipher)
+	})
+}
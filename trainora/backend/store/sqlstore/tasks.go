@@ -0,0 +1,42 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"trainora/store"
+)
+
+type taskStore struct {
+	db *sql.DB
+}
+
+const taskColumns = "id, title, COALESCE(description, ''), COALESCE(estimated_duration_minutes, 0), COALESCE(created_by, 0)"
+
+func (s *taskStore) ByID(ctx context.Context, id int64) (*store.Task, error) {
+	var t store.Task
+	err := s.db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = ?", id).
+		Scan(&t.ID, &t.Title, &t.Description, &t.Duration, &t.CreatedBy)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &t, nil
+}
+
+func (s *taskStore) ListByCreator(ctx context.Context, userID int64) ([]store.Task, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE created_by = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.Task
+	for rows.Next() {
+		var t store.Task
+		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Duration, &t.CreatedBy); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,131 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/crypto"
+	"trainora/store"
+)
+
+type twoFactorStore struct {
+	db     *sql.DB
+	cipher *crypto.FieldCipher
+}
+
+func (s *twoFactorStore) ByUser(ctx context.Context, userID int64) (*store.TwoFactor, error) {
+	secret := crypto.EncryptedString{Binding: s.cipher.Bind(userID, "totp_secret_encrypted")}
+	var enabledAt sql.NullTime
+	var tf store.TwoFactor
+	err := s.db.QueryRowContext(ctx,
+		"SELECT totp_secret_encrypted, totp_enabled_at, totp_last_step FROM users WHERE id = ?", userID).
+		Scan(&secret, &enabledAt, &tf.LastStep)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if !secret.Valid {
+		return nil, store.ErrNotFound
+	}
+	tf.Secret = secret.String
+	tf.EnabledAt = enabledAt.Time
+	return &tf, nil
+}
+
+func (s *twoFactorStore) SetSecret(ctx context.Context, userID int64, secret string) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET totp_secret_encrypted = ?, totp_enabled_at = NULL, totp_last_step = 0 WHERE id = ?",
+		s.cipher.Bind(userID, "totp_secret_encrypted").String(secret), userID)
+	return err
+}
+
+func (s *twoFactorStore) Enable(ctx context.Context, userID int64, at time.Time, codeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET totp_enabled_at = ? WHERE id = ?", at.UTC(), userID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *twoFactorStore) Disable(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE users SET totp_secret_encrypted = NULL, totp_enabled_at = NULL, totp_last_step = 0 WHERE id = ?", userID)
+	if err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *twoFactorStore) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	// Bedingtes UPDATE, damit ein Code auch bei parallelen Anfragen nur einmal gilt
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *twoFactorStore) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *twoFactorStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, at time.Time) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+		at.UTC(), userID, codeHash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *twoFactorStore) RecoveryCodesLeft(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL", userID).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,264 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"trainora/crypto"
+	"trainora/store"
+)
+
+type userStore struct {
+	db     *sql.DB
+	cipher *crypto.FieldCipher
+}
+
+// defaultBirthday wird bei der Registrierung gesetzt, bis das Setup erfolgt
+const defaultBirthday = "2000-01-01"
+
+func (s *userStore) Create(ctx context.Context, username, email, passwordHash string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO users (username, email, password_hash) VALUES (?, ?, ?)",
+		username, email, passwordHash,
+	)
+	if err != nil {
+		return 0, conflict(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// Der Geburtstag ist an die user_id gebunden und kann daher erst nach dem
+	// INSERT verschlüsselt werden
+	birthday := s.cipher.Bind(id, "birthday_encrypted").String(defaultBirthday)
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET birthday_encrypted = ? WHERE id = ?", birthday, id); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+const userColumns = "id, username, email, password_hash, setup_completed, created_at, failed_login_count, locked_until, email_verified_at, password_changed_at, disabled_at, deleted_at"
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*store.User, error) {
+	var u store.User
+	var setup string
+	var lockedUntil, emailVerifiedAt, passwordChangedAt, disabledAt, deletedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &setup, &u.CreatedAt,
+		&u.FailedLogins, &lockedUntil, &emailVerifiedAt, &passwordChangedAt, &disabledAt, &deletedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	u.SetupCompleted = setup == "yes"
+	u.LockedUntil = lockedUntil.Time
+	u.EmailVerifiedAt = emailVerifiedAt.Time
+	u.PasswordChangedAt = passwordChangedAt.Time
+	u.DisabledAt = disabledAt.Time
+	u.DeletedAt = deletedAt.Time
+	return &u, nil
+}
+
+func (s *userStore) ByID(ctx context.Context, id int64) (*store.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+func (s *userStore) ByLogin(ctx context.Context, login string) (*store.User, error) {
+	return scanUser(s.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE username = ? OR email = ?", login, login))
+}
+
+func (s *userStore) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email=?)", email).Scan(&exists)
+	return exists, err
+}
+
+func (s *userStore) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username=?)", username).Scan(&exists)
+	return exists, err
+}
+
+func (s *userStore) SetPasswordHash(ctx context.Context, id int64, hash string, changedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET password_hash = ?, password_changed_at = ? WHERE id = ?",
+		hash, changedAt.UTC(), id)
+	return err
+}
+
+func (s *userStore) MarkEmailVerified(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET email_verified_at = ? WHERE id = ?", at.UTC(), id)
+	return err
+}
+
+func (s *userStore) SetLoginFailures(ctx context.Context, id int64, count int, lockedUntil time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE users SET failed_login_count = ?, locked_until = ? WHERE id = ?",
+		count, nullTime(lockedUntil), id)
+	return err
+}
+
+func (s *userStore) SetDisabled(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET disabled_at = ? WHERE id = ?", nullTime(at), id)
+	return err
+}
+
+func (s *userStore) SetDeleted(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET deleted_at = ? WHERE id = ?", nullTime(at), id)
+	return err
+}
+
+func (s *userStore) DeletedBefore(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY id", before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *userStore) List(ctx context.Context, offset, limit int) ([]store.User, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+userColumns+" FROM users ORDER BY id LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var list []store.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, *u)
+	}
+	return list, total, rows.Err()
+}
+
+func (s *userStore) Profile(ctx context.Context, id int64) (*store.Profile, error) {
+	// Die Felder entschlüsseln sich beim Scan selbst
+	birthday := crypto.EncryptedString{Binding: s.cipher.Bind(id, "birthday_encrypted")}
+	height := crypto.EncryptedInt{Binding: s.cipher.Bind(id, "height_cm_encrypted")}
+	weight := crypto.EncryptedInt{Binding: s.cipher.Bind(id, "weight_kg_encrypted")}
+	goal := crypto.EncryptedString{Binding: s.cipher.Bind(id, "goal_encrypted")}
+	activity := crypto.EncryptedString{Binding: s.cipher.Bind(id, "activity_level_encrypted")}
+	allergies := crypto.EncryptedString{Binding: s.cipher.Bind(id, "allergies_encrypted")}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT birthday_encrypted, height_cm_encrypted, weight_kg_encrypted,
+		       goal_encrypted, activity_level_encrypted, allergies_encrypted
+		FROM users WHERE id = ?`, id).
+		Scan(&birthday, &height, &weight, &goal, &activity, &allergies)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &store.Profile{
+		Birthday:      birthday.String,
+		HeightCM:      height.Int,
+		WeightKG:      weight.Int,
+		ActivityLevel: activity.String,
+		Goal:          goal.String,
+		Allergies:     allergies.String,
+	}, nil
+}
+
+func (s *userStore) SaveProfile(ctx context.Context, id int64, p store.Profile) error {
+	// Alle Felder werden beim Schreiben an user_id und Spalte gebunden verschlüsselt
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE users SET
+			birthday_encrypted = ?,
+			height_cm_encrypted = ?,
+			weight_kg_encrypted = ?,
+			activity_level_encrypted = ?,
+			goal_encrypted = ?,
+			allergies_encrypted = ?,
+			setup_completed = 'yes'
+		WHERE id = ?`,
+		s.cipher.Bind(id, "birthday_encrypted").String(p.Birthday),
+		s.cipher.Bind(id, "height_cm_encrypted").Int(p.HeightCM),
+		s.cipher.Bind(id, "weight_kg_encrypted").Int(p.WeightKG),
+		s.cipher.Bind(id, "activity_level_encrypted").String(p.ActivityLevel),
+		s.cipher.Bind(id, "goal_encrypted").String(p.Goal),
+		s.cipher.Bind(id, "allergies_encrypted").String(p.Allergies),
+		id)
+	return err
+}
+
+// deleteUserStatements löschen alle Daten eines Benutzers. Jede Anweisung
+// erhält die user_id für jeden Platzhalter. Abhängige Tabellen kommen zuerst,
+// damit die Löschung nicht von ON DELETE CASCADE abhängt.
+var deleteUserStatements = []string{
+	"DELETE FROM schedule_comments WHERE author_id = ? OR schedule_id IN (SELECT id FROM task_schedule WHERE user_id = ?)",
+	"DELETE FROM plan_approvals WHERE user_id = ?",
+	"UPDATE plan_approvals SET approved_by = NULL WHERE approved_by = ?",
+	// Klienten ohne weiteren aktiven Coach warten nicht länger auf Freigaben
+	`DELETE FROM plan_approvals WHERE approved_at IS NULL
+		AND user_id IN (SELECT client_id FROM coach_clients WHERE coach_id = ?)
+		AND user_id NOT IN (` + activeClientsQuery + ` AND cc.coach_id <> ?)`,
+	"DELETE FROM personal_records WHERE user_id = ?",
+	"DELETE FROM workout_sets WHERE session_id IN (SELECT id FROM workout_sessions WHERE user_id = ?)",
+	"DELETE FROM workout_sessions WHERE user_id = ?",
+	"DELETE FROM task_schedule WHERE user_id = ?",
+	"DELETE FROM workout_blocks WHERE task_id IN (SELECT id FROM tasks WHERE created_by = ?)",
+	"DELETE FROM tasks WHERE created_by = ?",
+	"DELETE FROM recipes WHERE user_id = ?",
+	"DELETE FROM exercises WHERE user_id = ?",
+	"DELETE FROM coach_clients WHERE coach_id = ? OR client_id = ?",
+	"DELETE FROM generation_jobs WHERE user_id = ?",
+	"DELETE FROM data_exports WHERE user_id = ?",
+	"DELETE FROM remember_tokens WHERE user_id = ?",
+	"DELETE FROM refresh_tokens WHERE user_id = ?",
+	"DELETE FROM personal_access_tokens WHERE user_id = ?",
+	"DELETE FROM identities WHERE user_id = ?",
+	"DELETE FROM recovery_codes WHERE user_id = ?",
+	"DELETE FROM email_tokens WHERE user_id = ?",
+	"DELETE FROM login_attempts WHERE user_id = ?",
+	"DELETE FROM audit_events WHERE user_id = ?",
+	"DELETE FROM user_consents WHERE user_id = ?",
+	"DELETE FROM user_roles WHERE user_id = ?",
+	"DELETE FROM users WHERE id = ?",
+}
+
+func (s *userStore) Delete(ctx context.Context, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range deleteUserStatements {
+		args := make([]interface{}, strings.Count(stmt, "?"))
+		for i := range args {
+			args[i] = id
+		}
+		if _, err := tx.ExecContext(ctx, stmt, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,283 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"trainora/store"
+)
+
+type workoutSessionStore struct {
+	db *sql.DB
+}
+
+const workoutSessionColumns = `id, user_id, COALESCE(schedule_id, 0), title, COALESCE(notes, ''), started_at, finished_at`
+
+func scanWorkoutSession(row interface{ Scan(...interface{}) error }) (*store.WorkoutSession, error) {
+	var w store.WorkoutSession
+	var finishedAt sql.NullTime
+	err := row.Scan(&w.ID, &w.UserID, &w.ScheduleID, &w.Title, &w.Notes, &w.StartedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	w.FinishedAt = finishedAt.Time
+	return &w, nil
+}
+
+func (s *workoutSessionStore) Start(ctx context.Context, w store.WorkoutSession) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO workout_sessions (user_id, schedule_id, title, notes, started_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		w.UserID, w.ScheduleID, w.Title, w.Notes, w.StartedAt.UTC())
+	if err != nil {
+		return 0, conflict(err)
+	}
+	return res.LastInsertId()
+}
+
+func (s *workoutSessionStore) ByID(ctx context.Context, userID, id int64) (*store.WorkoutSession, error) {
+	return s.one(ctx, s.db, "id = ? AND user_id = ?", id, userID)
+}
+
+func (s *workoutSessionStore) BySchedule(ctx context.Context, userID, scheduleID int64) (*store.WorkoutSession, error) {
+	return s.one(ctx, s.db, "schedule_id = ? AND user_id = ?", scheduleID, userID)
+}
+
+// one lädt eine Session samt Sätzen
+func (s *workoutSessionStore) one(ctx context.Context, q querier, where string, args ...interface{}) (*store.WorkoutSession, error) {
+	list, err := s.query(ctx, q, where+" LIMIT 1", args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, store.ErrNotFound
+	}
+	if err := attachWorkoutSets(ctx, q, list, "?", list[0].ID); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// query liefert Sessions ohne Sätze; where darf ORDER BY und LIMIT enthalten
+func (s *workoutSessionStore) query(ctx context.Context, q querier, where string, args ...interface{}) ([]store.WorkoutSession, error) {
+	rows, err := q.QueryContext(ctx, "SELECT "+workoutSessionColumns+" FROM workout_sessions WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.WorkoutSession
+	for rows.Next() {
+		w, err := scanWorkoutSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *w)
+	}
+	return list, rows.Err()
+}
+
+// attachWorkoutSets lädt die Sätze der Sessions in list. sessionIDs ist wie
+// bei attachWorkouts eine Unterabfrage, die mindestens alle IDs aus list liefert.
+func attachWorkoutSets(ctx context.Context, q querier, list []store.WorkoutSession, sessionIDs string, args ...interface{}) error {
+	if len(list) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT session_id, id, COALESCE(exercise_id, 0), exercise_name, reps, weight_kg, rpe, COALESCE(notes, '')
+		FROM workout_sets WHERE session_id IN (`+sessionIDs+`)
+		ORDER BY session_id, position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bySession := map[int64][]store.WorkoutSet{}
+	for rows.Next() {
+		var sessionID int64
+		var set store.WorkoutSet
+		var rpe sql.NullFloat64
+		err := rows.Scan(&sessionID, &set.ID, &set.ExerciseID, &set.ExerciseName, &set.Reps, &set.WeightKG, &rpe, &set.Notes)
+		if err != nil {
+			return err
+		}
+		set.RPE = rpe.Float64
+		bySession[sessionID] = append(bySession[sessionID], set)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range list {
+		list[i].Sets = bySession[list[i].ID]
+	}
+	return nil
+}
+
+func (s *workoutSessionStore) List(ctx context.Context, userID int64, offset, limit int) ([]store.WorkoutSession, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM workout_sessions WHERE user_id = ?", userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := s.query(ctx, s.db, "user_id = ? ORDER BY started_at DESC, id DESC LIMIT ? OFFSET ?", userID, limit, offset)
+	return list, total, err
+}
+
+func (s *workoutSessionStore) History(ctx context.Context, userID int64) ([]store.WorkoutSession, error) {
+	list, err := s.query(ctx, s.db, "user_id = ? ORDER BY started_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	return list, attachWorkoutSets(ctx, s.db, list, "SELECT id FROM workout_sessions WHERE user_id = ?", userID)
+}
+
+func (s *workoutSessionStore) FinishedSince(ctx context.Context, userID int64, since time.Time) ([]store.WorkoutSession, error) {
+	list, err := s.query(ctx, s.db,
+		"user_id = ? AND finished_at >= ? ORDER BY finished_at DESC, id DESC", userID, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return list, attachWorkoutSets(ctx, s.db, list,
+		"SELECT id FROM workout_sessions WHERE user_id = ? AND finished_at >= ?", userID, since.UTC())
+}
+
+// running lädt eine laufende Session; ErrConflict, wenn sie abgeschlossen
+// ist. Die Bedingung finished_at IS NULL in den folgenden Anweisungen fängt
+// einen gleichzeitigen Abschluss ab.
+func (s *workoutSessionStore) running(ctx context.Context, tx *sql.Tx, userID, id int64) (*store.WorkoutSession, error) {
+	w, err := s.one(ctx, tx, "id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if w.Finished() {
+		return nil, store.ErrConflict
+	}
+	return w, nil
+}
+
+func (s *workoutSessionStore) Update(ctx context.Context, userID int64, w store.WorkoutSession) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.running(ctx, tx, userID, w.ID); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx,
+		"UPDATE workout_sessions SET notes = ? WHERE id = ? AND finished_at IS NULL", w.Notes, w.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrConflict
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM workout_sets WHERE session_id = ?", w.ID); err != nil {
+		return err
+	}
+	for i, set := range w.Sets {
+		var exerciseID, rpe interface{}
+		if set.ExerciseID != 0 {
+			exerciseID = set.ExerciseID
+		}
+		if set.RPE != 0 {
+			rpe = set.RPE
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO workout_sets (session_id, position, exercise_id, exercise_name, reps, weight_kg, rpe, notes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			w.ID, i, exerciseID, set.ExerciseName, set.Reps, set.WeightKG, rpe, set.Notes)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *workoutSessionStore) Finish(ctx context.Context, userID, id int64, at time.Time) ([]store.PersonalRecord, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	w, err := s.running(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx,
+		"UPDATE workout_sessions SET finished_at = ? WHERE id = ? AND finished_at IS NULL", at.UTC(), id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, store.ErrConflict
+	}
+
+	current, err := s.records(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	best := make(map[store.RecordKey]float64, len(current))
+	for _, r := range current {
+		best[store.RecordKey{ExerciseID: r.ExerciseID, Kind: r.Kind}] = r.Value
+	}
+	records := store.NewRecords(w, best, at)
+	for i, r := range records {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO personal_records (user_id, exercise_id, kind, value, session_id, achieved_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			userID, r.ExerciseID, r.Kind, r.Value, id, at.UTC())
+		if err != nil {
+			return nil, err
+		}
+		if records[i].ID, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+	}
+	return records, tx.Commit()
+}
+
+const personalRecordQuery = `
+	SELECT p.id, p.user_id, p.exercise_id, e.name, p.kind, p.value, p.session_id, p.achieved_at
+	FROM personal_records p JOIN exercises e ON e.id = p.exercise_id
+	WHERE p.user_id = ?`
+
+func (s *workoutSessionStore) Records(ctx context.Context, userID int64) ([]store.PersonalRecord, error) {
+	return s.records(ctx, s.db, userID)
+}
+
+// records liefert je Übung und Art die neueste Verbesserung
+func (s *workoutSessionStore) records(ctx context.Context, q querier, userID int64) ([]store.PersonalRecord, error) {
+	return queryPersonalRecords(ctx, q, personalRecordQuery+`
+		AND p.id = (
+			SELECT MAX(id) FROM personal_records
+			WHERE user_id = p.user_id AND exercise_id = p.exercise_id AND kind = p.kind
+		)
+		ORDER BY e.name, p.exercise_id, p.kind`, userID)
+}
+
+func (s *workoutSessionStore) RecordHistory(ctx context.Context, userID int64) ([]store.PersonalRecord, error) {
+	return queryPersonalRecords(ctx, s.db, personalRecordQuery+" ORDER BY p.achieved_at, p.id", userID)
+}
+
+func queryPersonalRecords(ctx context.Context, q querier, query string, args ...interface{}) ([]store.PersonalRecord, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []store.PersonalRecord
+	for rows.Next() {
+		var r store.PersonalRecord
+		err := rows.Scan(&r.ID, &r.UserID, &r.ExerciseID, &r.ExerciseName, &r.Kind, &r.Value, &r.SessionID, &r.AchievedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,75 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"trainora/store"
+)
+
+// querier ist *sql.DB oder *sql.Tx
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// attachWorkouts lädt die Trainingsstruktur der Aufgaben in list. taskIDs
+// ist eine Unterabfrage, die mindestens alle Aufgaben-IDs aus list liefert.
+// Die Zeilen der vorherigen Abfrage müssen geschlossen sein, da SQLite nur
+// eine Verbindung nutzt.
+func attachWorkouts(ctx context.Context, q querier, list []store.ScheduledTask, taskIDs string, args ...interface{}) error {
+	if len(list) == 0 {
+		return nil
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT task_id, id, kind, COALESCE(exercise_id, 0), exercise_name, sets, reps, weight_kg,
+		       rounds, work_seconds, rest_seconds, COALESCE(notes, '')
+		FROM workout_blocks WHERE task_id IN (`+taskIDs+`)
+		ORDER BY task_id, position`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byTask := map[int64][]store.WorkoutBlock{}
+	for rows.Next() {
+		var taskID int64
+		var b store.WorkoutBlock
+		err := rows.Scan(&taskID, &b.ID, &b.Kind, &b.ExerciseID, &b.ExerciseName, &b.Sets, &b.Reps, &b.WeightKG,
+			&b.Rounds, &b.WorkSeconds, &b.RestSeconds, &b.Notes)
+		if err != nil {
+			return err
+		}
+		byTask[taskID] = append(byTask[taskID], b)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range list {
+		list[i].Task.Workout = byTask[list[i].Task.ID]
+	}
+	return nil
+}
+
+// saveWorkout ersetzt die Trainingsstruktur einer Aufgabe
+func saveWorkout(ctx context.Context, q querier, taskID int64, blocks []store.WorkoutBlock) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM workout_blocks WHERE task_id = ?", taskID); err != nil {
+		return err
+	}
+	for i, b := range blocks {
+		var exerciseID interface{}
+		if b.ExerciseID != 0 {
+			exerciseID = b.ExerciseID
+		}
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO workout_blocks (task_id, position, kind, exercise_id, exercise_name, sets, reps, weight_kg,
+			                            rounds, work_seconds, rest_seconds, notes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			taskID, i, b.Kind, exerciseID, b.ExerciseName, b.Sets, b.Reps, b.WeightKG,
+			b.Rounds, b.WorkSeconds, b.RestSeconds, b.Notes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,833 @@
+// Package store definiert die Datenzugriffsschicht. Handler arbeiten nur gegen
+// diese Interfaces; die MySQL-Implementierung liegt in store/sqlstore, eine
+// In-Memory-Variante für Tests in store/memory.
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNotFound wird zurückgegeben, wenn ein Datensatz nicht existiert
+var ErrNotFound = errors.New("nicht gefunden")
+
+// ErrConflict wird zurückgegeben, wenn ein eindeutiger Wert bereits vergeben ist
+var ErrConflict = errors.New("bereits vorhanden")
+
+// Store bündelt alle Stores einer Implementierung
+type Store struct {
+	Users          UserStore
+	Roles          RoleStore
+	RememberTokens RememberTokenStore
+	LoginAttempts  LoginAttemptStore
+	AuditEvents    AuditStore
+	Consents       ConsentStore
+	EmailTokens    EmailTokenStore
+	TwoFactor      TwoFactorStore
+	Identities     IdentityStore
+	PersonalTokens PersonalTokenStore
+	RefreshTokens  RefreshTokenStore
+	Coaches        CoachStore
+	Plans          PlanStore
+	Comments       CommentStore
+	GenerationJobs GenerationJobStore
+	DataExports    DataExportStore
+	Tasks          TaskStore
+	Recipes        RecipeStore
+	Exercises      ExerciseStore
+	Workouts       WorkoutSessionStore
+}
+
+// User ist ein Benutzerkonto ohne die verschlüsselten Profildaten
+type User struct {
+	ID             int64
+	Username       string
+	Email          string
+	PasswordHash   string
+	SetupCompleted bool
+	CreatedAt      time.Time
+	// EmailVerifiedAt ist der Zeitpunkt der E-Mail-Bestätigung (Nullwert = unbestätigt)
+	EmailVerifiedAt time.Time
+	// PasswordChangedAt ist der Zeitpunkt der letzten Passwortänderung (Nullwert = nie)
+	PasswordChangedAt time.Time
+
+	// FailedLogins zählt aufeinanderfolgende Fehlversuche
+	FailedLogins int
+	// LockedUntil ist der Zeitpunkt, bis zu dem das Konto gesperrt ist (Nullwert = nicht gesperrt)
+	LockedUntil time.Time
+	// DisabledAt ist der Zeitpunkt, zu dem ein Admin das Konto deaktiviert hat
+	// (Nullwert = aktiv)
+	DisabledAt time.Time
+	// DeletedAt ist der Zeitpunkt, zu dem der Benutzer sein Konto gelöscht
+	// hat (Nullwert = aktiv). Bis zum Ende der Karenzzeit kann er es
+	// wiederherstellen, danach wird es endgültig entfernt.
+	DeletedAt time.Time
+}
+
+// Disabled meldet, ob ein Admin das Konto deaktiviert hat
+func (u *User) Disabled() bool {
+	return !u.DisabledAt.IsZero()
+}
+
+// Deleted meldet, ob das Konto zur Löschung vorgemerkt ist
+func (u *User) Deleted() bool {
+	return !u.DeletedAt.IsZero()
+}
+
+// Profile enthält die entschlüsselten Gesundheitsdaten aus dem Setup
+type Profile struct {
+	Birthday      string // YYYY-MM-DD
+	HeightCM      int
+	WeightKG      int
+	ActivityLevel string
+	Goal          string
+	Allergies     string
+}
+
+// UserStore verwaltet Benutzerkonten und ihre Profildaten
+type UserStore interface {
+	// Create legt einen Benutzer an und setzt den Standard-Geburtstag
+	Create(ctx context.Context, username, email, passwordHash string) (int64, error)
+	ByID(ctx context.Context, id int64) (*User, error)
+	// ByLogin sucht nach Benutzername oder E-Mail
+	ByLogin(ctx context.Context, login string) (*User, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UsernameExists(ctx context.Context, username string) (bool, error)
+
+	// SetPasswordHash speichert ein neues Passwort und den Zeitpunkt der Änderung
+	SetPasswordHash(ctx context.Context, id int64, hash string, changedAt time.Time) error
+	MarkEmailVerified(ctx context.Context, id int64, at time.Time) error
+
+	// SetLoginFailures setzt den Fehlversuchszähler und die Sperre; ein
+	// Nullwert für lockedUntil hebt die Sperre auf
+	SetLoginFailures(ctx context.Context, id int64, count int, lockedUntil time.Time) error
+	// SetDisabled deaktiviert ein Konto; ein Nullwert aktiviert es wieder
+	SetDisabled(ctx context.Context, id int64, at time.Time) error
+	// SetDeleted merkt ein Konto zur Löschung vor; ein Nullwert stellt es
+	// wieder her
+	SetDeleted(ctx context.Context, id int64, at time.Time) error
+	// DeletedBefore liefert die IDs der vor before gelöschten Konten
+	DeletedBefore(ctx context.Context, before time.Time) ([]int64, error)
+
+	// List liefert eine Seite aller Benutzer nach ID sortiert und die Gesamtzahl
+	List(ctx context.Context, offset, limit int) ([]User, int, error)
+
+	Profile(ctx context.Context, id int64) (*Profile, error)
+	// SaveProfile speichert die Setup-Daten und markiert das Setup als abgeschlossen
+	SaveProfile(ctx context.Context, id int64, p Profile) error
+
+	// Delete entfernt das Konto endgültig mit allen zugehörigen Daten in
+	// einer Transaktion
+	Delete(ctx context.Context, id int64) error
+}
+
+// Rollen eines Benutzers. Jeder Benutzer hat RoleUser, gespeichert werden
+// nur die zusätzlich vergebenen Rollen.
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
+)
+
+// Roles sind alle bekannten Rollen
+var Roles = []string{RoleUser, RoleCoach, RoleAdmin}
+
+// RoleStore verwaltet die zusätzlich vergebenen Rollen
+type RoleStore interface {
+	// ByUser liefert die vergebenen Rollen ohne RoleUser, sortiert
+	ByUser(ctx context.Context, userID int64) ([]string, error)
+	// Set ersetzt die vergebenen Rollen eines Benutzers
+	Set(ctx context.Context, userID int64, roles []string) error
+}
+
+// RememberToken ist ein Remember-Me-Token eines Geräts. Gespeichert wird nur
+// der Hash des Validators, der Selector dient zum Nachschlagen.
+type RememberToken struct {
+	ID            int64
+	UserID        int64
+	Selector      string
+	ValidatorHash string
+	// PreviousValidatorHash ist der Validator vor dem letzten Austausch um RotatedAt
+	PreviousValidatorHash string
+	Device                string // User-Agent beim Login
+	CreatedAt             time.Time
+	LastUsedAt            time.Time
+	ExpiresAt             time.Time
+	RotatedAt             time.Time
+}
+
+// RememberTokenStore verwaltet die Remember-Me-Tokens aller Geräte
+type RememberTokenStore interface {
+	Create(ctx context.Context, t RememberToken) (int64, error)
+	BySelector(ctx context.Context, selector string) (*RememberToken, error)
+	// ByID liefert einen Token des Benutzers; ErrNotFound, wenn er nicht existiert
+	ByID(ctx context.Context, userID, id int64) (*RememberToken, error)
+	// Rotate ersetzt den Validator oldHash eines Tokens durch newHash, merkt
+	// sich den alten und verlängert die Laufzeit; ErrConflict, wenn der
+	// Validator inzwischen schon ausgetauscht wurde
+	Rotate(ctx context.Context, id int64, oldHash, newHash string, lastUsed, expiresAt time.Time) error
+	// ListByUser liefert alle noch gültigen Tokens, zuletzt genutzte zuerst
+	ListByUser(ctx context.Context, userID int64, now time.Time) ([]RememberToken, error)
+	// Delete löscht einen Token des Benutzers; ErrNotFound, wenn er nicht existiert
+	Delete(ctx context.Context, userID, id int64) error
+	DeleteByUser(ctx context.Context, userID int64) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// LoginAttempt ist ein protokollierter Login-Versuch
+type LoginAttempt struct {
+	UserID      int64 // 0, wenn kein Konto zum Login gefunden wurde
+	Login       string
+	IP          string
+	UserAgent   string
+	Success     bool
+	AttemptedAt time.Time
+}
+
+// LoginAttemptStore protokolliert Login-Versuche und zählt Fehlversuche in
+// einem Zeitfenster. Die Count-Methoden liefern zusätzlich den ältesten
+// Fehlversuch im Fenster, um die Wartezeit zu berechnen.
+type LoginAttemptStore interface {
+	Record(ctx context.Context, a LoginAttempt) error
+	FailuresByIP(ctx context.Context, ip string, since time.Time) (int, time.Time, error)
+	FailuresByUser(ctx context.Context, userID int64, since time.Time) (int, time.Time, error)
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// Ereignistypen des Audit-Logs
+const (
+	AuditLogin             = "login"
+	AuditLogout            = "logout"
+	AuditPasswordChanged   = "password_changed"
+	AuditPasswordReset     = "password_reset"
+	AuditTwoFactorEnabled  = "two_factor_enabled"
+	AuditTwoFactorDisabled = "two_factor_disabled"
+	AuditSetupCompleted    = "setup_completed"
+	AuditProfileUpdated    = "profile_updated"
+	AuditConsentGiven      = "consent_given"
+	AuditConsentWithdrawn  = "consent_withdrawn"
+	AuditExportRequested   = "export_requested"
+	AuditExportDownloaded  = "export_downloaded"
+	AuditAccountDeleted    = "account_deleted"
+	AuditAccountRestored   = "account_restored"
+	AuditRolesChanged      = "roles_changed"
+	AuditAccountDisabled   = "account_disabled"
+	AuditAccountEnabled    = "account_enabled"
+	AuditPlanRegenerated   = "plan_regenerated"
+)
+
+// AuditEvent ist ein Eintrag im Audit-Log. UserID ist das betroffene Konto,
+// ActorID der Handelnde; bei Admin-Aktionen unterscheiden sie sich.
+type AuditEvent struct {
+	ID        int64
+	UserID    int64
+	ActorID   int64 // 0 = System, z. B. Hintergrund-Jobs
+	Type      string
+	IP        string
+	UserAgent string
+	// Metadata enthält Details zum Ereignis, nie Passwörter oder Profilwerte
+	Metadata  map[string]string
+	CreatedAt time.Time
+}
+
+// AuditStore führt das Audit-Log. Einträge werden nur angehängt; gelöscht
+// werden sie ausschließlich zusammen mit dem Konto.
+type AuditStore interface {
+	Record(ctx context.Context, e AuditEvent) error
+	// ByUser liefert eine Seite der Ereignisse eines Kontos, neueste zuerst,
+	// und die Gesamtzahl
+	ByUser(ctx context.Context, userID int64, offset, limit int) ([]AuditEvent, int, error)
+}
+
+// Consent ist die Einwilligung in eine Version eines Textes aus dem Paket
+// consent
+type Consent struct {
+	ID          int64
+	UserID      int64
+	Kind        string
+	Version     int
+	IP          string
+	GivenAt     time.Time
+	WithdrawnAt time.Time // Nullwert = gültig
+}
+
+// ConsentStore verwaltet die Einwilligungen. Einträge werden nie gelöscht,
+// ein Widerruf wird am Eintrag vermerkt.
+type ConsentStore interface {
+	Give(ctx context.Context, c Consent) error
+	// Active liefert je Art die neueste nicht widerrufene Einwilligung
+	Active(ctx context.Context, userID int64) ([]Consent, error)
+	// Withdraw widerruft alle Einwilligungen einer Art; ErrNotFound, wenn
+	// keine gültige besteht
+	Withdraw(ctx context.Context, userID int64, kind string, at time.Time) error
+	// History liefert alle Einwilligungen, älteste zuerst
+	History(ctx context.Context, userID int64) ([]Consent, error)
+}
+
+// Zwecke von Mail-Tokens
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenPasswordReset = "password_reset"
+	TokenDataExport    = "data_export"
+	TokenRestore       = "restore_account"
+)
+
+// EmailToken ist ein Einmal-Token aus einem Mail-Link
+type EmailToken struct {
+	UserID    int64
+	Purpose   string
+	NonceHash string
+	ExpiresAt time.Time
+}
+
+// EmailTokenStore verwaltet die Einmal-Tokens aus Mail-Links
+type EmailTokenStore interface {
+	Create(ctx context.Context, t EmailToken) error
+	// Consume markiert einen gültigen Token als benutzt und liefert die
+	// user_id; ErrNotFound bei unbekannten, benutzten oder abgelaufenen Tokens
+	Consume(ctx context.Context, nonceHash, purpose string, now time.Time) (int64, error)
+	// DeleteByUser entfernt alle offenen Tokens eines Zwecks
+	DeleteByUser(ctx context.Context, userID int64, purpose string) error
+}
+
+// TwoFactor ist die TOTP-Einrichtung eines Benutzers
+type TwoFactor struct {
+	Secret string // Base32, entschlüsselt
+	// EnabledAt ist der Zeitpunkt der Aktivierung (Nullwert = noch nicht bestätigt)
+	EnabledAt time.Time
+	// LastStep ist der zuletzt akzeptierte TOTP-Zeitschritt
+	LastStep int64
+}
+
+// TwoFactorStore verwaltet TOTP-Secrets und die gehashten
+// Wiederherstellungscodes
+type TwoFactorStore interface {
+	// ByUser liefert die Einrichtung; ErrNotFound, wenn kein Secret hinterlegt ist
+	ByUser(ctx context.Context, userID int64) (*TwoFactor, error)
+	// SetSecret hinterlegt ein neues, noch nicht bestätigtes Secret
+	SetSecret(ctx context.Context, userID int64, secret string) error
+	// Enable aktiviert 2FA und ersetzt die Wiederherstellungscodes
+	Enable(ctx context.Context, userID int64, at time.Time, codeHashes []string) error
+	// Disable entfernt Secret und Wiederherstellungscodes
+	Disable(ctx context.Context, userID int64) error
+	// UseStep merkt sich einen akzeptierten Zeitschritt; false, wenn er nicht
+	// neuer als der zuletzt akzeptierte ist (Code bereits benutzt)
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	// UseRecoveryCode entwertet einen Code; ErrNotFound, wenn er nicht
+	// existiert oder schon benutzt wurde
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string, at time.Time) error
+	RecoveryCodesLeft(ctx context.Context, userID int64) (int, error)
+}
+
+// Identity verknüpft ein Konto beim OpenID-Connect-Provider mit einem Benutzer
+type Identity struct {
+	ID          int64
+	UserID      int64
+	Issuer      string
+	Subject     string
+	Email       string // E-Mail laut Provider beim Verknüpfen
+	CreatedAt   time.Time
+	LastLoginAt time.Time
+}
+
+// IdentityStore verwaltet verknüpfte Identitäten
+type IdentityStore interface {
+	// Create verknüpft eine Identität; ErrConflict, wenn sie schon zu einem
+	// Benutzer gehört
+	Create(ctx context.Context, i Identity) (int64, error)
+	BySubject(ctx context.Context, issuer, subject string) (*Identity, error)
+	ListByUser(ctx context.Context, userID int64) ([]Identity, error)
+	Touch(ctx context.Context, id int64, at time.Time) error
+	// Delete löst eine Identität des Benutzers; ErrNotFound, wenn sie nicht existiert
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+// PersonalToken ist ein persönliches Zugriffstoken für Skripte und fremde
+// Clients. Gespeichert wird nur der Hash des Tokens.
+type PersonalToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt time.Time // Nullwert = nie benutzt
+	ExpiresAt  time.Time // Nullwert = unbegrenzt gültig
+}
+
+// PersonalTokenStore verwaltet die persönlichen Zugriffstokens
+type PersonalTokenStore interface {
+	Create(ctx context.Context, t PersonalToken) (int64, error)
+	ByHash(ctx context.Context, tokenHash string) (*PersonalToken, error)
+	// ListByUser liefert alle Tokens des Benutzers, neueste zuerst
+	ListByUser(ctx context.Context, userID int64) ([]PersonalToken, error)
+	Touch(ctx context.Context, id int64, at time.Time) error
+	// Delete widerruft einen Token des Benutzers; ErrNotFound, wenn er nicht existiert
+	Delete(ctx context.Context, userID, id int64) error
+}
+
+// RefreshToken tauscht ein abgelaufenes Access-Token gegen ein neues. Jeder
+// Refresh-Token gilt nur einmal; alle Nachfolger eines Logins bilden eine
+// Familie, die bei erneuter Nutzung eines alten Tokens komplett widerrufen wird.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	Family    string
+	Scopes    []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // Nullwert = noch nicht benutzt
+}
+
+// RefreshTokenStore verwaltet die Refresh-Tokens der API
+type RefreshTokenStore interface {
+	Create(ctx context.Context, t RefreshToken) (int64, error)
+	ByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// Use markiert einen Token als benutzt; false, wenn er bereits benutzt war
+	Use(ctx context.Context, id int64, at time.Time) (bool, error)
+	DeleteFamily(ctx context.Context, family string) error
+	DeleteByUser(ctx context.Context, userID int64) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Task ist eine Aufgabe, wie sie das LLM erzeugt
+type Task struct {
+	ID          int64
+	Title       string
+	Description string
+	Duration    int // estimated_duration_minutes
+	CreatedBy   int64
+	// Workout ist die Trainingsstruktur in Reihenfolge, leer bei Aufgaben
+	// ohne Übungen
+	Workout []WorkoutBlock
+}
+
+// Arten von Trainingsblöcken
+const (
+	// WorkoutSets sind Sätze mit Wiederholungen und Gewicht
+	WorkoutSets = "sets"
+	// WorkoutInterval sind Runden aus Belastung und Pause
+	WorkoutInterval = "interval"
+)
+
+// WorkoutBlock ist ein Abschnitt eines strukturierten Trainings
+type WorkoutBlock struct {
+	ID   int64
+	Kind string // WorkoutSets oder WorkoutInterval
+	// ExerciseID verweist auf den Übungskatalog (0 = Übung gelöscht oder
+	// unbekannt); ExerciseName bleibt in jedem Fall erhalten
+	ExerciseID   int64
+	ExerciseName string
+	// Nur WorkoutSets
+	Sets     int
+	Reps     int
+	WeightKG float64 // 0 = Körpergewicht
+	// Nur WorkoutInterval
+	Rounds      int
+	WorkSeconds int
+	// RestSeconds ist die Pause zwischen Sätzen bzw. Runden
+	RestSeconds int
+	Notes       string
+}
+
+// ScheduledTask ist eine Aufgabe an einem Wochentag eines Wochenplans
+type ScheduledTask struct {
+	ScheduleID     int64
+	UserID         int64
+	Task           Task
+	Weekday        int // 0 = Sonntag, 6 = Samstag
+	DayPeriod      string
+	WeekStartDate  string // YYYY-MM-DD, Montag der Woche
+	Feedback       string
+	FeedbackOption string
+}
+
+// PlanStore verwaltet die Wochenpläne (task_schedule)
+type PlanStore interface {
+	// Week liefert die Aufgaben einer Woche sortiert nach Wochentag und Tageszeit
+	Week(ctx context.Context, userID int64, weekStartDate string) ([]ScheduledTask, error)
+	// History liefert alle Einträge des Benutzers sortiert nach Woche,
+	// Wochentag und Tageszeit
+	History(ctx context.Context, userID int64) ([]ScheduledTask, error)
+	// Count zählt alle Einträge des Benutzers
+	Count(ctx context.Context, userID int64) (int, error)
+	HasWeek(ctx context.Context, userID int64, weekStartDate string) (bool, error)
+	// SaveWeek legt Aufgaben und Einplanungen einer Woche in einer Transaktion
+	// an. Ist approvalAt gesetzt, hält dieselbe Transaktion die Woche bis zur
+	// Freigabe durch den Coach zurück, auch wenn sie schon freigegeben war.
+	SaveWeek(ctx context.Context, userID int64, weekStartDate string, tasks []ScheduledTask, approvalAt time.Time) error
+	// ReplaceWeek ersetzt den Plan einer Woche samt Feedback in einer
+	// Transaktion; approvalAt wie bei SaveWeek
+	ReplaceWeek(ctx context.Context, userID int64, weekStartDate string, tasks []ScheduledTask, approvalAt time.Time) error
+	// SetFeedback speichert das Feedback zu einer eingeplanten Aufgabe des
+	// Benutzers; ErrNotFound, wenn sie nicht existiert
+	SetFeedback(ctx context.Context, userID, scheduleID int64, option, feedback string) error
+
+	// Entry liefert einen Eintrag des Benutzers; ErrNotFound, wenn er nicht
+	// zum Benutzer gehört
+	Entry(ctx context.Context, userID, scheduleID int64) (*ScheduledTask, error)
+	// UpdateEntry ändert Aufgabe samt Trainingsstruktur, Wochentag und
+	// Tageszeit eines Eintrags; ErrNotFound, wenn er nicht zum Benutzer gehört
+	UpdateEntry(ctx context.Context, userID int64, entry ScheduledTask) error
+	// DeleteEntry entfernt einen Eintrag samt Aufgabe; ErrNotFound, wenn er
+	// nicht zum Benutzer gehört
+	DeleteEntry(ctx context.Context, userID, scheduleID int64) error
+
+	// Approval liefert den Freigabestatus einer Woche; ErrNotFound, wenn
+	// keine Freigabe nötig ist
+	Approval(ctx context.Context, userID int64, weekStartDate string) (*PlanApproval, error)
+	// Approve gibt eine Woche frei; ErrNotFound, wenn keine Freigabe aussteht
+	Approve(ctx context.Context, userID int64, weekStartDate string, coachID int64, at time.Time) error
+	// ReleaseApprovals gibt alle zurückgehaltenen Wochen frei, z. B. wenn der
+	// Benutzer keinen Coach mehr hat
+	ReleaseApprovals(ctx context.Context, userID int64) error
+}
+
+// PlanApproval ist die Freigabe einer Woche durch einen Coach
+type PlanApproval struct {
+	UserID        int64
+	WeekStartDate string
+	RequestedAt   time.Time
+	ApprovedBy    int64     // 0 = noch nicht freigegeben
+	ApprovedAt    time.Time // Nullwert = noch nicht freigegeben
+}
+
+// Pending meldet, ob die Woche noch auf die Freigabe wartet
+func (a *PlanApproval) Pending() bool {
+	return a.ApprovedAt.IsZero()
+}
+
+// Status einer Coach-Beziehung
+const (
+	CoachPending = "pending"
+	CoachActive  = "active"
+)
+
+// CoachLink verbindet einen Coach mit einem Klienten. Der Coach lädt ein,
+// erst nach Annahme durch den Klienten ist die Beziehung aktiv.
+type CoachLink struct {
+	ID         int64
+	CoachID    int64
+	CoachName  string
+	ClientID   int64
+	ClientName string
+	Status     string
+	CreatedAt  time.Time
+	AcceptedAt time.Time // Nullwert = noch nicht angenommen
+}
+
+// CoachStore verwaltet die Beziehungen zwischen Coaches und Klienten
+type CoachStore interface {
+	// Invite legt eine offene Einladung an; ErrConflict, wenn schon eine
+	// Beziehung besteht
+	Invite(ctx context.Context, coachID, clientID int64, at time.Time) (int64, error)
+	// Accept nimmt eine offene Einladung an; ErrNotFound, wenn keine besteht
+	Accept(ctx context.Context, coachID, clientID int64, at time.Time) error
+	// Delete beendet eine Beziehung oder lehnt eine Einladung ab; ErrNotFound,
+	// wenn keine besteht
+	Delete(ctx context.Context, coachID, clientID int64) error
+	// ListByCoach liefert alle Klienten eines Coaches, ListByClient alle
+	// Coaches eines Klienten, jeweils nach Benutzername sortiert
+	ListByCoach(ctx context.Context, coachID int64) ([]CoachLink, error)
+	ListByClient(ctx context.Context, clientID int64) ([]CoachLink, error)
+	// IsActive meldet, ob der Coach den Klienten aktiv betreut. Coaches, die
+	// deaktiviert, gelöscht oder ohne Rolle RoleCoach sind, zählen nicht.
+	IsActive(ctx context.Context, coachID, clientID int64) (bool, error)
+	// HasActiveCoach meldet, ob der Klient mindestens einen aktiven Coach
+	// hat, mit denselben Einschränkungen wie IsActive
+	HasActiveCoach(ctx context.Context, clientID int64) (bool, error)
+}
+
+// Comment ist ein Kommentar zu einem Eintrag im Wochenplan
+type Comment struct {
+	ID         int64
+	ScheduleID int64
+	AuthorID   int64
+	AuthorName string
+	Body       string
+	CreatedAt  time.Time
+}
+
+// CommentStore verwaltet die Kommentare zu Einträgen im Wochenplan
+type CommentStore interface {
+	Create(ctx context.Context, cm Comment) (int64, error)
+	// Week liefert alle Kommentare zu einer Woche des Benutzers, älteste zuerst
+	Week(ctx context.Context, userID int64, weekStartDate string) ([]Comment, error)
+	// History liefert alle Kommentare zu Einträgen des Benutzers, älteste zuerst
+	History(ctx context.Context, userID int64) ([]Comment, error)
+}
+
+// FeedbackOptions sind die erlaubten Werte für feedback_option
+var FeedbackOptions = []string{"none", "too_hard", "didnt_like", "not_possible"}
+
+// Auslöser und Status einer Plan-Generierung
+const (
+	TriggerAfterSetup = "after_setup"
+	TriggerNextWeek   = "next_week"
+	TriggerAdmin      = "admin"
+
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// GenerationJob ist ein Lauf der Wochenplan-Generierung durch das LLM
+type GenerationJob struct {
+	ID            int64
+	UserID        int64
+	WeekStartDate string
+	TriggeredBy   string
+	Status        string
+	Error         string
+	StartedAt     time.Time
+	FinishedAt    time.Time // Nullwert = läuft noch
+}
+
+// GenerationJobStore protokolliert die Läufe der Plan-Generierung
+type GenerationJobStore interface {
+	// Start legt einen laufenden Job an
+	Start(ctx context.Context, j GenerationJob) (int64, error)
+	// Finish schließt einen Job ab; ein leerer errMsg bedeutet Erfolg
+	Finish(ctx context.Context, id int64, at time.Time, errMsg string) error
+	// Failures liefert die fehlgeschlagenen Jobs, neueste zuerst
+	Failures(ctx context.Context, limit int) ([]GenerationJob, error)
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// DataExport ist der Datenexport eines Benutzers. Es gibt höchstens einen
+// je Benutzer; Status ist JobRunning, JobSucceeded oder JobFailed.
+type DataExport struct {
+	UserID     int64
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time // Nullwert = läuft noch
+}
+
+// DataExportStore verwaltet die Datenexporte. Das ZIP-Archiv wird
+// verschlüsselt gespeichert und nur bis zum Download aufbewahrt.
+type DataExportStore interface {
+	// Start legt einen laufenden Export an und ersetzt einen vorhandenen
+	Start(ctx context.Context, userID int64, at time.Time) error
+	// ByUser liefert den Export ohne Archiv; ErrNotFound, wenn keiner existiert
+	ByUser(ctx context.Context, userID int64) (*DataExport, error)
+	// Finish speichert das Archiv; ein nicht leerer errMsg markiert den
+	// Export als fehlgeschlagen
+	Finish(ctx context.Context, userID int64, at time.Time, archive []byte, errMsg string) error
+	// Archive liefert das entschlüsselte Archiv; ErrNotFound, wenn keins existiert
+	Archive(ctx context.Context, userID int64) ([]byte, error)
+	Delete(ctx context.Context, userID int64) error
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// TaskStore liest einzelne Aufgaben
+type TaskStore interface {
+	ByID(ctx context.Context, id int64) (*Task, error)
+	ListByCreator(ctx context.Context, userID int64) ([]Task, error)
+}
+
+// Recipe ist ein Rezept eines Benutzers
+type Recipe struct {
+	ID           int64
+	UserID       int64
+	Title        string
+	Ingredients  []string
+	Instructions string
+	CreatedAt    time.Time
+}
+
+// RecipeStore verwaltet die Rezepte der Benutzer
+type RecipeStore interface {
+	ListByUser(ctx context.Context, userID int64) ([]Recipe, error)
+	Create(ctx context.Context, r Recipe) (int64, error)
+	DeleteByUser(ctx context.Context, userID int64) error
+}
+
+// Exercise ist eine Übung aus dem Katalog oder eine eigene Übung eines Benutzers
+type Exercise struct {
+	ID int64
+	// UserID ist der Besitzer einer eigenen Übung (0 = Katalog)
+	UserID int64
+	// Slug ist der Schlüssel im Katalog-Datensatz, leer bei eigenen Übungen
+	Slug              string
+	Name              string
+	MuscleGroups      []string
+	Equipment         []string // leer = ohne Geräte
+	Difficulty        string
+	Instructions      string
+	Contraindications []string
+	CreatedAt         time.Time
+}
+
+// Custom meldet, ob die Übung einem Benutzer gehört
+func (e *Exercise) Custom() bool {
+	return e.UserID != 0
+}
+
+// ExerciseFilter schränkt die Suche im Katalog ein; leere Felder filtern nicht
+type ExerciseFilter struct {
+	// Query sucht im Namen
+	Query       string
+	MuscleGroup string
+	Equipment   string
+	Difficulty  string
+	// OwnOnly liefert nur eigene Übungen
+	OwnOnly bool
+	Offset  int
+	Limit   int
+}
+
+// ExerciseStore verwaltet den Übungskatalog und die eigenen Übungen
+type ExerciseStore interface {
+	// Search liefert die für userID sichtbaren Übungen (Katalog und eigene)
+	// nach Namen sortiert und die Gesamtzahl der Treffer
+	Search(ctx context.Context, userID int64, f ExerciseFilter) ([]Exercise, int, error)
+	ByID(ctx context.Context, id int64) (*Exercise, error)
+	ListByUser(ctx context.Context, userID int64) ([]Exercise, error)
+	Create(ctx context.Context, e Exercise) (int64, error)
+	// Update ändert eine eigene Übung; ErrNotFound, wenn sie nicht e.UserID gehört
+	Update(ctx context.Context, e Exercise) error
+	// Delete löscht eine eigene Übung; ErrNotFound, wenn sie nicht userID gehört
+	Delete(ctx context.Context, userID, id int64) error
+	// SyncCatalog legt Katalog-Übungen anhand des Slugs an oder aktualisiert
+	// sie. Übungen, die im Datensatz fehlen, bleiben erhalten.
+	SyncCatalog(ctx context.Context, list []Exercise) error
+}
+
+// WorkoutSession ist ein protokolliertes Training zu einem Eintrag im Wochenplan
+type WorkoutSession struct {
+	ID     int64
+	UserID int64
+	// ScheduleID ist der Eintrag im Wochenplan (0 = Eintrag ersetzt oder
+	// gelöscht); Title bleibt in jedem Fall erhalten
+	ScheduleID int64
+	Title      string
+	Notes      string
+	StartedAt  time.Time
+	FinishedAt time.Time // Nullwert = läuft noch
+	// Sets sind die absolvierten Sätze in Reihenfolge
+	Sets []WorkoutSet
+}
+
+// Finished meldet, ob die Session abgeschlossen ist
+func (w *WorkoutSession) Finished() bool {
+	return !w.FinishedAt.IsZero()
+}
+
+// WorkoutSet ist ein tatsächlich absolvierter Satz
+type WorkoutSet struct {
+	ID int64
+	// ExerciseID verweist auf den Übungskatalog (0 = Übung gelöscht oder
+	// frei benannt); ExerciseName bleibt in jedem Fall erhalten
+	ExerciseID   int64
+	ExerciseName string
+	Reps         int
+	WeightKG     float64 // 0 = Körpergewicht
+	RPE          float64 // 0 = nicht angegeben, sonst 1 bis 10
+	Notes        string
+}
+
+// Arten persönlicher Rekorde
+const (
+	// RecordMaxWeight ist das höchste Gewicht eines Satzes
+	RecordMaxWeight = "max_weight"
+	// RecordEstimated1RM ist das nach Epley geschätzte Maximalgewicht für
+	// eine Wiederholung
+	RecordEstimated1RM = "estimated_1rm"
+	// RecordMaxReps sind die meisten Wiederholungen eines Satzes mit
+	// Körpergewicht
+	RecordMaxReps = "max_reps"
+)
+
+// PersonalRecord ist eine Verbesserung eines Bestwerts
+type PersonalRecord struct {
+	ID           int64
+	UserID       int64
+	ExerciseID   int64
+	ExerciseName string
+	Kind         string
+	Value        float64
+	SessionID    int64
+	AchievedAt   time.Time
+}
+
+// RecordKey identifiziert einen Bestwert
+type RecordKey struct {
+	ExerciseID int64
+	Kind       string
+}
+
+// NewRecords ermittelt die Rekorde, die w gegenüber den Bestwerten best
+// aufstellt, höchstens einen je Übung und Art. Sätze ohne Übung zählen nicht.
+func NewRecords(w *WorkoutSession, best map[RecordKey]float64, at time.Time) []PersonalRecord {
+	var records []PersonalRecord
+	index := map[RecordKey]int{}
+	for _, set := range w.Sets {
+		if set.ExerciseID == 0 || set.Reps < 1 {
+			continue
+		}
+		kinds, values := []string{RecordMaxReps}, []float64{float64(set.Reps)}
+		if set.WeightKG > 0 {
+			oneRM := set.WeightKG
+			if set.Reps > 1 {
+				oneRM = math.Round(set.WeightKG*(1+float64(set.Reps)/30)*100) / 100
+			}
+			kinds, values = []string{RecordMaxWeight, RecordEstimated1RM}, []float64{set.WeightKG, oneRM}
+		}
+		for i, kind := range kinds {
+			value := values[i]
+			key := RecordKey{set.ExerciseID, kind}
+			if old, ok := best[key]; ok && value <= old {
+				continue
+			}
+			best[key] = value
+			r := PersonalRecord{UserID: w.UserID, ExerciseID: set.ExerciseID, ExerciseName: set.ExerciseName,
+				Kind: kind, Value: value, SessionID: w.ID, AchievedAt: at}
+			if j, ok := index[key]; ok {
+				records[j] = r
+				continue
+			}
+			index[key] = len(records)
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// WorkoutSessionStore protokolliert Trainings und persönliche Rekorde
+type WorkoutSessionStore interface {
+	// Start legt eine laufende Session an; ErrConflict, wenn es zum Eintrag
+	// bereits eine gibt
+	Start(ctx context.Context, w WorkoutSession) (int64, error)
+	// ByID liefert eine Session samt Sätzen; ErrNotFound, wenn sie nicht zum
+	// Benutzer gehört
+	ByID(ctx context.Context, userID, id int64) (*WorkoutSession, error)
+	// BySchedule liefert die Session zu einem Eintrag im Wochenplan;
+	// ErrNotFound, wenn es keine gibt
+	BySchedule(ctx context.Context, userID, scheduleID int64) (*WorkoutSession, error)
+	// List liefert Sessions ohne Sätze, neueste zuerst, und die Gesamtzahl
+	List(ctx context.Context, userID int64, offset, limit int) ([]WorkoutSession, int, error)
+	// History liefert alle Sessions samt Sätzen, älteste zuerst
+	History(ctx context.Context, userID int64) ([]WorkoutSession, error)
+	// FinishedSince liefert die seit since abgeschlossenen Sessions samt
+	// Sätzen, neueste zuerst
+	FinishedSince(ctx context.Context, userID int64, since time.Time) ([]WorkoutSession, error)
+	// Update ersetzt Notizen und Sätze einer laufenden Session; ErrNotFound,
+	// wenn sie nicht zum Benutzer gehört, ErrConflict, wenn sie abgeschlossen ist
+	Update(ctx context.Context, userID int64, w WorkoutSession) error
+	// Finish schließt eine laufende Session ab und speichert in derselben
+	// Transaktion die neuen Rekorde (siehe NewRecords), die es zurückgibt
+	Finish(ctx context.Context, userID, id int64, at time.Time) ([]PersonalRecord, error)
+	// Records liefert den aktuellen Bestwert je Übung und Art, nach Übung sortiert
+	Records(ctx context.Context, userID int64) ([]PersonalRecord, error)
+	// RecordHistory liefert alle Verbesserungen, älteste zuerst
+	RecordHistory(ctx context.Context, userID int64) ([]PersonalRecord, error)
+}
+
+// DayPeriods ist die Sortierreihenfolge der Tageszeiten innerhalb eines Tages
+var DayPeriods = []string{"morning", "noon", "afternoon", "evening", "anytime"}
@@ -0,0 +1,290 @@
+package storetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+// saveWeek speichert einen Plan mit zwei Aufgaben und liefert die
+// Einplanung der ersten
+func saveWeek(t *testing.T, st *store.Store, userID int64, week string) int64 {
+	t.Helper()
+	must(t, st.Plans.SaveWeek(ctx(), userID, week, []store.ScheduledTask{
+		{Task: store.Task{Title: "Laufen", Duration: 30}, Weekday: 3, DayPeriod: "evening"},
+		{Task: store.Task{Title: "Krafttraining", Duration: 45}, Weekday: 1, DayPeriod: "morning"},
+	}, time.Time{}))
+	list, err := st.Plans.Week(ctx(), userID, week)
+	must(t, err)
+	if len(list) == 0 {
+		t.Fatal("SaveWeek hat nichts gespeichert")
+	}
+	return list[0].ScheduleID
+}
+
+func testPlans(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+
+	workout := []store.WorkoutBlock{
+		{Kind: store.WorkoutSets, ExerciseName: "Kniebeuge", Sets: 3, Reps: 10, WeightKG: 40, RestSeconds: 90, Notes: "langsam"},
+		{Kind: store.WorkoutInterval, ExerciseName: "Burpees", Rounds: 8, WorkSeconds: 20, RestSeconds: 10},
+	}
+	must(t, st.Plans.SaveWeek(ctx(), anna, "2026-03-02", []store.ScheduledTask{
+		{Task: store.Task{Title: "Abendlauf", Description: "locker", Duration: 30}, Weekday: 1, DayPeriod: "evening"},
+		{Task: store.Task{Title: "Sonntag", Duration: 20}, Weekday: 0, DayPeriod: "anytime"},
+		{Task: store.Task{Title: "Kraft", Duration: 45, Workout: workout}, Weekday: 1, DayPeriod: "morning"},
+	}, time.Time{}))
+	saveWeek(t, st, anna, "2026-02-23")
+
+	week, err := st.Plans.Week(ctx(), anna, "2026-03-02")
+	must(t, err)
+	var titles []string
+	for _, e := range week {
+		titles = append(titles, e.Task.Title)
+		if e.UserID != anna || e.WeekStartDate != "2026-03-02" || e.FeedbackOption != "none" {
+			t.Errorf("Eintrag %q = %+v", e.Task.Title, e)
+		}
+	}
+	if !slices.Equal(titles, []string{"Sonntag", "Kraft", "Abendlauf"}) {
+		t.Errorf("Week-Reihenfolge = %v", titles)
+	}
+	kraft := week[1]
+	if len(kraft.Task.Workout) != 2 {
+		t.Fatalf("Workout = %+v", kraft.Task.Workout)
+	}
+	for i, b := range kraft.Task.Workout {
+		b.ID = 0
+		if b != workout[i] {
+			t.Errorf("Block %d = %+v, want %+v", i, b, workout[i])
+		}
+	}
+	if kraft.Task.Workout[0].ID == 0 {
+		t.Error("Block ohne ID")
+	}
+
+	if ok, err := st.Plans.HasWeek(ctx(), anna, "2026-03-02"); err != nil || !ok {
+		t.Errorf("HasWeek = %v, %v", ok, err)
+	}
+	if ok, err := st.Plans.HasWeek(ctx(), bob, "2026-03-02"); err != nil || ok {
+		t.Errorf("HasWeek(bob) = %v, %v", ok, err)
+	}
+	if n, err := st.Plans.Count(ctx(), anna); err != nil || n != 5 {
+		t.Errorf("Count = %d, %v", n, err)
+	}
+	history, err := st.Plans.History(ctx(), anna)
+	must(t, err)
+	if len(history) != 5 || history[0].WeekStartDate != "2026-02-23" || history[4].Task.Title != "Abendlauf" {
+		t.Errorf("History = %+v", history)
+	}
+
+	id := kraft.ScheduleID
+	wantErr(t, "SetFeedback eines fremden Eintrags", st.Plans.SetFeedback(ctx(), bob, id, "too_hard", "x"), store.ErrNotFound)
+	must(t, st.Plans.SetFeedback(ctx(), anna, id, "too_hard", "zu schwer"))
+	_, err = st.Plans.Entry(ctx(), bob, id)
+	wantErr(t, "Entry eines fremden Eintrags", err, store.ErrNotFound)
+	entry, err := st.Plans.Entry(ctx(), anna, id)
+	must(t, err)
+	if entry.Feedback != "zu schwer" || entry.FeedbackOption != "too_hard" || len(entry.Task.Workout) != 2 {
+		t.Errorf("Entry = %+v", entry)
+	}
+
+	entry.Task.Title = "Kraft kurz"
+	entry.Task.Workout = entry.Task.Workout[:1]
+	entry.Weekday, entry.DayPeriod = 4, "noon"
+	wantErr(t, "UpdateEntry eines fremden Eintrags", st.Plans.UpdateEntry(ctx(), bob, *entry), store.ErrNotFound)
+	must(t, st.Plans.UpdateEntry(ctx(), anna, *entry))
+	entry, err = st.Plans.Entry(ctx(), anna, id)
+	must(t, err)
+	if entry.Task.Title != "Kraft kurz" || entry.Weekday != 4 || entry.DayPeriod != "noon" || len(entry.Task.Workout) != 1 {
+		t.Errorf("Entry nach UpdateEntry = %+v", entry)
+	}
+	if task, err := st.Tasks.ByID(ctx(), entry.Task.ID); err != nil || task.Title != "Kraft kurz" {
+		t.Errorf("Tasks.ByID nach UpdateEntry = %+v, %v", task, err)
+	}
+
+	wantErr(t, "DeleteEntry eines fremden Eintrags", st.Plans.DeleteEntry(ctx(), bob, id), store.ErrNotFound)
+	must(t, st.Plans.DeleteEntry(ctx(), anna, id))
+	_, err = st.Plans.Entry(ctx(), anna, id)
+	wantErr(t, "Entry nach DeleteEntry", err, store.ErrNotFound)
+	_, err = st.Tasks.ByID(ctx(), entry.Task.ID)
+	wantErr(t, "Aufgabe nach DeleteEntry", err, store.ErrNotFound)
+
+	must(t, st.Plans.ReplaceWeek(ctx(), anna, "2026-03-02", []store.ScheduledTask{
+		{Task: store.Task{Title: "Neu", Duration: 10}, Weekday: 2, DayPeriod: "noon"},
+	}, time.Time{}))
+	week, err = st.Plans.Week(ctx(), anna, "2026-03-02")
+	must(t, err)
+	if len(week) != 1 || week[0].Task.Title != "Neu" || week[0].Feedback != "" {
+		t.Errorf("Week nach ReplaceWeek = %+v", week)
+	}
+	if n, _ := st.Plans.Count(ctx(), anna); n != 3 {
+		t.Errorf("Count nach ReplaceWeek = %d, want 3", n)
+	}
+}
+
+func testApprovals(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	coach := createUser(t, st, "coach")
+	tasks := []store.ScheduledTask{{Task: store.Task{Title: "Laufen"}, Weekday: 1, DayPeriod: "morning"}}
+
+	saveWeek(t, st, anna, "2026-02-23")
+	_, err := st.Plans.Approval(ctx(), anna, "2026-02-23")
+	wantErr(t, "Approval ohne Freigabe", err, store.ErrNotFound)
+
+	must(t, st.Plans.SaveWeek(ctx(), anna, "2026-03-02", tasks, now))
+	a, err := st.Plans.Approval(ctx(), anna, "2026-03-02")
+	must(t, err)
+	if !a.Pending() || a.ApprovedBy != 0 {
+		t.Errorf("Approval nach SaveWeek = %+v", a)
+	}
+	sameTime(t, "RequestedAt", a.RequestedAt, now)
+
+	must(t, st.Plans.Approve(ctx(), anna, "2026-03-02", coach, now.Add(time.Hour)))
+	wantErr(t, "zweites Approve", st.Plans.Approve(ctx(), anna, "2026-03-02", coach, now), store.ErrNotFound)
+	a, err = st.Plans.Approval(ctx(), anna, "2026-03-02")
+	must(t, err)
+	if a.Pending() || a.ApprovedBy != coach {
+		t.Errorf("Approval nach Approve = %+v", a)
+	}
+
+	// Ein neu generierter Plan muss wieder freigegeben werden
+	must(t, st.Plans.ReplaceWeek(ctx(), anna, "2026-03-02", tasks, now.Add(2*time.Hour)))
+	a, err = st.Plans.Approval(ctx(), anna, "2026-03-02")
+	must(t, err)
+	if !a.Pending() {
+		t.Errorf("Approval nach ReplaceWeek = %+v", a)
+	}
+	sameTime(t, "RequestedAt nach ReplaceWeek", a.RequestedAt, now.Add(2*time.Hour))
+
+	must(t, st.Plans.SaveWeek(ctx(), anna, "2026-03-09", tasks, now))
+	must(t, st.Plans.Approve(ctx(), anna, "2026-03-09", coach, now))
+	must(t, st.Plans.ReleaseApprovals(ctx(), anna))
+	_, err = st.Plans.Approval(ctx(), anna, "2026-03-02")
+	wantErr(t, "ausstehende Freigabe nach ReleaseApprovals", err, store.ErrNotFound)
+	if _, err := st.Plans.Approval(ctx(), anna, "2026-03-09"); err != nil {
+		t.Errorf("erteilte Freigabe nach ReleaseApprovals: %v", err)
+	}
+}
+
+func testCoaches(t *testing.T, st *store.Store) {
+	coach := createUser(t, st, "coach")
+	zoe := createUser(t, st, "zoe")
+	anna := createUser(t, st, "anna")
+	must(t, st.Roles.Set(ctx(), coach, []string{store.RoleCoach}))
+
+	_, err := st.Coaches.Invite(ctx(), coach, zoe, now)
+	must(t, err)
+	_, err = st.Coaches.Invite(ctx(), coach, anna, now)
+	must(t, err)
+	_, err = st.Coaches.Invite(ctx(), coach, anna, now)
+	wantErr(t, "zweite Einladung", err, store.ErrConflict)
+
+	if ok, err := st.Coaches.IsActive(ctx(), coach, anna); err != nil || ok {
+		t.Errorf("IsActive vor Annahme = %v, %v", ok, err)
+	}
+	wantErr(t, "Accept ohne Einladung", st.Coaches.Accept(ctx(), anna, coach, now), store.ErrNotFound)
+	must(t, st.Coaches.Accept(ctx(), coach, anna, now.Add(time.Hour)))
+	wantErr(t, "zweites Accept", st.Coaches.Accept(ctx(), coach, anna, now), store.ErrNotFound)
+
+	if ok, err := st.Coaches.IsActive(ctx(), coach, anna); err != nil || !ok {
+		t.Errorf("IsActive = %v, %v", ok, err)
+	}
+	if ok, err := st.Coaches.HasActiveCoach(ctx(), anna); err != nil || !ok {
+		t.Errorf("HasActiveCoach(anna) = %v, %v", ok, err)
+	}
+	if ok, err := st.Coaches.HasActiveCoach(ctx(), zoe); err != nil || ok {
+		t.Errorf("HasActiveCoach(zoe) = %v, %v", ok, err)
+	}
+
+	links, err := st.Coaches.ListByCoach(ctx(), coach)
+	must(t, err)
+	if len(links) != 2 || links[0].ClientName != "anna" || links[1].ClientName != "zoe" {
+		t.Fatalf("ListByCoach = %+v", links)
+	}
+	if links[0].Status != store.CoachActive || links[1].Status != store.CoachPending || links[0].CoachName != "coach" {
+		t.Errorf("ListByCoach = %+v", links)
+	}
+	sameTime(t, "AcceptedAt", links[0].AcceptedAt, now.Add(time.Hour))
+	if !links[1].AcceptedAt.IsZero() {
+		t.Errorf("offene Einladung mit AcceptedAt %v", links[1].AcceptedAt)
+	}
+
+	links, err = st.Coaches.ListByClient(ctx(), anna)
+	must(t, err)
+	if len(links) != 1 || links[0].CoachID != coach || links[0].ClientID != anna {
+		t.Errorf("ListByClient = %+v", links)
+	}
+
+	// Nur Coaches, die noch betreuen dürfen, zählen als aktiv
+	active := func(what string, want bool) {
+		t.Helper()
+		ok, err := st.Coaches.IsActive(ctx(), coach, anna)
+		must(t, err)
+		has, err := st.Coaches.HasActiveCoach(ctx(), anna)
+		must(t, err)
+		if ok != want || has != want {
+			t.Errorf("%s: IsActive = %v, HasActiveCoach = %v, want %v", what, ok, has, want)
+		}
+	}
+	must(t, st.Users.SetDisabled(ctx(), coach, now))
+	active("deaktivierter Coach", false)
+	must(t, st.Users.SetDisabled(ctx(), coach, time.Time{}))
+	must(t, st.Users.SetDeleted(ctx(), coach, now))
+	active("gelöschter Coach", false)
+	must(t, st.Users.SetDeleted(ctx(), coach, time.Time{}))
+	must(t, st.Roles.Set(ctx(), coach, nil))
+	active("Coach ohne Rolle", false)
+	must(t, st.Roles.Set(ctx(), coach, []string{store.RoleCoach}))
+	active("Coach mit Rolle", true)
+
+	must(t, st.Coaches.Delete(ctx(), coach, anna))
+	wantErr(t, "zweites Delete", st.Coaches.Delete(ctx(), coach, anna), store.ErrNotFound)
+	if ok, _ := st.Coaches.HasActiveCoach(ctx(), anna); ok {
+		t.Error("HasActiveCoach nach Delete")
+	}
+}
+
+func testCoachDelete(t *testing.T, st *store.Store) {
+	coach := createUser(t, st, "coach")
+	other := createUser(t, st, "other")
+	anna := createUser(t, st, "anna")
+	zoe := createUser(t, st, "zoe")
+	tasks := []store.ScheduledTask{{Task: store.Task{Title: "Laufen"}, Weekday: 1, DayPeriod: "morning"}}
+	for _, id := range []int64{coach, other} {
+		must(t, st.Roles.Set(ctx(), id, []string{store.RoleCoach}))
+	}
+	link := func(coachID, clientID int64) {
+		t.Helper()
+		_, err := st.Coaches.Invite(ctx(), coachID, clientID, now)
+		must(t, err)
+		must(t, st.Coaches.Accept(ctx(), coachID, clientID, now))
+	}
+	link(coach, anna)
+	link(coach, zoe)
+	link(other, zoe)
+
+	must(t, st.Plans.SaveWeek(ctx(), anna, "2026-03-02", tasks, now))
+	must(t, st.Plans.SaveWeek(ctx(), anna, "2026-03-09", tasks, now))
+	must(t, st.Plans.Approve(ctx(), anna, "2026-03-09", coach, now))
+	must(t, st.Plans.SaveWeek(ctx(), zoe, "2026-03-02", tasks, now))
+
+	must(t, st.Users.Delete(ctx(), coach))
+
+	// anna hat keinen Coach mehr, ihre offene Woche wird sichtbar
+	_, err := st.Plans.Approval(ctx(), anna, "2026-03-02")
+	wantErr(t, "offene Freigabe ohne Coach", err, store.ErrNotFound)
+	a, err := st.Plans.Approval(ctx(), anna, "2026-03-09")
+	must(t, err)
+	if a.Pending() || a.ApprovedBy != 0 {
+		t.Errorf("erteilte Freigabe nach Löschen des Coaches = %+v", a)
+	}
+	// zoe wird weiter betreut und wartet auf den anderen Coach
+	a, err = st.Plans.Approval(ctx(), zoe, "2026-03-02")
+	must(t, err)
+	if !a.Pending() {
+		t.Errorf("Freigabe von zoe = %+v", a)
+	}
+}
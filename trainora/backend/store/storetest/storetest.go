@@ -0,0 +1,86 @@
+// Package storetest prüft Implementierungen der Interfaces aus store gegen
+// dasselbe Verhalten. store/sqlstore und store/memory rufen Run aus ihren
+// Tests auf, damit die In-Memory-Variante nicht von der Datenbank abweicht.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+// Run führt alle Prüfungen aus. newStore liefert für jeden Testfall einen
+// leeren Store.
+func Run(t *testing.T, newStore func(t *testing.T) *store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, st *store.Store)
+	}{
+		{"Users", testUsers},
+		{"UserDelete", testUserDelete},
+		{"Roles", testRoles},
+		{"LoginAttempts", testLoginAttempts},
+		{"Consents", testConsents},
+		{"RememberTokens", testRememberTokens},
+		{"EmailTokens", testEmailTokens},
+		{"PersonalTokens", testPersonalTokens},
+		{"RefreshTokens", testRefreshTokens},
+		{"Plans", testPlans},
+		{"Approvals", testApprovals},
+		{"Coaches", testCoaches},
+		{"CoachDelete", testCoachDelete},
+		{"Exercises", testExercises},
+		{"WorkoutSessions", testWorkoutSessions},
+		{"PersonalRecords", testPersonalRecords},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// now ist ein fester Zeitpunkt in ganzen Sekunden, den MySQL, SQLite und
+// der Arbeitsspeicher gleich zurückliefern
+var now = time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+func ctx() context.Context {
+	return context.Background()
+}
+
+// createUser legt einen Benutzer mit E-Mail name@example.org an
+func createUser(t *testing.T, st *store.Store, name string) int64 {
+	t.Helper()
+	id, err := st.Users.Create(ctx(), name, name+"@example.org", "hash-"+name)
+	if err != nil {
+		t.Fatalf("Users.Create(%q): %v", name, err)
+	}
+	return id
+}
+
+// must bricht den Test ab, wenn err nicht nil ist
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantErr prüft, dass err target ist
+func wantErr(t *testing.T, what string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: err = %v, want %v", what, err, target)
+	}
+}
+
+// sameTime vergleicht Zeitpunkte unabhängig von der Zeitzone
+func sameTime(t *testing.T, what string, got, want time.Time) {
+	t.Helper()
+	if !got.Equal(want) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package storetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+func testRememberTokens(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+
+	token := func(userID int64, selector string, lastUsed time.Time, expires time.Duration) int64 {
+		t.Helper()
+		id, err := st.RememberTokens.Create(ctx(), store.RememberToken{
+			UserID: userID, Selector: selector, ValidatorHash: "hash-" + selector, Device: "Firefox",
+			CreatedAt: now, LastUsedAt: lastUsed, ExpiresAt: now.Add(expires),
+		})
+		must(t, err)
+		return id
+	}
+	laptop := token(anna, "laptop", now, time.Hour)
+	phone := token(anna, "phone", now.Add(time.Minute), time.Hour)
+	token(anna, "old", now, -time.Hour)
+	token(bob, "bob", now, time.Hour)
+
+	_, err := st.RememberTokens.Create(ctx(), store.RememberToken{UserID: bob, Selector: "laptop", ExpiresAt: now})
+	wantErr(t, "Create mit vergebenem Selector", err, store.ErrConflict)
+
+	got, err := st.RememberTokens.BySelector(ctx(), "laptop")
+	must(t, err)
+	if got.ID != laptop || got.UserID != anna || got.ValidatorHash != "hash-laptop" || got.Device != "Firefox" ||
+		got.PreviousValidatorHash != "" || !got.RotatedAt.IsZero() {
+		t.Errorf("BySelector = %+v", got)
+	}
+	_, err = st.RememberTokens.BySelector(ctx(), "unknown")
+	wantErr(t, "BySelector unbekannt", err, store.ErrNotFound)
+	if got, err := st.RememberTokens.ByID(ctx(), anna, laptop); err != nil || got.Selector != "laptop" {
+		t.Errorf("ByID = %+v, %v", got, err)
+	}
+	_, err = st.RememberTokens.ByID(ctx(), bob, laptop)
+	wantErr(t, "ByID eines fremden Tokens", err, store.ErrNotFound)
+
+	rotated := now.Add(2 * time.Minute)
+	must(t, st.RememberTokens.Rotate(ctx(), laptop, "hash-laptop", "hash-new", rotated, rotated.Add(time.Hour)))
+	wantErr(t, "Rotate mit altem Validator",
+		st.RememberTokens.Rotate(ctx(), laptop, "hash-laptop", "hash-other", rotated, rotated.Add(time.Hour)), store.ErrConflict)
+	got, err = st.RememberTokens.BySelector(ctx(), "laptop")
+	must(t, err)
+	if got.ValidatorHash != "hash-new" || got.PreviousValidatorHash != "hash-laptop" {
+		t.Errorf("nach Rotate = %+v", got)
+	}
+	sameTime(t, "RotatedAt", got.RotatedAt, rotated)
+	sameTime(t, "LastUsedAt", got.LastUsedAt, rotated)
+	sameTime(t, "ExpiresAt", got.ExpiresAt, rotated.Add(time.Hour))
+
+	list, err := st.RememberTokens.ListByUser(ctx(), anna, now)
+	must(t, err)
+	if len(list) != 2 || list[0].ID != laptop || list[1].ID != phone {
+		t.Errorf("ListByUser = %+v, want laptop, phone", list)
+	}
+
+	wantErr(t, "Delete eines fremden Tokens", st.RememberTokens.Delete(ctx(), bob, phone), store.ErrNotFound)
+	must(t, st.RememberTokens.Delete(ctx(), anna, phone))
+	_, err = st.RememberTokens.BySelector(ctx(), "phone")
+	wantErr(t, "BySelector nach Delete", err, store.ErrNotFound)
+
+	must(t, st.RememberTokens.DeleteExpired(ctx(), now))
+	_, err = st.RememberTokens.BySelector(ctx(), "old")
+	wantErr(t, "BySelector nach DeleteExpired", err, store.ErrNotFound)
+
+	must(t, st.RememberTokens.DeleteByUser(ctx(), anna))
+	_, err = st.RememberTokens.BySelector(ctx(), "laptop")
+	wantErr(t, "BySelector nach DeleteByUser", err, store.ErrNotFound)
+	if _, err := st.RememberTokens.BySelector(ctx(), "bob"); err != nil {
+		t.Errorf("Token eines anderen Benutzers nach DeleteByUser: %v", err)
+	}
+}
+
+func testEmailTokens(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+
+	create := func(nonce, purpose string, expires time.Duration) {
+		t.Helper()
+		must(t, st.EmailTokens.Create(ctx(), store.EmailToken{UserID: anna, Purpose: purpose, NonceHash: nonce, ExpiresAt: now.Add(expires)}))
+	}
+	create("verify", store.TokenVerifyEmail, time.Hour)
+	create("expired", store.TokenVerifyEmail, -time.Hour)
+	create("reset-1", store.TokenPasswordReset, time.Hour)
+	create("reset-2", store.TokenPasswordReset, time.Hour)
+
+	_, err := st.EmailTokens.Consume(ctx(), "verify", store.TokenPasswordReset, now)
+	wantErr(t, "Consume mit falschem Zweck", err, store.ErrNotFound)
+	id, err := st.EmailTokens.Consume(ctx(), "verify", store.TokenVerifyEmail, now)
+	if err != nil || id != anna {
+		t.Errorf("Consume = %d, %v", id, err)
+	}
+	_, err = st.EmailTokens.Consume(ctx(), "verify", store.TokenVerifyEmail, now)
+	wantErr(t, "zweites Consume", err, store.ErrNotFound)
+	_, err = st.EmailTokens.Consume(ctx(), "expired", store.TokenVerifyEmail, now)
+	wantErr(t, "Consume abgelaufen", err, store.ErrNotFound)
+
+	must(t, st.EmailTokens.DeleteByUser(ctx(), anna, store.TokenPasswordReset))
+	_, err = st.EmailTokens.Consume(ctx(), "reset-2", store.TokenPasswordReset, now)
+	wantErr(t, "Consume nach DeleteByUser", err, store.ErrNotFound)
+}
+
+func testPersonalTokens(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+
+	first, err := st.PersonalTokens.Create(ctx(), store.PersonalToken{
+		UserID: anna, Name: "Skript", TokenHash: "hash-1", Scopes: []string{"read:profile", "read:plans"}, CreatedAt: now,
+	})
+	must(t, err)
+	second, err := st.PersonalTokens.Create(ctx(), store.PersonalToken{
+		UserID: anna, Name: "Uhr", TokenHash: "hash-2", Scopes: []string{"read:plans"}, CreatedAt: now.Add(time.Minute), ExpiresAt: now.Add(time.Hour),
+	})
+	must(t, err)
+
+	got, err := st.PersonalTokens.ByHash(ctx(), "hash-1")
+	must(t, err)
+	if got.ID != first || got.UserID != anna || got.Name != "Skript" ||
+		!slices.Equal(got.Scopes, []string{"read:profile", "read:plans"}) ||
+		!got.LastUsedAt.IsZero() || !got.ExpiresAt.IsZero() {
+		t.Errorf("ByHash = %+v", got)
+	}
+	_, err = st.PersonalTokens.ByHash(ctx(), "unknown")
+	wantErr(t, "ByHash unbekannt", err, store.ErrNotFound)
+
+	must(t, st.PersonalTokens.Touch(ctx(), first, now.Add(time.Hour)))
+	got, err = st.PersonalTokens.ByHash(ctx(), "hash-1")
+	must(t, err)
+	sameTime(t, "LastUsedAt", got.LastUsedAt, now.Add(time.Hour))
+
+	list, err := st.PersonalTokens.ListByUser(ctx(), anna)
+	must(t, err)
+	if len(list) != 2 || list[0].ID != second || list[1].ID != first {
+		t.Errorf("ListByUser = %+v, want neueste zuerst", list)
+	}
+	sameTime(t, "ExpiresAt", list[0].ExpiresAt, now.Add(time.Hour))
+
+	wantErr(t, "Delete eines fremden Tokens", st.PersonalTokens.Delete(ctx(), bob, first), store.ErrNotFound)
+	must(t, st.PersonalTokens.Delete(ctx(), anna, first))
+	_, err = st.PersonalTokens.ByHash(ctx(), "hash-1")
+	wantErr(t, "ByHash nach Delete", err, store.ErrNotFound)
+}
+
+func testRefreshTokens(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+
+	create := func(hash, family string, expires time.Duration) int64 {
+		t.Helper()
+		id, err := st.RefreshTokens.Create(ctx(), store.RefreshToken{
+			UserID: anna, TokenHash: hash, Family: family, Scopes: []string{"read:plans"},
+			CreatedAt: now, ExpiresAt: now.Add(expires),
+		})
+		must(t, err)
+		return id
+	}
+	first := create("hash-1", "family-a", time.Hour)
+	create("hash-2", "family-a", time.Hour)
+	create("hash-3", "family-b", time.Hour)
+	create("hash-4", "family-c", -time.Hour)
+
+	got, err := st.RefreshTokens.ByHash(ctx(), "hash-1")
+	must(t, err)
+	if got.ID != first || got.Family != "family-a" || !slices.Equal(got.Scopes, []string{"read:plans"}) || !got.UsedAt.IsZero() {
+		t.Errorf("ByHash = %+v", got)
+	}
+
+	ok, err := st.RefreshTokens.Use(ctx(), first, now)
+	if err != nil || !ok {
+		t.Errorf("erstes Use = %v, %v", ok, err)
+	}
+	ok, err = st.RefreshTokens.Use(ctx(), first, now)
+	if err != nil || ok {
+		t.Errorf("zweites Use = %v, %v", ok, err)
+	}
+	got, err = st.RefreshTokens.ByHash(ctx(), "hash-1")
+	must(t, err)
+	sameTime(t, "UsedAt", got.UsedAt, now)
+
+	must(t, st.RefreshTokens.DeleteFamily(ctx(), "family-a"))
+	_, err = st.RefreshTokens.ByHash(ctx(), "hash-2")
+	wantErr(t, "ByHash nach DeleteFamily", err, store.ErrNotFound)
+
+	must(t, st.RefreshTokens.DeleteExpired(ctx(), now))
+	_, err = st.RefreshTokens.ByHash(ctx(), "hash-4")
+	wantErr(t, "ByHash nach DeleteExpired", err, store.ErrNotFound)
+
+	must(t, st.RefreshTokens.DeleteByUser(ctx(), anna))
+	_, err = st.RefreshTokens.ByHash(ctx(), "hash-3")
+	wantErr(t, "ByHash nach DeleteByUser", err, store.ErrNotFound)
+}
@@ -0,0 +1,263 @@
+package storetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+func testUsers(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+
+	_, err := st.Users.Create(ctx(), "anna", "other@example.org", "hash")
+	wantErr(t, "Create mit vergebenem Benutzernamen", err, store.ErrConflict)
+	_, err = st.Users.Create(ctx(), "other", "anna@example.org", "hash")
+	wantErr(t, "Create mit vergebener E-Mail", err, store.ErrConflict)
+
+	u, err := st.Users.ByID(ctx(), anna)
+	must(t, err)
+	if u.Username != "anna" || u.Email != "anna@example.org" || u.PasswordHash != "hash-anna" {
+		t.Errorf("ByID = %+v", u)
+	}
+	if u.SetupCompleted || !u.EmailVerifiedAt.IsZero() || u.Disabled() || u.Deleted() {
+		t.Errorf("neuer Benutzer hat Zustand: %+v", u)
+	}
+	_, err = st.Users.ByID(ctx(), bob+100)
+	wantErr(t, "ByID eines unbekannten Benutzers", err, store.ErrNotFound)
+
+	for _, login := range []string{"bob", "bob@example.org"} {
+		u, err := st.Users.ByLogin(ctx(), login)
+		if err != nil || u.ID != bob {
+			t.Errorf("ByLogin(%q) = %v, %v", login, u, err)
+		}
+	}
+	_, err = st.Users.ByLogin(ctx(), "carla")
+	wantErr(t, "ByLogin eines unbekannten Benutzers", err, store.ErrNotFound)
+
+	if ok, err := st.Users.EmailExists(ctx(), "anna@example.org"); err != nil || !ok {
+		t.Errorf("EmailExists = %v, %v", ok, err)
+	}
+	if ok, err := st.Users.UsernameExists(ctx(), "carla"); err != nil || ok {
+		t.Errorf("UsernameExists(carla) = %v, %v", ok, err)
+	}
+
+	must(t, st.Users.SetPasswordHash(ctx(), anna, "new-hash", now))
+	must(t, st.Users.MarkEmailVerified(ctx(), anna, now))
+	must(t, st.Users.SetLoginFailures(ctx(), anna, 3, now.Add(time.Minute)))
+	u, err = st.Users.ByID(ctx(), anna)
+	must(t, err)
+	if u.PasswordHash != "new-hash" || u.FailedLogins != 3 {
+		t.Errorf("nach Änderungen: %+v", u)
+	}
+	sameTime(t, "PasswordChangedAt", u.PasswordChangedAt, now)
+	sameTime(t, "EmailVerifiedAt", u.EmailVerifiedAt, now)
+	sameTime(t, "LockedUntil", u.LockedUntil, now.Add(time.Minute))
+
+	must(t, st.Users.SetLoginFailures(ctx(), anna, 0, time.Time{}))
+	must(t, st.Users.SetDisabled(ctx(), anna, now))
+	must(t, st.Users.SetDeleted(ctx(), bob, now))
+	u, err = st.Users.ByID(ctx(), anna)
+	must(t, err)
+	if !u.LockedUntil.IsZero() || !u.Disabled() {
+		t.Errorf("Sperre aufgehoben und deaktiviert erwartet: %+v", u)
+	}
+	ids, err := st.Users.DeletedBefore(ctx(), now.Add(time.Second))
+	must(t, err)
+	if !slices.Equal(ids, []int64{bob}) {
+		t.Errorf("DeletedBefore = %v, want [%d]", ids, bob)
+	}
+	ids, err = st.Users.DeletedBefore(ctx(), now)
+	must(t, err)
+	if len(ids) != 0 {
+		t.Errorf("DeletedBefore(now) = %v, want []", ids)
+	}
+	must(t, st.Users.SetDeleted(ctx(), bob, time.Time{}))
+	if u, _ := st.Users.ByID(ctx(), bob); u.Deleted() {
+		t.Error("Konto nach Wiederherstellung noch gelöscht")
+	}
+
+	carla := createUser(t, st, "carla")
+	list, total, err := st.Users.List(ctx(), 1, 1)
+	must(t, err)
+	if total != 3 || len(list) != 1 || list[0].ID != bob {
+		t.Errorf("List(1, 1) = %v, %d", list, total)
+	}
+	list, _, err = st.Users.List(ctx(), 2, 10)
+	must(t, err)
+	if len(list) != 1 || list[0].ID != carla {
+		t.Errorf("List(2, 10) = %v", list)
+	}
+
+	p, err := st.Users.Profile(ctx(), anna)
+	must(t, err)
+	if p.Birthday == "" {
+		t.Error("neuer Benutzer ohne Standard-Geburtstag")
+	}
+	want := store.Profile{Birthday: "1990-05-17", HeightCM: 172, WeightKG: 68, ActivityLevel: "moderate", Goal: "Ausdauer", Allergies: "Nüsse"}
+	must(t, st.Users.SaveProfile(ctx(), anna, want))
+	p, err = st.Users.Profile(ctx(), anna)
+	must(t, err)
+	if *p != want {
+		t.Errorf("Profile = %+v, want %+v", *p, want)
+	}
+	if u, _ := st.Users.ByID(ctx(), anna); !u.SetupCompleted {
+		t.Error("SaveProfile schließt das Setup nicht ab")
+	}
+}
+
+// testUserDelete prüft, dass mit einem Konto alle zugehörigen Daten
+// verschwinden, die anderer Benutzer aber bleiben
+func testUserDelete(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	coach := createUser(t, st, "coach")
+
+	_, err := st.RememberTokens.Create(ctx(), store.RememberToken{UserID: anna, Selector: "sel-anna", ValidatorHash: "v", CreatedAt: now, LastUsedAt: now, ExpiresAt: now.Add(time.Hour)})
+	must(t, err)
+	must(t, st.Consents.Give(ctx(), store.Consent{UserID: anna, Kind: "privacy", Version: 1, GivenAt: now}))
+	must(t, st.Roles.Set(ctx(), anna, []string{store.RoleCoach}))
+	_, err = st.Coaches.Invite(ctx(), coach, anna, now)
+	must(t, err)
+	scheduleID := saveWeek(t, st, anna, "2026-03-02")
+	exerciseID := createExercise(t, st, anna, "Kniebeuge")
+	sessionID := startSession(t, st, anna, scheduleID)
+	must(t, st.Workouts.Update(ctx(), anna, store.WorkoutSession{ID: sessionID, Sets: []store.WorkoutSet{
+		{ExerciseID: exerciseID, ExerciseName: "Kniebeuge", Reps: 5, WeightKG: 60},
+	}}))
+	_, err = st.Workouts.Finish(ctx(), anna, sessionID, now)
+	must(t, err)
+	keepID := saveWeek(t, st, coach, "2026-03-02")
+
+	must(t, st.Users.Delete(ctx(), anna))
+
+	_, err = st.Users.ByID(ctx(), anna)
+	wantErr(t, "ByID nach Delete", err, store.ErrNotFound)
+	_, err = st.RememberTokens.BySelector(ctx(), "sel-anna")
+	wantErr(t, "BySelector nach Delete", err, store.ErrNotFound)
+	if list, _ := st.Consents.History(ctx(), anna); len(list) != 0 {
+		t.Errorf("Einwilligungen nach Delete: %v", list)
+	}
+	if roles, _ := st.Roles.ByUser(ctx(), anna); len(roles) != 0 {
+		t.Errorf("Rollen nach Delete: %v", roles)
+	}
+	if links, _ := st.Coaches.ListByCoach(ctx(), coach); len(links) != 0 {
+		t.Errorf("Coach-Beziehungen nach Delete: %v", links)
+	}
+	if n, _ := st.Plans.Count(ctx(), anna); n != 0 {
+		t.Errorf("Plans.Count nach Delete = %d", n)
+	}
+	if list, _ := st.Workouts.History(ctx(), anna); len(list) != 0 {
+		t.Errorf("Sessions nach Delete: %v", list)
+	}
+	if list, _ := st.Workouts.RecordHistory(ctx(), anna); len(list) != 0 {
+		t.Errorf("Rekorde nach Delete: %v", list)
+	}
+	_, err = st.Exercises.ByID(ctx(), exerciseID)
+	wantErr(t, "eigene Übung nach Delete", err, store.ErrNotFound)
+
+	if _, err := st.Plans.Entry(ctx(), coach, keepID); err != nil {
+		t.Errorf("Plan eines anderen Benutzers nach Delete: %v", err)
+	}
+}
+
+func testRoles(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+
+	roles, err := st.Roles.ByUser(ctx(), anna)
+	must(t, err)
+	if len(roles) != 0 {
+		t.Errorf("neuer Benutzer hat Rollen %v", roles)
+	}
+
+	must(t, st.Roles.Set(ctx(), anna, []string{store.RoleUser, store.RoleCoach, store.RoleAdmin}))
+	roles, err = st.Roles.ByUser(ctx(), anna)
+	must(t, err)
+	if !slices.Equal(roles, []string{store.RoleAdmin, store.RoleCoach}) {
+		t.Errorf("ByUser = %v, want [admin coach]", roles)
+	}
+
+	must(t, st.Roles.Set(ctx(), anna, []string{store.RoleUser}))
+	roles, err = st.Roles.ByUser(ctx(), anna)
+	must(t, err)
+	if len(roles) != 0 {
+		t.Errorf("ByUser nach Entzug = %v", roles)
+	}
+}
+
+func testLoginAttempts(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+
+	attempts := []store.LoginAttempt{
+		{UserID: anna, Login: "anna", IP: "10.0.0.1", AttemptedAt: now.Add(-time.Hour)},
+		{UserID: anna, Login: "anna", IP: "10.0.0.1", AttemptedAt: now.Add(-2 * time.Minute)},
+		{UserID: anna, Login: "anna", IP: "10.0.0.2", AttemptedAt: now.Add(-time.Minute)},
+		{UserID: anna, Login: "anna", IP: "10.0.0.1", Success: true, AttemptedAt: now},
+		{Login: "niemand", IP: "10.0.0.1", AttemptedAt: now},
+	}
+	for _, a := range attempts {
+		must(t, st.LoginAttempts.Record(ctx(), a))
+	}
+
+	since := now.Add(-10 * time.Minute)
+	n, oldest, err := st.LoginAttempts.FailuresByIP(ctx(), "10.0.0.1", since)
+	must(t, err)
+	if n != 2 {
+		t.Errorf("FailuresByIP = %d, want 2", n)
+	}
+	sameTime(t, "ältester Fehlversuch der IP", oldest, now.Add(-2*time.Minute))
+
+	n, oldest, err = st.LoginAttempts.FailuresByUser(ctx(), anna, since)
+	must(t, err)
+	if n != 2 {
+		t.Errorf("FailuresByUser = %d, want 2", n)
+	}
+	sameTime(t, "ältester Fehlversuch des Kontos", oldest, now.Add(-2*time.Minute))
+
+	must(t, st.LoginAttempts.DeleteBefore(ctx(), now.Add(-90*time.Second)))
+	n, _, err = st.LoginAttempts.FailuresByUser(ctx(), anna, now.Add(-24*time.Hour))
+	must(t, err)
+	if n != 1 {
+		t.Errorf("FailuresByUser nach DeleteBefore = %d, want 1", n)
+	}
+}
+
+func testConsents(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+
+	must(t, st.Consents.Give(ctx(), store.Consent{UserID: anna, Kind: "privacy", Version: 1, IP: "10.0.0.1", GivenAt: now}))
+	must(t, st.Consents.Give(ctx(), store.Consent{UserID: anna, Kind: "privacy", Version: 2, GivenAt: now.Add(time.Hour)}))
+	must(t, st.Consents.Give(ctx(), store.Consent{UserID: anna, Kind: "health_data", Version: 1, GivenAt: now}))
+
+	active, err := st.Consents.Active(ctx(), anna)
+	must(t, err)
+	versions := map[string]int{}
+	for _, c := range active {
+		versions[c.Kind] = c.Version
+	}
+	if len(active) != 2 || versions["privacy"] != 2 || versions["health_data"] != 1 {
+		t.Errorf("Active = %+v", active)
+	}
+
+	must(t, st.Consents.Withdraw(ctx(), anna, "health_data", now.Add(2*time.Hour)))
+	wantErr(t, "zweiter Widerruf", st.Consents.Withdraw(ctx(), anna, "health_data", now), store.ErrNotFound)
+	active, err = st.Consents.Active(ctx(), anna)
+	must(t, err)
+	if len(active) != 1 || active[0].Kind != "privacy" {
+		t.Errorf("Active nach Widerruf = %+v", active)
+	}
+
+	history, err := st.Consents.History(ctx(), anna)
+	must(t, err)
+	if len(history) != 3 {
+		t.Fatalf("History = %+v", history)
+	}
+	if history[0].Version != 1 || history[0].Kind != "privacy" || history[0].IP != "10.0.0.1" {
+		t.Errorf("History[0] = %+v", history[0])
+	}
+	if history[1].Kind != "health_data" || history[2].Version != 2 || !history[2].WithdrawnAt.IsZero() {
+		t.Errorf("History nicht nach Erteilung sortiert: %+v", history)
+	}
+	sameTime(t, "WithdrawnAt", history[1].WithdrawnAt, now.Add(2*time.Hour))
+}
@@ -0,0 +1,307 @@
+package storetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"trainora/store"
+)
+
+// createExercise legt eine eigene Übung des Benutzers an
+func createExercise(t *testing.T, st *store.Store, userID int64, name string) int64 {
+	t.Helper()
+	id, err := st.Exercises.Create(ctx(), store.Exercise{
+		UserID: userID, Name: name, MuscleGroups: []string{"legs"}, Difficulty: "beginner",
+	})
+	must(t, err)
+	return id
+}
+
+// startSession startet eine Session zum Eintrag im Wochenplan
+func startSession(t *testing.T, st *store.Store, userID, scheduleID int64) int64 {
+	t.Helper()
+	id, err := st.Workouts.Start(ctx(), store.WorkoutSession{UserID: userID, ScheduleID: scheduleID, Title: "Krafttraining", StartedAt: now})
+	must(t, err)
+	return id
+}
+
+func testExercises(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+
+	must(t, st.Exercises.SyncCatalog(ctx(), []store.Exercise{
+		{Slug: "squat", Name: "Kniebeuge", MuscleGroups: []string{"legs", "glutes"}, Difficulty: "beginner"},
+		{Slug: "pushup", Name: "Liegestütz", MuscleGroups: []string{"chest"}, Equipment: nil, Difficulty: "beginner"},
+		{Slug: "deadlift", Name: "Kreuzheben", MuscleGroups: []string{"back"}, Equipment: []string{"barbell"}, Difficulty: "advanced"},
+	}))
+	own, err := st.Exercises.Create(ctx(), store.Exercise{
+		UserID: anna, Name: "Kniebeuge am Kasten", MuscleGroups: []string{"legs"}, Equipment: []string{"box"},
+		Difficulty: "beginner", Instructions: "Langsam absenken", Contraindications: []string{"Knieschmerzen"},
+	})
+	must(t, err)
+	createExercise(t, st, bob, "Bobs Übung")
+
+	names := func(list []store.Exercise) []string {
+		var out []string
+		for _, e := range list {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+	list, total, err := st.Exercises.Search(ctx(), anna, store.ExerciseFilter{Limit: 10})
+	must(t, err)
+	if total != 4 || !slices.Equal(names(list), []string{"Kniebeuge", "Kniebeuge am Kasten", "Kreuzheben", "Liegestütz"}) {
+		t.Errorf("Search = %v, %d", names(list), total)
+	}
+	list, total, err = st.Exercises.Search(ctx(), anna, store.ExerciseFilter{Query: "knie", Offset: 1, Limit: 10})
+	must(t, err)
+	if total != 2 || !slices.Equal(names(list), []string{"Kniebeuge am Kasten"}) {
+		t.Errorf("Search(knie, Offset 1) = %v, %d", names(list), total)
+	}
+	list, _, err = st.Exercises.Search(ctx(), anna, store.ExerciseFilter{MuscleGroup: "legs", Equipment: "box", Limit: 10})
+	must(t, err)
+	if !slices.Equal(names(list), []string{"Kniebeuge am Kasten"}) {
+		t.Errorf("Search(legs, box) = %v", names(list))
+	}
+	list, _, err = st.Exercises.Search(ctx(), anna, store.ExerciseFilter{Difficulty: "advanced", Limit: 10})
+	must(t, err)
+	if !slices.Equal(names(list), []string{"Kreuzheben"}) {
+		t.Errorf("Search(advanced) = %v", names(list))
+	}
+	list, _, err = st.Exercises.Search(ctx(), anna, store.ExerciseFilter{OwnOnly: true, Limit: 10})
+	must(t, err)
+	if !slices.Equal(names(list), []string{"Kniebeuge am Kasten"}) {
+		t.Errorf("Search(OwnOnly) = %v", names(list))
+	}
+
+	e, err := st.Exercises.ByID(ctx(), own)
+	must(t, err)
+	if !e.Custom() || e.Slug != "" || e.Instructions != "Langsam absenken" ||
+		!slices.Equal(e.Equipment, []string{"box"}) || !slices.Equal(e.Contraindications, []string{"Knieschmerzen"}) {
+		t.Errorf("ByID = %+v", e)
+	}
+
+	// Erneuter Abgleich aktualisiert anhand des Slugs, statt doppelt anzulegen
+	must(t, st.Exercises.SyncCatalog(ctx(), []store.Exercise{
+		{Slug: "squat", Name: "Kniebeuge", MuscleGroups: []string{"legs"}, Difficulty: "intermediate"},
+	}))
+	list, total, err = st.Exercises.Search(ctx(), bob, store.ExerciseFilter{Query: "Kniebeuge", Limit: 10})
+	must(t, err)
+	if total != 1 || list[0].Difficulty != "intermediate" || list[0].Custom() {
+		t.Errorf("Search nach erneutem SyncCatalog = %+v", list)
+	}
+	if _, total, _ := st.Exercises.Search(ctx(), bob, store.ExerciseFilter{Limit: 10}); total != 4 {
+		t.Errorf("SyncCatalog entfernt fehlende Übungen, total = %d", total)
+	}
+
+	catalogID := list[0].ID
+	wantErr(t, "Update einer Katalog-Übung",
+		st.Exercises.Update(ctx(), store.Exercise{ID: catalogID, UserID: anna, Name: "x"}), store.ErrNotFound)
+	wantErr(t, "Update einer fremden Übung",
+		st.Exercises.Update(ctx(), store.Exercise{ID: own, UserID: bob, Name: "x"}), store.ErrNotFound)
+	e.Name, e.Difficulty = "Box Squat", "intermediate"
+	must(t, st.Exercises.Update(ctx(), *e))
+	if got, _ := st.Exercises.ByID(ctx(), own); got.Name != "Box Squat" || got.Difficulty != "intermediate" {
+		t.Errorf("ByID nach Update = %+v", got)
+	}
+
+	if mine, err := st.Exercises.ListByUser(ctx(), anna); err != nil || len(mine) != 1 || mine[0].ID != own {
+		t.Errorf("ListByUser = %+v, %v", mine, err)
+	}
+
+	wantErr(t, "Delete einer Katalog-Übung", st.Exercises.Delete(ctx(), anna, catalogID), store.ErrNotFound)
+	wantErr(t, "Delete einer fremden Übung", st.Exercises.Delete(ctx(), bob, own), store.ErrNotFound)
+	must(t, st.Exercises.Delete(ctx(), anna, own))
+	_, err = st.Exercises.ByID(ctx(), own)
+	wantErr(t, "ByID nach Delete", err, store.ErrNotFound)
+}
+
+func testWorkoutSessions(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	bob := createUser(t, st, "bob")
+	squat := createExercise(t, st, anna, "Kniebeuge")
+	first := saveWeek(t, st, anna, "2026-02-23")
+	second := saveWeek(t, st, anna, "2026-03-02")
+
+	id := startSession(t, st, anna, first)
+	_, err := st.Workouts.Start(ctx(), store.WorkoutSession{UserID: anna, ScheduleID: first, Title: "nochmal", StartedAt: now})
+	wantErr(t, "zweiter Start zum Eintrag", err, store.ErrConflict)
+
+	_, err = st.Workouts.ByID(ctx(), bob, id)
+	wantErr(t, "ByID einer fremden Session", err, store.ErrNotFound)
+	_, err = st.Workouts.BySchedule(ctx(), anna, second)
+	wantErr(t, "BySchedule ohne Session", err, store.ErrNotFound)
+	w, err := st.Workouts.BySchedule(ctx(), anna, first)
+	must(t, err)
+	if w.ID != id || w.Title != "Krafttraining" || w.Finished() || len(w.Sets) != 0 {
+		t.Errorf("BySchedule = %+v", w)
+	}
+
+	sets := []store.WorkoutSet{
+		{ExerciseID: squat, ExerciseName: "Kniebeuge", Reps: 10, WeightKG: 40, RPE: 7.5},
+		{ExerciseName: "Seilspringen", Reps: 50, Notes: "frei benannt"},
+	}
+	wantErr(t, "Update einer fremden Session",
+		st.Workouts.Update(ctx(), bob, store.WorkoutSession{ID: id, Sets: sets}), store.ErrNotFound)
+	must(t, st.Workouts.Update(ctx(), anna, store.WorkoutSession{ID: id, Notes: "gut", Sets: sets}))
+	w, err = st.Workouts.ByID(ctx(), anna, id)
+	must(t, err)
+	if w.Notes != "gut" || len(w.Sets) != 2 {
+		t.Fatalf("ByID nach Update = %+v", w)
+	}
+	for i, s := range w.Sets {
+		if s.ID == 0 {
+			t.Errorf("Satz %d ohne ID", i)
+		}
+		s.ID = 0
+		if s != sets[i] {
+			t.Errorf("Satz %d = %+v, want %+v", i, s, sets[i])
+		}
+	}
+
+	_, err = st.Workouts.Finish(ctx(), bob, id, now)
+	wantErr(t, "Finish einer fremden Session", err, store.ErrNotFound)
+	_, err = st.Workouts.Finish(ctx(), anna, id, now.Add(time.Hour))
+	must(t, err)
+	_, err = st.Workouts.Finish(ctx(), anna, id, now)
+	wantErr(t, "zweites Finish", err, store.ErrConflict)
+	wantErr(t, "Update einer abgeschlossenen Session",
+		st.Workouts.Update(ctx(), anna, store.WorkoutSession{ID: id}), store.ErrConflict)
+
+	later := startSession(t, st, anna, second)
+	list, total, err := st.Workouts.List(ctx(), anna, 0, 10)
+	must(t, err)
+	if total != 2 || len(list) != 2 || list[0].ID != later || list[1].ID != id || len(list[1].Sets) != 0 {
+		t.Errorf("List = %+v, %d", list, total)
+	}
+	list, total, err = st.Workouts.List(ctx(), anna, 1, 10)
+	must(t, err)
+	if total != 2 || len(list) != 1 || list[0].ID != id {
+		t.Errorf("List(1, 10) = %+v, %d", list, total)
+	}
+
+	history, err := st.Workouts.History(ctx(), anna)
+	must(t, err)
+	if len(history) != 2 || history[0].ID != id || len(history[0].Sets) != 2 {
+		t.Errorf("History = %+v", history)
+	}
+
+	finished, err := st.Workouts.FinishedSince(ctx(), anna, now)
+	must(t, err)
+	if len(finished) != 1 || finished[0].ID != id || len(finished[0].Sets) != 2 {
+		t.Errorf("FinishedSince = %+v", finished)
+	}
+	sameTime(t, "FinishedAt", finished[0].FinishedAt, now.Add(time.Hour))
+	if finished, _ := st.Workouts.FinishedSince(ctx(), anna, now.Add(2*time.Hour)); len(finished) != 0 {
+		t.Errorf("FinishedSince nach dem Abschluss = %+v", finished)
+	}
+
+	// Ersetzte Einträge lösen die Session vom Wochenplan, sie bleibt erhalten
+	must(t, st.Plans.ReplaceWeek(ctx(), anna, "2026-02-23", nil, time.Time{}))
+	w, err = st.Workouts.ByID(ctx(), anna, id)
+	must(t, err)
+	if w.ScheduleID != 0 || w.Title != "Krafttraining" {
+		t.Errorf("Session nach ReplaceWeek = %+v", w)
+	}
+
+	// Gelöschte Übungen bleiben mit Namen in den Sätzen
+	must(t, st.Exercises.Delete(ctx(), anna, squat))
+	w, err = st.Workouts.ByID(ctx(), anna, id)
+	must(t, err)
+	if w.Sets[0].ExerciseID != 0 || w.Sets[0].ExerciseName != "Kniebeuge" {
+		t.Errorf("Satz nach Löschen der Übung = %+v", w.Sets[0])
+	}
+}
+
+func testPersonalRecords(t *testing.T, st *store.Store) {
+	anna := createUser(t, st, "anna")
+	squat := createExercise(t, st, anna, "Kniebeuge")
+	pullup := createExercise(t, st, anna, "Klimmzug")
+
+	session := func(week string, at time.Time, sets ...store.WorkoutSet) []store.PersonalRecord {
+		t.Helper()
+		id := startSession(t, st, anna, saveWeek(t, st, anna, week))
+		must(t, st.Workouts.Update(ctx(), anna, store.WorkoutSession{ID: id, Sets: sets}))
+		records, err := st.Workouts.Finish(ctx(), anna, id, at)
+		must(t, err)
+		return records
+	}
+	kinds := func(records []store.PersonalRecord) map[store.RecordKey]float64 {
+		out := map[store.RecordKey]float64{}
+		for _, r := range records {
+			out[store.RecordKey{ExerciseID: r.ExerciseID, Kind: r.Kind}] = r.Value
+		}
+		return out
+	}
+
+	records := session("2026-02-23", now,
+		store.WorkoutSet{ExerciseID: squat, ExerciseName: "Kniebeuge", Reps: 8, WeightKG: 25},
+		store.WorkoutSet{ExerciseID: pullup, ExerciseName: "Klimmzug", Reps: 6},
+		store.WorkoutSet{ExerciseName: "frei", Reps: 100, WeightKG: 500},
+	)
+	want := map[store.RecordKey]float64{
+		{ExerciseID: squat, Kind: store.RecordMaxWeight}:    25,
+		{ExerciseID: squat, Kind: store.RecordEstimated1RM}: 31.67,
+		{ExerciseID: pullup, Kind: store.RecordMaxReps}:     6,
+	}
+	if got := kinds(records); len(got) != len(want) || !mapsEqual(got, want) {
+		t.Errorf("erste Rekorde = %v, want %v", got, want)
+	}
+	for _, r := range records {
+		if r.ID == 0 || r.SessionID == 0 || r.UserID != anna {
+			t.Errorf("Rekord ohne IDs: %+v", r)
+		}
+	}
+
+	// Nur Verbesserungen zählen
+	records = session("2026-03-02", now.Add(24*time.Hour),
+		store.WorkoutSet{ExerciseID: squat, ExerciseName: "Kniebeuge", Reps: 1, WeightKG: 30},
+		store.WorkoutSet{ExerciseID: pullup, ExerciseName: "Klimmzug", Reps: 5},
+	)
+	want = map[store.RecordKey]float64{{ExerciseID: squat, Kind: store.RecordMaxWeight}: 30}
+	if got := kinds(records); !mapsEqual(got, want) {
+		t.Errorf("zweite Rekorde = %v, want %v", got, want)
+	}
+
+	current, err := st.Workouts.Records(ctx(), anna)
+	must(t, err)
+	want = map[store.RecordKey]float64{
+		{ExerciseID: squat, Kind: store.RecordMaxWeight}:    30,
+		{ExerciseID: squat, Kind: store.RecordEstimated1RM}: 31.67,
+		{ExerciseID: pullup, Kind: store.RecordMaxReps}:     6,
+	}
+	if got := kinds(current); len(current) != 3 || !mapsEqual(got, want) {
+		t.Errorf("Records = %+v", current)
+	}
+	if current[0].ExerciseName != "Klimmzug" || current[1].ExerciseName != "Kniebeuge" {
+		t.Errorf("Records nicht nach Übung sortiert: %+v", current)
+	}
+
+	history, err := st.Workouts.RecordHistory(ctx(), anna)
+	must(t, err)
+	if len(history) != 4 || history[3].Value != 30 || history[3].Kind != store.RecordMaxWeight {
+		t.Errorf("RecordHistory = %+v", history)
+	}
+	sameTime(t, "AchievedAt", history[3].AchievedAt, now.Add(24*time.Hour))
+
+	// Mit der Übung verschwinden ihre Rekorde
+	must(t, st.Exercises.Delete(ctx(), anna, squat))
+	current, err = st.Workouts.Records(ctx(), anna)
+	must(t, err)
+	if len(current) != 1 || current[0].ExerciseID != pullup {
+		t.Errorf("Records nach Löschen der Übung = %+v", current)
+	}
+}
+
+func mapsEqual(a, b map[store.RecordKey]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
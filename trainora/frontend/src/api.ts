@@ -0,0 +1,36 @@
+// CSRF-Schutz: Verändernde Anfragen brauchen den Token aus /api/csrf im
+// Header X-Csrf-Token. Er hängt an der Session und wird nach Login/Logout
+// oder abgelaufener Session neu geholt.
+let csrfToken: string | null = null;
+
+async function fetchCsrfToken(): Promise<string> {
+  const res = await fetch("/api/csrf", { credentials: "include" });
+  const data = await res.json();
+  csrfToken = data.csrf_token;
+  return csrfToken as string;
+}
+
+// apiFetch ist fetch mit Cookies und CSRF-Token. Lehnt das Backend den
+// Token ab, wird einmal mit frischem Token wiederholt.
+export async function apiFetch(url: string, init: RequestInit = {}): Promise<Response> {
+  const method = (init.method || "GET").toUpperCase();
+  if (method === "GET" || method === "HEAD") {
+    return fetch(url, { credentials: "include", ...init });
+  }
+
+  const send = (token: string) =>
+    fetch(url, {
+      credentials: "include",
+      ...init,
+      headers: { ...(init.headers as Record<string, string>), "X-Csrf-Token": token },
+    });
+
+  const res = await send(csrfToken ?? (await fetchCsrfToken()));
+  if (res.status === 403) {
+    const data = await res.clone().json().catch(() => ({}));
+    if (data.code === "csrf_invalid") {
+      return send(await fetchCsrfToken());
+    }
+  }
+  return res;
+}
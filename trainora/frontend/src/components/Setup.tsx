@@ -3,12 +3,16 @@ import { useRef } from "react";
 import { useNavigate } from "react-router-dom";
 import { motion, AnimatePresence } from "framer-motion";
 import "./css/Setup.css";
+import { apiFetch } from "../api";
 import calendarIcon from "../assets/calendar.svg";
 import heightIcon from "../assets/height.svg";
 import weightIcon from "../assets/weight.svg";
 import activityIcon from "../assets/activity.svg";
 import goalIcon from "../assets/goal.svg";
 import allergiesIcon from "../assets/allergies.svg";
+import privacyIcon from "../assets/feature-privacy.svg";
+
+type ConsentDocument = { kind: string; version: number; title: string; given: boolean };
 
 export default function Setup() {
   const navigate = useNavigate();
@@ -55,6 +59,15 @@ export default function Setup() {
     allergies: ""
   });
   const [errors, setErrors] = useState<string | null>(null);
+  const [consents, setConsents] = useState<ConsentDocument[]>([]);
+  const [accepted, setAccepted] = useState<Record<string, boolean>>({});
+
+  useEffect(() => {
+    if (!authorized) return;
+    fetch("/api/consents", { credentials: "include" })
+      .then((res) => res.json())
+      .then((data) => setConsents((data.consents || []).filter((c: ConsentDocument) => !c.given)));
+  }, [authorized]);
 
   const validateStep = (): boolean => {
     setErrors(null);
@@ -95,6 +108,12 @@ export default function Setup() {
           return false;
         }
         break;
+      case 6:
+        if (consents.some((c) => !accepted[c.kind])) {
+          setErrors("Bitte stimmen Sie der Verarbeitung Ihrer Daten zu.");
+          return false;
+        }
+        break;
     }
     return true;
   };
@@ -124,16 +143,17 @@ export default function Setup() {
 
   const submitSetup = async () => {
     console.log("submitSetup gestartet");
-    setErrors(null);
+    if (!validateStep()) return;
     try {
       const payload = {
         ...formData,
         height_cm: Number(formData.height_cm),
         weight_kg: Number(formData.weight_kg),
+        consents: Object.fromEntries(consents.map((c) => [c.kind, c.version])),
       };
 
       console.log("anfrage an setup.go gestartet");
-      const response = await fetch("/api/setup", {
+      const response = await apiFetch("/api/setup", {
         method: "POST",
         credentials: "include",
         headers: { "Content-Type": "application/json" },
@@ -154,7 +174,7 @@ export default function Setup() {
 
         try {
           console.log("anfrage an ollama.go gestartet");
-          const genResponse = await fetch("/api/ollama/after-setup", {
+          const genResponse = await apiFetch("/api/ollama/after-setup", {
             method: "POST",
             credentials: "include",
           });
@@ -325,6 +345,31 @@ export default function Setup() {
         />
       ),
     },
+    {
+      title: "Einwilligung",
+      icon: privacyIcon,
+      description: "Für Ihre Pläne verarbeiten wir Ihre Gesundheitsdaten. Sie können die Einwilligung jederzeit in den Einstellungen widerrufen.",
+      content: (
+        <div>
+          {consents.map((c) => (
+            <label key={c.kind} style={{ display: "flex", gap: "0.5rem", alignItems: "flex-start", textAlign: "left" }}>
+              <input
+                type="checkbox"
+                checked={!!accepted[c.kind]}
+                onChange={(e) => setAccepted((prev) => ({ ...prev, [c.kind]: e.target.checked }))}
+              />
+              <span>
+                Ich stimme zu:{" "}
+                <a href={`/api/consents/documents/${c.kind}/${c.version}`} target="_blank" rel="noreferrer">
+                  {c.title}
+                </a>
+              </span>
+            </label>
+          ))}
+          {consents.length === 0 && <p>Alle Einwilligungen liegen bereits vor.</p>}
+        </div>
+      ),
+    },
   ];
 
   return (
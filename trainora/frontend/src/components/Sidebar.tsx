@@ -5,14 +5,15 @@ import settingsIcon from "../assets/settings.svg";
 import exitIcon from "../assets/exit.svg";
 import logo from "../../public/App-Icon-Black.svg";
 import "./css/Sidebar.css";
+import { apiFetch } from "../api";
 
 export default function Sidebar() {
   const navigate = useNavigate();
   const [open, setOpen] = useState(false);
 
   const handleLogout = async () => {
-    await fetch("/api/logout", {
-      method: "GET",
+    await apiFetch("/api/logout", {
+      method: "POST",
       credentials: "include",
     });
     navigate("/login");
@@ -0,0 +1,188 @@
+import { useState } from "react";
+import { apiFetch } from "../api";
+import "./css/WorkoutLogger.css";
+
+export interface WorkoutBlock {
+  id?: number;
+  type: "sets" | "interval";
+  exercise_id: number;
+  exercise_name: string;
+  sets?: number;
+  reps?: number;
+  weight_kg?: number;
+  rounds?: number;
+  work_seconds?: number;
+  rest_seconds: number;
+  notes?: string;
+}
+
+interface LoggedSet {
+  exercise_id: number;
+  exercise_name: string;
+  reps: number;
+  weight_kg: number;
+  rpe?: number;
+  notes?: string;
+}
+
+interface WorkoutSession {
+  id: number;
+  notes: string;
+  finished: boolean;
+  sets: LoggedSet[];
+}
+
+interface PersonalRecord {
+  exercise_name: string;
+  type: "max_weight" | "estimated_1rm" | "max_reps";
+  value: number;
+}
+
+const recordLabels: Record<PersonalRecord["type"], string> = {
+  max_weight: "Höchstes Gewicht",
+  estimated_1rm: "Geschätztes 1RM",
+  max_reps: "Meiste Wiederholungen",
+};
+
+// Satzblöcke werden als einzelne Sätze vorbelegt, Intervalle nicht
+function plannedSets(blocks: WorkoutBlock[]): LoggedSet[] {
+  return blocks.flatMap((b) =>
+    b.type === "sets"
+      ? Array.from({ length: b.sets ?? 1 }, () => ({
+          exercise_id: b.exercise_id,
+          exercise_name: b.exercise_name,
+          reps: b.reps ?? 1,
+          weight_kg: b.weight_kg ?? 0,
+        }))
+      : []
+  );
+}
+
+function formatRecord(r: PersonalRecord): string {
+  const unit = r.type === "max_reps" ? "Wdh." : "kg";
+  return `${r.exercise_name}: ${recordLabels[r.type]} ${r.value} ${unit}`;
+}
+
+export default function WorkoutLogger({ scheduleId, workout }: { scheduleId: number; workout: WorkoutBlock[] }) {
+  const [session, setSession] = useState<WorkoutSession | null>(null);
+  const [sets, setSets] = useState<LoggedSet[]>([]);
+  const [records, setRecords] = useState<PersonalRecord[] | null>(null);
+  const [message, setMessage] = useState<string | null>(null);
+
+  const start = async () => {
+    setMessage(null);
+    const res = await apiFetch("/api/workout-sessions", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ schedule_id: scheduleId }),
+    });
+    const data = await res.json();
+    // 409: Es gibt bereits ein Training zu diesem Eintrag
+    const s: WorkoutSession | undefined = res.status === 409 ? data.session : res.ok ? data : undefined;
+    if (!s) {
+      setMessage(data.error || "Training konnte nicht gestartet werden");
+      return;
+    }
+    setSession(s);
+    setSets(s.sets.length > 0 ? s.sets : plannedSets(workout));
+  };
+
+  const updateSet = (index: number, field: "reps" | "weight_kg" | "rpe", value: string) => {
+    setSets((prev) => prev.map((s, i) => (i === index ? { ...s, [field]: Number(value) } : s)));
+  };
+
+  const save = async (): Promise<boolean> => {
+    if (!session) return false;
+    const res = await apiFetch(`/api/workout-sessions/${session.id}`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ notes: session.notes, sets }),
+    });
+    const data = await res.json();
+    if (!res.ok) {
+      setMessage(data.error || "Training konnte nicht gespeichert werden");
+      return false;
+    }
+    setSession(data);
+    setMessage("Training gespeichert");
+    return true;
+  };
+
+  const finish = async () => {
+    if (!session || !(await save())) return;
+    const res = await apiFetch(`/api/workout-sessions/${session.id}/finish`, { method: "POST" });
+    const data = await res.json();
+    if (!res.ok) {
+      setMessage(data.error || "Training konnte nicht abgeschlossen werden");
+      return;
+    }
+    setSession(data.session);
+    setRecords(data.new_records);
+    setMessage("Training abgeschlossen");
+  };
+
+  if (!session) {
+    return (
+      <div className="workout-logger">
+        <button className="workout-start" onClick={start}>Training starten</button>
+        {message && <p className="workout-message">{message}</p>}
+      </div>
+    );
+  }
+
+  return (
+    <div className="workout-logger">
+      <h3>Protokoll</h3>
+      <table className="workout-sets">
+        <thead>
+          <tr>
+            <th>Übung</th>
+            <th>Wdh.</th>
+            <th>kg</th>
+            <th>RPE</th>
+            {!session.finished && <th />}
+          </tr>
+        </thead>
+        <tbody>
+          {sets.map((s, i) => (
+            <tr key={i}>
+              <td>{s.exercise_name}</td>
+              <td>
+                <input type="number" min={1} value={s.reps} disabled={session.finished}
+                  onChange={(e) => updateSet(i, "reps", e.target.value)} />
+              </td>
+              <td>
+                <input type="number" min={0} step={0.5} value={s.weight_kg} disabled={session.finished}
+                  onChange={(e) => updateSet(i, "weight_kg", e.target.value)} />
+              </td>
+              <td>
+                <input type="number" min={0} max={10} step={0.5} value={s.rpe ?? 0} disabled={session.finished}
+                  onChange={(e) => updateSet(i, "rpe", e.target.value)} />
+              </td>
+              {!session.finished && (
+                <td>
+                  <button onClick={() => setSets((prev) => [...prev.slice(0, i + 1), { ...s }, ...prev.slice(i + 1)])}>+</button>
+                  <button onClick={() => setSets((prev) => prev.filter((_, j) => j !== i))}>✖</button>
+                </td>
+              )}
+            </tr>
+          ))}
+        </tbody>
+      </table>
+      {!session.finished && (
+        <div className="workout-actions">
+          <button onClick={save}>Speichern</button>
+          <button className="workout-finish" onClick={finish}>Abschließen</button>
+        </div>
+      )}
+      {message && <p className="workout-message">{message}</p>}
+      {records && records.length > 0 && (
+        <ul className="workout-records">
+          {records.map((r, i) => (
+            <li key={i}>🏆 {formatRecord(r)}</li>
+          ))}
+        </ul>
+      )}
+    </div>
+  );
+}
@@ -2,13 +2,27 @@ import { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import Sidebar from "../components/Sidebar";
 import "./css/Dashboard.css";
+import { apiFetch } from "../api";
+import WorkoutLogger, { type WorkoutBlock } from "../components/WorkoutLogger";
 
 interface Task {
   id?: number;
+  schedule_id?: number;
   title: string;
   description: string;
   duration: number;
   day_period: string;
+  workout?: WorkoutBlock[];
+}
+
+// z. B. "3 × 10 @ 20 kg, 60 s Pause" oder "8 Runden 20 s / 10 s"
+function formatBlock(block: WorkoutBlock): string {
+  if (block.type === "interval") {
+    return `${block.rounds} Runden ${block.work_seconds} s / ${block.rest_seconds} s`;
+  }
+  const weight = block.weight_kg ? ` @ ${block.weight_kg} kg` : "";
+  const rest = block.rest_seconds ? `, ${block.rest_seconds} s Pause` : "";
+  return `${block.sets} × ${block.reps}${weight}${rest}`;
 }
 
 type WeekPlan = {
@@ -32,6 +46,7 @@ export default function Dashboard() {
   const [authorized, setAuthorized] = useState(false);
   const [weekPlan, setWeekPlan] = useState<WeekPlan>({});
   const [error, setError] = useState<string | null>(null);
+  const [pendingApproval, setPendingApproval] = useState(false);
   const [selectedTask, setSelectedTask] = useState<Task | null>(null);
   const [isVisible, setIsVisible] = useState(false);
   const [activeDay, setActiveDay] = useState<number>(0); // vorinitialisieren
@@ -39,7 +54,7 @@ export default function Dashboard() {
   useEffect(() => {
     async function ensureNextWeekPlan() {
       try {
-        await fetch("/api/ollama/generate-next-week", { method: "POST", credentials: "include" });
+        await apiFetch("/api/ollama/generate-next-week", { method: "POST", credentials: "include" });
         // Optional: Du kannst hier eine Rückmeldung anzeigen oder ignorieren
       } catch (err) {
         // Optional: Fehlerbehandlung
@@ -82,6 +97,7 @@ export default function Dashboard() {
         if (!res.ok) throw new Error("Fehler beim Laden des Wochenplans");
         const data = await res.json();
         setWeekPlan(data.week_plan);
+        setPendingApproval(Boolean(data.pending_approval));
       } catch (err: any) {
         setError(err.message || "Unbekannter Fehler");
       }
@@ -110,6 +126,9 @@ export default function Dashboard() {
       <Sidebar />
       <div className="content">
         <h1>Dein Wochenplan</h1>
+        {pendingApproval && (
+          <p className="info">Dein Coach prüft den Plan für diese Woche noch.</p>
+        )}
 
         <div className="timeline-container">
           <div className="day-circles">
@@ -135,7 +154,10 @@ export default function Dashboard() {
               <div className="task-details">
                 <h3>{task.title}</h3>
                 <p>{task.description}</p>
-                <span className="duration">{task.duration} Minuten</span>
+                <span className="duration">
+                  {task.duration} Minuten
+                  {task.workout && task.workout.length > 0 && ` · ${task.workout.length} Übungen`}
+                </span>
               </div>
             </div>
           ))}
@@ -160,6 +182,19 @@ export default function Dashboard() {
                   <p><strong>Zeitraum:</strong> {dayPeriodTranslations[selectedTask.day_period] ?? selectedTask.day_period}</p>
                   <p>{selectedTask.description}</p>
                   <p><strong>Dauer:</strong> {selectedTask.duration} Minuten</p>
+                  {selectedTask.workout && selectedTask.workout.length > 0 && (
+                    <ol className="workout-list">
+                      {selectedTask.workout.map((block, i) => (
+                        <li key={block.id ?? i}>
+                          <strong>{block.exercise_name}</strong> – {formatBlock(block)}
+                          {block.notes && <div className="workout-notes">{block.notes}</div>}
+                        </li>
+                      ))}
+                    </ol>
+                  )}
+                  {selectedTask.schedule_id && selectedTask.workout && selectedTask.workout.length > 0 && (
+                    <WorkoutLogger scheduleId={selectedTask.schedule_id} workout={selectedTask.workout} />
+                  )}
                 </div>
                 <div className="feedback-section">
                   <h3>Feedback</h3>
@@ -2,10 +2,64 @@ import { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import "./css/Fitness.css"; 
 import Sidebar from "../components/Sidebar";
+import { apiFetch } from "../api";
+
+type Option = { value: string; label: string };
+type Filters = { muscle_groups: Option[]; equipment: Option[]; difficulties: Option[] };
+type Exercise = {
+  id: number;
+  name: string;
+  muscle_groups: string[];
+  equipment: string[];
+  difficulty: string;
+  instructions: string;
+  contraindications: string[];
+  custom: boolean;
+};
+
+type Target = {
+  exercise_id: number;
+  exercise_name: string;
+  sets: number;
+  reps: number;
+  weight_kg: number;
+  action: "increase_weight" | "increase_reps" | "hold" | "deload";
+  reason: string;
+};
+
+type PersonalRecord = {
+  exercise_id: number;
+  exercise_name: string;
+  type: "max_weight" | "estimated_1rm" | "max_reps";
+  value: number;
+};
+
+const actionIcons: Record<Target["action"], string> = {
+  increase_weight: "⬆️",
+  increase_reps: "➕",
+  hold: "⏸️",
+  deload: "⬇️",
+};
+
+const recordLabels: Record<PersonalRecord["type"], string> = {
+  max_weight: "Höchstes Gewicht",
+  estimated_1rm: "Geschätztes 1RM",
+  max_reps: "Meiste Wiederholungen",
+};
+
+const emptyForm = { name: "", muscle_group: "", equipment: "", difficulty: "beginner", instructions: "", contraindications: "" };
 
 export default function Fitness() {
 const navigate = useNavigate();
   const [authorized, setAuthorized] = useState(false);
+  const [filters, setFilters] = useState<Filters>({ muscle_groups: [], equipment: [], difficulties: [] });
+  const [query, setQuery] = useState({ q: "", muscle_group: "", equipment: "", difficulty: "", own: false });
+  const [exercises, setExercises] = useState<Exercise[]>([]);
+  const [total, setTotal] = useState(0);
+  const [form, setForm] = useState(emptyForm);
+  const [msg, setMsg] = useState("");
+  const [targets, setTargets] = useState<Target[]>([]);
+  const [records, setRecords] = useState<PersonalRecord[]>([]);
 
   useEffect(() => {
     async function checkAuth() {
@@ -29,12 +83,189 @@ const navigate = useNavigate();
     checkAuth();
   }, [navigate]);
 
+  useEffect(() => {
+    fetch("/api/exercises/filters")
+      .then((res) => res.json())
+      .then(setFilters);
+  }, []);
+
+  async function loadExercises() {
+    const params = new URLSearchParams();
+    if (query.q) params.set("q", query.q);
+    if (query.muscle_group) params.set("muscle_group", query.muscle_group);
+    if (query.equipment) params.set("equipment", query.equipment);
+    if (query.difficulty) params.set("difficulty", query.difficulty);
+    if (query.own) params.set("own", "true");
+    const res = await apiFetch(`/api/exercises?${params}`);
+    if (res.ok) {
+      const data = await res.json();
+      setExercises(data.exercises);
+      setTotal(data.total);
+    }
+  }
+
+  useEffect(() => {
+    if (authorized) loadExercises();
+  }, [authorized, query]);
+
+  useEffect(() => {
+    if (!authorized) return;
+    apiFetch("/api/progression")
+      .then((res) => (res.ok ? res.json() : { targets: [] }))
+      .then((data) => setTargets(data.targets));
+    apiFetch("/api/personal-records")
+      .then((res) => (res.ok ? res.json() : { records: [] }))
+      .then((data) => setRecords(data.records));
+  }, [authorized]);
+
+  const label = (options: Option[], value: string) => options.find((o) => o.value === value)?.label || value;
+
+  async function handleCreate(e) {
+    e.preventDefault();
+    setMsg("");
+    const res = await apiFetch("/api/exercises", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({
+        name: form.name,
+        muscle_groups: form.muscle_group ? [form.muscle_group] : [],
+        equipment: form.equipment ? [form.equipment] : [],
+        difficulty: form.difficulty,
+        instructions: form.instructions,
+        contraindications: form.contraindications.split(",").map((c) => c.trim()).filter(Boolean),
+      }),
+    });
+    const data = await res.json().catch(() => ({}));
+    if (!res.ok) {
+      setMsg(data.error || "Die Übung konnte nicht gespeichert werden.");
+      return;
+    }
+    setForm(emptyForm);
+    loadExercises();
+  }
+
+  async function handleDelete(id: number) {
+    if (!confirm("Übung wirklich löschen?")) return;
+    const res = await apiFetch(`/api/exercises/${id}`, { method: "DELETE" });
+    if (res.ok) loadExercises();
+  }
+
   if (!authorized) return null;
 
   return (
     <div className="fitness-page">
       <Sidebar />
       <h1>Fitness</h1>
+
+      {targets.length > 0 && (
+        <>
+          <h2>Deine Ziele für nächste Woche</h2>
+          <div className="exercise-list">
+            {targets.map((t) => (
+              <div key={t.exercise_id} className="exercise-card">
+                <h3>{actionIcons[t.action]} {t.exercise_name}</h3>
+                <p>
+                  {t.sets} × {t.reps}
+                  {t.weight_kg > 0 && ` @ ${t.weight_kg} kg`}
+                </p>
+                <p className="exercise-meta">{t.reason}</p>
+              </div>
+            ))}
+          </div>
+        </>
+      )}
+
+      {records.length > 0 && (
+        <>
+          <h2>Persönliche Rekorde</h2>
+          <ul className="record-list">
+            {records.map((r) => (
+              <li key={`${r.exercise_id}-${r.type}`}>
+                🏆 {r.exercise_name}: {recordLabels[r.type]} {r.value} {r.type === "max_reps" ? "Wdh." : "kg"}
+              </li>
+            ))}
+          </ul>
+        </>
+      )}
+
+      <h2>Übungskatalog</h2>
+      <div className="exercise-filters">
+        <input
+          placeholder="Übung suchen"
+          value={query.q}
+          onChange={(e) => setQuery({ ...query, q: e.target.value })}
+        />
+        <select value={query.muscle_group} onChange={(e) => setQuery({ ...query, muscle_group: e.target.value })}>
+          <option value="">Alle Muskelgruppen</option>
+          {filters.muscle_groups.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <select value={query.equipment} onChange={(e) => setQuery({ ...query, equipment: e.target.value })}>
+          <option value="">Alle Geräte</option>
+          {filters.equipment.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <select value={query.difficulty} onChange={(e) => setQuery({ ...query, difficulty: e.target.value })}>
+          <option value="">Alle Schwierigkeitsgrade</option>
+          {filters.difficulties.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <label>
+          <input type="checkbox" checked={query.own} onChange={(e) => setQuery({ ...query, own: e.target.checked })} />
+          Nur eigene
+        </label>
+      </div>
+      <p>{total} Übungen gefunden</p>
+
+      <div className="exercise-list">
+        {exercises.map((ex) => (
+          <div key={ex.id} className="exercise-card">
+            <h3>{ex.name}{ex.custom && <span className="exercise-badge">Eigene</span>}</h3>
+            <p className="exercise-meta">
+              {ex.muscle_groups.map((m) => label(filters.muscle_groups, m)).join(", ")}
+              {" · "}
+              {ex.equipment.length ? ex.equipment.map((m) => label(filters.equipment, m)).join(", ") : "ohne Geräte"}
+              {" · "}
+              {label(filters.difficulties, ex.difficulty)}
+            </p>
+            {ex.instructions && <p>{ex.instructions}</p>}
+            {ex.contraindications.length > 0 && (
+              <p className="exercise-warning">Nicht geeignet bei: {ex.contraindications.join(", ")}</p>
+            )}
+            {ex.custom && <button onClick={() => handleDelete(ex.id)}>Löschen</button>}
+          </div>
+        ))}
+      </div>
+
+      <h2>Eigene Übung anlegen</h2>
+      <form className="exercise-form" onSubmit={handleCreate}>
+        <input
+          placeholder="Name"
+          value={form.name}
+          onChange={(e) => setForm({ ...form, name: e.target.value })}
+          required
+        />
+        <select value={form.muscle_group} onChange={(e) => setForm({ ...form, muscle_group: e.target.value })} required>
+          <option value="">Muskelgruppe wählen...</option>
+          {filters.muscle_groups.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <select value={form.equipment} onChange={(e) => setForm({ ...form, equipment: e.target.value })}>
+          <option value="">Ohne Geräte</option>
+          {filters.equipment.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <select value={form.difficulty} onChange={(e) => setForm({ ...form, difficulty: e.target.value })}>
+          {filters.difficulties.map((o) => <option key={o.value} value={o.value}>{o.label}</option>)}
+        </select>
+        <textarea
+          placeholder="Anleitung"
+          value={form.instructions}
+          onChange={(e) => setForm({ ...form, instructions: e.target.value })}
+        />
+        <input
+          placeholder="Nicht geeignet bei (durch Komma getrennt)"
+          value={form.contraindications}
+          onChange={(e) => setForm({ ...form, contraindications: e.target.value })}
+        />
+        <button type="submit">Speichern</button>
+        {msg && <p className="exercise-warning">{msg}</p>}
+      </form>
     </div>
   );
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ import { useEffect } from "react";
 import { Link } from "react-router-dom";
 import { useNavigate } from "react-router-dom";
 import "./css/Login.css";
+import { apiFetch } from "../api";
 import userIcon from "../assets/user.svg";
 import passwordIconHidden from "../assets/pw_hidden.svg";
 import passwordIconVisible from "../assets/pw_visible.svg";
@@ -14,6 +15,39 @@ export default function Login() {
   const [showPassword, setShowPassword] = useState(false);
   const navigate = useNavigate();
   const [rememberMe, setRememberMe] = useState(false);
+  // Zweiter Schritt bei aktivierter Zwei-Faktor-Authentifizierung
+  const [twoFactor, setTwoFactor] = useState(false);
+  const [code, setCode] = useState("");
+  const [oidc, setOidc] = useState<{ enabled: boolean, name?: string }>({ enabled: false });
+
+  // Rückkehr vom Single Sign-On: 2FA-Schritt oder Fehlermeldung
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    if (params.get("two_factor") === "required") {
+      setTwoFactor(true);
+      setMsg("Bitte Code aus der Authenticator-App eingeben");
+    }
+    const oidcErrors: Record<string, string> = {
+      email_taken: "Zu dieser E-Mail gibt es bereits ein Konto. Bitte mit Passwort anmelden und in den Einstellungen verknüpfen.",
+      linked_elsewhere: "Dieses Konto ist bereits mit einem anderen Benutzer verknüpft.",
+      email_missing: "Der Anbieter hat keine E-Mail-Adresse übermittelt.",
+      disabled: "Dieses Konto wurde deaktiviert.",
+      deleted: "Dieses Konto wird gelöscht. Über den Link in der Bestätigungsmail kannst du es wiederherstellen.",
+    };
+    const oidcError = params.get("oidc_error");
+    if (oidcError) setMsg(oidcErrors[oidcError] || "Anmeldung über Single Sign-On fehlgeschlagen");
+    const restored = params.get("account_restored");
+    if (restored) {
+      setMsg(restored === "true"
+        ? "Dein Konto wurde wiederhergestellt. Du kannst dich wieder anmelden."
+        : "Der Link ist ungültig oder abgelaufen.");
+    }
+
+    fetch("/api/oidc/config")
+      .then(res => res.json())
+      .then(data => setOidc(data))
+      .catch(() => {});
+  }, []);
 
   useEffect(() => {
     fetch("/api/me", {
@@ -40,16 +74,27 @@ export default function Login() {
     setMsg("");
 
     try {
-      const res = await fetch(`/api/login?remember=${rememberMe}`, {
-        method: "POST",
-        headers: { "Content-Type": "application/json" },
-        credentials: "include",
-        body: JSON.stringify(form),
-      });
+      const res = twoFactor
+        ? await apiFetch("/api/login/2fa", {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            credentials: "include",
+            body: JSON.stringify({ code }),
+          })
+        : await apiFetch(`/api/login?remember=${rememberMe}`, {
+            method: "POST",
+            headers: { "Content-Type": "application/json" },
+            credentials: "include",
+            body: JSON.stringify(form),
+          });
 
       const data = await res.json();
 
-      if (res.ok && data.message?.toLowerCase().includes("erfolg")) {
+      if (res.ok && data.two_factor_required) {
+        setTwoFactor(true);
+        setMsg(data.message);
+        setLoading(false);
+      } else if (res.ok && data.message?.toLowerCase().includes("erfolg")) {
         // 👇 Weiterleitung abhängig vom Setup-Status
         setTimeout(() => {
           setLoading(false);
@@ -60,6 +105,11 @@ export default function Login() {
           }
         }, 1000);
       } else {
+        // Abgelaufene oder gesperrte 2FA-Anmeldung beginnt wieder beim Passwort
+        if (twoFactor && (res.status === 429 || data.error?.includes("abgelaufen"))) {
+          setTwoFactor(false);
+          setCode("");
+        }
         setMsg(data.error || "Ein Fehler ist aufgetreten");
         setLoading(false);
       }
@@ -74,6 +124,21 @@ export default function Login() {
       <div className="register-tile">
         <h2>Anmelden</h2>
         <form onSubmit={handleSubmit} className="register-form">
+          {twoFactor ? (
+            <div className="input-icon-wrapper">
+                <input
+                name="code"
+                type="text"
+                autoComplete="one-time-code"
+                placeholder="Code aus der App oder Wiederherstellungscode"
+                value={code}
+                onChange={(e) => setCode(e.target.value)}
+                required
+                autoFocus
+                />
+            </div>
+          ) : (
+          <>
             <div className="input-icon-wrapper">
                 <input
                 name="login"
@@ -122,12 +187,20 @@ export default function Login() {
                 />
                 <label style={{cursor: "pointer"}} htmlFor="remember"> Angemeldet bleiben</label>
             </div>
+          </>
+          )}
 
             {/* Button */}
             <button type="submit" className="btn btn-primary" disabled={loading}>
-                {loading ? "Anmelden..." : "Anmelden"}
+                {loading ? "Anmelden..." : twoFactor ? "Bestätigen" : "Anmelden"}
             </button>
 
+            {oidc.enabled && !twoFactor && (
+              <a className="btn" href={`/api/oidc/login?remember=${rememberMe}`} style={{ textAlign: "center", textDecoration: "none" }}>
+                Anmelden mit {oidc.name}
+              </a>
+            )}
+
             {/* Feedback */}
             {msg && <div className={`register-msg${msg.toLowerCase().includes("erfolg") ? " success" : " error"}`}>{msg}</div>}
             </form>
@@ -1,6 +1,7 @@
 import { useState, useEffect } from "react";
 import { Link, useNavigate } from "react-router-dom";
 import "./css/Register.css";
+import { apiFetch } from "../api";
 import mailIcon from "../assets/mail.svg";
 import userIcon from "../assets/user.svg";
 import passwordIconHidden from "../assets/pw_hidden.svg";
@@ -17,6 +18,8 @@ export default function Register() {
   const [showPassword, setShowPassword] = useState(false);
   const [showConfirmPassword, setShowConfirmPassword] = useState(false);
   const [success, setSuccess] = useState(false);
+  const [privacyVersion, setPrivacyVersion] = useState(0);
+  const [privacyAccepted, setPrivacyAccepted] = useState(false);
   const navigate = useNavigate();
 
   useEffect(() => {
@@ -35,6 +38,15 @@ export default function Register() {
     } else setUsernameStatus("");
   }, [form.username]);
 
+  useEffect(() => {
+    fetch("/api/consents/documents")
+      .then(res => res.json())
+      .then(data => {
+        const doc = (data.documents || []).find(d => d.kind === "privacy");
+        if (doc) setPrivacyVersion(doc.version);
+      });
+  }, []);
+
   const handleChange = e => setForm({ ...form, [e.target.name]: e.target.value });
 
   const handleSubmit = async e => {
@@ -49,10 +61,10 @@ export default function Register() {
     }
 
     try {
-      const res = await fetch("/api/register", {
+      const res = await apiFetch("/api/register", {
         method: "POST",
         headers: { "Content-Type": "application/json" },
-        body: JSON.stringify(form),
+        body: JSON.stringify({ ...form, consents: { privacy: privacyVersion } }),
       });
       const data = await res.json();
 
@@ -185,7 +197,23 @@ export default function Register() {
                 </div>
               )}
 
-              <button type="submit" className="btn btn-primary" disabled={loading}>
+              <label style={{ display: "flex", gap: "0.5rem", alignItems: "flex-start", marginBottom: "0.5rem" }}>
+                <input
+                  type="checkbox"
+                  checked={privacyAccepted}
+                  onChange={e => setPrivacyAccepted(e.target.checked)}
+                  required
+                />
+                <span>
+                  Ich habe die{" "}
+                  <a href={`/api/consents/documents/privacy/${privacyVersion}`} target="_blank" rel="noreferrer">
+                    Datenschutzerklärung
+                  </a>{" "}
+                  gelesen und stimme ihr zu.
+                </span>
+              </label>
+
+              <button type="submit" className="btn btn-primary" disabled={loading || !privacyVersion}>
                 {loading ? "Registriere..." : "Registrieren"}
               </button>
 
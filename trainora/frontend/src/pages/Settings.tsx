@@ -1,6 +1,7 @@
 import { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import "./css/Settings.css"; 
+import { apiFetch } from "../api";
 import Sidebar from "../components/Sidebar";
 
 export default function Settings() {
@@ -9,14 +10,15 @@ export default function Settings() {
 
   async function handleDeleteAccount() {
   if (confirm("Sind Sie sicher, dass Sie Ihr Konto löschen möchten?")) {
-    const res = await fetch("/api/delete-account", {
+    const res = await apiFetch("/api/delete-account", {
       method: "DELETE",
       credentials: "include",
     });
     if (res.ok) {
-      alert("Ihr Konto wurde erfolgreich gelöscht.");
-      await fetch("/api/logout", {
-        method: "GET",
+      const data = await res.json();
+      alert(`Ihr Konto wurde zur Löschung vorgemerkt und wird am ${new Date(data.purge_at).toLocaleDateString("de-DE")} endgültig gelöscht. Bis dahin können Sie es über den Link in der Bestätigungsmail wiederherstellen.`);
+      await apiFetch("/api/logout", {
+        method: "POST",
         credentials: "include",
       });
       navigate("/login");
@@ -26,6 +28,28 @@ export default function Settings() {
   }
 }
 
+  async function handleExport() {
+    const res = await apiFetch("/api/account/export");
+    const data = await res.json().catch(() => ({}));
+    if (res.status === 202) {
+      alert("Ihr Export wird erstellt. Sie erhalten eine E-Mail mit dem Download-Link, sobald er fertig ist.");
+    } else if (res.ok && data.download_url) {
+      window.location.href = data.download_url;
+    } else {
+      alert(data.error || "Der Export konnte nicht erstellt werden.");
+    }
+  }
+
+  async function handleWithdrawHealthData() {
+    if (!confirm("Ohne Einwilligung in die Verarbeitung Ihrer Gesundheitsdaten werden keine neuen Pläne mehr erstellt. Fortfahren?")) return;
+    const res = await apiFetch("/api/consents/health_data", {
+      method: "DELETE",
+      credentials: "include",
+    });
+    const data = await res.json().catch(() => ({}));
+    alert(data.message || data.error || "Die Einwilligung konnte nicht widerrufen werden.");
+  }
+
   useEffect(() => {
     async function checkAuth() {
       try {
@@ -55,6 +79,8 @@ export default function Settings() {
       <Sidebar />
       <h1>Einstellungen</h1>
       <p>Hier können Sie Ihre Einstellungen anpassen.</p>
+      <button onClick={handleExport}>Meine Daten exportieren</button>
+      <button onClick={handleWithdrawHealthData}>Einwilligung Gesundheitsdaten widerrufen</button>
       <button onClick={handleDeleteAccount}>Account löschen</button>
     </div>
   );